@@ -28,6 +28,10 @@ func SetupRoutes(r *gin.Engine) {
 		SetupBranchRoutes(api)
 		SetupChildBranchRoutes(api)
 		SetupEventRoutes(api)
+		SetupEventSeriesRoutes(api)
+		SetupEventTemplateRoutes(api)
+		SetupEventCustomFieldRoutes(api)
+		SetupCalendarRoutes(api)
 		SetupPromotionRoutes(api)
 		SetupMediaRoutes(api)
 		SetupSpecialGuestRoutes(api)
@@ -38,6 +42,8 @@ func SetupRoutes(r *gin.Engine) {
 		SetupBranchMediaRoutes(api)
 		SetupChildBranchMediaRoutes(api)
 		SetupUserPreferencesRoutes(api)
+		SetupTrashRoutes(api)
+		SetupWebhookRoutes(api)
 
 		// RBAC routes
 		rbacHandler := handlers.NewRBACHandler(config.DB)
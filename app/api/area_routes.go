@@ -9,7 +9,7 @@ import (
 // SetupAreaRoutes configures area CRUD routes
 func SetupAreaRoutes(r *gin.RouterGroup) {
 	areas := r.Group("/areas")
-	areas.Use(middleware.AuthRequired())
+	areas.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		areas.POST("", handlers.CreateAreaHandler)
 		areas.GET("", handlers.GetAllAreasHandler)
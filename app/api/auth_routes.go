@@ -76,7 +76,7 @@ func SetupAuthRoutes(r *gin.RouterGroup) {
 
 	// Protected routes
 	protected := r.Group("/auth")
-	protected.Use(middleware.AuthRequired())
+	protected.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		// Get current user
 		protected.GET("/me", authHandler.Me)
@@ -10,7 +10,7 @@ import (
 // SetupBranchRoutes configures branch CRUD routes
 func SetupBranchRoutes(r *gin.RouterGroup) {
 	branches := r.Group("/branches")
-	branches.Use(middleware.AuthRequired())
+	branches.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		branches.POST("", 
 			middleware.RequirePermission(models.ResourceBranch, models.ActionCreate),
@@ -24,6 +24,9 @@ func SetupBranchRoutes(r *gin.RouterGroup) {
 		branches.GET("/export", 
 			middleware.RequirePermission(models.ResourceBranch, models.ActionList),
 			handlers.ExportBranchesHandler) // Must be before /:id route
+		branches.GET("/map",
+			middleware.RequirePermission(models.ResourceBranch, models.ActionList),
+			handlers.GetBranchesMapHandler) // Must be before /:id route
 		branches.GET("/parent/:parent_id/children", 
 			middleware.RequirePermission(models.ResourceBranch, models.ActionList),
 			handlers.GetChildBranchesHandler)
@@ -40,7 +43,7 @@ func SetupBranchRoutes(r *gin.RouterGroup) {
 
 	// Branch Infrastructure routes
 	branchInfra := r.Group("/branch-infra")
-	branchInfra.Use(middleware.AuthRequired())
+	branchInfra.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		branchInfra.POST("", handlers.CreateBranchInfrastructureHandler)
 		branchInfra.GET("", handlers.GetAllBranchInfrastructureHandler)
@@ -51,7 +54,7 @@ func SetupBranchRoutes(r *gin.RouterGroup) {
 
 	// Branch Member routes
 	branchMember := r.Group("/branch-member")
-	branchMember.Use(middleware.AuthRequired())
+	branchMember.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		branchMember.POST("", handlers.CreateBranchMemberHandler)
 		branchMember.GET("", handlers.GetAllBranchMembersHandler)
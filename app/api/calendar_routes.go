@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupCalendarRoutes configures calendar feed token management and the token-authenticated feeds
+func SetupCalendarRoutes(r *gin.RouterGroup) {
+	calendar := r.Group("/calendar")
+	{
+		tokens := calendar.Group("/tokens")
+		tokens.Use(middleware.AuthRequired(), middleware.Idempotency())
+		{
+			tokens.POST("", handlers.CreateCalendarFeedTokenHandler)
+			tokens.GET("", handlers.GetCalendarFeedTokensHandler)
+			tokens.DELETE("/:id", handlers.RevokeCalendarFeedTokenHandler)
+		}
+
+		// Calendar apps cannot send the Authorization header; feeds use a revocable token instead
+		calendar.GET("/feeds/:scope/:value",
+			middleware.CalendarFeedTokenRequired(),
+			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
+			handlers.GetCalendarFeedHandler)
+	}
+}
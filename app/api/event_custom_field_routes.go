@@ -0,0 +1,28 @@
+package api
+
+import (
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupEventCustomFieldRoutes configures the routes of the custom fields of event categories.
+// Any signed-in user can read them to render the event form; admins manage them.
+func SetupEventCustomFieldRoutes(r *gin.RouterGroup) {
+	fields := r.Group("/event-custom-fields")
+	fields.Use(middleware.AuthRequired(), middleware.Idempotency())
+	{
+		fields.GET("", handlers.GetEventCustomFieldsHandler)
+		fields.GET("/:field_id", handlers.GetEventCustomFieldHandler)
+		fields.POST("",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.CreateEventCustomFieldHandler)
+		fields.PUT("/:field_id",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.UpdateEventCustomFieldHandler)
+		fields.DELETE("/:field_id",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.DeleteEventCustomFieldHandler)
+	}
+}
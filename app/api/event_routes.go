@@ -10,7 +10,7 @@ import (
 // SetupEventRoutes configures event CRUD routes
 func SetupEventRoutes(r *gin.RouterGroup) {
 	events := r.Group("/events")
-	events.Use(middleware.AuthRequired())
+	events.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		events.POST("", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
@@ -24,6 +24,61 @@ func SetupEventRoutes(r *gin.RouterGroup) {
 		events.GET("/export", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
 			handlers.ExportEventsHandler)
+		events.POST("/import",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.ImportEventsHandler)
+		events.GET("/analytics",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
+			handlers.GetImpactAnalyticsHandler)
+		events.POST("/validate",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.ValidateEventCreateHandler)
+		events.GET("/schema",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventSchemaVersionsHandler)
+		events.GET("/schema/:version",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventSchemaHandler)
+		events.GET("/map",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
+			handlers.GetEventsMapHandler)
+		events.GET("/comments/mentions",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetMyCommentMentionsHandler)
+
+		// Duplicate detection report and merging (admins only)
+		events.GET("/duplicates",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.GetDuplicateSuspectsHandler)
+		events.POST("/duplicates/scan",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.ScanEventDuplicatesHandler)
+		events.POST("/duplicates/:suspect_id/dismiss",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.DismissDuplicateSuspectHandler)
+		events.GET("/merge/preview",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.PreviewEventMergeHandler)
+		events.POST("/merge",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.MergeEventsHandler)
+
+		// Overdue reports: incomplete reports of ended events, reminders and escalation contacts
+		events.GET("/overdue",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
+			handlers.GetOverdueReportsHandler)
+		events.POST("/overdue/remind",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.RunOverdueRemindersHandler)
+		events.GET("/overdue/region-admins",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.GetRegionAdminsHandler)
+		events.POST("/overdue/region-admins",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.AddRegionAdminHandler)
+		events.DELETE("/overdue/region-admins/:id",
+			middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin),
+			handlers.RemoveRegionAdminHandler)
 
 		// Event-specific routes (must be before /:event_id to avoid conflicts)
 		events.GET("/:event_id/specialguests", 
@@ -48,15 +103,49 @@ func SetupEventRoutes(r *gin.RouterGroup) {
 			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
 			handlers.GetPromotionMaterialDetailsByEventIDHandler)
 
+		// Day-wise attendance of multi-day events (the event's beneficiary counts are derived from it)
+		events.GET("/:event_id/attendance",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventAttendanceHandler)
+		events.POST("/:event_id/attendance",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.AddEventAttendanceHandler)
+		events.PUT("/:event_id/attendance",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.ReplaceEventAttendanceHandler)
+		events.PUT("/:event_id/attendance/:attendance_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.UpdateEventAttendanceHandler)
+		events.DELETE("/:event_id/attendance/:attendance_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.DeleteEventAttendanceHandler)
+
+		// Branches co-hosting the event with its lead branch
+		events.GET("/:event_id/cohosts",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventCoHostsHandler)
+		events.PUT("/:event_id/cohosts",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.ReplaceEventCoHostsHandler)
+
 		events.GET("/:event_id", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
 			handlers.GetEventByIdHandler)
 		events.GET("/:event_id/download", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
 			handlers.DownloadEventHandler)
+		events.GET("/:event_id/ics",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventICSHandler)
+		events.POST("/:event_id/clone",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.CloneEventHandler)
 		events.PUT("/:event_id", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
 			handlers.UpdateEventHandler)
+		events.POST("/:event_id/validate",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.ValidateEventUpdateHandler)
 		events.DELETE("/:event_id", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionDelete),
 			handlers.DeleteEventHandler)
@@ -64,6 +153,51 @@ func SetupEventRoutes(r *gin.RouterGroup) {
 			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
 			handlers.UpdateEventStatusHandler)
 
+		// Review workflow routes (transitions are additionally gated by role in the service)
+		events.POST("/:event_id/workflow/transition", 
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.TransitionEventWorkflowHandler)
+		events.GET("/:event_id/workflow/history", 
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventWorkflowHistoryHandler)
+
+		// Reviewer comment threads (commenting does not change the event itself)
+		events.GET("/:event_id/comments",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventCommentsHandler)
+		events.POST("/:event_id/comments",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.AddEventCommentHandler)
+		events.PUT("/:event_id/comments/:comment_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.UpdateEventCommentHandler)
+		events.DELETE("/:event_id/comments/:comment_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.DeleteEventCommentHandler)
+		events.POST("/:event_id/comments/:comment_id/resolve",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.ResolveEventCommentHandler)
+		events.POST("/:event_id/comments/:comment_id/unresolve",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.UnresolveEventCommentHandler)
+
+		// Revision history routes
+		events.GET("/:event_id/revisions", 
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventRevisionsHandler)
+		events.GET("/:event_id/merges",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventMergesHandler)
+		events.GET("/:event_id/revisions/diff", 
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.DiffEventRevisionsHandler)
+		events.GET("/:event_id/revisions/:revision_id", 
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventRevisionHandler)
+		events.POST("/:event_id/revisions/:revision_id/restore", 
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.RestoreEventRevisionHandler)
+
 		// Draft routes
 		events.POST("/draft", 
 			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupEventSeriesRoutes configures recurring event series routes
+func SetupEventSeriesRoutes(r *gin.RouterGroup) {
+	series := r.Group("/event-series")
+	series.Use(middleware.AuthRequired(), middleware.Idempotency())
+	{
+		series.POST("",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.CreateEventSeriesHandler)
+		series.GET("",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
+			handlers.GetAllEventSeriesHandler)
+		series.GET("/:series_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventSeriesHandler)
+		series.PUT("/:series_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.UpdateEventSeriesHandler)
+		series.DELETE("/:series_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionDelete),
+			handlers.DeleteEventSeriesHandler)
+
+		series.POST("/:series_id/exceptions",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.AddEventSeriesExceptionHandler)
+		series.DELETE("/:series_id/exceptions/:exception_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.RemoveEventSeriesExceptionHandler)
+
+		series.POST("/:series_id/generate",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.GenerateEventSeriesOccurrencesHandler)
+		series.GET("/:series_id/occurrences",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventSeriesOccurrencesHandler)
+		series.PUT("/:series_id/occurrences/:event_id/report",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.ReportEventSeriesOccurrenceHandler)
+		series.GET("/:series_id/rollup",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventSeriesRollupHandler)
+	}
+}
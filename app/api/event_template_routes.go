@@ -0,0 +1,34 @@
+package api
+
+import (
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupEventTemplateRoutes configures reusable event template routes
+func SetupEventTemplateRoutes(r *gin.RouterGroup) {
+	templates := r.Group("/event-templates")
+	templates.Use(middleware.AuthRequired(), middleware.Idempotency())
+	{
+		templates.POST("",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.CreateEventTemplateHandler)
+		templates.GET("",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionList),
+			handlers.GetEventTemplatesHandler)
+		templates.GET("/:template_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionRead),
+			handlers.GetEventTemplateHandler)
+		templates.PUT("/:template_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionUpdate),
+			handlers.UpdateEventTemplateHandler)
+		templates.DELETE("/:template_id",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionDelete),
+			handlers.DeleteEventTemplateHandler)
+		templates.POST("/:template_id/draft",
+			middleware.RequirePermission(models.ResourceEvent, models.ActionCreate),
+			handlers.CreateDraftFromTemplateHandler)
+	}
+}
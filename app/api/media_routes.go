@@ -9,7 +9,7 @@ import (
 // SetupMediaRoutes configures media CRUD routes
 func SetupMediaRoutes(r *gin.RouterGroup) {
 	media := r.Group("/event-media")
-	media.Use(middleware.AuthRequired())
+	media.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		media.POST("", handlers.CreateEventMediaHandler)
 		media.GET("", handlers.GetAllEventMediaHandler)
@@ -11,7 +11,7 @@ import (
 func SetupRBACRoutes(apiGroup *gin.RouterGroup, rbacHandler *handlers.RBACHandler) {
 	// RBAC management routes - require authentication and super_admin role
 	rbac := apiGroup.Group("/rbac")
-	rbac.Use(middleware.AuthRequired())
+	rbac.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		// Role management - Super Admin only
 		roles := rbac.Group("/roles")
@@ -54,7 +54,7 @@ func SetupRBACRoutes(apiGroup *gin.RouterGroup, rbacHandler *handlers.RBACHandle
 // Uncomment and use this pattern to protect your existing routes
 func SetupUserRoutesWithRBAC(router *gin.Engine, userHandler *handlers.UserHandler) {
 	users := router.Group("/api/users")
-	users.Use(middleware.AuthRequired())
+	users.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		// List users - requires users:list permission
 		users.GET("", 
@@ -86,7 +86,7 @@ func SetupUserRoutesWithRBAC(router *gin.Engine, userHandler *handlers.UserHandl
 // Example: Update event routes with RBAC
 func SetupEventRoutesWithRBAC(router *gin.Engine, eventHandler *handlers.EventHandler) {
 	events := router.Group("/api/events")
-	events.Use(middleware.AuthRequired())
+	events.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		// List events - requires events:list permission
 		events.GET("", 
@@ -118,7 +118,7 @@ func SetupEventRoutesWithRBAC(router *gin.Engine, eventHandler *handlers.EventHa
 // Example: Update branch routes with RBAC
 func SetupBranchRoutesWithRBAC(router *gin.Engine, branchHandler *handlers.BranchHandler) {
 	branches := router.Group("/api/branches")
-	branches.Use(middleware.AuthRequired())
+	branches.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		// List branches - requires branches:list permission
 		branches.GET("", 
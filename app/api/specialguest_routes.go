@@ -9,7 +9,7 @@ import (
 // SetupSpecialGuestRoutes configures special guest routes
 func SetupSpecialGuestRoutes(r *gin.RouterGroup) {
 	specialguests := r.Group("/specialguests")
-	specialguests.Use(middleware.AuthRequired())
+	specialguests.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		specialguests.POST("", handlers.CreateSpecialGuestHandler)
 		specialguests.GET("", handlers.GetAllSpecialGuestsHandler)
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTrashRoutes configures trash listing and restore routes for soft-deleted records
+func SetupTrashRoutes(r *gin.RouterGroup) {
+	trash := r.Group("/trash")
+	trash.Use(middleware.AuthRequired(), middleware.Idempotency())
+	{
+		trash.GET("/:resource",
+			requireTrashPermission(),
+			handlers.ListTrashHandler)
+		trash.POST("/:resource/:id/restore",
+			requireTrashPermission(),
+			handlers.RestoreFromTrashHandler)
+	}
+}
+
+// requireTrashPermission allows access to the trash of a resource to users who may delete it
+func requireTrashPermission() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resource, ok := services.TrashResources[c.Param("resource")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": services.ErrUnknownTrashResource.Error()})
+			c.Abort()
+			return
+		}
+		middleware.RequirePermission(resource, models.ActionDelete)(c)
+	}
+}
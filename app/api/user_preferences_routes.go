@@ -9,7 +9,7 @@ import (
 // SetupUserPreferencesRoutes configures user preferences routes
 func SetupUserPreferencesRoutes(r *gin.RouterGroup) {
 	prefs := r.Group("/user-preferences")
-	prefs.Use(middleware.AuthRequired())
+	prefs.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		prefs.POST("", handlers.SaveUserPreferenceHandler)
 		prefs.GET("", handlers.GetUserPreferenceHandler)
@@ -9,7 +9,7 @@ import (
 // SetupVolunteerRoutes configures volunteer routes
 func SetupVolunteerRoutes(r *gin.RouterGroup) {
 	volunteers := r.Group("/volunteers")
-	volunteers.Use(middleware.AuthRequired())
+	volunteers.Use(middleware.AuthRequired(), middleware.Idempotency())
 	{
 		volunteers.POST("", handlers.CreateVolunteerHandler)
 		volunteers.GET("", handlers.GetAllVolunteersHandler)
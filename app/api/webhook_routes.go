@@ -0,0 +1,27 @@
+package api
+
+import (
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWebhookRoutes configures the admin routes managing outgoing webhooks
+func SetupWebhookRoutes(r *gin.RouterGroup) {
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(middleware.AuthRequired(), middleware.Idempotency(),
+		middleware.RequireRole(models.RoleTypeSuperAdmin, models.RoleTypeAdmin))
+	{
+		webhooks.GET("/event-types", handlers.GetWebhookEventTypesHandler)
+		webhooks.POST("", handlers.CreateWebhookHandler)
+		webhooks.GET("", handlers.GetWebhooksHandler)
+		webhooks.GET("/:webhook_id", handlers.GetWebhookHandler)
+		webhooks.PUT("/:webhook_id", handlers.UpdateWebhookHandler)
+		webhooks.DELETE("/:webhook_id", handlers.DeleteWebhookHandler)
+		webhooks.POST("/:webhook_id/rotate-secret", handlers.RotateWebhookSecretHandler)
+		webhooks.POST("/:webhook_id/test", handlers.TestWebhookHandler)
+		webhooks.GET("/:webhook_id/deliveries", handlers.GetWebhookDeliveriesHandler)
+		webhooks.POST("/:webhook_id/deliveries/:delivery_id/replay", handlers.ReplayWebhookDeliveryHandler)
+	}
+}
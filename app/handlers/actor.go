@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// getActor builds the acting user (ID, email and role) from the gin context
+func getActor(c *gin.Context) (services.Actor, bool) {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return services.Actor{}, false
+	}
+
+	email, _ := middleware.GetUserEmail(c)
+	roleName, _ := middleware.GetRoleName(c)
+
+	return services.Actor{
+		UserID: userID,
+		Email:  email,
+		Role:   roleName,
+	}, true
+}
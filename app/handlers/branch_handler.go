@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -38,6 +39,8 @@ type BranchCreateRequest struct {
 	Pincode        string      `json:"pincode,omitempty"`
 	PostOffice     string      `json:"post_office,omitempty"`
 	PoliceStation  string      `json:"police_station,omitempty"`
+	Latitude       *float64    `json:"latitude,omitempty"`
+	Longitude      *float64    `json:"longitude,omitempty"`
 	OpenDays       string      `json:"open_days,omitempty"`
 	DailyStartTime string      `json:"daily_start_time,omitempty"`
 	DailyEndTime   string      `json:"daily_end_time,omitempty"`
@@ -76,6 +79,8 @@ func (r *BranchCreateRequest) ToBranch() (*models.Branch, error) {
 		Pincode:         r.Pincode,
 		PostOffice:      r.PostOffice,
 		PoliceStation:   r.PoliceStation,
+		Latitude:        r.Latitude,
+		Longitude:       r.Longitude,
 		OpenDays:        r.OpenDays,
 		DailyStartTime:  r.DailyStartTime,
 		DailyEndTime:    r.DailyEndTime,
@@ -294,6 +299,10 @@ func CreateBranchHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validators.ValidateCoordinates(branch.Latitude, branch.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := services.CreateBranch(branch); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -356,7 +365,7 @@ func CreateBranchHandler(c *gin.Context) {
 		}
 		// Update child branch to set parent_branch_id to created branch
 		updateData := map[string]interface{}{"parent_branch_id": branch.ID}
-		if err := services.UpdateBranch(uint(cid), updateData); err != nil {
+		if err := services.UpdateBranch(uint(cid), updateData, nil); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -367,7 +376,7 @@ func CreateBranchHandler(c *gin.Context) {
 		if memberID == 0 {
 			continue
 		}
-		if err := services.UpdateBranchMember(memberID, map[string]interface{}{"branch_id": branch.ID}); err != nil {
+		if err := services.UpdateBranchMember(memberID, map[string]interface{}{"branch_id": branch.ID}, nil); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -427,6 +436,7 @@ func GetBranchHandler(c *gin.Context) {
 		return
 	}
 
+	setETag(c, branch.Version)
 	c.JSON(http.StatusOK, branch)
 }
 
@@ -501,9 +511,13 @@ func GetChildBranchesHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Branch ID"
+// @Param If-Match header string false "ETag of the record the change is based on (required when the server enforces it)"
 // @Param branch body map[string]interface{} true "Updated fields"
 // @Success 200 {object} models.Branch
+// @Header 200 {string} ETag "New version of the branch"
 // @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]interface{} "The record changed since If-Match; the body holds its current representation"
+// @Failure 428 {object} map[string]string "If-Match is required"
 // @Failure 500 {object} map[string]string
 // @Router /api/branches/{id} [put]
 func UpdateBranchHandler(c *gin.Context) {
@@ -514,6 +528,11 @@ func UpdateBranchHandler(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	// Bind into a generic map so we can accept nested keys (infrastructure, child_branches, branch_members)
 	var payload map[string]interface{}
 	if err := c.ShouldBindJSON(&payload); err != nil {
@@ -540,6 +559,7 @@ func UpdateBranchHandler(c *gin.Context) {
 	delete(payload, "id")         // Should not be updated
 	delete(payload, "created_on") // Should not be updated
 	delete(payload, "created_by") // Should not be updated
+	delete(payload, "version")    // Maintained by the database; sent as If-Match instead
 
 	// Handle empty strings - convert to nil for optional fields
 	// Email: if empty string, remove it (don't update) or set to nil if explicitly clearing
@@ -665,13 +685,21 @@ func UpdateBranchHandler(c *gin.Context) {
 	}
 
 	// Update branch table
-	if err := services.UpdateBranch(uint(branchID), payload); err != nil {
+	if err := services.UpdateBranch(uint(branchID), payload, expectedVersion); err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			respondBranchVersionConflict(c, uint(branchID))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Infrastructure, child branches and members are part of the branch: changing them moves its version
+	subResourcesChanged := false
+
 	// Process infrastructure: replace existing infra with provided list (if provided)
 	if hasInfra {
+		subResourcesChanged = true
 		// Delete existing infra for branch
 		if existing, err := services.GetInfrastructureByBranch(uint(branchID)); err == nil {
 			for _, e := range existing {
@@ -775,7 +803,9 @@ func UpdateBranchHandler(c *gin.Context) {
 								if address, ok := m["address"]; ok && address != nil && address != "" {
 									updateData["address"] = address
 								}
-								_ = services.UpdateBranch(uint(cid), updateData)
+								if services.UpdateBranch(uint(cid), updateData, nil) == nil {
+									subResourcesChanged = true
+								}
 							}
 						}
 					}
@@ -791,21 +821,33 @@ func UpdateBranchHandler(c *gin.Context) {
 				switch v := item.(type) {
 				case float64:
 					mid := uint(v)
-					_ = services.UpdateBranchMember(mid, map[string]interface{}{"branch_id": uint(branchID)})
+					if services.UpdateBranchMember(mid, map[string]interface{}{"branch_id": uint(branchID)}, nil) == nil {
+						subResourcesChanged = true
+					}
 				case int:
 					mid := uint(v)
-					_ = services.UpdateBranchMember(mid, map[string]interface{}{"branch_id": uint(branchID)})
+					if services.UpdateBranchMember(mid, map[string]interface{}{"branch_id": uint(branchID)}, nil) == nil {
+						subResourcesChanged = true
+					}
 				}
 			}
 		}
 	}
 
+	if subResourcesChanged {
+		if err := services.BumpBranchVersion(uint(branchID)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Return the updated branch object (with relations preloaded)
 	branch, err := services.GetBranch(uint(branchID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, branch.Version)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Branch updated successfully",
@@ -813,6 +855,16 @@ func UpdateBranchHandler(c *gin.Context) {
 	})
 }
 
+// respondBranchVersionConflict answers 412 with the current representation of the branch
+func respondBranchVersionConflict(c *gin.Context, branchID uint) {
+	branch, err := services.GetBranch(branchID)
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": services.ErrVersionConflict.Error()})
+		return
+	}
+	respondVersionConflict(c, branch.Version, branch)
+}
+
 // DeleteBranchHandler godoc
 // @Summary Delete a branch
 // @Description Delete a branch by ID. This will also delete associated infrastructure and members
@@ -832,7 +884,8 @@ func DeleteBranchHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteBranch(uint(branchID)); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteBranch(uint(branchID), actor.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1135,9 +1188,13 @@ func GetMembersByBranchHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Member ID"
+// @Param If-Match header string false "ETag of the record the change is based on (required when the server enforces it)"
 // @Param member body map[string]interface{} true "Updated fields"
 // @Success 200 {object} map[string]string
+// @Header 200 {string} ETag "New version of the member"
 // @Failure 400 {object} map[string]string
+// @Failure 412 {object} map[string]interface{} "The record changed since If-Match; the body holds its current representation"
+// @Failure 428 {object} map[string]string "If-Match is required"
 // @Failure 500 {object} map[string]string
 // @Router /api/branch-member/{id} [put]
 func UpdateBranchMemberHandler(c *gin.Context) {
@@ -1148,11 +1205,17 @@ func UpdateBranchMemberHandler(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	var updateData map[string]interface{}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	delete(updateData, "version") // Maintained by the database; sent as If-Match instead
 
 	// Validate update fields
 	if err := validators.ValidateBranchMemberUpdateFields(updateData); err != nil {
@@ -1160,11 +1223,23 @@ func UpdateBranchMemberHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.UpdateBranchMember(uint(id), updateData); err != nil {
+	if err := services.UpdateBranchMember(uint(id), updateData, expectedVersion); err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			var member models.BranchMember
+			if config.DB.First(&member, id).Error != nil {
+				c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+				return
+			}
+			respondVersionConflict(c, member.Version, member)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	if version, err := services.GetBranchMemberVersion(uint(id)); err == nil && version > 0 {
+		setETag(c, version)
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Branch member updated successfully"})
 }
 
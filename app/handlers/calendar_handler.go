@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// GetEventICSHandler godoc
+// @Summary Download an event as iCalendar
+// @Description Returns a single event as an .ics file that can be imported into a calendar app
+// @Tags Calendar
+// @Security ApiKeyAuth
+// @Produce text/calendar
+// @Param event_id path int true "Event ID"
+// @Success 200 {file} file "iCalendar file"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/ics [get]
+func GetEventICSHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := services.GetEventByID(uint(eventID))
+	if err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event"})
+		return
+	}
+
+	ics := services.BuildEventsICS("", []models.EventDetails{*event})
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=event_%d.ics", event.ID))
+	c.Data(http.StatusOK, calendarContentType, ics)
+}
+
+// GetCalendarFeedHandler godoc
+// @Summary Subscribable calendar feed
+// @Description iCalendar feed of the upcoming (and last 30 days of) events of a branch (including child branches), a region or a spiritual orator. Authenticated by a calendar feed token in the query string because calendar apps cannot send the Authorization header.
+// @Tags Calendar
+// @Produce text/calendar
+// @Param scope path string true "branch, region or orator"
+// @Param value path string true "Branch ID, region ID or orator name, optionally followed by .ics"
+// @Param token query string true "Calendar feed token"
+// @Success 200 {file} file "iCalendar feed"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/calendar/feeds/{scope}/{value} [get]
+func GetCalendarFeedHandler(c *gin.Context) {
+	value := strings.TrimSuffix(c.Param("value"), ".ics")
+	var scope services.CalendarFeedScope
+	var name string
+
+	switch c.Param("scope") {
+	case "branch":
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch ID"})
+			return
+		}
+		scope.BranchID = uint(id)
+		name = "Branch events"
+		if branch, err := services.GetBranch(uint(id)); err == nil {
+			name = branch.Name + " events"
+		}
+	case "region":
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region ID"})
+			return
+		}
+		scope.RegionID = uint(id)
+		name = fmt.Sprintf("Region %d events", id)
+	case "orator":
+		if strings.TrimSpace(value) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Orator name is required"})
+			return
+		}
+		scope.Orator = value
+		name = value + " events"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be branch, region or orator"})
+		return
+	}
+
+	events, err := services.GetCalendarFeedEvents(scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, calendarContentType, services.BuildEventsICS(name, events))
+}
+
+// CreateCalendarFeedTokenHandler godoc
+// @Summary Create a calendar feed token
+// @Description Issues a token for subscribing to calendar feeds. The token is shown only once; revoke it to stop all subscriptions using it.
+// @Tags Calendar
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param token body object false "{\"name\": \"My phone\"}"
+// @Success 201 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/calendar/tokens [post]
+func CreateCalendarFeedTokenHandler(c *gin.Context) {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	_ = c.ShouldBindJSON(&req)
+
+	feedToken, token, err := services.CreateCalendarFeedToken(userID, req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar feed token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":      token,
+		"feed_token": feedToken,
+		"feed_path":  "/api/calendar/feeds/{branch|region|orator}/{id or name}.ics?token=" + token,
+	})
+}
+
+// GetCalendarFeedTokensHandler godoc
+// @Summary List calendar feed tokens
+// @Description Lists the caller's calendar feed tokens (without the token values)
+// @Tags Calendar
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.CalendarFeedToken
+// @Failure 401 {object} map[string]string
+// @Router /api/calendar/tokens [get]
+func GetCalendarFeedTokensHandler(c *gin.Context) {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	tokens, err := services.GetCalendarFeedTokens(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch calendar feed tokens"})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// RevokeCalendarFeedTokenHandler godoc
+// @Summary Revoke a calendar feed token
+// @Tags Calendar
+// @Security ApiKeyAuth
+// @Param id path int true "Token ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/calendar/tokens/{id} [delete]
+func RevokeCalendarFeedTokenHandler(c *gin.Context) {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+	if err := services.RevokeCalendarFeedToken(userID, uint(tokenID)); err != nil {
+		if errors.Is(err, services.ErrCalendarFeedTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke calendar feed token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed token revoked"})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -107,6 +108,7 @@ func GetChildBranchHandler(c *gin.Context) {
 		return
 	}
 
+	setETag(c, childBranch.Version)
 	c.JSON(http.StatusOK, childBranch)
 }
 
@@ -145,10 +147,14 @@ func GetChildBranchesByParentHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Child Branch ID"
+// @Param If-Match header string false "ETag of the record the change is based on (required when the server enforces it)"
 // @Param childBranch body map[string]interface{} true "Update Data"
 // @Success 200 {object} models.Branch
+// @Header 200 {string} ETag "New version of the child branch"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]interface{} "The record changed since If-Match; the body holds its current representation"
+// @Failure 428 {object} map[string]string "If-Match is required"
 // @Router /api/child-branches/{id} [put]
 func UpdateChildBranchHandler(c *gin.Context) {
 	idParam := c.Param("id")
@@ -158,6 +164,11 @@ func UpdateChildBranchHandler(c *gin.Context) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
 	var updateData map[string]interface{}
 	if err := c.ShouldBindJSON(&updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -187,8 +198,17 @@ func UpdateChildBranchHandler(c *gin.Context) {
 	delete(updateData, "created_on")
 	delete(updateData, "created_by")
 	delete(updateData, "parent_branch_id") // Don't allow changing parent
-
-	if err := services.UpdateChildBranch(uint(id), updateData); err != nil {
+	delete(updateData, "version")          // Maintained by the database; sent as If-Match instead
+
+	if err := services.UpdateChildBranch(uint(id), updateData, expectedVersion); err != nil {
+		if errors.Is(err, services.ErrVersionConflict) {
+			if current, err := services.GetChildBranch(uint(id)); err == nil {
+				respondVersionConflict(c, current.Version, current)
+				return
+			}
+			c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -200,6 +220,7 @@ func UpdateChildBranchHandler(c *gin.Context) {
 		return
 	}
 
+	setETag(c, updatedBranch.Version)
 	c.JSON(http.StatusOK, updatedBranch)
 }
 
@@ -222,7 +243,8 @@ func DeleteChildBranchHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteChildBranch(uint(id)); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteChildBranch(uint(id), actor.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -16,6 +16,7 @@ import (
 // @Security ApiKeyAuth
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key replays the first response (header Idempotent-Replayed; status and body only, headers such as ETag are not replayed), a different payload with the same key is rejected with 422"
 // @Param donation body models.Donation true "Donation Payload"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
@@ -148,7 +149,8 @@ func DeleteDonation(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteDonation(uint(donationID)); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteDonation(uint(donationID), actor.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// requireIfMatch makes If-Match mandatory on updates of versioned records (events, branches, branch members)
+var requireIfMatch bool
+
+// SetRequireIfMatch turns the If-Match requirement on or off. When off, updates without the
+// header are applied unconditionally.
+func SetRequireIfMatch(required bool) {
+	requireIfMatch = required
+}
+
+// versionETag formats a record version as an entity tag
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// setETag exposes the version of a record as the ETag of the response
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", versionETag(version))
+}
+
+// ifMatchVersion reads the If-Match header of an update as the version the change is based on.
+// It returns nil for "*" or (unless required) a missing header. On a malformed or missing
+// required header it answers 400/428 itself and returns false.
+func ifMatchVersion(c *gin.Context) (*int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if requireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the ETag of the record is required"})
+			return nil, false
+		}
+		return nil, true
+	}
+	if header == "*" {
+		return nil, true
+	}
+
+	// Proxies that compress responses weaken ETags, so W/ is accepted as well
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) >= 2 && strings.HasPrefix(tag, `"`) && strings.HasSuffix(tag, `"`) {
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil && version > 0 {
+			return &version, true
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be a single ETag as returned by the API, e.g. \"3\""})
+	return nil, false
+}
+
+// respondVersionConflict answers 412 with the current representation of the record and its ETag
+func respondVersionConflict(c *gin.Context, version int, current interface{}) {
+	setETag(c, version)
+	c.JSON(http.StatusPreconditionFailed, gin.H{
+		"error":   services.ErrVersionConflict.Error(),
+		"current": current,
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetImpactAnalyticsHandler godoc
+// @Summary Event impact analytics
+// @Description Aggregates beneficiary and initiation numbers of events grouped by month, quarter, year, branch, region, state, event type, event category or language.
+// @Description Only completed reports count by default (status=all counts every report). With compare=true the totals are compared with the preceding period of the same length.
+// @Description Co-hosted events count for every hosting branch in the branch and region groups and under branch_id, with their beneficiaries and initiations split by the hosts' shares; national totals count each event once.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param group_by query string false "month, quarter, year, branch, region, state, event_type, event_category or language (default month)"
+// @Param start_date query string false "Events starting on or after this date (YYYY-MM-DD)"
+// @Param end_date query string false "Events starting on or before this date (YYYY-MM-DD)"
+// @Param status query string false "Comma-separated statuses to count (default complete, 'all' for every status)"
+// @Param workflow_state query string false "Comma-separated workflow states to count (e.g. approved)"
+// @Param branch_id query int false "Restrict to the events led or co-hosted by a branch and its child branches (credited with their share)"
+// @Param series_id query int false "Restrict to the occurrences of a recurring series"
+// @Param compare query bool false "Compare with the preceding period (requires start_date and end_date)"
+// @Param top query int false "Number of top branches (default 10)"
+// @Param format query string false "json (default) or xlsx"
+// @Success 200 {object} services.ImpactAnalytics
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/analytics [get]
+func GetImpactAnalyticsHandler(c *gin.Context) {
+	query := services.ImpactAnalyticsQuery{
+		GroupBy:  c.DefaultQuery("group_by", "month"),
+		Statuses: []string{"complete"},
+	}
+
+	if status := c.Query("status"); status == "all" {
+		query.Statuses = nil
+	} else if status != "" {
+		query.Statuses = splitQueryList(status)
+	}
+	for _, state := range splitQueryList(c.Query("workflow_state")) {
+		if !models.IsValidWorkflowState(state) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow_state: " + state})
+			return
+		}
+		query.WorkflowStates = append(query.WorkflowStates, state)
+	}
+
+	if v := c.Query("start_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format. Use YYYY-MM-DD"})
+			return
+		}
+		query.From = &parsed
+	}
+	if v := c.Query("end_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format. Use YYYY-MM-DD"})
+			return
+		}
+		// The end date is inclusive; the service takes an exclusive bound
+		to := parsed.AddDate(0, 0, 1)
+		query.To = &to
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be before or equal to end_date"})
+		return
+	}
+
+	if v := c.Query("branch_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch_id"})
+			return
+		}
+		query.BranchID = uint(id)
+	}
+	if v := c.Query("series_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series_id"})
+			return
+		}
+		query.SeriesID = uint(id)
+	}
+	if v := c.Query("top"); v != "" {
+		top, err := strconv.Atoi(v)
+		if err != nil || top <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid top"})
+			return
+		}
+		query.TopN = top
+	}
+	query.Compare = c.Query("compare") == "true"
+	if query.Compare && (query.From == nil || query.To == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "compare requires start_date and end_date"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format. Use json or xlsx"})
+		return
+	}
+
+	analytics, err := services.GetImpactAnalytics(query)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAnalyticsGroupBy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics: " + err.Error()})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, analytics)
+		return
+	}
+
+	excelBuffer, err := services.ExportImpactAnalyticsToExcel(analytics)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate Excel file: " + err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("impact_by_%s.xlsx", query.GroupBy)
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Length", fmt.Sprintf("%d", excelBuffer.Len()))
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", excelBuffer.Bytes())
+}
+
+// splitQueryList splits a comma-separated query value, dropping empty entries
+func splitQueryList(value string) []string {
+	var items []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
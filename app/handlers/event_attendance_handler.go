@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// eventAttendanceRequest is the payload of one day/session of attendance
+type eventAttendanceRequest struct {
+	AttendanceDate string `json:"attendance_date" example:"2024-01-15"`
+	Session        string `json:"session,omitempty" example:"morning"`
+	Men            int    `json:"men"`
+	Women          int    `json:"women"`
+	Children       int    `json:"children"`
+	NewAttendees   int    `json:"new_attendees"`
+}
+
+func (r eventAttendanceRequest) toModel() (models.EventAttendance, error) {
+	date, err := time.Parse("2006-01-02", r.AttendanceDate)
+	if err != nil {
+		return models.EventAttendance{}, errors.New("attendance_date must be in YYYY-MM-DD format")
+	}
+	return models.EventAttendance{
+		AttendanceDate: date,
+		Session:        r.Session,
+		Men:            r.Men,
+		Women:          r.Women,
+		Children:       r.Children,
+		NewAttendees:   r.NewAttendees,
+	}, nil
+}
+
+// respondAttendanceError maps attendance service errors to HTTP responses
+func respondAttendanceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrAttendanceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAttendanceDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAttendanceOutOfRange), errors.Is(err, services.ErrInvalidAttendance):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseAttendanceIDs reads the event and (optionally) attendance ID path parameters
+func parseAttendanceIDs(c *gin.Context, withAttendance bool) (uint, uint, bool) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return 0, 0, false
+	}
+	if !withAttendance {
+		return uint(eventID), 0, true
+	}
+	attendanceID, err := strconv.ParseUint(c.Param("attendance_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attendance ID"})
+		return 0, 0, false
+	}
+	return uint(eventID), uint(attendanceID), true
+}
+
+// GetEventAttendanceHandler godoc
+// @Summary Get the day-wise attendance of an event
+// @Description Returns the attendance records of an event, a per-day series covering every day from the start to the end date (sessions of a day summed) and the totals
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Success 200 {object} services.EventAttendanceSummary
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/attendance [get]
+func GetEventAttendanceHandler(c *gin.Context) {
+	eventID, _, ok := parseAttendanceIDs(c, false)
+	if !ok {
+		return
+	}
+	summary, err := services.GetEventAttendance(eventID)
+	if err != nil {
+		respondAttendanceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// AddEventAttendanceHandler godoc
+// @Summary Record the attendance of a day
+// @Description Adds the attendance of one day (and optional session) of the event. The date must fall within the event dates; the event's beneficiary counts are re-derived from all attendance records.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param attendance body eventAttendanceRequest true "Attendance"
+// @Success 201 {object} models.EventAttendance
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/events/{event_id}/attendance [post]
+func AddEventAttendanceHandler(c *gin.Context) {
+	eventID, _, ok := parseAttendanceIDs(c, false)
+	if !ok {
+		return
+	}
+	var req eventAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	record, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	created, err := services.AddEventAttendance(eventID, record, actor)
+	if err != nil {
+		respondAttendanceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// ReplaceEventAttendanceHandler godoc
+// @Summary Replace the day-wise attendance of an event
+// @Description Replaces all attendance records of the event with the submitted list (e.g. from a day-wise grid). An empty list clears the attendance.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param attendance body []eventAttendanceRequest true "Attendance records"
+// @Success 200 {object} services.EventAttendanceSummary
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/events/{event_id}/attendance [put]
+func ReplaceEventAttendanceHandler(c *gin.Context) {
+	eventID, _, ok := parseAttendanceIDs(c, false)
+	if !ok {
+		return
+	}
+	var req []eventAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	records := make([]models.EventAttendance, 0, len(req))
+	for i, item := range req {
+		record, err := item.toModel()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "record " + strconv.Itoa(i+1) + ": " + err.Error()})
+			return
+		}
+		records = append(records, record)
+	}
+
+	actor, _ := getActor(c)
+	if _, err := services.ReplaceEventAttendance(eventID, records, actor); err != nil {
+		respondAttendanceError(c, err)
+		return
+	}
+	summary, err := services.GetEventAttendance(eventID)
+	if err != nil {
+		respondAttendanceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// UpdateEventAttendanceHandler godoc
+// @Summary Update an attendance record
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param attendance_id path int true "Attendance record ID"
+// @Param attendance body eventAttendanceRequest true "Attendance"
+// @Success 200 {object} models.EventAttendance
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/events/{event_id}/attendance/{attendance_id} [put]
+func UpdateEventAttendanceHandler(c *gin.Context) {
+	eventID, attendanceID, ok := parseAttendanceIDs(c, true)
+	if !ok {
+		return
+	}
+	var req eventAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	record, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	updated, err := services.UpdateEventAttendance(eventID, attendanceID, record, actor)
+	if err != nil {
+		respondAttendanceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteEventAttendanceHandler godoc
+// @Summary Delete an attendance record
+// @Tags Events
+// @Security ApiKeyAuth
+// @Param event_id path int true "Event ID"
+// @Param attendance_id path int true "Attendance record ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/attendance/{attendance_id} [delete]
+func DeleteEventAttendanceHandler(c *gin.Context) {
+	eventID, attendanceID, ok := parseAttendanceIDs(c, true)
+	if !ok {
+		return
+	}
+	actor, _ := getActor(c)
+	if err := services.DeleteEventAttendance(eventID, attendanceID, actor); err != nil {
+		respondAttendanceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attendance record deleted successfully"})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// respondCoHostError maps co-host service errors to HTTP responses
+func respondCoHostError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCoHost):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetEventCoHostsHandler godoc
+// @Summary Get the hosting branches of an event
+// @Description Returns the lead branch of an event, the branches co-hosting it and the share of the beneficiaries credited to each in branch analytics (the lead branch keeps what the co-hosts' shares leave)
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Success 200 {object} services.EventHosting
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/cohosts [get]
+func GetEventCoHostsHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	hosting, err := services.GetEventHosting(uint(eventID))
+	if err != nil {
+		respondCoHostError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, hosting)
+}
+
+// ReplaceEventCoHostsHandler godoc
+// @Summary Replace the co-hosts of an event
+// @Description Replaces the branches participating in the event with the submitted list; an empty list makes the lead branch the only host. The event must have a lead branch, which cannot also be a co-host. Shares are optional percentages of the beneficiaries and may add up to at most 100. A change is recorded in the event's revision history. Co-hosted events appear in the listings, calendars and analytics of every hosting branch.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param cohosts body []services.EventCoHostInput true "Co-hosting branches"
+// @Success 200 {object} services.EventHosting
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/cohosts [put]
+func ReplaceEventCoHostsHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	var req []services.EventCoHostInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	hosting, err := services.SetEventCoHosts(uint(eventID), req, actor)
+	if err != nil {
+		respondCoHostError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, hosting)
+}
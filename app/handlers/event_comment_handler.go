@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// eventCommentRequest is the payload of a new comment
+type eventCommentRequest struct {
+	Body     string `json:"body" example:"Volunteer count is missing for day 2. @coordinator@example.org please check."`
+	Section  string `json:"section,omitempty" example:"volunteers"`
+	ParentID *uint  `json:"parent_id,omitempty"`
+}
+
+// respondCommentError maps comment service errors to HTTP responses
+func respondCommentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrCommentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCommentNotAuthor):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidComment), errors.Is(err, services.ErrCommentNotThreadRoot):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseCommentIDs reads the event and (optionally) comment ID path parameters
+func parseCommentIDs(c *gin.Context, withComment bool) (uint, uint, bool) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return 0, 0, false
+	}
+	if !withComment {
+		return uint(eventID), 0, true
+	}
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return 0, 0, false
+	}
+	return uint(eventID), uint(commentID), true
+}
+
+// GetEventCommentsHandler godoc
+// @Summary List the comment threads of an event
+// @Description Returns the top-level comments of an event with their replies, oldest first
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param section query string false "Only threads anchored to this section (general, participants, special_guests, volunteers, media, promotion, donations, attendance)"
+// @Param status query string false "open or resolved (default all)"
+// @Success 200 {array} models.EventComment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/comments [get]
+func GetEventCommentsHandler(c *gin.Context) {
+	eventID, _, ok := parseCommentIDs(c, false)
+	if !ok {
+		return
+	}
+	filter := services.EventCommentFilter{Section: c.Query("section"), Status: c.Query("status")}
+	if !models.IsValidCommentSection(filter.Section) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section"})
+		return
+	}
+	if filter.Status != "" && filter.Status != services.CommentStatusOpen && filter.Status != services.CommentStatusResolved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or resolved"})
+		return
+	}
+
+	threads, err := services.GetEventComments(eventID, filter)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, threads)
+}
+
+// AddEventCommentHandler godoc
+// @Summary Comment on an event
+// @Description Starts a thread (optionally anchored to a section) or, with parent_id, replies to one; a reply reopens a resolved thread. Users are mentioned with @ followed by their e-mail address.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param comment body eventCommentRequest true "Comment"
+// @Success 201 {object} models.EventComment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/comments [post]
+func AddEventCommentHandler(c *gin.Context) {
+	eventID, _, ok := parseCommentIDs(c, false)
+	if !ok {
+		return
+	}
+	var req eventCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	comment, err := services.AddEventComment(eventID, models.EventComment{
+		Body:     req.Body,
+		Section:  req.Section,
+		ParentID: req.ParentID,
+	}, actor)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateEventCommentHandler godoc
+// @Summary Edit a comment
+// @Description Replaces the body of the caller's own comment; mentions are re-read from the new body
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param comment_id path int true "Comment ID"
+// @Param comment body object true "New body" example({"body":"Updated text"})
+// @Success 200 {object} models.EventComment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/comments/{comment_id} [put]
+func UpdateEventCommentHandler(c *gin.Context) {
+	eventID, commentID, ok := parseCommentIDs(c, true)
+	if !ok {
+		return
+	}
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	comment, err := services.UpdateEventComment(eventID, commentID, req.Body, actor)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteEventCommentHandler godoc
+// @Summary Delete a comment
+// @Description Deletes the caller's own comment (admins can delete any). Deleting a top-level comment deletes its replies.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Param event_id path int true "Event ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/comments/{comment_id} [delete]
+func DeleteEventCommentHandler(c *gin.Context) {
+	eventID, commentID, ok := parseCommentIDs(c, true)
+	if !ok {
+		return
+	}
+	actor, _ := getActor(c)
+	if err := services.DeleteEventComment(eventID, commentID, actor); err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
+
+// ResolveEventCommentHandler godoc
+// @Summary Resolve a comment thread
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param comment_id path int true "Top-level comment ID"
+// @Success 200 {object} models.EventComment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/comments/{comment_id}/resolve [post]
+func ResolveEventCommentHandler(c *gin.Context) {
+	setEventCommentResolved(c, true)
+}
+
+// UnresolveEventCommentHandler godoc
+// @Summary Reopen a resolved comment thread
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param comment_id path int true "Top-level comment ID"
+// @Success 200 {object} models.EventComment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/comments/{comment_id}/unresolve [post]
+func UnresolveEventCommentHandler(c *gin.Context) {
+	setEventCommentResolved(c, false)
+}
+
+func setEventCommentResolved(c *gin.Context, resolved bool) {
+	eventID, commentID, ok := parseCommentIDs(c, true)
+	if !ok {
+		return
+	}
+	actor, _ := getActor(c)
+	comment, err := services.SetEventCommentResolved(eventID, commentID, resolved, actor)
+	if err != nil {
+		respondCommentError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+// GetMyCommentMentionsHandler godoc
+// @Summary Comments mentioning me
+// @Description Lists the latest event comments that @mention the caller, newest first
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param open query bool false "Only comments of unresolved threads"
+// @Param limit query int false "Maximum number of comments (default 50, max 200)"
+// @Success 200 {array} models.EventComment
+// @Failure 401 {object} map[string]string
+// @Router /api/events/comments/mentions [get]
+func GetMyCommentMentionsHandler(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok || actor.UserID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	comments, err := services.GetCommentsMentioningUser(actor.UserID, c.Query("open") == "true", limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mentions"})
+		return
+	}
+	c.JSON(http.StatusOK, comments)
+}
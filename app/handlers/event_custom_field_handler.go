@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+	"github.com/gin-gonic/gin"
+)
+
+// parseCustomFieldID reads the field_id path parameter, responding 400 when it is invalid
+func parseCustomFieldID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("field_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondCustomFieldError maps custom field definition errors to responses
+func respondCustomFieldError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrCustomFieldNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCustomField):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrCustomFieldKeyTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// respondCustomFieldValuesError responds to a failed check of an event's custom field values:
+// invalid values are listed by path like schema errors
+func respondCustomFieldValuesError(c *gin.Context, err error) {
+	var schemaErr *validators.SchemaError
+	switch {
+	case errors.As(err, &schemaErr):
+		respondEventSubmissionError(c, err)
+	case errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check custom fields"})
+	}
+}
+
+// GetEventCustomFieldsHandler godoc
+// @Summary List event custom fields
+// @Description Lists the custom fields defined for event categories and sub categories. With event_category_id and/or event_sub_category_id it returns the fields an event of that category gets, the category's first, each group by sort order; send their values in customFields of the event payload, by key.
+// @Tags Event Custom Fields
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_category_id query int false "Event category"
+// @Param event_sub_category_id query int false "Event sub category"
+// @Param include_inactive query bool false "Include deactivated fields (default false)"
+// @Success 200 {array} models.EventCustomField
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/event-custom-fields [get]
+func GetEventCustomFieldsHandler(c *gin.Context) {
+	var categoryID uint
+	if raw := c.Query("event_category_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event_category_id"})
+			return
+		}
+		categoryID = uint(id)
+	}
+	var subCategoryID *uint
+	if raw := c.Query("event_sub_category_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid event_sub_category_id"})
+			return
+		}
+		subID := uint(id)
+		subCategoryID = &subID
+	}
+	includeInactive := c.Query("include_inactive") == "true"
+
+	fields, err := services.GetEventCustomFields(categoryID, subCategoryID, includeInactive)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch custom fields"})
+		return
+	}
+	c.JSON(http.StatusOK, fields)
+}
+
+// GetEventCustomFieldHandler godoc
+// @Summary Get an event custom field
+// @Tags Event Custom Fields
+// @Security ApiKeyAuth
+// @Produce json
+// @Param field_id path int true "Custom field ID"
+// @Success 200 {object} models.EventCustomField
+// @Failure 404 {object} map[string]string
+// @Router /api/event-custom-fields/{field_id} [get]
+func GetEventCustomFieldHandler(c *gin.Context) {
+	id, ok := parseCustomFieldID(c)
+	if !ok {
+		return
+	}
+	field, err := services.GetEventCustomField(id)
+	if err != nil {
+		respondCustomFieldError(c, err, "failed to fetch custom field")
+		return
+	}
+	c.JSON(http.StatusOK, field)
+}
+
+// CreateEventCustomFieldHandler godoc
+// @Summary Create an event custom field
+// @Description Defines an extra field for the events of one category (event_category_id) or one sub category (event_sub_category_id). The key names the value in customFields and must be unique among the fields an event can get. field_type is text, textarea, number, integer, boolean, date (YYYY-MM-DD), select or multiselect; select and multiselect fields need options. validation may hold min/max (numbers) or min_length/max_length/pattern (text). Required fields must be filled when an event is submitted as complete.
+// @Tags Event Custom Fields
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param field body services.EventCustomFieldInput true "Custom field"
+// @Success 201 {object} models.EventCustomField
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string "Key already in use for the category"
+// @Failure 500 {object} map[string]string
+// @Router /api/event-custom-fields [post]
+func CreateEventCustomFieldHandler(c *gin.Context) {
+	var input services.EventCustomFieldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, _ := middleware.GetUserEmail(c)
+	field, err := services.CreateEventCustomField(input, email)
+	if err != nil {
+		respondCustomFieldError(c, err, "failed to create custom field")
+		return
+	}
+	c.JSON(http.StatusCreated, field)
+}
+
+// UpdateEventCustomFieldHandler godoc
+// @Summary Update an event custom field
+// @Description Changes the label, required flag, options, validation, help text, sort order or active flag of a custom field; omitted fields are left unchanged. The category, key and type cannot be changed. Deactivated fields are no longer asked for, but recorded values are still shown.
+// @Tags Event Custom Fields
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param field_id path int true "Custom field ID"
+// @Param field body services.EventCustomFieldInput true "Fields to change"
+// @Success 200 {object} models.EventCustomField
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/event-custom-fields/{field_id} [put]
+func UpdateEventCustomFieldHandler(c *gin.Context) {
+	id, ok := parseCustomFieldID(c)
+	if !ok {
+		return
+	}
+	var input services.EventCustomFieldInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, _ := middleware.GetUserEmail(c)
+	field, err := services.UpdateEventCustomField(id, input, email)
+	if err != nil {
+		respondCustomFieldError(c, err, "failed to update custom field")
+		return
+	}
+	c.JSON(http.StatusOK, field)
+}
+
+// DeleteEventCustomFieldHandler godoc
+// @Summary Delete an event custom field
+// @Description Deletes a custom field. Values already recorded stay on the events but are no longer shown or exported; deactivate the field to keep them visible.
+// @Tags Event Custom Fields
+// @Security ApiKeyAuth
+// @Produce json
+// @Param field_id path int true "Custom field ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/event-custom-fields/{field_id} [delete]
+func DeleteEventCustomFieldHandler(c *gin.Context) {
+	id, ok := parseCustomFieldID(c)
+	if !ok {
+		return
+	}
+	if err := services.DeleteEventCustomField(id); err != nil {
+		respondCustomFieldError(c, err, "failed to delete custom field")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Custom field deleted successfully"})
+}
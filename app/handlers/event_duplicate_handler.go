@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// confirmedNotDuplicate reports whether the client asked to save despite likely duplicates
+func confirmedNotDuplicate(c *gin.Context) bool {
+	return c.Query("create_anyway") == "true"
+}
+
+// respondPossibleDuplicates answers 409 with the likely duplicates so the client can ask the user
+func respondPossibleDuplicates(c *gin.Context, duplicates []services.DuplicateCandidate) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":      "Possible duplicate event",
+		"message":    "Similar events already exist for this branch and dates. Resend the request with create_anyway=true to save anyway.",
+		"duplicates": duplicates,
+	})
+}
+
+// checkUpdateDuplicates runs the duplicate check for an update and answers 409 when the changed
+// event looks like a duplicate. It returns false when the handler must stop.
+func checkUpdateDuplicates(c *gin.Context, eventID uint, updateData map[string]interface{}) bool {
+	if confirmedNotDuplicate(c) {
+		return true
+	}
+	duplicates, err := services.FindEventDuplicatesForUpdate(eventID, updateData)
+	if err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return false
+		}
+		log.Printf("Warning: Duplicate check failed for event %d: %v", eventID, err)
+		return true
+	}
+	if len(duplicates) > 0 {
+		respondPossibleDuplicates(c, duplicates)
+		return false
+	}
+	return true
+}
+
+// GetDuplicateSuspectsHandler godoc
+// @Summary List suspected duplicate events
+// @Description Lists pairs of events the periodic duplicate scan considers likely duplicates (same branch, overlapping dates, same type/category, similar place), highest score first
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param include_dismissed query bool false "Include pairs an admin dismissed"
+// @Success 200 {array} models.EventDuplicateSuspect
+// @Failure 500 {object} map[string]string
+// @Router /api/events/duplicates [get]
+func GetDuplicateSuspectsHandler(c *gin.Context) {
+	suspects, err := services.GetDuplicateSuspects(c.Query("include_dismissed") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duplicate suspects"})
+		return
+	}
+	c.JSON(http.StatusOK, suspects)
+}
+
+// ScanEventDuplicatesHandler godoc
+// @Summary Scan all events for duplicates
+// @Description Runs the duplicate scan now instead of waiting for the daily run
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/events/duplicates/scan [post]
+func ScanEventDuplicatesHandler(c *gin.Context) {
+	found, err := services.ScanEventDuplicates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Duplicate scan completed", "suspected_pairs": found})
+}
+
+// DismissDuplicateSuspectHandler godoc
+// @Summary Dismiss a suspected duplicate
+// @Description Marks a suspected pair as not a duplicate; later scans do not report it again
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param suspect_id path int true "Suspect ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/duplicates/{suspect_id}/dismiss [post]
+func DismissDuplicateSuspectHandler(c *gin.Context) {
+	suspectID, err := strconv.ParseUint(c.Param("suspect_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suspect ID"})
+		return
+	}
+	actor, _ := getActor(c)
+	if err := services.DismissDuplicateSuspect(uint(suspectID), actor.Email); err != nil {
+		if errors.Is(err, services.ErrDuplicateSuspectNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss duplicate suspect"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Duplicate suspect dismissed"})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -21,36 +23,36 @@ import (
 
 // CreateEventHandler godoc
 // @Summary Create a new event
-// @Description Creates a new event from frontend payload structure. Accepts generalDetails, mediaPromotion, involvedParticipants, donationTypes, materialTypes, specialGuests, volunteers, uploadedFiles, and optional draftId. If draftId is provided, the draft will be automatically deleted from event_drafts table after successful event creation.
+// @Description Creates a new event from frontend payload structure. Accepts generalDetails, mediaPromotion, involvedParticipants, donationTypes, materialTypes, specialGuests, volunteers, uploadedFiles, customFields (values of the custom fields of the event category, see GET /api/event-custom-fields; required ones must be filled when status is complete), and optional draftId. If draftId is provided, the draft will be automatically deleted from event_drafts table after successful event creation.
 // @Tags Events
 // @Security ApiKeyAuth
 // @Accept json
 // @Produce json
-// @Param event body object true "Frontend event payload" example({"generalDetails":{"eventType":"Spiritual","scale":"Large (L)","theme":"Devotional"},"mediaPromotion":{},"involvedParticipants":{"beneficiariesMen":50},"donationTypes":[],"materialTypes":[],"specialGuests":[],"volunteers":[],"uploadedFiles":{},"draftId":1})
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key replays the first response (header Idempotent-Replayed; status and body only, headers such as ETag are not replayed), a different payload with the same key is rejected with 422"
+// @Param create_anyway query bool false "Create even if likely duplicates exist"
+// @Param dry_run query bool false "Only validate: run the whole pipeline in a rolled-back transaction and return the per-step report (see POST /api/events/validate)"
+// @Param event body object true "Frontend event payload (see GET /api/events/schema/{version}); schemaVersion defaults to the latest version" example({"schemaVersion":1,"generalDetails":{"eventType":"Spiritual","eventCategory":"Satsang","duration":"01 Jan 2024 - 02 Jan 2024","scale":"Large (L)","theme":"Devotional"},"mediaPromotion":{},"involvedParticipants":{"beneficiariesMen":50},"donationTypes":[],"materialTypes":[],"specialGuests":[],"volunteers":[],"uploadedFiles":{},"draftId":1})
 // @Success 201 {object} map[string]interface{} "Event created successfully" example({"message":"Event created successfully","event":{"id":1,"event_type_id":1,"event_category_id":1}})
-// @Failure 400 {object} map[string]string "Bad Request" example({"error":"Invalid event data"})
+// @Failure 400 {object} handlers.eventSubmissionErrorResponse "Invalid fields"
+// @Failure 409 {object} map[string]interface{} "Likely duplicates found; resend with create_anyway=true to create anyway"
 // @Failure 500 {object} map[string]string "Internal Server Error" example({"error":"Failed to create event"})
 // @Router /api/events [post]
 func CreateEventHandler(c *gin.Context) {
-	// Accept frontend payload structure
-	var frontendPayload struct {
-		GeneralDetails       map[string]interface{} `json:"generalDetails"`
-		MediaPromotion       map[string]interface{} `json:"mediaPromotion"`
-		InvolvedParticipants map[string]interface{} `json:"involvedParticipants"`
-		DonationTypes        []interface{}          `json:"donationTypes"`
-		MaterialTypes        []interface{}          `json:"materialTypes"`
-		SpecialGuests        []interface{}          `json:"specialGuests"`
-		Volunteers           []interface{}          `json:"volunteers"`
-		UploadedFiles        map[string]interface{} `json:"uploadedFiles"`
-		DraftID              *uint                  `json:"draftId,omitempty"`
-		Status               string                 `json:"status,omitempty"`
-	}
-
-	// Try to bind frontend payload structure first
-	if err := c.ShouldBindJSON(&frontendPayload); err != nil {
+	// Strictly decode the frontend payload against its schema version
+	body, err := c.GetRawData()
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
 		return
 	}
+	if isDryRun(c) {
+		c.JSON(http.StatusOK, services.ValidateEventCreate(body))
+		return
+	}
+	frontendPayload, _, err := services.DecodeEventSubmission(body)
+	if err != nil {
+		respondEventSubmissionError(c, err)
+		return
+	}
 
 	// Process frontend payload - map to EventDetails with status support
 	event, err := services.MapFrontendPayloadToEventWithStatus(frontendPayload.GeneralDetails, frontendPayload.InvolvedParticipants, frontendPayload.Status)
@@ -64,18 +66,34 @@ func CreateEventHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validators.ValidateCoordinates(event.Latitude, event.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// Create event in main table
-	if err := services.CreateEvent(event); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create event"})
+	// Check the values of the category's custom fields
+	if err := services.ApplyEventCustomFields(event, frontendPayload.CustomFields); err != nil {
+		respondCustomFieldValuesError(c, err)
 		return
 	}
 
-	// Create related records (media, special guests, volunteers, donations, etc.)
-	if err := services.CreateEventRelatedData(event.ID, frontendPayload); err != nil {
-		// Log error but don't fail event creation
-		// The event is already created, related data can be added later
-		log.Printf("Warning: Failed to create related data: %v", err)
+	// Stop at likely duplicates unless the client confirmed creating anyway
+	if !confirmedNotDuplicate(c) {
+		duplicates, err := services.FindEventDuplicates(event, 0)
+		if err != nil {
+			log.Printf("Warning: Duplicate check failed: %v", err)
+		} else if len(duplicates) > 0 {
+			respondPossibleDuplicates(c, duplicates)
+			return
+		}
+	}
+
+	// Create the event with its related records (media, special guests, volunteers, donations,
+	// etc.) and its first revision
+	actor, _ := getActor(c)
+	if err := services.CreateEvent(event, frontendPayload, actor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create event"})
+		return
 	}
 
 	// Delete draft ONLY after successful event creation with status='complete' (submit)
@@ -96,21 +114,69 @@ func CreateEventHandler(c *gin.Context) {
 
 // GetAllEventsHandler godoc
 // @Summary Get all events
-// @Description Get all events, optionally filtered by status (complete/incomplete)
+// @Description Lists events page by page (cursor pagination) with filters and multi-column sorting. Pass next_cursor from a response as cursor to fetch the following page with the same filters and sort.
 // @Tags Events
 // @Security ApiKeyAuth
 // @Produce json
 // @Param status query string false "Filter by status: complete or incomplete"
-// @Success 200 {array} models.EventDetails
+// @Param workflow_state query string false "Filter by workflow state: draft, submitted, under_review, approved, returned_with_comments, resubmitted"
+// @Param branch_id query int false "Filter by branch (events it leads or co-hosts)"
+// @Param series_id query int false "Filter by recurring series (occurrences only)"
+// @Param include_descendants query bool false "Include events of the branch's child branches (default true)"
+// @Param event_type_id query string false "Comma-separated event type IDs"
+// @Param event_category_id query string false "Comma-separated event category IDs"
+// @Param event_sub_category_id query string false "Comma-separated event sub-category IDs"
+// @Param start_date_from query string false "Events starting on or after this date (YYYY-MM-DD)"
+// @Param start_date_to query string false "Events starting on or before this date (YYYY-MM-DD)"
+// @Param end_date_from query string false "Events ending on or after this date (YYYY-MM-DD)"
+// @Param end_date_to query string false "Events ending on or before this date (YYYY-MM-DD)"
+// @Param state query string false "Filter by state"
+// @Param city query string false "Filter by city"
+// @Param district query string false "Filter by district"
+// @Param language query string false "Filter by language"
+// @Param spiritual_orator query string false "Filter by orator (partial match)"
+// @Param scale query string false "Filter by scale"
+// @Param sort query string false "Comma-separated sort fields, '-' prefix for descending (start_date, end_date, created_on, theme, state, city, district, language, spiritual_orator, scale, id). Default -created_on"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} map[string]interface{} "data, total, next_cursor, has_more"
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/events [get]
 func GetAllEventsHandler(c *gin.Context) {
-	statusFilter := c.Query("status")
-	events, err := services.GetAllEvents(statusFilter)
+	filter, err := parseEventListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sorts, err := services.ParseEventSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	page, err := services.ListEvents(filter, sorts, limit, c.Query("cursor"))
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidEventCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch events"})
 		return
 	}
+	events := page.Data
+
+	// Unresolved reviewer comment threads per event
+	eventIDs := make([]uint, len(events))
+	for i, event := range events {
+		eventIDs[i] = event.ID
+	}
+	openComments, err := services.GetOpenCommentCounts(eventIDs)
+	if err != nil {
+		log.Printf("Warning: Failed to count open comments: %v", err)
+		openComments = map[uint]int64{}
+	}
 
 	// Add counts for related data to each event
 	eventsWithCounts := make([]gin.H, 0, len(events))
@@ -218,6 +284,7 @@ func GetAllEventsHandler(c *gin.Context) {
 			"initiation_women":         event.InitiationWomen,
 			"initiation_child":         event.InitiationChild,
 			"status":                   event.Status,
+			"workflow_state":           event.WorkflowState,
 			"created_on":               event.CreatedOn,
 			"updated_on":               event.UpdatedOn,
 			"created_by":               event.CreatedBy,
@@ -231,11 +298,110 @@ func GetAllEventsHandler(c *gin.Context) {
 			"media_count":              len(mediaList),
 			"promotion_materials_count": len(promotionMaterials),
 			"donations_count":          len(donations),
+			"open_comments_count":      openComments[event.ID],
 		}
 		eventsWithCounts = append(eventsWithCounts, eventMap)
 	}
 
-	c.JSON(http.StatusOK, eventsWithCounts)
+	c.JSON(http.StatusOK, gin.H{
+		"data":        eventsWithCounts,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+		"has_more":    page.HasMore,
+	})
+}
+
+// parseEventListFilter reads the event listing filters from the query string
+func parseEventListFilter(c *gin.Context) (services.EventListFilter, error) {
+	filter := services.EventListFilter{
+		Status:             c.Query("status"),
+		WorkflowState:      c.Query("workflow_state"),
+		IncludeDescendants: c.DefaultQuery("include_descendants", "true") != "false",
+		State:              c.Query("state"),
+		City:               c.Query("city"),
+		District:           c.Query("district"),
+		Language:           c.Query("language"),
+		SpiritualOrator:    c.Query("spiritual_orator"),
+		Scale:              c.Query("scale"),
+	}
+
+	if filter.WorkflowState != "" && !models.IsValidWorkflowState(filter.WorkflowState) {
+		return filter, fmt.Errorf("Invalid workflow_state")
+	}
+
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		branchID, err := strconv.ParseUint(branchIDStr, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid branch_id")
+		}
+		filter.BranchID = uint(branchID)
+	}
+	if seriesIDStr := c.Query("series_id"); seriesIDStr != "" {
+		seriesID, err := strconv.ParseUint(seriesIDStr, 10, 64)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid series_id")
+		}
+		filter.SeriesID = uint(seriesID)
+	}
+
+	var err error
+	if filter.EventTypeIDs, err = parseIDList(c.Query("event_type_id")); err != nil {
+		return filter, fmt.Errorf("Invalid event_type_id")
+	}
+	if filter.EventCategoryIDs, err = parseIDList(c.Query("event_category_id")); err != nil {
+		return filter, fmt.Errorf("Invalid event_category_id")
+	}
+	if filter.EventSubCategoryIDs, err = parseIDList(c.Query("event_sub_category_id")); err != nil {
+		return filter, fmt.Errorf("Invalid event_sub_category_id")
+	}
+
+	// "to" dates are inclusive for the caller, so the service gets the start of the next day
+	dates := []struct {
+		param  string
+		target **time.Time
+		to     bool
+	}{
+		{"start_date_from", &filter.StartDateFrom, false},
+		{"start_date_to", &filter.StartDateTo, true},
+		{"end_date_from", &filter.EndDateFrom, false},
+		{"end_date_to", &filter.EndDateTo, true},
+	}
+	for _, d := range dates {
+		value := c.Query(d.param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return filter, fmt.Errorf("Invalid %s format. Use YYYY-MM-DD", d.param)
+		}
+		if d.to {
+			t = t.AddDate(0, 0, 1)
+		}
+		*d.target = &t
+	}
+
+	return filter, nil
+}
+
+// parseIDList parses a comma-separated list of IDs
+func parseIDList(value string) ([]uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids, nil
 }
 
 // ----------------------------------------------------
@@ -244,12 +410,13 @@ func GetAllEventsHandler(c *gin.Context) {
 
 // GetEventByIdHandler godoc
 // @Summary Get event by ID
-// @Description Get a single event by its ID with related data (special guests, volunteers, media)
+// @Description Get a single event by its ID with related data (special guests, volunteers, media) and the definitions of its custom fields (customFieldDefinitions)
 // @Tags Events
 // @Security ApiKeyAuth
 // @Produce json
 // @Param event_id path int true "Event ID"
 // @Success 200 {object} map[string]interface{} "Event with related data"
+// @Header 200 {string} ETag "Version of the event, to send as If-Match when updating it"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -268,20 +435,37 @@ func GetEventByIdHandler(c *gin.Context) {
 		return
 	}
 
+	response, err := eventDetailResponse(c, event)
+	if err != nil {
+		// Fail fast - return HTTP 500 with structured error
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "failed to generate presigned URLs for event media",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	setETag(c, event.Version)
+	c.JSON(http.StatusOK, response)
+}
+
+// eventDetailResponse builds the representation of an event with its related data, as returned by
+// GET /api/events/{event_id}
+func eventDetailResponse(c *gin.Context, event *models.EventDetails) (gin.H, error) {
 	// Fetch related data (return empty arrays if not found)
-	specialGuests, errSG := services.GetSpecialGuestByEventID(uint(eventID))
+	specialGuests, errSG := services.GetSpecialGuestByEventID(event.ID)
 	if errSG != nil {
 		// Special guests service returns error only on DB error, not on empty result
 		specialGuests = []models.SpecialGuest{}
 	}
 
-	volunteers, errVol := services.GetVolunteerByEventID(uint(eventID))
+	volunteers, errVol := services.GetVolunteerByEventID(event.ID)
 	if errVol != nil {
 		// Volunteers service returns ErrVolunteerNotFound if empty, treat as empty array
 		volunteers = []models.Volunteer{}
 	}
 
-	mediaList, errMedia := services.GetEventMediaByEventID(uint(eventID))
+	mediaList, errMedia := services.GetEventMediaByEventID(event.ID)
 	if errMedia != nil {
 		// Media service returns error if not found, treat as empty array
 		mediaList = []models.EventMedia{}
@@ -289,24 +473,19 @@ func GetEventByIdHandler(c *gin.Context) {
 		// Convert to presigned URLs - HARD GUARD: fail fast if S3Key is empty
 		mediaListWithPresignedURLs, err := services.ConvertEventMediaToPresignedURLs(c.Request.Context(), mediaList)
 		if err != nil {
-			// Fail fast - return HTTP 500 with structured error
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "failed to generate presigned URLs for event media",
-				"details": err.Error(),
-			})
-			return
+			return nil, err
 		}
 		mediaList = mediaListWithPresignedURLs
 
 	// Fetch promotion materials
-	promotionMaterials, errPromo := services.GetPromotionMaterialDetailsByEventID(uint(eventID))
+	promotionMaterials, errPromo := services.GetPromotionMaterialDetailsByEventID(event.ID)
 	if errPromo != nil {
 		// Return empty array if not found (consistent with other related data)
 		promotionMaterials = []models.PromotionMaterialDetails{}
 	}
 
 	// Fetch donations
-	donations, errDonations := services.GetDonationsByEvent(uint(eventID))
+	donations, errDonations := services.GetDonationsByEvent(event.ID)
 	if errDonations != nil {
 		donations = []models.Donation{}
 	}
@@ -341,6 +520,12 @@ func GetEventByIdHandler(c *gin.Context) {
 			}
 		}
 
+		// Custom fields of the event's category, to label and render custom_fields
+		customFieldDefinitions, err := services.EventCustomFieldDefinitions(event)
+		if err != nil {
+			return nil, err
+		}
+
 		// Build response with event and related data
 		response := gin.H{
 			"event":                  event,
@@ -356,9 +541,10 @@ func GetEventByIdHandler(c *gin.Context) {
 		"mediaCount":             len(mediaList),
 		"promotionMaterialsCount": len(promotionMaterials),
 		"donationsCount":         len(donations),
+		"customFieldDefinitions": customFieldDefinitions,
 	}
 
-	c.JSON(http.StatusOK, response)
+	return response, nil
 }
 
 // ----------------------------------------------------
@@ -367,24 +553,28 @@ func GetEventByIdHandler(c *gin.Context) {
 
 // SearchEventsHandler godoc
 // @Summary Search events
-// @Description Search events by keyword
+// @Description Ranked full-text search over theme, orator, city, district, address, branch name and event type/category. Misspelled place names are matched by trigram similarity. Results include highlight snippets (matches wrapped in <mark>) and are paginated; no hits returns an empty list.
 // @Tags Events
 // @Security ApiKeyAuth
 // @Produce json
-// @Param search query string false "Search keyword"
-// @Success 200 {array} models.EventDetails
+// @Param search query string false "Search text (supports quoted phrases, OR and -exclusion)"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Results per page (default 20, max 100)"
+// @Success 200 {object} services.EventSearchResult
 // @Failure 500 {object} map[string]string
 // @Router /api/events/search [get]
 func SearchEventsHandler(c *gin.Context) {
 	search := c.Query("search")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	events, err := services.SearchEvents(search)
+	result, err := services.SearchEvents(search, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	c.JSON(http.StatusOK, result)
 }
 
 // ----------------------------------------------------
@@ -393,15 +583,22 @@ func SearchEventsHandler(c *gin.Context) {
 
 // UpdateEventHandler godoc
 // @Summary Update an event
-// @Description Updates an event. Accepts both flat structure (for simple updates) and nested frontend payload structure (for full updates with related data)
+// @Description Updates an event. Accepts both flat structure (for simple updates) and nested frontend payload structure (for full updates with related data). Custom field values (customFields, or custom_fields in the flat structure) are checked against the category the event has after the update; when left out, the current values are kept and checked again.
 // @Tags Events
 // @Security ApiKeyAuth
 // @Accept json
 // @Produce json
 // @Param event_id path int true "Event ID"
-// @Param event body object true "Updated fields (can be flat or nested frontend payload)"
+// @Param If-Match header string false "ETag of the event the change is based on (required when the server enforces it)"
+// @Param create_anyway query bool false "Save even if the changed event looks like a duplicate"
+// @Param dry_run query bool false "Only validate a nested payload and return the per-step report (see POST /api/events/{event_id}/validate)"
+// @Param event body object true "Updated fields (can be flat or nested frontend payload; a nested payload is validated against its schema version)"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} map[string]string
+// @Header 200 {string} ETag "New version of the event"
+// @Failure 400 {object} handlers.eventSubmissionErrorResponse
+// @Failure 409 {object} map[string]interface{} "Likely duplicates found; resend with create_anyway=true to save anyway"
+// @Failure 412 {object} map[string]interface{} "The event changed since If-Match; the body holds its current representation"
+// @Failure 428 {object} map[string]string "If-Match is required"
 // @Failure 500 {object} map[string]string
 // @Router /api/events/{event_id} [put]
 func UpdateEventHandler(c *gin.Context) {
@@ -412,22 +609,35 @@ func UpdateEventHandler(c *gin.Context) {
 		return
 	}
 
-	// Try to bind as frontend payload structure first
-	var frontendPayload struct {
-		GeneralDetails       map[string]interface{} `json:"generalDetails"`
-		MediaPromotion       map[string]interface{} `json:"mediaPromotion"`
-		InvolvedParticipants map[string]interface{} `json:"involvedParticipants"`
-		DonationTypes        []interface{}          `json:"donationTypes"`
-		MaterialTypes        []interface{}          `json:"materialTypes"`
-		SpecialGuests        []interface{}          `json:"specialGuests"`
-		Volunteers           []interface{}          `json:"volunteers"`
-		UploadedFiles        map[string]interface{} `json:"uploadedFiles"`
-		DraftID              *uint                  `json:"draftId,omitempty"`
-		Status               string                 `json:"status,omitempty"`
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	if isDryRun(c) {
+		respondEventUpdateValidation(c, uint(eventID), body, actor)
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
 	}
 
 	// Check if it's a nested frontend payload
-	if err := c.ShouldBindJSON(&frontendPayload); err == nil && frontendPayload.GeneralDetails != nil {
+	var probe struct {
+		GeneralDetails json.RawMessage `json:"generalDetails"`
+	}
+	if json.Unmarshal(body, &probe) == nil && len(probe.GeneralDetails) > 0 && string(probe.GeneralDetails) != "null" {
+		// Strictly decode it against its schema version
+		frontendPayload, _, err := services.DecodeEventSubmission(body)
+		if err != nil {
+			respondEventSubmissionError(c, err)
+			return
+		}
+
 		// It's a nested frontend payload - map to EventDetails and update
 		event, err := services.MapFrontendPayloadToEventWithStatus(frontendPayload.GeneralDetails, frontendPayload.InvolvedParticipants, frontendPayload.Status)
 		if err != nil {
@@ -436,96 +646,7 @@ func UpdateEventHandler(c *gin.Context) {
 		}
 
 		// Convert event to update map
-		updateData := make(map[string]interface{})
-		if event.EventTypeID > 0 {
-			updateData["event_type_id"] = event.EventTypeID
-		}
-		if event.EventCategoryID > 0 {
-			updateData["event_category_id"] = event.EventCategoryID
-		}
-		if event.EventSubCategoryID != nil && *event.EventSubCategoryID > 0 {
-			updateData["event_sub_category_id"] = *event.EventSubCategoryID
-		} else {
-			// If eventSubCategoryID is nil or 0, set it to NULL in database
-			updateData["event_sub_category_id"] = nil
-		}
-		if event.Scale != "" {
-			updateData["scale"] = event.Scale
-		}
-		if event.Theme != "" {
-			updateData["theme"] = event.Theme
-		}
-		if !event.StartDate.IsZero() {
-			updateData["start_date"] = event.StartDate
-		}
-		if !event.EndDate.IsZero() {
-			updateData["end_date"] = event.EndDate
-		}
-		if event.DailyStartTime != nil {
-			updateData["daily_start_time"] = event.DailyStartTime
-		}
-		if event.DailyEndTime != nil {
-			updateData["daily_end_time"] = event.DailyEndTime
-		}
-		if event.SpiritualOrator != "" {
-			updateData["spiritual_orator"] = event.SpiritualOrator
-		}
-		if event.Language != "" {
-			updateData["language"] = event.Language
-		}
-		if event.Country != "" {
-			updateData["country"] = event.Country
-		}
-		if event.State != "" {
-			updateData["state"] = event.State
-		}
-		if event.District != "" {
-			updateData["district"] = event.District
-		}
-		if event.City != "" {
-			updateData["city"] = event.City
-		}
-		if event.Pincode != "" {
-			updateData["pincode"] = event.Pincode
-		}
-		if event.PostOffice != "" {
-			updateData["post_office"] = event.PostOffice
-		}
-		if event.Address != "" {
-			updateData["address"] = event.Address
-		}
-		// Always update address_type (even if empty) to allow clearing it
-		updateData["address_type"] = event.AddressType
-		if event.PoliceStation != "" {
-			updateData["police_station"] = event.PoliceStation
-		}
-		if event.AreaCovered != "" {
-			updateData["area_covered"] = event.AreaCovered
-		}
-		if event.BeneficiaryMen > 0 {
-			updateData["beneficiary_men"] = event.BeneficiaryMen
-		}
-		if event.BeneficiaryWomen > 0 {
-			updateData["beneficiary_women"] = event.BeneficiaryWomen
-		}
-		if event.BeneficiaryChild > 0 {
-			updateData["beneficiary_child"] = event.BeneficiaryChild
-		}
-		if event.InitiationMen > 0 {
-			updateData["initiation_men"] = event.InitiationMen
-		}
-		if event.InitiationWomen > 0 {
-			updateData["initiation_women"] = event.InitiationWomen
-		}
-		if event.InitiationChild > 0 {
-			updateData["initiation_child"] = event.InitiationChild
-		}
-		if event.BranchID != nil && *event.BranchID > 0 {
-			updateData["branch_id"] = *event.BranchID
-		}
-		if event.Status != "" {
-			updateData["status"] = event.Status
-		}
+		updateData := services.EventUpdateData(event)
 
 		// Validate update fields
 		if err := validators.ValidateEventUpdateFields(updateData); err != nil {
@@ -533,20 +654,30 @@ func UpdateEventHandler(c *gin.Context) {
 			return
 		}
 
-		// Update event
-		if err := services.UpdateEvent(uint(eventID), updateData); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Check the custom field values against the category the event has after the update
+		customFields, err := services.EventCustomFieldsForUpdate(uint(eventID), updateData, frontendPayload.CustomFields, frontendPayload.CustomFields != nil)
+		if err != nil {
+			respondCustomFieldValuesError(c, err)
 			return
 		}
+		updateData["custom_fields"] = customFields
 
-		// Delete old related data before creating new ones (to avoid duplicates)
-		if err := services.DeleteEventRelatedData(uint(eventID)); err != nil {
-			log.Printf("Warning: Failed to delete old related data: %v", err)
+		if !checkUpdateDuplicates(c, uint(eventID), updateData) {
+			return
 		}
 
-		// Create new related data (replaces the old ones)
-		if err := services.CreateEventRelatedData(uint(eventID), frontendPayload); err != nil {
-			log.Printf("Warning: Failed to update related data: %v", err)
+		// Update event and reconcile related data (matched by ID) in one transaction
+		if err := services.UpdateEventWithRelatedData(uint(eventID), updateData, frontendPayload, actor, expectedVersion); err != nil {
+			if errors.Is(err, services.ErrEventNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			if errors.Is(err, services.ErrVersionConflict) {
+				respondEventVersionConflict(c, uint(eventID))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
 		// Delete draft ONLY if status is 'complete' (submit)
@@ -555,16 +686,19 @@ func UpdateEventHandler(c *gin.Context) {
 			_ = services.DeleteDraft(*frontendPayload.DraftID)
 		}
 
+		setEventETag(c, uint(eventID))
 		c.JSON(http.StatusOK, gin.H{"message": "Event updated successfully"})
 		return
 	}
 
 	// Fallback: try as flat structure (for simple updates)
 	var updateData map[string]interface{}
-	if err := c.ShouldBindJSON(&updateData); err != nil {
+	if err := json.Unmarshal(body, &updateData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// The version is maintained by the database; If-Match carries the client's copy
+	delete(updateData, "version")
 
 	// Extract draftId and status from flat structure if present
 	var draftID *uint
@@ -612,8 +746,25 @@ func UpdateEventHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := services.NormalizeFlatEventCustomFields(uint(eventID), updateData); err != nil {
+		respondCustomFieldValuesError(c, err)
+		return
+	}
 
-	if err := services.UpdateEvent(uint(eventID), updateData); err != nil {
+	if !checkUpdateDuplicates(c, uint(eventID), updateData) {
+		return
+	}
+
+	// Update the event and record the revision in one transaction
+	if err := services.UpdateEvent(uint(eventID), updateData, actor, expectedVersion); err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrVersionConflict) {
+			respondEventVersionConflict(c, uint(eventID))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -624,21 +775,45 @@ func UpdateEventHandler(c *gin.Context) {
 		_ = services.DeleteDraft(*draftID)
 	}
 
+	setEventETag(c, uint(eventID))
 	c.JSON(http.StatusOK, gin.H{"message": "Event updated successfully"})
 }
 
+// setEventETag sets the ETag of an event after it was changed
+func setEventETag(c *gin.Context, eventID uint) {
+	if version, err := services.GetEventVersion(eventID); err == nil && version > 0 {
+		setETag(c, version)
+	}
+}
+
+// respondEventVersionConflict answers 412 with the current representation of the event
+func respondEventVersionConflict(c *gin.Context, eventID uint) {
+	event, err := services.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": services.ErrVersionConflict.Error()})
+		return
+	}
+	current, err := eventDetailResponse(c, event)
+	if err != nil {
+		current = gin.H{"event": event}
+	}
+	respondVersionConflict(c, event.Version, current)
+}
+
 // ----------------------------------------------------
 // Delete Event
 // ----------------------------------------------------
 
 // DeleteEventHandler godoc
 // @Summary Delete an event
+// @Description Moves the event and its special guests, volunteers, media and donations to the trash. It can be restored from the trash until it is purged.
 // @Tags Events
 // @Security ApiKeyAuth
 // @Produce json
 // @Param event_id path int true "Event ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/events/{event_id} [delete]
 func DeleteEventHandler(c *gin.Context) {
@@ -649,7 +824,12 @@ func DeleteEventHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteEvent(uint(eventID)); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteEvent(uint(eventID), actor.Email); err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -705,9 +885,10 @@ func DownloadEventHandler(c *gin.Context) {
 	mediaList = mediaListWithPresignedURLs
 	promotionMaterials, _ := services.GetPromotionMaterialDetailsByEventID(uint(eventID))
 	donations, _ := services.GetDonationsByEvent(uint(eventID))
+	attendance, _ := services.GetAttendanceByEventIDs([]uint{uint(eventID)})
 
 	// Generate PDF document
-	pdfBytes, err := services.GenerateEventPDF(event, specialGuests, volunteers, mediaList, promotionMaterials, donations)
+	pdfBytes, err := services.GenerateEventPDF(event, specialGuests, volunteers, mediaList, promotionMaterials, donations, attendance)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF: " + err.Error()})
 		return
@@ -925,10 +1106,13 @@ func GetLatestDraftByUserHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param event_id path int true "Event ID"
+// @Param If-Match header string false "ETag of the event the change is based on (required when the server enforces it)"
 // @Param status body object true "Status update" example({"status":"complete"})
 // @Success 200 {object} map[string]interface{} "Status updated successfully" example({"message":"Event status updated successfully","status":"complete"})
-// @Failure 400 {object} map[string]string "Bad Request" example({"error":"Invalid status. Must be 'complete' or 'incomplete'"})
+// @Failure 400 {object} map[string]string "Bad Request (errors lists required custom fields still empty when the status is complete)" example({"error":"Invalid status. Must be 'complete' or 'incomplete'"})
 // @Failure 404 {object} map[string]string "Not Found" example({"error":"Event not found"})
+// @Failure 412 {object} map[string]interface{} "The event changed since If-Match; the body holds its current representation"
+// @Failure 428 {object} map[string]string "If-Match is required"
 // @Failure 500 {object} map[string]string "Internal Server Error" example({"error":"Failed to update event status"})
 // @Router /api/events/{event_id}/status [patch]
 func UpdateEventStatusHandler(c *gin.Context) {
@@ -948,15 +1132,30 @@ func UpdateEventStatusHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.UpdateEventStatus(uint(eventID), request.Status); err != nil {
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := services.UpdateEventStatus(uint(eventID), request.Status, expectedVersion); err != nil {
 		if err.Error() == "event not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, services.ErrVersionConflict) {
+			respondEventVersionConflict(c, uint(eventID))
+			return
+		}
+		var schemaErr *validators.SchemaError
+		if errors.As(err, &schemaErr) {
+			respondCustomFieldValuesError(c, err)
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	setEventETag(c, uint(eventID))
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Event status updated successfully",
 		"status":  request.Status,
@@ -1005,6 +1204,12 @@ func parseEventFromMap(data map[string]interface{}, event *models.EventDetails)
 	if address, ok := data["address"].(string); ok {
 		event.Address = address
 	}
+	if latitude, ok := data["latitude"].(float64); ok {
+		event.Latitude = &latitude
+	}
+	if longitude, ok := data["longitude"].(float64); ok {
+		event.Longitude = &longitude
+	}
 	if status, ok := data["status"].(string); ok {
 		event.Status = status
 	}
@@ -1093,6 +1298,7 @@ func parseEventFromMap(data map[string]interface{}, event *models.EventDetails)
 // @Param start_date query string false "Start date (YYYY-MM-DD)"
 // @Param end_date query string false "End date (YYYY-MM-DD)"
 // @Param status query string false "Status filter (complete/incomplete)"
+// @Param workflow_state query string false "Workflow state filter (draft, submitted, under_review, approved, returned_with_comments, resubmitted)"
 // @Success 200 {file} file "Excel file"
 // @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -1102,6 +1308,11 @@ func ExportEventsHandler(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	statusFilter := c.Query("status")
+	workflowStateFilter := c.Query("workflow_state")
+	if workflowStateFilter != "" && !models.IsValidWorkflowState(workflowStateFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow_state"})
+		return
+	}
 	
 	// Always filter by created_on date
 	dateFilterType := "created_on"
@@ -1140,7 +1351,7 @@ func ExportEventsHandler(c *gin.Context) {
 	}
 
 	// Get events by date range
-	events, err := services.GetEventsByDateRange(startDate, endDate, statusFilter, dateFilterType)
+	events, err := services.GetEventsByDateRange(startDate, endDate, statusFilter, workflowStateFilter, dateFilterType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events: " + err.Error()})
 		return
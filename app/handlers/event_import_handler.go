@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// maxEventImportFileSize bounds the size of an uploaded events spreadsheet
+const maxEventImportFileSize = 10 << 20
+
+// ImportEventsHandler godoc
+// @Summary Import events from Excel
+// @Description Imports events from an .xlsx in the column layout of the events export. Type, category, branch and language names are resolved to master data and each row is validated like an event created through the API. With dry_run=true nothing is saved and the per-row report is returned; otherwise all valid rows are created in a single transaction (as drafts in the review workflow) and invalid rows are reported and skipped. Custom field columns, headed by the field label (as exported) or key, are read for the fields of the row's category and checked like custom field values sent to the API; required ones must be filled for complete rows. The ID, Workflow State and audit columns are ignored.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Events spreadsheet (.xlsx)"
+// @Param dry_run query bool false "Validate only, do not save"
+// @Success 200 {object} services.EventImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/import [post]
+func ImportEventsHandler(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(file.Filename), ".xlsx") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only .xlsx files can be imported"})
+		return
+	}
+	if file.Size > maxEventImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is too large (max 10 MB)"})
+		return
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer src.Close()
+
+	actor, _ := getActor(c)
+	result, err := services.ImportEventsFromExcel(src, c.Query("dry_run") == "true", actor)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidImportFile) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import events: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// respondMergeError maps merge errors to HTTP responses
+func respondMergeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMerge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge events: " + err.Error()})
+	}
+}
+
+// PreviewEventMergeHandler godoc
+// @Summary Preview merging duplicate events
+// @Description Lists the fields on which the events disagree (with each event's value), the fields the survivor would take from a duplicate, and how many special guests, volunteers, media, donations and promotion materials would be moved or dropped as exact duplicates
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param survivor_id query int true "Event that is kept"
+// @Param duplicate_ids query string true "Comma-separated IDs of the events merged into the survivor"
+// @Success 200 {object} services.EventMergePreview
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/merge/preview [get]
+func PreviewEventMergeHandler(c *gin.Context) {
+	survivorID, err := strconv.ParseUint(c.Query("survivor_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid survivor_id"})
+		return
+	}
+	req := services.EventMergeRequest{SurvivorID: uint(survivorID)}
+	for _, value := range splitQueryList(c.Query("duplicate_ids")) {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duplicate_ids"})
+			return
+		}
+		req.DuplicateIDs = append(req.DuplicateIDs, uint(id))
+	}
+
+	preview, err := services.PreviewEventMerge(req)
+	if err != nil {
+		respondMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// MergeEventsHandler godoc
+// @Summary Merge duplicate events
+// @Description Merges one or more duplicate events into a surviving event. "fields" maps an event column to the event whose value the survivor keeps; other conflicting columns keep the survivor's value and columns empty on the survivor are filled from the duplicates. Child rows are re-parented to the survivor, exact duplicates are dropped, the duplicates are moved to the trash and the merge is recorded in the survivor's history.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param merge body services.EventMergeRequest true "Merge request" example({"survivor_id":10,"duplicate_ids":[11,12],"fields":{"theme":11,"address":12}})
+// @Success 200 {object} services.EventMergeResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/merge [post]
+func MergeEventsHandler(c *gin.Context) {
+	var req services.EventMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	result, err := services.MergeEvents(req, actor)
+	if err != nil {
+		respondMergeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Events merged successfully", "merge": result})
+}
+
+// GetEventMergesHandler godoc
+// @Summary Merge history of an event
+// @Description Lists the merges the event took part in, as survivor or as merged duplicate
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Success 200 {array} models.EventMerge
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/{event_id}/merges [get]
+func GetEventMergesHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	merges, err := services.GetEventMerges(uint(eventID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch merge history"})
+		return
+	}
+	c.JSON(http.StatusOK, merges)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetOverdueReportsHandler godoc
+// @Summary Overdue event reports
+// @Description Lists the events that ended before today but whose report is still incomplete, grouped per branch (most overdue reports first) with counts per ageing bucket (1-7, 8-14, 15-30, 31-60, 61-90 and over_90 days) and the reminders sent so far
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param branch_id query int false "Restrict to a branch and its child branches"
+// @Param region_id query int false "Restrict to the branches of a region"
+// @Param bucket query string false "Only one ageing bucket, e.g. 15-30"
+// @Success 200 {object} services.OverdueReport
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/overdue [get]
+func GetOverdueReportsHandler(c *gin.Context) {
+	var filter services.OverdueFilter
+	if v := c.Query("branch_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch_id"})
+			return
+		}
+		filter.BranchID = uint(id)
+	}
+	if v := c.Query("region_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region_id"})
+			return
+		}
+		filter.RegionID = uint(id)
+	}
+	if v := c.Query("bucket"); v != "" {
+		if !services.IsValidOverdueBucket(v) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bucket"})
+			return
+		}
+		filter.Bucket = v
+	}
+
+	report, err := services.GetOverdueReport(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch overdue reports"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RunOverdueRemindersHandler godoc
+// @Summary Send due overdue reminders now
+// @Description Runs the daily reminder job immediately: sends the reminder and escalation stages that became due and were not sent yet (admins only). While no e-mail sender is configured the digests are only logged, nothing is recorded and the result has undelivered set
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} services.OverdueRunResult
+// @Failure 500 {object} map[string]string
+// @Router /api/events/overdue/remind [post]
+func RunOverdueRemindersHandler(c *gin.Context) {
+	result, err := services.RunOverdueReminders()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send overdue reminders: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetRegionAdminsHandler godoc
+// @Summary List region admins
+// @Description Lists the users overdue reports of a region are escalated to (super admins receive escalations of regions without admins)
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param region_id query int false "Region ID (all regions when omitted)"
+// @Success 200 {array} models.RegionAdmin
+// @Failure 400 {object} map[string]string
+// @Router /api/events/overdue/region-admins [get]
+func GetRegionAdminsHandler(c *gin.Context) {
+	var regionID uint
+	if v := c.Query("region_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid region_id"})
+			return
+		}
+		regionID = uint(id)
+	}
+	admins, err := services.GetRegionAdmins(regionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch region admins"})
+		return
+	}
+	c.JSON(http.StatusOK, admins)
+}
+
+// AddRegionAdminHandler godoc
+// @Summary Assign a region admin
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param admin body object true "Region and user" example({"region_id":1,"user_id":5})
+// @Success 201 {object} models.RegionAdmin
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/events/overdue/region-admins [post]
+func AddRegionAdminHandler(c *gin.Context) {
+	var req struct {
+		RegionID uint `json:"region_id" binding:"required"`
+		UserID   uint `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	admin, err := services.AddRegionAdmin(req.RegionID, req.UserID, actor.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrRegionAdminExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, admin)
+}
+
+// RemoveRegionAdminHandler godoc
+// @Summary Remove a region admin
+// @Tags Events
+// @Security ApiKeyAuth
+// @Param id path int true "Region admin assignment ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/overdue/region-admins/{id} [delete]
+func RemoveRegionAdminHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := services.RemoveRegionAdmin(uint(id)); err != nil {
+		if errors.Is(err, services.ErrRegionAdminNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Region admin removed successfully"})
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetEventRevisionsHandler godoc
+// @Summary List event revisions
+// @Description Lists every stored revision of an event (newest first) with actor and timestamp. Snapshots are omitted; fetch a single revision to get its content.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Success 200 {array} models.EventRevision
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/{event_id}/revisions [get]
+func GetEventRevisionsHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	revisions, err := services.GetEventRevisions(uint(eventID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetEventRevisionHandler godoc
+// @Summary Get an event revision
+// @Description Returns a single revision of an event including its complete snapshot (event and related rows)
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param revision_id path int true "Revision ID"
+// @Success 200 {object} models.EventRevision
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/revisions/{revision_id} [get]
+func GetEventRevisionHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	revisionID, err := strconv.ParseUint(c.Param("revision_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+		return
+	}
+
+	revision, err := services.GetEventRevision(uint(eventID), uint(revisionID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
+
+// DiffEventRevisionsHandler godoc
+// @Summary Diff two event revisions
+// @Description Returns the field-level differences between two revisions of an event. Related rows are matched by ID, e.g. "volunteers[id=12].contact".
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param from query int true "Revision ID to compare from"
+// @Param to query int true "Revision ID to compare to"
+// @Success 200 {object} map[string]interface{} "Field changes"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/revisions/diff [get]
+func DiffEventRevisionsHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	fromID, err := strconv.ParseUint(c.Query("from"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing 'from' revision ID"})
+		return
+	}
+	toID, err := strconv.ParseUint(c.Query("to"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing 'to' revision ID"})
+		return
+	}
+
+	changes, err := services.DiffEventRevisions(uint(eventID), uint(fromID), uint(toID))
+	if err != nil {
+		if errors.Is(err, services.ErrRevisionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to diff revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    fromID,
+		"to":      toID,
+		"changes": changes,
+	})
+}
+
+// RestoreEventRevisionHandler godoc
+// @Summary Restore an event revision
+// @Description Reinstates the event and all its related rows (special guests, volunteers, media, donations, promotion materials) as captured in the chosen revision, in a single transaction. The restore is recorded as a new revision.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param revision_id path int true "Revision ID"
+// @Success 200 {object} map[string]interface{} "Revision restored"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/{event_id}/revisions/{revision_id}/restore [post]
+func RestoreEventRevisionHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	revisionID, err := strconv.ParseUint(c.Param("revision_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision ID"})
+		return
+	}
+
+	actor, ok := getActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	revision, err := services.RestoreEventRevision(uint(eventID), uint(revisionID), actor)
+	if err != nil {
+		if errors.Is(err, services.ErrRevisionNotFound) || errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Revision restored successfully",
+		"revision": revision,
+	})
+}
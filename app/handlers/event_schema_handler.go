@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+	"github.com/gin-gonic/gin"
+)
+
+// eventSubmissionErrorResponse lists every invalid field of an event payload
+type eventSubmissionErrorResponse struct {
+	Error  string                  `json:"error" example:"Invalid event payload"`
+	Errors []validators.FieldError `json:"errors"`
+}
+
+// respondEventSubmissionError writes the field-level errors of DecodeEventSubmission
+func respondEventSubmissionError(c *gin.Context, err error) {
+	var schemaErr *validators.SchemaError
+	if errors.As(err, &schemaErr) {
+		c.JSON(http.StatusBadRequest, eventSubmissionErrorResponse{Error: "Invalid event payload", Errors: schemaErr.Errors})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+}
+
+// GetEventSchemaVersionsHandler godoc
+// @Summary Event submission schema versions
+// @Description Lists the supported versions of the event create/update payload. Send schemaVersion in the payload to pick one (default latest).
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} map[string]interface{} "versions, latest"
+// @Router /api/events/schema [get]
+func GetEventSchemaVersionsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"versions": services.EventSchemaVersions(),
+		"latest":   services.LatestEventSchemaVersion,
+	})
+}
+
+// GetEventSchemaHandler godoc
+// @Summary Event submission JSON Schema
+// @Description Returns the JSON Schema (draft 2020-12) of a version of the event create/update payload
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param version path int true "Schema version"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/schema/{version} [get]
+func GetEventSchemaHandler(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schema version"})
+		return
+	}
+	schema, err := services.EventSubmissionSchema(version)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownEventSchemaVersion) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Type", "application/schema+json; charset=utf-8")
+	c.JSON(http.StatusOK, schema)
+}
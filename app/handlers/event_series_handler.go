@@ -0,0 +1,429 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+	"github.com/gin-gonic/gin"
+)
+
+// eventSeriesRequest is the create/update payload of a series; dates are YYYY-MM-DD
+type eventSeriesRequest struct {
+	models.EventSeries
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// toModel converts the request into a series
+func (r eventSeriesRequest) toModel() (models.EventSeries, error) {
+	series := r.EventSeries
+	series.Exceptions = nil
+	startDate, err := parseSeriesDate(r.StartDate)
+	if err != nil {
+		return series, errors.New("Invalid start_date format. Use YYYY-MM-DD")
+	}
+	series.StartDate = startDate
+	series.EndDate = nil
+	if r.EndDate != "" {
+		endDate, err := parseSeriesDate(r.EndDate)
+		if err != nil {
+			return series, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+		}
+		series.EndDate = &endDate
+	}
+	return series, nil
+}
+
+func parseSeriesDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// respondEventSeriesError maps series service errors to HTTP responses
+func respondEventSeriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEventSeriesNotFound),
+		errors.Is(err, services.ErrSeriesOccurrenceNotFound),
+		errors.Is(err, services.ErrSeriesExceptionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CreateEventSeriesHandler godoc
+// @Summary Create a recurring event series
+// @Description Creates a series from a recurrence rule (RRULE subset: FREQ=WEEKLY|MONTHLY, INTERVAL, BYDAY such as SU or 1SU/-1FR, BYMONTHDAY, COUNT, UNTIL) and an event template. Occurrences are generated by the generate endpoint and daily by the scheduler.
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param series body models.EventSeries true "Series (start_date/end_date as YYYY-MM-DD)"
+// @Success 201 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/event-series [post]
+func CreateEventSeriesHandler(c *gin.Context) {
+	var req eventSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	series, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	if err := services.CreateEventSeries(&series, actor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+// GetAllEventSeriesHandler godoc
+// @Summary List recurring event series
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Produce json
+// @Param branch_id query int false "Filter by branch"
+// @Success 200 {array} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/event-series [get]
+func GetAllEventSeriesHandler(c *gin.Context) {
+	var branchID uint64
+	if v := c.Query("branch_id"); v != "" {
+		var err error
+		if branchID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch_id"})
+			return
+		}
+	}
+
+	series, err := services.GetAllEventSeries(uint(branchID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch series"})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// GetEventSeriesHandler godoc
+// @Summary Get a recurring event series
+// @Description Returns a series with its template and exception dates
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Success 200 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id} [get]
+func GetEventSeriesHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	series, err := services.GetEventSeriesByID(seriesID)
+	if err != nil {
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// UpdateEventSeriesHandler godoc
+// @Summary Update a recurring event series
+// @Description Replaces the name, recurrence rule and template of a series. Upcoming occurrences that nobody has edited yet follow the changes; edited or reported occurrences are left alone.
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Param series body models.EventSeries true "Series (start_date/end_date as YYYY-MM-DD)"
+// @Success 200 {object} models.EventSeries
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id} [put]
+func UpdateEventSeriesHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	var req eventSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	input, err := req.toModel()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	series, err := services.UpdateEventSeries(seriesID, input, actor)
+	if err != nil {
+		if errors.Is(err, services.ErrEventSeriesNotFound) {
+			respondEventSeriesError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// DeleteEventSeriesHandler godoc
+// @Summary Delete a recurring event series
+// @Description Deletes a series. Upcoming occurrences nobody has edited yet are moved to the trash; all other occurrences are kept as one-off events.
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Param series_id path int true "Series ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id} [delete]
+func DeleteEventSeriesHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	actor, _ := getActor(c)
+	if err := services.DeleteEventSeries(seriesID, actor); err != nil {
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Event series deleted successfully"})
+}
+
+// AddEventSeriesExceptionHandler godoc
+// @Summary Add an exception date to a series
+// @Description Marks a date on which the series does not take place. An already generated occurrence on that date is moved to the trash unless it has been edited.
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Param exception body object true "{\"date\": \"YYYY-MM-DD\", \"reason\": \"...\"}"
+// @Success 201 {object} models.EventSeriesException
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id}/exceptions [post]
+func AddEventSeriesExceptionHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Date   string `json:"date" binding:"required"`
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	date, err := parseSeriesDate(req.Date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	actor, _ := getActor(c)
+	exception, err := services.AddSeriesException(seriesID, date, req.Reason, actor)
+	if err != nil {
+		if errors.Is(err, services.ErrSeriesExceptionOutOfRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, exception)
+}
+
+// RemoveEventSeriesExceptionHandler godoc
+// @Summary Remove an exception date from a series
+// @Description Reinstates the date; its occurrence is generated again if the date has already been generated
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Param series_id path int true "Series ID"
+// @Param exception_id path int true "Exception ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id}/exceptions/{exception_id} [delete]
+func RemoveEventSeriesExceptionHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	exceptionID, err := strconv.ParseUint(c.Param("exception_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid exception ID"})
+		return
+	}
+
+	actor, _ := getActor(c)
+	if err := services.RemoveSeriesException(seriesID, uint(exceptionID), actor); err != nil {
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Exception removed successfully"})
+}
+
+// GenerateEventSeriesOccurrencesHandler godoc
+// @Summary Generate occurrences of a series
+// @Description Creates the occurrences of a series, pre-filled from its template, up to horizon_days from today (default 60). Already generated dates are skipped.
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Param horizon_days query int false "Days ahead to generate (default 60)"
+// @Success 200 {object} services.SeriesGenerationResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id}/generate [post]
+func GenerateEventSeriesOccurrencesHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	horizonDays := services.DefaultSeriesHorizonDays
+	if v := c.Query("horizon_days"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > 366 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "horizon_days must be between 1 and 366"})
+			return
+		}
+		horizonDays = parsed
+	}
+
+	actor, _ := getActor(c)
+	result, err := services.GenerateSeriesOccurrences(seriesID, horizonDays, actor)
+	if err != nil {
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetEventSeriesOccurrencesHandler godoc
+// @Summary List occurrences of a series
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Success 200 {array} models.EventDetails
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id}/occurrences [get]
+func GetEventSeriesOccurrencesHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	events, err := services.GetSeriesOccurrences(seriesID)
+	if err != nil {
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// ReportEventSeriesOccurrenceHandler godoc
+// @Summary Report beneficiaries of an occurrence
+// @Description Records the beneficiary and initiation numbers of one occurrence and marks it complete (or the given status). custom_fields holds values of the custom fields of the occurrence's category; required ones must be filled for a complete report.
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Param event_id path int true "Occurrence (event) ID"
+// @Param report body services.SeriesOccurrenceReport true "Report"
+// @Success 200 {object} models.EventDetails
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id}/occurrences/{event_id}/report [put]
+func ReportEventSeriesOccurrenceHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	var report services.SeriesOccurrenceReport
+	if err := c.ShouldBindJSON(&report); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+
+	actor, _ := getActor(c)
+	event, err := services.ReportSeriesOccurrence(seriesID, uint(eventID), report, actor)
+	if err != nil {
+		if errors.Is(err, services.ErrSeriesOccurrenceNotFound) {
+			respondEventSeriesError(c, err)
+			return
+		}
+		var schemaErr *validators.SchemaError
+		if errors.As(err, &schemaErr) {
+			respondCustomFieldValuesError(c, err)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
+
+// GetEventSeriesRollupHandler godoc
+// @Summary Series rollup
+// @Description Occurrence counts (reported, pending, upcoming, exceptions) and beneficiary/initiation totals, averages and a monthly breakdown over reported occurrences
+// @Tags Event Series
+// @Security ApiKeyAuth
+// @Produce json
+// @Param series_id path int true "Series ID"
+// @Success 200 {object} services.EventSeriesRollup
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-series/{series_id}/rollup [get]
+func GetEventSeriesRollupHandler(c *gin.Context) {
+	seriesID, ok := parseSeriesIDParam(c)
+	if !ok {
+		return
+	}
+	rollup, err := services.GetEventSeriesRollup(seriesID)
+	if err != nil {
+		respondEventSeriesError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollup)
+}
+
+// parseSeriesIDParam reads the series_id path parameter, answering 400 when it is invalid
+func parseSeriesIDParam(c *gin.Context) (uint, bool) {
+	seriesID, err := strconv.ParseUint(c.Param("series_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return 0, false
+	}
+	return uint(seriesID), true
+}
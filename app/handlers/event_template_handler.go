@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// eventTemplateRequest is the create/update payload of a template
+type eventTemplateRequest struct {
+	models.EventTemplate
+	FromEventID uint `json:"from_event_id,omitempty"` // create only: take the sections from this event
+}
+
+// draftResponse renders a draft the way the draft endpoints do
+func draftResponse(draft *models.EventDraft) gin.H {
+	return gin.H{
+		"draftId":        draft.ID,
+		"generalDetails": draft.GeneralDetailsDraft,
+		"mediaPromotion": draft.MediaPromotionDraft,
+		"specialGuests":  draft.SpecialGuestsDraft,
+		"volunteers":     draft.VolunteersDraft,
+		"donations":      draft.DonationsDraft,
+		"createdOn":      draft.CreatedOn,
+		"updatedOn":      draft.UpdatedOn,
+	}
+}
+
+// respondEventTemplateError maps template service errors to HTTP responses
+func respondEventTemplateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrEventTemplateNotFound), errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEventTemplateNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidCloneSection):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// CloneEventHandler godoc
+// @Summary Clone an event into a new draft
+// @Description Copies an event's general details (without dates and participant counts) and the chosen related sections into a new draft of the caller, ready to be completed and submitted. Uploaded media files are not copied.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param clone body object false "Sections to copy: media, promotion, special_guests, volunteers, donations" example({"sections":["media","promotion","volunteers"]})
+// @Success 201 {object} map[string]interface{} "Draft data"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/clone [post]
+func CloneEventHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	var req struct {
+		Sections []string `json:"sections"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+			return
+		}
+	}
+
+	actor, _ := getActor(c)
+	draft, err := services.CloneEventToDraft(uint(eventID), req.Sections, actor.Email)
+	if err != nil {
+		respondEventTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, draftResponse(draft))
+}
+
+// CreateEventTemplateHandler godoc
+// @Summary Create an event template
+// @Description Creates a named template for a branch and/or event category (or for everyone when neither is set). The general_details and media_promotion sections use the draft step format; pass from_event_id to take them from an existing event instead.
+// @Tags Event Templates
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param template body models.EventTemplate true "Template (optionally with from_event_id)"
+// @Success 201 {object} models.EventTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/event-templates [post]
+func CreateEventTemplateHandler(c *gin.Context) {
+	var req eventTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	actor, _ := getActor(c)
+	template := req.EventTemplate
+	template.CreatedBy = actor.Email
+	if err := services.CreateEventTemplate(&template, req.FromEventID); err != nil {
+		respondEventTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// GetEventTemplatesHandler godoc
+// @Summary List event templates
+// @Description Lists templates by name. Filtering by branch or category also returns the templates shared by all branches or categories.
+// @Tags Event Templates
+// @Security ApiKeyAuth
+// @Produce json
+// @Param branch_id query int false "Branch ID"
+// @Param event_category_id query int false "Event category ID"
+// @Param search query string false "Part of the template name"
+// @Success 200 {array} models.EventTemplate
+// @Failure 400 {object} map[string]string
+// @Router /api/event-templates [get]
+func GetEventTemplatesHandler(c *gin.Context) {
+	var filter services.EventTemplateFilter
+	if v := c.Query("branch_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid branch_id"})
+			return
+		}
+		filter.BranchID = uint(id)
+	}
+	if v := c.Query("event_category_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event_category_id"})
+			return
+		}
+		filter.EventCategoryID = uint(id)
+	}
+	filter.Search = c.Query("search")
+
+	templates, err := services.GetEventTemplates(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event templates"})
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// GetEventTemplateHandler godoc
+// @Summary Get an event template
+// @Tags Event Templates
+// @Security ApiKeyAuth
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Success 200 {object} models.EventTemplate
+// @Failure 404 {object} map[string]string
+// @Router /api/event-templates/{template_id} [get]
+func GetEventTemplateHandler(c *gin.Context) {
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	template, err := services.GetEventTemplate(uint(templateID))
+	if err != nil {
+		respondEventTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// UpdateEventTemplateHandler godoc
+// @Summary Update an event template
+// @Description Replaces the name, description, scope and sections of a template
+// @Tags Event Templates
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Param template body models.EventTemplate true "Template"
+// @Success 200 {object} models.EventTemplate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/event-templates/{template_id} [put]
+func UpdateEventTemplateHandler(c *gin.Context) {
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	var req eventTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	if req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	actor, _ := getActor(c)
+	template, err := services.UpdateEventTemplate(uint(templateID), &req.EventTemplate, actor.Email)
+	if err != nil {
+		respondEventTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// DeleteEventTemplateHandler godoc
+// @Summary Delete an event template
+// @Tags Event Templates
+// @Security ApiKeyAuth
+// @Param template_id path int true "Template ID"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/event-templates/{template_id} [delete]
+func DeleteEventTemplateHandler(c *gin.Context) {
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	if err := services.DeleteEventTemplate(uint(templateID)); err != nil {
+		respondEventTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Event template deleted successfully"})
+}
+
+// CreateDraftFromTemplateHandler godoc
+// @Summary Start a draft from a template
+// @Description Creates a new draft of the caller with the template's general details and media/promotion sections filled in
+// @Tags Event Templates
+// @Security ApiKeyAuth
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Success 201 {object} map[string]interface{} "Draft data"
+// @Failure 404 {object} map[string]string
+// @Router /api/event-templates/{template_id}/draft [post]
+func CreateDraftFromTemplateHandler(c *gin.Context) {
+	templateID, err := strconv.ParseUint(c.Param("template_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+	actor, _ := getActor(c)
+	draft, err := services.CreateDraftFromTemplate(uint(templateID), actor.Email)
+	if err != nil {
+		respondEventTemplateError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, draftResponse(draft))
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// isDryRun reports whether the request only asks for validation
+func isDryRun(c *gin.Context) bool {
+	return c.Query("dry_run") == "true"
+}
+
+// respondEventUpdateValidation writes the dry run report of a nested event update
+func respondEventUpdateValidation(c *gin.Context, eventID uint, body []byte, actor services.Actor) {
+	report, err := services.ValidateEventUpdate(eventID, body, actor)
+	if err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ValidateEventCreateHandler godoc
+// @Summary Validate a new event without saving it
+// @Description Runs the complete create pipeline (schema decoding, master data resolution, validators, duplicate check and the inserts of the event and its related rows) inside a transaction that is rolled back, and returns the errors and warnings of every step. Steps after a failed one are skipped, except the duplicate check which runs whenever the event could be mapped. Same as POST /api/events?dry_run=true.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event body object true "Frontend event payload, as for POST /api/events"
+// @Success 200 {object} services.EventValidationReport
+// @Router /api/events/validate [post]
+func ValidateEventCreateHandler(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, services.ValidateEventCreate(body))
+}
+
+// ValidateEventUpdateHandler godoc
+// @Summary Validate an event update without saving it
+// @Description Runs the nested update pipeline (schema, master data, validators, duplicate check, the event update and the sync of its related rows) inside a transaction that is rolled back and returns the errors and warnings of every step. Same as PUT /api/events/{event_id}?dry_run=true.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param event body object true "Nested frontend event payload, as for PUT /api/events/{event_id}"
+// @Success 200 {object} services.EventValidationReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/events/{event_id}/validate [post]
+func ValidateEventUpdateHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payload format: " + err.Error()})
+		return
+	}
+	actor, _ := getActor(c)
+	respondEventUpdateValidation(c, uint(eventID), body, actor)
+}
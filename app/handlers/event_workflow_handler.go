@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// TransitionEventWorkflowRequest represents a workflow transition request
+type TransitionEventWorkflowRequest struct {
+	ToState string `json:"to_state" binding:"required"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// TransitionEventWorkflowHandler godoc
+// @Summary Transition event review workflow
+// @Description Moves an event through the review workflow (draft → submitted → under_review → approved / returned_with_comments → resubmitted). Transitions are gated by role; returning an event requires a comment.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Param request body TransitionEventWorkflowRequest true "Target state and optional reviewer comment"
+// @Success 200 {object} map[string]interface{} "Transition recorded"
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/{event_id}/workflow/transition [post]
+func TransitionEventWorkflowHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req TransitionEventWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, ok := getActor(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	history, err := services.TransitionEventWorkflow(uint(eventID), req.ToState, req.Comment, actor)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEventNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrWorkflowRoleNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidWorkflowTransition):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrInvalidWorkflowState), errors.Is(err, services.ErrWorkflowCommentRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update workflow state"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Event workflow state updated successfully",
+		"workflow_state": history.ToState,
+		"transition":     history,
+	})
+}
+
+// GetEventWorkflowHistoryHandler godoc
+// @Summary Get event workflow history
+// @Description Returns every workflow transition of an event with actor, time and reviewer comment, plus the transitions the current user may perform next
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param event_id path int true "Event ID"
+// @Success 200 {object} map[string]interface{} "Workflow history"
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/{event_id}/workflow/history [get]
+func GetEventWorkflowHistoryHandler(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("event_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := services.GetEventByID(uint(eventID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	history, err := services.GetEventStatusHistory(uint(eventID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch workflow history"})
+		return
+	}
+
+	actor, _ := getActor(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"event_id":            event.ID,
+		"workflow_state":      event.WorkflowState,
+		"allowed_transitions": services.GetAllowedWorkflowTransitions(event.WorkflowState, actor.Role),
+		"history":             history,
+	})
+}
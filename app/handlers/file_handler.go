@@ -227,7 +227,7 @@ func UploadFileHandler(c *gin.Context) {
 			media.MediaCoverageTypeID = mediaType.ID
 		}
 
-		if err := config.DB.Create(&media).Error; err != nil {
+		if err := services.CreateEventMedia(&media); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create media record"})
 			return
 		}
@@ -421,19 +421,22 @@ func DeleteFileHandler(c *gin.Context) {
 		}
 	}
 
+	// Delete media record if requested (default: true)
+	deleteRecord := c.DefaultQuery("delete_record", "true")
+
 	// Delete from S3 if file URL exists
-	if fileURL != "" {
+	// Event media records go to the trash, their objects are removed when the trash is purged
+	if fileURL != "" && !(isEventMedia && deleteRecord == "true") {
 		s3Key := services.GetS3KeyFromURL(fileURL)
 		if s3Key != "" {
 			_ = services.DeleteFile(c.Request.Context(), s3Key)
 		}
 	}
 
-	// Delete media record if requested (default: true)
-	deleteRecord := c.DefaultQuery("delete_record", "true")
 	if deleteRecord == "true" {
 		if isEventMedia {
-			if err := config.DB.Delete(&eventMedia).Error; err != nil {
+			actor, _ := getActor(c)
+			if err := services.DeleteEventMedia(eventMedia.ID, actor.Email); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete media record"})
 				return
 			}
@@ -478,6 +481,7 @@ func DeleteFileHandler(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Accept multipart/form-data
 // @Produce json
+// @Param Idempotency-Key header string false "Client-chosen key making retries safe: a repeated request with the same key replays the first response (header Idempotent-Replayed; status and body only, headers such as ETag are not replayed), a different payload with the same key is rejected with 422"
 // @Param files formData file true "Files to upload (multiple files allowed)"
 // @Param event_id formData int true "Event ID"
 // @Param category formData string false "File category (Event Photos, Video Coverage, Testimonials, Press Release)"
@@ -666,7 +670,7 @@ func UploadMultipleFilesHandler(c *gin.Context) {
 			media.MediaCoverageTypeID = mediaType.ID
 		}
 
-		if err := config.DB.Create(&media).Error; err != nil {
+		if err := services.CreateEventMedia(&media); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: failed to create media record", fileHeader.Filename))
 			continue
 		}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// parseGeoQuery reads the map viewport, radius search, clustering and event filters
+func parseGeoQuery(c *gin.Context) (services.GeoQuery, error) {
+	var q services.GeoQuery
+
+	if v := c.Query("bbox"); v != "" {
+		parts := splitQueryList(v)
+		if len(parts) != 4 {
+			return q, errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+		}
+		var values [4]float64
+		for i, part := range parts {
+			f, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				return q, errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+			}
+			values[i] = f
+		}
+		q.BBox = &services.BoundingBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	}
+
+	lat, lng, radius := c.Query("lat"), c.Query("lng"), c.Query("radius_km")
+	if lat != "" || lng != "" || radius != "" {
+		if lat == "" || lng == "" || radius == "" {
+			return q, errors.New("lat, lng and radius_km must be given together")
+		}
+		var circle services.GeoCircle
+		var err error
+		if circle.Lat, err = strconv.ParseFloat(lat, 64); err != nil {
+			return q, errors.New("Invalid lat")
+		}
+		if circle.Lng, err = strconv.ParseFloat(lng, 64); err != nil {
+			return q, errors.New("Invalid lng")
+		}
+		if circle.RadiusKm, err = strconv.ParseFloat(radius, 64); err != nil {
+			return q, errors.New("Invalid radius_km")
+		}
+		q.Circle = &circle
+	}
+
+	q.Cluster = c.Query("cluster")
+
+	if v := c.Query("start_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return q, errors.New("Invalid start_date format. Use YYYY-MM-DD")
+		}
+		q.From = &parsed
+	}
+	if v := c.Query("end_date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return q, errors.New("Invalid end_date format. Use YYYY-MM-DD")
+		}
+		to := parsed.AddDate(0, 0, 1)
+		q.To = &to
+	}
+	q.Statuses = splitQueryList(c.Query("status"))
+	if v := c.Query("branch_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return q, errors.New("Invalid branch_id")
+		}
+		q.BranchID = uint(id)
+	}
+	if v := c.Query("event_type_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return q, errors.New("Invalid event_type_id")
+		}
+		q.EventTypeID = uint(id)
+	}
+	return q, nil
+}
+
+// respondGeoJSON writes a FeatureCollection with the GeoJSON media type
+func respondGeoJSON(c *gin.Context, collection *services.GeoJSONFeatureCollection, err error) {
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidGeoQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch map data: " + err.Error()})
+		return
+	}
+	c.Header("Content-Type", "application/geo+json; charset=utf-8")
+	c.JSON(http.StatusOK, collection)
+}
+
+// GetEventsMapHandler godoc
+// @Summary Events on a map
+// @Description Returns located events as a GeoJSON FeatureCollection (Point [lng, lat]) with type, dates, branch and beneficiary totals as properties.
+// @Description Filter by viewport (bbox) and/or a radius around a point (results then carry distance_km and are ordered by it). With cluster=district one point per district is returned (centroid of its events) with the event count and summed totals, for zoomed-out views.
+// @Tags Events
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bbox query string false "Viewport as minLng,minLat,maxLng,maxLat (minLng > maxLng crosses the antimeridian)"
+// @Param lat query number false "Radius search centre latitude"
+// @Param lng query number false "Radius search centre longitude"
+// @Param radius_km query number false "Radius search distance in km"
+// @Param cluster query string false "district to cluster the points by district"
+// @Param start_date query string false "Events starting on or after this date (YYYY-MM-DD)"
+// @Param end_date query string false "Events starting on or before this date (YYYY-MM-DD)"
+// @Param status query string false "Comma-separated statuses"
+// @Param branch_id query int false "Restrict to the events led or co-hosted by a branch and its child branches"
+// @Param event_type_id query int false "Restrict to an event type"
+// @Success 200 {object} services.GeoJSONFeatureCollection
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/events/map [get]
+func GetEventsMapHandler(c *gin.Context) {
+	q, err := parseGeoQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	collection, err := services.GetEventsMap(q)
+	respondGeoJSON(c, collection, err)
+}
+
+// GetBranchesMapHandler godoc
+// @Summary Branches on a map
+// @Description Returns located branches as a GeoJSON FeatureCollection with contact details and the event count and beneficiary totals of their events (the date, status and event type filters apply to those totals).
+// @Description Filter by viewport (bbox) and/or a radius around a point; cluster=district returns one point per district.
+// @Tags Branches
+// @Security ApiKeyAuth
+// @Produce json
+// @Param bbox query string false "Viewport as minLng,minLat,maxLng,maxLat (minLng > maxLng crosses the antimeridian)"
+// @Param lat query number false "Radius search centre latitude"
+// @Param lng query number false "Radius search centre longitude"
+// @Param radius_km query number false "Radius search distance in km"
+// @Param cluster query string false "district to cluster the points by district"
+// @Param start_date query string false "Count events starting on or after this date (YYYY-MM-DD)"
+// @Param end_date query string false "Count events starting on or before this date (YYYY-MM-DD)"
+// @Param status query string false "Comma-separated event statuses to count"
+// @Param branch_id query int false "Restrict to a branch and its child branches"
+// @Param event_type_id query int false "Count only events of this type"
+// @Success 200 {object} services.GeoJSONFeatureCollection
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/branches/map [get]
+func GetBranchesMapHandler(c *gin.Context) {
+	q, err := parseGeoQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	collection, err := services.GetBranchesMap(q)
+	respondGeoJSON(c, collection, err)
+}
@@ -287,7 +287,8 @@ func DeleteEventMediaHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteEventMedia(uint(id)); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteEventMedia(uint(id), actor.Email); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
@@ -204,7 +204,8 @@ func DeleteSpecialGuestHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteSpecialGuest(specialGuest.(*models.SpecialGuest).ID); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteSpecialGuest(specialGuest.(*models.SpecialGuest).ID, actor.Email); err != nil {
 		if errors.Is(err, services.ErrSpecialGuestNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		} else {
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ListTrashHandler godoc
+// @Summary List trash
+// @Description Lists the soft-deleted records of a resource, most recently deleted first. Records stay in the trash until they are restored or purged after the retention period.
+// @Tags Trash
+// @Security ApiKeyAuth
+// @Produce json
+// @Param resource path string true "Resource" Enums(events, special_guests, volunteers, donations, media, branches)
+// @Success 200 {array} object
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/trash/{resource} [get]
+func ListTrashHandler(c *gin.Context) {
+	items, err := services.ListTrash(c.Param("resource"))
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownTrashResource) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch trash"})
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreFromTrashHandler godoc
+// @Summary Restore from trash
+// @Description Restores a soft-deleted record. Restoring an event also restores the guests, volunteers, media and donations deleted with it; restoring a branch also restores the child branches deleted with it.
+// @Tags Trash
+// @Security ApiKeyAuth
+// @Produce json
+// @Param resource path string true "Resource" Enums(events, special_guests, volunteers, donations, media, branches)
+// @Param id path int true "Record ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/trash/{resource}/{id}/restore [post]
+func RestoreFromTrashHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+
+	if err := services.RestoreFromTrash(c.Param("resource"), uint(id)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownTrashResource), errors.Is(err, services.ErrNotInTrash):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTrashParentDeleted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore record"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Record restored successfully"})
+}
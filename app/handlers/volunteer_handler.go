@@ -206,7 +206,8 @@ func DeleteVolunteerHandler(c *gin.Context) {
 		return
 	}
 
-	if err := services.DeleteVolunteer(volunteer.(*models.Volunteer).ID); err != nil {
+	actor, _ := getActor(c)
+	if err := services.DeleteVolunteer(volunteer.(*models.Volunteer).ID, actor.Email); err != nil {
 		if errors.Is(err, services.ErrVolunteerNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		} else {
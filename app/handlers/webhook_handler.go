@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// webhookWithSecretResponse is a subscription together with its secret, returned only when the
+// secret is set
+type webhookWithSecretResponse struct {
+	Webhook *models.WebhookSubscription `json:"webhook"`
+	Secret  string                      `json:"secret"`
+}
+
+// webhookActorEmail returns the email of the acting admin for the audit columns
+func webhookActorEmail(c *gin.Context) string {
+	email, _ := middleware.GetUserEmail(c)
+	return email
+}
+
+// parseWebhookID reads the webhook_id path parameter, responding 400 when it is invalid
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("webhook_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondWebhookError maps webhook service errors to responses
+func respondWebhookError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GetWebhookEventTypesHandler godoc
+// @Summary List webhook event types
+// @Description Lists the domain event types a webhook can subscribe to; "*" subscribes to all of them
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} string
+// @Router /api/webhooks/event-types [get]
+func GetWebhookEventTypesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, services.DomainEventTypes())
+}
+
+// CreateWebhookHandler godoc
+// @Summary Create a webhook
+// @Description Subscribes a URL to domain events. Every delivery is a JSON POST with the headers X-Webhook-Id (delivery ID), X-Webhook-Event (event type), X-Webhook-Timestamp (unix seconds) and X-Webhook-Signature. To verify a delivery, compute the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<raw body>" keyed with the secret and compare it with the signature after its "sha256=" prefix; reject old timestamps to prevent replays. The body "id" identifies the event and is the same for retries and replays. A secret is generated when none is given; it is only returned here and by rotate-secret.
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param webhook body services.WebhookInput true "Webhook"
+// @Success 201 {object} handlers.webhookWithSecretResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks [post]
+func CreateWebhookHandler(c *gin.Context) {
+	var input services.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, secret, err := services.CreateWebhookSubscription(input, webhookActorEmail(c))
+	if err != nil {
+		respondWebhookError(c, err, "failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhookWithSecretResponse{Webhook: webhook, Secret: secret})
+}
+
+// GetWebhooksHandler godoc
+// @Summary List webhooks
+// @Description Lists all webhook subscriptions (without their secrets)
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} models.WebhookSubscription
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks [get]
+func GetWebhooksHandler(c *gin.Context) {
+	webhooks, err := services.GetWebhookSubscriptions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhookHandler godoc
+// @Summary Get a webhook
+// @Description Returns a webhook subscription, including its failure counter and why it was disabled
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id} [get]
+func GetWebhookHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := services.GetWebhookSubscription(id)
+	if err != nil {
+		respondWebhookError(c, err, "failed to fetch webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhookHandler godoc
+// @Summary Update a webhook
+// @Description Changes the given fields of a webhook. Setting active to true re-enables a disabled webhook and resets its failure counter; its pending deliveries are then sent again.
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Param webhook body services.WebhookInput true "Fields to change"
+// @Success 200 {object} models.WebhookSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id} [put]
+func UpdateWebhookHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var input services.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := services.UpdateWebhookSubscription(id, input, webhookActorEmail(c))
+	if err != nil {
+		respondWebhookError(c, err, "failed to update webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhookHandler godoc
+// @Summary Delete a webhook
+// @Description Deletes a webhook subscription and its delivery log
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id} [delete]
+func DeleteWebhookHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := services.DeleteWebhookSubscription(id); err != nil {
+		respondWebhookError(c, err, "failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// RotateWebhookSecretHandler godoc
+// @Summary Rotate a webhook secret
+// @Description Replaces the signing secret of a webhook with a new random one, effective for the next delivery attempt
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id}/rotate-secret [post]
+func RotateWebhookSecretHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	secret, err := services.RotateWebhookSecret(id, webhookActorEmail(c))
+	if err != nil {
+		respondWebhookError(c, err, "failed to rotate webhook secret")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"secret": secret})
+}
+
+// TestWebhookHandler godoc
+// @Summary Send a test delivery
+// @Description Sends a signed webhook.ping delivery to the webhook right away (also when it is disabled) and returns the logged outcome. Pings are not retried and do not count towards disabling the webhook.
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Success 200 {object} models.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id}/test [post]
+func TestWebhookHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := services.SendWebhookPing(id, webhookActorEmail(c))
+	if err != nil {
+		respondWebhookError(c, err, "failed to send test delivery")
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// GetWebhookDeliveriesHandler godoc
+// @Summary List webhook deliveries
+// @Description Returns the delivery log of a webhook, newest first, with the response status, the start of the response body and the error of the latest attempt
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Param status query string false "Delivery status" Enums(pending, succeeded, failed)
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Results per page (default 20, max 100)"
+// @Success 200 {object} services.WebhookDeliveryPage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id}/deliveries [get]
+func GetWebhookDeliveriesHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := services.GetWebhookDeliveries(id, status, page, limit)
+	if err != nil {
+		respondWebhookError(c, err, "failed to fetch webhook deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ReplayWebhookDeliveryHandler godoc
+// @Summary Replay a webhook delivery
+// @Description Queues a new delivery with the body of an earlier one; it is signed afresh and sent by the dispatcher while the webhook is active
+// @Tags Webhooks
+// @Security ApiKeyAuth
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/webhooks/{webhook_id}/deliveries/{delivery_id}/replay [post]
+func ReplayWebhookDeliveryHandler(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery ID"})
+		return
+	}
+
+	delivery, err := services.ReplayWebhookDelivery(id, uint(deliveryID))
+	if err != nil {
+		respondWebhookError(c, err, "failed to replay webhook delivery")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
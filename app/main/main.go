@@ -28,6 +28,7 @@ import (
 	"time"
 
 	"github.com/followCode/djjs-event-reporting-backend/app/api"
+	"github.com/followCode/djjs-event-reporting-backend/app/handlers"
 	"github.com/followCode/djjs-event-reporting-backend/app/middleware"
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
 	"github.com/followCode/djjs-event-reporting-backend/app/services"
@@ -121,6 +122,109 @@ func main() {
 	}
 	services.StartDraftCleanupScheduler(cleanupHour, daysOld)
 
+	// 3️⃣d Start trash purge scheduler (permanently removes soft-deleted records and their S3 objects)
+	purgeHour := 3 // Default to 3 AM
+	if purgeHourStr := os.Getenv("TRASH_PURGE_HOUR"); purgeHourStr != "" {
+		if parsedHour, err := strconv.Atoi(purgeHourStr); err == nil && parsedHour >= 0 && parsedHour <= 23 {
+			purgeHour = parsedHour
+		}
+	}
+	retentionDays := 30 // Keep deleted records in the trash for 30 days
+	if retentionStr := os.Getenv("TRASH_RETENTION_DAYS"); retentionStr != "" {
+		if parsedDays, err := strconv.Atoi(retentionStr); err == nil && parsedDays > 0 {
+			retentionDays = parsedDays
+		}
+	}
+	services.StartTrashPurgeScheduler(purgeHour, retentionDays)
+
+	// 3️⃣e Start recurring series scheduler (generates upcoming occurrences of every active series)
+	generationHour := 1 // Default to 1 AM
+	if generationHourStr := os.Getenv("SERIES_GENERATION_HOUR"); generationHourStr != "" {
+		if parsedHour, err := strconv.Atoi(generationHourStr); err == nil && parsedHour >= 0 && parsedHour <= 23 {
+			generationHour = parsedHour
+		}
+	}
+	horizonDays := services.DefaultSeriesHorizonDays
+	if horizonStr := os.Getenv("SERIES_HORIZON_DAYS"); horizonStr != "" {
+		if parsedDays, err := strconv.Atoi(horizonStr); err == nil && parsedDays > 0 {
+			horizonDays = parsedDays
+		}
+	}
+	services.StartSeriesGenerationScheduler(generationHour, horizonDays)
+
+	// 3️⃣f Start duplicate scan scheduler (records suspected duplicate events for admin review)
+	duplicateScanHour := 4 // Default to 4 AM
+	if scanHourStr := os.Getenv("DUPLICATE_SCAN_HOUR"); scanHourStr != "" {
+		if parsedHour, err := strconv.Atoi(scanHourStr); err == nil && parsedHour >= 0 && parsedHour <= 23 {
+			duplicateScanHour = parsedHour
+		}
+	}
+	services.StartDuplicateScanScheduler(duplicateScanHour)
+
+	// 3️⃣g Start overdue report reminder scheduler (reminds about incomplete reports of ended events)
+	overdueReminderHour := 6 // Default to 6 AM
+	if reminderHourStr := os.Getenv("OVERDUE_REMINDER_HOUR"); reminderHourStr != "" {
+		if parsedHour, err := strconv.Atoi(reminderHourStr); err == nil && parsedHour >= 0 && parsedHour <= 23 {
+			overdueReminderHour = parsedHour
+		}
+	}
+	overdueConfig := services.DefaultOverdueConfig
+	if reminderDaysStr := os.Getenv("OVERDUE_REMINDER_DAYS"); reminderDaysStr != "" {
+		if parsedDays, err := services.ParseOverdueDays(reminderDaysStr); err == nil && len(parsedDays) > 0 {
+			overdueConfig.ReminderDays = parsedDays
+		}
+	}
+	if escalationDaysStr := os.Getenv("OVERDUE_ESCALATION_DAYS"); escalationDaysStr != "" {
+		if parsedDays, err := strconv.Atoi(escalationDaysStr); err == nil && parsedDays > 0 {
+			overdueConfig.EscalationDays = parsedDays
+		}
+	}
+	services.StartOverdueReminderScheduler(overdueReminderHour, overdueConfig)
+
+	// 3️⃣h Start idempotency key cleanup scheduler (drops stored responses of expired Idempotency-Keys)
+	if ttlHoursStr := os.Getenv("IDEMPOTENCY_TTL_HOURS"); ttlHoursStr != "" {
+		if parsedHours, err := strconv.Atoi(ttlHoursStr); err == nil && parsedHours > 0 {
+			services.SetIdempotencyTTL(time.Duration(parsedHours) * time.Hour)
+		}
+	}
+	idempotencyCleanupHour := 5 // Default to 5 AM
+	if cleanupHourStr := os.Getenv("IDEMPOTENCY_CLEANUP_HOUR"); cleanupHourStr != "" {
+		if parsedHour, err := strconv.Atoi(cleanupHourStr); err == nil && parsedHour >= 0 && parsedHour <= 23 {
+			idempotencyCleanupHour = parsedHour
+		}
+	}
+	services.StartIdempotencyCleanupScheduler(idempotencyCleanupHour)
+
+	// 3️⃣i Optimistic concurrency: updates of events, branches and members may be required to carry If-Match
+	if requireIfMatchStr := os.Getenv("REQUIRE_IF_MATCH"); requireIfMatchStr != "" {
+		if requireIfMatch, err := strconv.ParseBool(requireIfMatchStr); err == nil {
+			handlers.SetRequireIfMatch(requireIfMatch)
+		}
+	}
+
+	// 3️⃣j Start outbox dispatcher (delivers domain events written by the services to their subscribers)
+	outboxPollInterval := services.DefaultOutboxPollInterval
+	if pollSecondsStr := os.Getenv("OUTBOX_POLL_SECONDS"); pollSecondsStr != "" {
+		if parsedSeconds, err := strconv.Atoi(pollSecondsStr); err == nil && parsedSeconds > 0 {
+			outboxPollInterval = time.Duration(parsedSeconds) * time.Second
+		}
+	}
+	services.StartOutboxDispatcher(outboxPollInterval)
+
+	// 3️⃣k Start webhook dispatcher (sends signed domain event deliveries to the admin-managed webhooks)
+	if disableAfterStr := os.Getenv("WEBHOOK_DISABLE_AFTER_FAILURES"); disableAfterStr != "" {
+		if parsedFailures, err := strconv.Atoi(disableAfterStr); err == nil && parsedFailures > 0 {
+			services.SetWebhookDisableAfterFailures(parsedFailures)
+		}
+	}
+	webhookPollInterval := services.DefaultWebhookPollInterval
+	if pollSecondsStr := os.Getenv("WEBHOOK_POLL_SECONDS"); pollSecondsStr != "" {
+		if parsedSeconds, err := strconv.Atoi(pollSecondsStr); err == nil && parsedSeconds > 0 {
+			webhookPollInterval = time.Duration(parsedSeconds) * time.Second
+		}
+	}
+	services.StartWebhookDispatcher(webhookPollInterval)
+
 	// 4️⃣ Create Gin router
 	r := gin.New()
 	
@@ -169,8 +273,8 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     origins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", "x-request-id", "X-Request-Id"},
-		ExposeHeaders:    []string{"Content-Length", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", "x-request-id", "X-Request-Id", "Idempotency-Key", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "Authorization", "Idempotent-Replayed", "ETag"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -168,6 +168,29 @@ func GetUserEmail(c *gin.Context) (string, bool) {
 	return email, true
 }
 
+// GetRoleName extracts the user's role name from gin context
+// Falls back to the database when the token did not carry role information
+func GetRoleName(c *gin.Context) (string, bool) {
+	if roleName, exists := c.Get("roleName"); exists {
+		if rn, ok := roleName.(string); ok && rn != "" {
+			return rn, true
+		}
+	}
 
+	userID, exists := GetUserID(c)
+	if !exists {
+		return "", false
+	}
 
+	var roleName string
+	err := config.DB.Model(&models.User{}).
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Where("users.id = ? AND users.is_deleted = false", userID).
+		Select("roles.name").
+		Scan(&roleName).Error
+	if err != nil || roleName == "" {
+		return "", false
+	}
 
+	return roleName, true
+}
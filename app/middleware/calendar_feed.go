@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CalendarFeedTokenRequired authenticates calendar feed requests by the token query parameter
+// (calendar apps cannot send the Authorization header) and sets the same user context as
+// AuthRequired, so RequirePermission can follow it
+func CalendarFeedTokenRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := services.ResolveCalendarFeedToken(c.Query("token"))
+		if err != nil {
+			if errors.Is(err, services.ErrCalendarFeedTokenInvalid) {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify calendar feed token"})
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set(contextUserIDKey, int64(user.ID))
+		c.Set("roleID", int64(user.RoleID))
+		if user.Role.Name != "" {
+			c.Set("roleName", user.Role.Name)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader names the client-chosen key of a POST that may be retried
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks a response replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// responseRecorder keeps a copy of the response body while it is written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency honours the Idempotency-Key header on POST requests of authenticated users (it must
+// run after AuthRequired). The first successful or validation-failed (400, 422) response is stored
+// per user, key and route and replayed for retries; reusing a key with a different payload answers
+// 422 and a retry arriving while the first request still runs answers 409. A replay restores the
+// status, content type and body only: other headers of the first response, such as ETag, are not
+// sent again. Requests without the header are not affected.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must not exceed 255 characters"})
+			c.Abort()
+			return
+		}
+		userID, err := ExtractUserID(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			if body, err = io.ReadAll(c.Request.Body); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+				c.Abort()
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		record, err := services.BeginIdempotentRequest(userID, key, c.Request.Method, c.FullPath(), idempotencyRequestHash(c, body))
+		switch {
+		case errors.Is(err, services.ErrIdempotencyKeyReused):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		case err != nil:
+			// The key store is unavailable: serve the request without the duplicate protection
+			log.Printf("Warning: Idempotency key lookup failed, processing request without it: %v", err)
+			c.Next()
+			return
+		}
+
+		if record.Status == models.IdempotencyStatusCompleted {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.ResponseStatus, record.ResponseContentType, record.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stored := false
+		defer func() {
+			// Responses that are not stored, and panics, release the key so the retry runs again
+			if !stored {
+				if err := services.ReleaseIdempotentRequest(record.ID); err != nil {
+					log.Printf("Warning: Failed to release idempotency key %d: %v", record.ID, err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if !storesIdempotentResponse(status) {
+			return
+		}
+		if err := services.CompleteIdempotentRequest(record.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("Warning: Failed to store response for idempotency key %d: %v", record.ID, err)
+			return
+		}
+		stored = true
+	}
+}
+
+// storesIdempotentResponse reports whether a response is final for its payload and is replayed to
+// retries. Other client errors depend on more than the payload: a 401/403 on the session and the
+// user's permissions (the middleware runs before authorization), a 409 asks the client to confirm
+// or resolve something (such as likely duplicates, resent with create_anyway=true), a 404 or 429
+// may pass later. Server errors are transient.
+func storesIdempotentResponse(status int) bool {
+	return status < http.StatusBadRequest || status == http.StatusBadRequest || status == http.StatusUnprocessableEntity
+}
+
+// idempotencyRequestHash fingerprints a request: URL (with path parameters and query), media
+// type and body. The multipart boundary is left out because clients pick a new one per attempt.
+func idempotencyRequestHash(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+
+	mediaType, params, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err == nil {
+		h.Write([]byte(mediaType + "\n"))
+		if boundary := params["boundary"]; boundary != "" {
+			body = bytes.ReplaceAll(body, []byte(boundary), nil)
+		}
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // swagger:model Branch
 // Branch represents both parent branches and child branches in a single table.
@@ -27,6 +31,8 @@ type Branch struct {
 	Pincode         string     `json:"pincode,omitempty" validate:"omitempty,numeric,len=5|len=6"`
 	PostOffice      string     `json:"post_office,omitempty" validate:"omitempty,max=100"`
 	PoliceStation   string     `json:"police_station,omitempty" validate:"omitempty,max=100"`
+	Latitude        *float64   `json:"latitude,omitempty" validate:"omitempty,min=-90,max=90"`
+	Longitude       *float64   `json:"longitude,omitempty" validate:"omitempty,min=-180,max=180"`
 	OpenDays        string     `json:"open_days,omitempty" validate:"omitempty,max=100"`
 	DailyStartTime  string     `json:"daily_start_time,omitempty" validate:"omitempty"`
 	DailyEndTime    string     `json:"daily_end_time,omitempty" validate:"omitempty"`
@@ -39,10 +45,13 @@ type Branch struct {
 	NCR             bool       `gorm:"column:ncr;default:false" json:"ncr"`
 	RegionID        *uint      `gorm:"column:region_id" json:"region_id,omitempty"`
 	BranchCode      string     `gorm:"column:branch_code;unique" json:"branch_code,omitempty" validate:"omitempty,max=50"`
+	Version         int        `gorm:"not null;default:1" json:"version"` // Bumped by the database on every change; exposed as ETag
 	CreatedOn       time.Time  `gorm:"autoCreateTime" json:"created_on,omitempty"`
 	UpdatedOn       *time.Time `gorm:"autoUpdateTime" json:"updated_on,omitempty"`
 	CreatedBy       string     `json:"created_by,omitempty"`
 	UpdatedBy       string     `json:"updated_by,omitempty"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
+	DeletedBy       string     `json:"deleted_by,omitempty"`
 }
 
 // swagger:model BranchInfrastructure
@@ -74,6 +83,7 @@ type BranchMember struct {
 	DateOfBirth    *time.Time `json:"date_of_birth,omitempty"`
 	BranchID       *uint      `gorm:"column:branch_id" json:"branch_id,omitempty" validate:"omitempty,min=1"`
 	Branch         *Branch    `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+	Version        int        `gorm:"not null;default:1" json:"version"` // Bumped by the database on every change; exposed as ETag
 	CreatedOn      time.Time  `gorm:"autoCreateTime" json:"created_on,omitempty"`
 	UpdatedOn      *time.Time `gorm:"autoUpdateTime" json:"updated_on,omitempty"`
 	CreatedBy      string     `json:"created_by,omitempty"`
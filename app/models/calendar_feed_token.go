@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// CalendarFeedToken authenticates calendar subscriptions (.ics feeds). Calendar apps cannot send
+// our JWT header, so the token travels in the feed URL; only its SHA-256 hash is stored.
+type CalendarFeedToken struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `json:"name,omitempty"`
+	TokenHash  string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"`
+	TokenHint  string     `gorm:"type:varchar(8)" json:"token_hint"` // last characters, to tell tokens apart
+	CreatedOn  time.Time  `json:"created_on"`
+	LastUsedOn *time.Time `json:"last_used_on,omitempty"`
+	RevokedOn  *time.Time `json:"revoked_on,omitempty"`
+}
+
+func (CalendarFeedToken) TableName() string {
+	return "calendar_feed_tokens"
+}
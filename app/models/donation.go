@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Donation represents donation details for an event
 type Donation struct {
@@ -18,6 +22,9 @@ type Donation struct {
 	CreatedBy string `json:"created_by,omitempty" gorm:"<-:create"` // only set on create
 	UpdatedBy string `json:"updated_by,omitempty"`
 
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
+	DeletedBy string         `json:"deleted_by,omitempty"`
+
 	// Relations
 	Event  Event  `gorm:"foreignKey:EventID;references:ID" json:"event,omitempty"`
 	Branch Branch `gorm:"foreignKey:BranchID;references:ID" json:"branch,omitempty"`
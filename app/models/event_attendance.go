@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// EventAttendance is the attendance of one day (and optionally one session of that day) of an
+// event. While an event has attendance records, its beneficiary counts are the sums of them.
+type EventAttendance struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID        uint      `gorm:"not null;index" json:"event_id"`
+	AttendanceDate time.Time `gorm:"type:date;not null" json:"attendance_date" swaggertype:"string" example:"2024-01-15"`
+	Session        string    `gorm:"type:varchar(50);not null;default:''" json:"session,omitempty"` // e.g. morning, evening; empty for the whole day
+	Men            int       `json:"men"`
+	Women          int       `json:"women"`
+	Children       int       `json:"children"`
+	NewAttendees   int       `json:"new_attendees"` // attending for the first time, included in the counts above
+
+	CreatedOn time.Time  `json:"created_on,omitempty"`
+	UpdatedOn *time.Time `json:"updated_on,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+func (EventAttendance) TableName() string {
+	return "event_attendance"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// EventCoHost is a branch participating in an event organised jointly with the event's lead
+// branch (EventDetails.BranchID). BeneficiarySharePercent is the part of the event's beneficiaries
+// credited to the co-host; the lead branch is credited with what the co-hosts' shares leave.
+type EventCoHost struct {
+	EventID                 uint     `gorm:"primaryKey" json:"event_id"`
+	BranchID                uint     `gorm:"primaryKey" json:"branch_id"`
+	Branch                  *Branch  `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+	BeneficiarySharePercent *float64 `gorm:"type:numeric(5,2)" json:"beneficiary_share_percent,omitempty"`
+
+	CreatedOn time.Time `json:"created_on,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+func (EventCoHost) TableName() string {
+	return "event_cohost_branches"
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Event sections a comment can be anchored to (empty means the event as a whole)
+const (
+	CommentSectionGeneral       = "general"
+	CommentSectionParticipants  = "participants"
+	CommentSectionSpecialGuests = "special_guests"
+	CommentSectionVolunteers    = "volunteers"
+	CommentSectionMedia         = "media"
+	CommentSectionPromotion     = "promotion"
+	CommentSectionDonations     = "donations"
+	CommentSectionAttendance    = "attendance"
+)
+
+// IsValidCommentSection checks if the given section can anchor a comment
+func IsValidCommentSection(section string) bool {
+	switch section {
+	case "", CommentSectionGeneral, CommentSectionParticipants, CommentSectionSpecialGuests,
+		CommentSectionVolunteers, CommentSectionMedia, CommentSectionPromotion,
+		CommentSectionDonations, CommentSectionAttendance:
+		return true
+	}
+	return false
+}
+
+// EventComment is a reviewer comment on an event. Top-level comments start a thread that can be
+// resolved; replies point to the thread's top-level comment.
+type EventComment struct {
+	ID          uint                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID     uint                  `gorm:"not null;index" json:"event_id"`
+	ParentID    *uint                 `gorm:"index" json:"parent_id,omitempty"`
+	Section     string                `gorm:"type:varchar(30);default:''" json:"section,omitempty"`
+	Body        string                `gorm:"type:text;not null" json:"body"`
+	AuthorID    uint                  `json:"author_id"`
+	AuthorEmail string                `json:"author_email"`
+	AuthorName  string                `json:"author_name,omitempty"`
+	Resolved    bool                  `gorm:"default:false" json:"resolved"`
+	ResolvedBy  string                `json:"resolved_by,omitempty"`
+	ResolvedOn  *time.Time            `json:"resolved_on,omitempty"`
+	Mentions    []EventCommentMention `gorm:"foreignKey:CommentID" json:"mentions,omitempty"`
+	Replies     []EventComment        `gorm:"-" json:"replies,omitempty"`
+	CreatedOn   time.Time             `gorm:"autoCreateTime" json:"created_on"`
+	EditedOn    *time.Time            `json:"edited_on,omitempty"`
+	DeletedAt   gorm.DeletedAt        `gorm:"index" json:"-"`
+	DeletedBy   string                `json:"-"`
+}
+
+func (EventComment) TableName() string {
+	return "event_comments"
+}
+
+// EventCommentMention records a user @mentioned in a comment
+type EventCommentMention struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	CommentID uint      `gorm:"not null;index" json:"-"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	UserEmail string    `json:"user_email"`
+	UserName  string    `json:"user_name,omitempty"`
+	CreatedOn time.Time `gorm:"autoCreateTime" json:"-"`
+}
+
+func (EventCommentMention) TableName() string {
+	return "event_comment_mentions"
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Custom field types
+const (
+	CustomFieldTypeText        = "text"
+	CustomFieldTypeTextarea    = "textarea"
+	CustomFieldTypeNumber      = "number"
+	CustomFieldTypeInteger     = "integer"
+	CustomFieldTypeBoolean     = "boolean"
+	CustomFieldTypeDate        = "date" // YYYY-MM-DD
+	CustomFieldTypeSelect      = "select"
+	CustomFieldTypeMultiselect = "multiselect"
+)
+
+// CustomFieldValidation holds the optional constraints of a custom field: Min/Max bound numbers,
+// MinLength/MaxLength and Pattern (a regular expression) bound text
+type CustomFieldValidation struct {
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	MinLength *int     `json:"min_length,omitempty"`
+	MaxLength *int     `json:"max_length,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+}
+
+// Value implements the driver.Valuer interface
+func (v CustomFieldValidation) Value() (driver.Value, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (v *CustomFieldValidation) Scan(value interface{}) error {
+	if value == nil {
+		*v = CustomFieldValidation{}
+		return nil
+	}
+	var raw []byte
+	switch data := value.(type) {
+	case []byte:
+		raw = data
+	case string:
+		raw = []byte(data)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// EventCustomField is an admin-defined field of the events of one category or one sub category.
+// Its values are stored by Key in EventDetails.CustomFields. Inactive fields are no longer asked
+// for, but the values already recorded are still shown.
+type EventCustomField struct {
+	ID                 uint                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventCategoryID    *uint                 `json:"event_category_id,omitempty"`
+	EventSubCategoryID *uint                 `json:"event_sub_category_id,omitempty"`
+	Key                string                `gorm:"type:varchar(100);not null" json:"key"`
+	Label              string                `gorm:"type:varchar(255);not null" json:"label"`
+	FieldType          string                `gorm:"type:varchar(20);not null" json:"field_type"`
+	Required           bool                  `gorm:"not null;default:false" json:"required"`
+	Options            StringList            `gorm:"type:jsonb;not null" json:"options"`
+	Validation         CustomFieldValidation `gorm:"type:jsonb;not null" json:"validation"`
+	HelpText           string                `json:"help_text,omitempty"`
+	SortOrder          int                   `gorm:"not null;default:0" json:"sort_order"`
+	Active             bool                  `gorm:"not null" json:"active"`
+	CreatedOn          time.Time             `json:"created_on"`
+	UpdatedOn          *time.Time            `json:"updated_on,omitempty"`
+	CreatedBy          string                `json:"created_by,omitempty"`
+	UpdatedBy          string                `json:"updated_by,omitempty"`
+}
+
+func (EventCustomField) TableName() string {
+	return "event_custom_fields"
+}
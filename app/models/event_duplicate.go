@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// EventDuplicateSuspect is a pair of events the periodic duplicate scan considers likely to
+// describe the same programme. EventID is always the lower of the two IDs.
+type EventDuplicateSuspect struct {
+	ID               uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID          uint          `gorm:"not null;index" json:"event_id"`
+	Event            *EventDetails `gorm:"foreignKey:EventID" json:"event,omitempty"`
+	DuplicateEventID uint          `gorm:"not null;index" json:"duplicate_event_id"`
+	DuplicateEvent   *EventDetails `gorm:"foreignKey:DuplicateEventID" json:"duplicate_event,omitempty"`
+	Score            float64       `json:"score"`
+	Reasons          string        `json:"reasons"`
+	DetectedOn       time.Time     `json:"detected_on"`
+	LastSeenOn       time.Time     `json:"last_seen_on"`
+	DismissedOn      *time.Time    `json:"dismissed_on,omitempty"`
+	DismissedBy      string        `json:"dismissed_by,omitempty"`
+}
+
+func (EventDuplicateSuspect) TableName() string {
+	return "event_duplicate_suspects"
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const RevisionActionMerge = "merge"
+
+// EventMerge records that a duplicate event was merged into a surviving event. One row is
+// written per absorbed duplicate; rows written by the same merge share SurvivorRevisionID.
+// MergedEventID is not a foreign key, so the record outlives the purge of the merged event.
+type EventMerge struct {
+	ID                 uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SurvivorEventID    uint      `gorm:"not null;index" json:"survivor_event_id"`
+	MergedEventID      uint      `gorm:"not null;index" json:"merged_event_id"`
+	SurvivorRevisionID *uint     `json:"survivor_revision_id,omitempty"`
+	Fields             JSONB     `gorm:"type:jsonb" json:"fields,omitempty"`  // column -> value taken from the merged event
+	Moved              JSONB     `gorm:"type:jsonb" json:"moved,omitempty"`   // child rows re-parented, per section
+	Removed            JSONB     `gorm:"type:jsonb" json:"removed,omitempty"` // exact-duplicate child rows dropped, per section
+	ActorID            uint      `json:"actor_id"`
+	ActorEmail         string    `json:"actor_email,omitempty"`
+	MergedOn           time.Time `json:"merged_on"`
+}
+
+func (EventMerge) TableName() string {
+	return "event_merges"
+}
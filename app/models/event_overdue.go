@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Levels of overdue report notifications
+const (
+	OverdueLevelReminder   = "reminder"   // sent to the event creator and the branch coordinator
+	OverdueLevelEscalation = "escalation" // sent to the regional admins
+)
+
+// EventOverdueReminder records a reminder stage sent for an overdue report, so that every stage
+// is sent once
+type EventOverdueReminder struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID       uint      `gorm:"not null;index" json:"event_id"`
+	Level         string    `gorm:"type:varchar(20);not null" json:"level"`
+	ThresholdDays int       `gorm:"not null" json:"threshold_days"`
+	DaysOverdue   int       `json:"days_overdue"`
+	Recipients    string    `json:"recipients"` // comma-separated e-mail addresses
+	SentOn        time.Time `gorm:"autoCreateTime" json:"sent_on"`
+}
+
+func (EventOverdueReminder) TableName() string {
+	return "event_overdue_reminders"
+}
+
+// RegionAdmin assigns a user as admin of a region; overdue reports of the region's branches are
+// escalated to them
+type RegionAdmin struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RegionID  uint      `gorm:"not null;index" json:"region_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	User      *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	CreatedOn time.Time `gorm:"autoCreateTime" json:"created_on"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+func (RegionAdmin) TableName() string {
+	return "region_admins"
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Revision actions describing what produced a revision
+const (
+	RevisionActionCreate  = "create"
+	RevisionActionUpdate  = "update"
+	RevisionActionRestore = "restore"
+)
+
+// EventRevision stores a complete snapshot of an event and its related rows
+// (special guests, volunteers, media, donations, promotion materials) at a point in time
+type EventRevision struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID        uint      `gorm:"not null;index" json:"event_id"`
+	RevisionNumber int       `gorm:"not null" json:"revision_number"`
+	Action         string    `gorm:"type:varchar(20);not null" json:"action"`
+	RestoredFrom   *uint     `json:"restored_from,omitempty"`
+	Snapshot       JSONB     `gorm:"type:jsonb" json:"snapshot,omitempty"`
+	ActorID        uint      `json:"actor_id"`
+	ActorEmail     string    `json:"actor_email,omitempty"`
+	CreatedOn      time.Time `gorm:"autoCreateTime" json:"created_on"`
+}
+
+func (EventRevision) TableName() string {
+	return "event_revisions"
+}
+
+// EventSnapshot is the typed content of EventRevision.Snapshot
+type EventSnapshot struct {
+	Event              EventDetails               `json:"event"`
+	SpecialGuests      []SpecialGuest             `json:"special_guests"`
+	Volunteers         []Volunteer                `json:"volunteers"`
+	Media              []EventMedia               `json:"media"`
+	Donations          []Donation                 `json:"donations"`
+	PromotionMaterials []PromotionMaterialDetails `json:"promotion_materials"`
+	CoHosts            []EventCoHost              `json:"co_hosts"` // nil in snapshots taken before co-hosts were recorded
+}
+
+// RevisionFieldChange describes a single field-level difference between two revisions
+type RevisionFieldChange struct {
+	Path   string      `json:"path"`
+	Change string      `json:"change"` // added, removed, changed
+	From   interface{} `json:"from,omitempty"`
+	To     interface{} `json:"to,omitempty"`
+}
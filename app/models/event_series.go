@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+// EventSeries is a recurring event (e.g. a weekly satsang). Its template fields pre-fill every
+// generated occurrence; occurrences are regular EventDetails rows linked through SeriesID.
+type EventSeries struct {
+	ID   uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name string `gorm:"not null" json:"name"`
+
+	// Recurrence: a subset of RFC 5545 RRULE (FREQ=WEEKLY|MONTHLY, INTERVAL, BYDAY, BYMONTHDAY, COUNT, UNTIL)
+	// anchored at StartDate. Occurrences last DurationDays days.
+	RRule        string     `gorm:"type:varchar(255);not null" json:"rrule"`
+	StartDate    time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate      *time.Time `gorm:"type:date" json:"end_date,omitempty"`
+	DurationDays int        `gorm:"default:1" json:"duration_days"`
+	Active       bool       `gorm:"default:true" json:"active"`
+
+	// GeneratedUntil is the last date up to which occurrences have been generated
+	GeneratedUntil *time.Time `gorm:"type:date" json:"generated_until,omitempty"`
+
+	// Template copied into every occurrence
+	BranchID           *uint             `json:"branch_id,omitempty"`
+	Branch             *Branch           `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+	EventTypeID        uint              `json:"event_type_id"`
+	EventType          EventType         `gorm:"foreignKey:EventTypeID" json:"event_type,omitempty"`
+	EventCategoryID    uint              `json:"event_category_id"`
+	EventCategory      EventCategory     `gorm:"foreignKey:EventCategoryID" json:"event_category,omitempty"`
+	EventSubCategoryID *uint             `json:"event_sub_category_id,omitempty"`
+	EventSubCategory   *EventSubCategory `gorm:"foreignKey:EventSubCategoryID" json:"event_sub_category,omitempty"`
+	Scale              string            `json:"scale,omitempty"`
+	Theme              string            `json:"theme,omitempty"`
+	DailyStartTime     *TimeOnly         `gorm:"type:time" json:"daily_start_time,omitempty"`
+	DailyEndTime       *TimeOnly         `gorm:"type:time" json:"daily_end_time,omitempty"`
+	SpiritualOrator    string            `json:"spiritual_orator,omitempty"`
+	Language           string            `json:"language,omitempty"`
+	Country            string            `json:"country,omitempty"`
+	State              string            `json:"state,omitempty"`
+	City               string            `json:"city,omitempty"`
+	District           string            `json:"district,omitempty"`
+	PostOffice         string            `json:"post_office,omitempty"`
+	Pincode            string            `json:"pincode,omitempty"`
+	Address            string            `json:"address,omitempty"`
+	AddressType        string            `json:"address_type,omitempty"`
+	PoliceStation      string            `json:"police_station,omitempty"`
+	AreaCovered        string            `json:"area_covered,omitempty"`
+
+	Exceptions []EventSeriesException `gorm:"foreignKey:SeriesID" json:"exceptions,omitempty"`
+
+	CreatedOn time.Time  `json:"created_on,omitempty"`
+	UpdatedOn *time.Time `json:"updated_on,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+func (EventSeries) TableName() string {
+	return "event_series"
+}
+
+// EventSeriesException is a date on which a series does not take place
+type EventSeriesException struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	SeriesID  uint      `gorm:"not null;index" json:"series_id"`
+	Date      time.Time `gorm:"type:date;not null" json:"date"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedOn time.Time `json:"created_on,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+func (EventSeriesException) TableName() string {
+	return "event_series_exceptions"
+}
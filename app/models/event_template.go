@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// EventTemplate is a named, reusable starting point for new events. A template belongs to a
+// branch, an event category, both or neither (shared by everyone). Its two sections use the same
+// shape as the matching EventDraft steps and are copied into a new draft when the template is used.
+type EventTemplate struct {
+	ID              uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name            string         `gorm:"not null" json:"name"`
+	Description     string         `json:"description,omitempty"`
+	BranchID        *uint          `gorm:"index" json:"branch_id,omitempty"`
+	Branch          *Branch        `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+	EventCategoryID *uint          `gorm:"index" json:"event_category_id,omitempty"`
+	EventCategory   *EventCategory `gorm:"foreignKey:EventCategoryID" json:"event_category,omitempty"`
+
+	GeneralDetails JSONB `gorm:"type:jsonb" json:"general_details,omitempty"`
+	MediaPromotion JSONB `gorm:"type:jsonb" json:"media_promotion,omitempty"`
+
+	CreatedOn time.Time  `json:"created_on,omitempty"`
+	UpdatedOn *time.Time `json:"updated_on,omitempty"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+}
+
+func (EventTemplate) TableName() string {
+	return "event_templates"
+}
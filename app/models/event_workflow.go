@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Workflow states for the event review process
+const (
+	WorkflowStateDraft                = "draft"
+	WorkflowStateSubmitted            = "submitted"
+	WorkflowStateUnderReview          = "under_review"
+	WorkflowStateApproved             = "approved"
+	WorkflowStateReturnedWithComments = "returned_with_comments"
+	WorkflowStateResubmitted          = "resubmitted"
+)
+
+// IsValidWorkflowState checks if the given state is a known workflow state
+func IsValidWorkflowState(state string) bool {
+	switch state {
+	case WorkflowStateDraft, WorkflowStateSubmitted, WorkflowStateUnderReview,
+		WorkflowStateApproved, WorkflowStateReturnedWithComments, WorkflowStateResubmitted:
+		return true
+	}
+	return false
+}
+
+// EventStatusHistory records a single workflow transition of an event
+type EventStatusHistory struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID    uint      `gorm:"not null;index" json:"event_id"`
+	FromState  string    `gorm:"type:varchar(30)" json:"from_state"`
+	ToState    string    `gorm:"type:varchar(30);not null" json:"to_state"`
+	Comment    string    `json:"comment,omitempty"`
+	ActorID    uint      `json:"actor_id"`
+	ActorEmail string    `json:"actor_email,omitempty"`
+	ActorRole  string    `json:"actor_role,omitempty"`
+	CreatedOn  time.Time `gorm:"autoCreateTime" json:"created_on"`
+}
+
+func (EventStatusHistory) TableName() string {
+	return "event_status_history"
+}
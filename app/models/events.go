@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+
+	"gorm.io/gorm"
 )
 
 // JSONB type for PostgreSQL JSONB fields
@@ -165,6 +167,10 @@ type EventDetails struct {
 	PoliceStation string `json:"police_station,omitempty"`
 	AreaCovered  string `json:"area_covered,omitempty"`
 
+	// Geo-coordinates of the venue (decimal degrees); both set or both empty
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+
 	BeneficiaryMen   int `json:"beneficiary_men"`
 	BeneficiaryWomen int `json:"beneficiary_women"`
 	BeneficiaryChild int `json:"beneficiary_child"`
@@ -178,10 +184,27 @@ type EventDetails struct {
 
 	Status string `gorm:"default:'incomplete';type:varchar(20)" json:"status,omitempty"`
 
+	// Review workflow state (draft, submitted, under_review, approved, returned_with_comments, resubmitted)
+	WorkflowState string `gorm:"default:'draft';type:varchar(30)" json:"workflow_state,omitempty"`
+
+	// Recurring series this event is an occurrence of (nil for one-off events)
+	SeriesID       *uint      `json:"series_id,omitempty"`
+	OccurrenceDate *time.Time `gorm:"type:date" json:"occurrence_date,omitempty"`
+
+	// Values of the custom fields of the event's category and sub category, by field key
+	CustomFields JSONB `gorm:"type:jsonb;default:'{}'" json:"custom_fields,omitempty" swaggertype:"object"`
+
+	// Optimistic concurrency version, bumped by the database on every change and exposed as ETag
+	Version int `gorm:"not null;default:1" json:"version"`
+
 	CreatedOn time.Time  `json:"created_on,omitempty"`
 	UpdatedOn *time.Time `json:"updated_on,omitempty"`
 	CreatedBy string     `json:"created_by,omitempty"`
 	UpdatedBy string     `json:"updated_by,omitempty"`
 
+	// Soft delete: deleted events are kept in the trash until purged
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
+	DeletedBy string         `json:"deleted_by,omitempty"`
+
 	// Note: Draft fields removed - now using separate event_drafts table
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Idempotency key states
+const (
+	IdempotencyStatusInProgress = "in_progress"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyKey remembers the first response of a POST sent with an Idempotency-Key header, so
+// retries of the same request (same user, key and route) get that response instead of creating
+// the resource again. RequestHash detects a key reused for a different payload.
+type IdempotencyKey struct {
+	ID                  uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID              uint       `gorm:"not null" json:"user_id"`
+	Key                 string     `gorm:"column:idempotency_key;type:varchar(255);not null" json:"key"`
+	Method              string     `gorm:"type:varchar(10);not null" json:"method"`
+	Route               string     `gorm:"type:varchar(255);not null" json:"route"`
+	RequestHash         string     `gorm:"type:char(64);not null" json:"-"`
+	Status              string     `gorm:"type:varchar(20);not null" json:"status"`
+	ResponseStatus      int        `json:"response_status,omitempty"`
+	ResponseContentType string     `gorm:"type:varchar(255)" json:"-"`
+	ResponseBody        []byte     `json:"-"`
+	CreatedOn           time.Time  `json:"created_on"`
+	CompletedOn         *time.Time `json:"completed_on,omitempty"`
+	ExpiresOn           time.Time  `json:"expires_on"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
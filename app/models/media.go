@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // MediaCoverageType represents types of media coverage
@@ -40,6 +42,8 @@ type EventMedia struct {
 	UpdatedOn           time.Time         `gorm:"autoUpdateTime" json:"updated_on"`
 	CreatedBy           string            `json:"created_by,omitempty" gorm:"<-:create"` // only set on create
 	UpdatedBy           string            `json:"updated_by,omitempty"`
+	DeletedAt           gorm.DeletedAt    `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string"`
+	DeletedBy           string            `json:"deleted_by,omitempty"`
 	MediaCoverageType   MediaCoverageType `gorm:"foreignKey:MediaCoverageTypeID;references:ID" json:"media_coverage_type,omitempty"`
 	Event               Event             `gorm:"foreignKey:EventID;references:ID" json:"event,omitempty"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Outbox event states
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusDispatched = "dispatched"
+	OutboxStatusFailed     = "failed"
+)
+
+// OutboxEvent is a domain event (event created, media uploaded, ...) written in the same
+// transaction as the change it announces and delivered to subscribers afterwards
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventType     string     `gorm:"type:varchar(100);not null" json:"event_type"`
+	AggregateType string     `gorm:"type:varchar(50);not null" json:"aggregate_type"`
+	AggregateID   uint       `gorm:"not null" json:"aggregate_id"`
+	Payload       JSONB      `gorm:"type:jsonb" json:"payload"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptOn time.Time  `json:"next_attempt_on"`
+	LastError     string     `json:"last_error,omitempty"`
+	OccurredOn    time.Time  `json:"occurred_on"`
+	DispatchedOn  *time.Time `json:"dispatched_on,omitempty"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxDelivery records that a subscriber handled an outbox event, so a retry after a
+// failure of another subscriber does not deliver it to this one again
+type OutboxDelivery struct {
+	ID            uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OutboxEventID uint      `gorm:"not null" json:"outbox_event_id"`
+	Subscriber    string    `gorm:"type:varchar(100);not null" json:"subscriber"`
+	DeliveredOn   time.Time `json:"delivered_on"`
+}
+
+func (OutboxDelivery) TableName() string {
+	return "outbox_deliveries"
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// WebhookEventTypeAll subscribes a webhook to every domain event type
+const WebhookEventTypeAll = "*"
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+// Value implements the driver.Valuer interface
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+	var raw []byte
+	switch v := value.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(raw, (*[]string)(l))
+}
+
+// WebhookSubscription sends the domain events of the chosen types to an external URL as signed
+// JSON. It is disabled automatically after too many consecutive failed delivery attempts.
+type WebhookSubscription struct {
+	ID                  uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name                string     `gorm:"type:varchar(255);not null" json:"name"`
+	URL                 string     `gorm:"column:url;type:varchar(2048);not null" json:"url"`
+	EventTypes          StringList `gorm:"type:jsonb;not null" json:"event_types"`
+	Secret              string     `gorm:"type:varchar(255);not null" json:"-"`
+	Active              bool       `gorm:"not null;default:true" json:"active"`
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"`
+	DisabledOn          *time.Time `json:"disabled_on,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty"`
+	LastDeliveryOn      *time.Time `json:"last_delivery_on,omitempty"`
+	CreatedOn           time.Time  `json:"created_on"`
+	UpdatedOn           *time.Time `json:"updated_on,omitempty"`
+	CreatedBy           string     `json:"created_by,omitempty"`
+	UpdatedBy           string     `json:"updated_by,omitempty"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WebhookDelivery is one domain event to be sent (or sent) to a subscription, with the outcome of
+// the latest attempt. A replay is a new delivery of the same body.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubscriptionID uint       `gorm:"not null" json:"subscription_id"`
+	OutboxEventID  *uint      `json:"outbox_event_id,omitempty"`
+	ReplayOf       *uint      `json:"replay_of,omitempty"`
+	EventType      string     `gorm:"type:varchar(100);not null" json:"event_type"`
+	Body           JSONB      `gorm:"type:jsonb" json:"body"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptOn  time.Time  `json:"next_attempt_on"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	DurationMs     int64      `json:"duration_ms,omitempty"`
+	CreatedOn      time.Time  `json:"created_on"`
+	LastAttemptOn  *time.Time `json:"last_attempt_on,omitempty"`
+	DeliveredOn    *time.Time `json:"delivered_on,omitempty"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
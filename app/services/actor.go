@@ -0,0 +1,15 @@
+package services
+
+import "github.com/followCode/djjs-event-reporting-backend/app/models"
+
+// Actor identifies the authenticated user performing an operation
+type Actor struct {
+	UserID uint
+	Email  string
+	Role   string
+}
+
+// IsAdmin reports whether the actor holds an admin or super admin role
+func (a Actor) IsAdmin() bool {
+	return a.Role == string(models.RoleTypeAdmin) || a.Role == string(models.RoleTypeSuperAdmin)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
 	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
 )
 
 // CreateBranch inserts a new branch record
@@ -103,8 +104,8 @@ func GetAllBranches() ([]models.Branch, error) {
 	if err := config.DB.
 		Select("id", "name", "email", "coordinator_name", "contact_number", "established_on", "aashram_area",
 			"country_id", "state_id", "district_id", "city_id", "parent_branch_id",
-			"address", "pincode", "post_office", "police_station", "open_days",
-			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code",
+			"address", "pincode", "post_office", "police_station", "latitude", "longitude", "open_days",
+			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code", "version",
 			"created_on", "updated_on", "created_by", "updated_by").
 		Where("parent_branch_id IS NULL"). // Only return parent branches
 		Preload("Country").
@@ -125,8 +126,8 @@ func GetBranch(branchID uint) (*models.Branch, error) {
 	if err := config.DB.
 		Select("id", "name", "email", "coordinator_name", "contact_number", "established_on", "aashram_area",
 			"country_id", "state_id", "district_id", "city_id", "parent_branch_id",
-			"address", "pincode", "post_office", "police_station", "open_days",
-			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code",
+			"address", "pincode", "post_office", "police_station", "latitude", "longitude", "open_days",
+			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code", "version",
 			"created_on", "updated_on", "created_by", "updated_by").
 		Preload("Country").
 		Preload("State").
@@ -148,8 +149,8 @@ func GetChildBranches(parentBranchID uint) ([]models.Branch, error) {
 	if err := config.DB.
 		Select("id", "name", "email", "coordinator_name", "contact_number", "established_on", "aashram_area",
 			"country_id", "state_id", "district_id", "city_id", "parent_branch_id",
-			"address", "pincode", "post_office", "police_station", "open_days",
-			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code",
+			"address", "pincode", "post_office", "police_station", "latitude", "longitude", "open_days",
+			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code", "version",
 			"created_on", "updated_on", "created_by", "updated_by").
 		Preload("Country").
 		Preload("State").
@@ -170,8 +171,8 @@ func GetBranchSearch(branchName, coordinator string) ([]models.Branch, error) {
 	db := config.DB.
 		Select("id", "name", "email", "coordinator_name", "contact_number", "established_on", "aashram_area",
 			"country_id", "state_id", "district_id", "city_id", "parent_branch_id",
-			"address", "pincode", "post_office", "police_station", "open_days",
-			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code",
+			"address", "pincode", "post_office", "police_station", "latitude", "longitude", "open_days",
+			"daily_start_time", "daily_end_time", "status", "ncr", "region_id", "branch_code", "version",
 			"created_on", "updated_on", "created_by", "updated_by").
 		Where("parent_branch_id IS NULL"). // Only search parent branches
 		Preload("Country").
@@ -203,11 +204,15 @@ func GetBranchSearch(branchName, coordinator string) ([]models.Branch, error) {
 }
 
 // UpdateBranch updates branch fields
-func UpdateBranch(branchID uint, updatedData map[string]interface{}) error {
+// expectedVersion (optional) is the version the change is based on; ErrVersionConflict is returned when it is stale
+func UpdateBranch(branchID uint, updatedData map[string]interface{}, expectedVersion *int) error {
 	var branch models.Branch
 	if err := config.DB.First(&branch, branchID).Error; err != nil {
 		return errors.New("branch not found")
 	}
+	if err := checkRowVersion(branch.Version, expectedVersion); err != nil {
+		return err
+	}
 
 	// Check email uniqueness if email is being updated (skip if empty or nil)
 	if email, ok := updatedData["email"]; ok && email != nil {
@@ -462,18 +467,17 @@ func UpdateBranch(branchID uint, updatedData map[string]interface{}) error {
 	now := time.Now()
 	updatedData["updated_on"] = &now
 
-	if err := config.DB.Model(&branch).Updates(updatedData).Error; err != nil {
+	if err := updateVersioned(config.DB, &branch, expectedVersion, updatedData); err != nil {
 		return err
 	}
 	return nil
 }
 
-// DeleteBranch deletes a branch by ID
-func DeleteBranch(branchID uint) error {
-	if err := config.DB.Delete(&models.Branch{}, branchID).Error; err != nil {
-		return err
-	}
-	return nil
+// DeleteBranch moves a branch and its child branches to the trash by ID
+func DeleteBranch(branchID uint, deletedBy string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		return softDeleteBranchTree(tx, branchID, deletedBy)
+	})
 }
 
 // *************************************** Branch Infrastructure ****************************************************** //
@@ -604,11 +608,15 @@ func GetMembersByBranch(branchID uint) ([]models.BranchMember, error) {
 }
 
 // UpdateBranchMember updates a member by ID
-func UpdateBranchMember(id uint, updatedData map[string]interface{}) error {
+// expectedVersion (optional) is the version the change is based on; ErrVersionConflict is returned when it is stale
+func UpdateBranchMember(id uint, updatedData map[string]interface{}, expectedVersion *int) error {
 	var member models.BranchMember
 	if err := config.DB.First(&member, id).Error; err != nil {
 		return errors.New("member not found")
 	}
+	if err := checkRowVersion(member.Version, expectedVersion); err != nil {
+		return err
+	}
 
 	// Parse date_of_birth if provided as string
 	if dob, ok := updatedData["date_of_birth"]; ok && dob != nil {
@@ -679,10 +687,7 @@ func UpdateBranchMember(id uint, updatedData map[string]interface{}) error {
 	now := time.Now()
 	updatedData["updated_on"] = &now
 
-	if err := config.DB.Model(&member).Updates(updatedData).Error; err != nil {
-		return err
-	}
-	return nil
+	return updateVersioned(config.DB, &member, expectedVersion, updatedData)
 }
 
 // DeleteBranchMember deletes a member by ID
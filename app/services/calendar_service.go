@@ -0,0 +1,296 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // event times are wall-clock times in CALENDAR_TIMEZONE
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCalendarFeedTokenInvalid  = errors.New("invalid or revoked calendar feed token")
+	ErrCalendarFeedTokenNotFound = errors.New("calendar feed token not found")
+)
+
+const (
+	// calendarFeedPastDays keeps recently finished events in feeds so they do not vanish from calendars at once
+	calendarFeedPastDays = 30
+	calendarProductID    = "-//DJJS//Event Reporting//EN"
+	calendarUIDDomain    = "djjs-event-reporting"
+)
+
+// CalendarFeedScope selects the events of a calendar feed; exactly one field is set
+type CalendarFeedScope struct {
+	BranchID uint // branch and its child branches
+	RegionID uint
+	Orator   string
+}
+
+// calendarLocation is the time zone in which daily start/end times are recorded
+func calendarLocation() *time.Location {
+	name := os.Getenv("CALENDAR_TIMEZONE")
+	if name == "" {
+		name = "Asia/Kolkata"
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func hashCalendarFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateCalendarFeedToken issues a new feed token for a user. The plain token is returned only here.
+func CreateCalendarFeedToken(userID uint, name string) (*models.CalendarFeedToken, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(raw)
+
+	feedToken := &models.CalendarFeedToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashCalendarFeedToken(token),
+		TokenHint: token[len(token)-6:],
+		CreatedOn: time.Now(),
+	}
+	if err := config.DB.Create(feedToken).Error; err != nil {
+		return nil, "", err
+	}
+	return feedToken, token, nil
+}
+
+// GetCalendarFeedTokens lists the feed tokens of a user, newest first
+func GetCalendarFeedTokens(userID uint) ([]models.CalendarFeedToken, error) {
+	var tokens []models.CalendarFeedToken
+	err := config.DB.Where("user_id = ?", userID).Order("created_on DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// RevokeCalendarFeedToken revokes one of the user's feed tokens; subscriptions using it stop updating
+func RevokeCalendarFeedToken(userID uint, tokenID uint) error {
+	result := config.DB.Model(&models.CalendarFeedToken{}).
+		Where("id = ? AND user_id = ? AND revoked_on IS NULL", tokenID, userID).
+		Update("revoked_on", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedTokenNotFound
+	}
+	return nil
+}
+
+// ResolveCalendarFeedToken returns the active user owning a valid, unrevoked feed token
+func ResolveCalendarFeedToken(token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrCalendarFeedTokenInvalid
+	}
+	var feedToken models.CalendarFeedToken
+	if err := config.DB.Where("token_hash = ? AND revoked_on IS NULL", hashCalendarFeedToken(token)).First(&feedToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedTokenInvalid
+		}
+		return nil, err
+	}
+
+	var user models.User
+	if err := config.DB.Preload("Role").Where("id = ? AND is_deleted = false", feedToken.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedTokenInvalid
+		}
+		return nil, err
+	}
+
+	config.DB.Model(&feedToken).UpdateColumn("last_used_on", time.Now())
+	return &user, nil
+}
+
+// GetCalendarFeedEvents returns the upcoming and recently finished events of a feed scope
+func GetCalendarFeedEvents(scope CalendarFeedScope) ([]models.EventDetails, error) {
+	db := config.DB.
+		Preload("EventType").
+		Preload("EventCategory").
+		Preload("Branch").
+		Where("end_date >= ?", time.Now().AddDate(0, 0, -calendarFeedPastDays))
+
+	switch {
+	case scope.BranchID > 0:
+		db = applyEventListFilter(db, EventListFilter{BranchID: scope.BranchID, IncludeDescendants: true})
+	case scope.RegionID > 0:
+		regionBranches := "SELECT id FROM branches WHERE region_id = ? AND deleted_at IS NULL"
+		db = db.Where("(branch_id IN ("+regionBranches+") OR id IN (SELECT event_id FROM event_cohost_branches WHERE branch_id IN ("+regionBranches+")))",
+			scope.RegionID, scope.RegionID)
+	case scope.Orator != "":
+		db = db.Where("LOWER(TRIM(spiritual_orator)) = LOWER(TRIM(?))", scope.Orator)
+	default:
+		return nil, errors.New("a branch, region or orator is required")
+	}
+
+	var events []models.EventDetails
+	err := db.Order("start_date ASC, id ASC").Limit(1000).Find(&events).Error
+	return events, err
+}
+
+// BuildEventsICS renders events as an iCalendar (RFC 5545) document
+func BuildEventsICS(calendarName string, events []models.EventDetails) []byte {
+	loc := calendarLocation()
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:"+calendarProductID)
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	if calendarName != "" {
+		writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(calendarName))
+	}
+	writeICSLine(&b, "X-WR-TIMEZONE:"+loc.String())
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT6H")
+	for i := range events {
+		writeEventICS(&b, &events[i], loc)
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func writeEventICS(b *strings.Builder, event *models.EventDetails, loc *time.Location) {
+	const utcFormat = "20060102T150405Z"
+
+	stamp := event.CreatedOn
+	if event.UpdatedOn != nil {
+		stamp = *event.UpdatedOn
+	}
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	writeICSLine(b, "BEGIN:VEVENT")
+	writeICSLine(b, fmt.Sprintf("UID:event-%d@%s", event.ID, calendarUIDDomain))
+	writeICSLine(b, "DTSTAMP:"+stamp.UTC().Format(utcFormat))
+	writeICSLine(b, "LAST-MODIFIED:"+stamp.UTC().Format(utcFormat))
+
+	startDay := dateOnly(event.StartDate)
+	endDay := dateOnly(event.EndDate)
+	if endDay.Before(startDay) {
+		endDay = startDay
+	}
+	days := int(endDay.Sub(startDay).Hours()/24) + 1
+
+	if event.DailyStartTime != nil && !event.DailyStartTime.IsZero() {
+		// Timed event repeating every day of the event
+		start := wallClock(startDay, event.DailyStartTime.Time, loc)
+		end := start.Add(time.Hour)
+		if event.DailyEndTime != nil && !event.DailyEndTime.IsZero() {
+			end = wallClock(startDay, event.DailyEndTime.Time, loc)
+			if !end.After(start) {
+				end = end.AddDate(0, 0, 1) // ends after midnight
+			}
+		}
+		writeICSLine(b, "DTSTART:"+start.UTC().Format(utcFormat))
+		writeICSLine(b, "DTEND:"+end.UTC().Format(utcFormat))
+		if days > 1 {
+			writeICSLine(b, fmt.Sprintf("RRULE:FREQ=DAILY;COUNT=%d", days))
+		}
+	} else {
+		// All-day event; DTEND is exclusive
+		writeICSLine(b, "DTSTART;VALUE=DATE:"+startDay.Format("20060102"))
+		writeICSLine(b, "DTEND;VALUE=DATE:"+endDay.AddDate(0, 0, 1).Format("20060102"))
+	}
+
+	writeICSLine(b, "SUMMARY:"+escapeICSText(eventICSSummary(event)))
+	if location := eventICSLocation(event); location != "" {
+		writeICSLine(b, "LOCATION:"+escapeICSText(location))
+	}
+	writeICSLine(b, "DESCRIPTION:"+escapeICSText(eventICSDescription(event)))
+	if event.EventCategory.Name != "" {
+		writeICSLine(b, "CATEGORIES:"+escapeICSText(event.EventCategory.Name))
+	}
+	writeICSLine(b, "STATUS:CONFIRMED")
+	writeICSLine(b, "TRANSP:OPAQUE")
+	writeICSLine(b, "END:VEVENT")
+}
+
+// wallClock combines a date with a wall-clock time of day in loc
+func wallClock(day time.Time, clock time.Time, loc *time.Location) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+}
+
+func eventICSSummary(event *models.EventDetails) string {
+	summary := strings.TrimSpace(event.Theme)
+	if summary == "" {
+		summary = strings.TrimSpace(event.EventCategory.Name)
+	}
+	if summary == "" {
+		summary = strings.TrimSpace(event.EventType.Name)
+	}
+	if summary == "" {
+		summary = fmt.Sprintf("Event %d", event.ID)
+	}
+	if event.Branch != nil && event.Branch.Name != "" {
+		summary += " - " + event.Branch.Name
+	}
+	return summary
+}
+
+func eventICSLocation(event *models.EventDetails) string {
+	var parts []string
+	for _, part := range []string{event.Address, event.City, event.District, event.State, event.Pincode, event.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func eventICSDescription(event *models.EventDetails) string {
+	var lines []string
+	add := func(label, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+	add("Type", event.EventType.Name)
+	add("Category", event.EventCategory.Name)
+	add("Spiritual orator", event.SpiritualOrator)
+	add("Language", event.Language)
+	if event.Branch != nil {
+		add("Branch", event.Branch.Name)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// escapeICSText escapes a TEXT value (RFC 5545 section 3.3.11)
+func escapeICSText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+// writeICSLine writes a content line folded at 75 octets without splitting UTF-8 sequences
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
 	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
 )
 
 // CreateChildBranch creates a new child branch (now using Branch model with parent_branch_id)
@@ -86,11 +87,15 @@ func GetChildBranchesByParent(parentBranchID uint) ([]models.Branch, error) {
 }
 
 // UpdateChildBranch updates a child branch
-func UpdateChildBranch(childBranchID uint, updatedData map[string]interface{}) error {
+// expectedVersion (optional) is the version the change is based on; ErrVersionConflict is returned when it is stale
+func UpdateChildBranch(childBranchID uint, updatedData map[string]interface{}, expectedVersion *int) error {
 	var childBranch models.Branch
 	if err := config.DB.Where("id = ? AND parent_branch_id IS NOT NULL", childBranchID).First(&childBranch).Error; err != nil {
 		return errors.New("child branch not found")
 	}
+	if err := checkRowVersion(childBranch.Version, expectedVersion); err != nil {
+		return err
+	}
 
 	// Validate parent_branch_id if being updated
 	if parentID, ok := updatedData["parent_branch_id"]; ok {
@@ -151,23 +156,19 @@ func UpdateChildBranch(childBranchID uint, updatedData map[string]interface{}) e
 	now := time.Now()
 	updatedData["updated_on"] = &now
 
-	if err := config.DB.Model(&childBranch).Updates(updatedData).Error; err != nil {
-		return err
-	}
-	return nil
+	return updateVersioned(config.DB, &childBranch, expectedVersion, updatedData)
 }
 
-// DeleteChildBranch deletes a child branch by ID
-func DeleteChildBranch(childBranchID uint) error {
+// DeleteChildBranch moves a child branch (and its own children) to the trash by ID
+func DeleteChildBranch(childBranchID uint, deletedBy string) error {
 	// Only delete if it's actually a child branch (has parent_branch_id)
 	var childBranch models.Branch
 	if err := config.DB.Where("id = ? AND parent_branch_id IS NOT NULL", childBranchID).First(&childBranch).Error; err != nil {
 		return errors.New("child branch not found")
 	}
-	if err := config.DB.Delete(&childBranch).Error; err != nil {
-		return err
-	}
-	return nil
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		return softDeleteBranchTree(tx, childBranch.ID, deletedBy)
+	})
 }
 
 // *************************************** Child Branch Infrastructure ****************************************************** //
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"gorm.io/gorm"
+)
+
+// Domain event types written to the outbox
+const (
+	DomainEventEventCreated         = "event.created"
+	DomainEventEventStatusChanged   = "event.status_changed"
+	DomainEventEventWorkflowChanged = "event.workflow_changed"
+	DomainEventEventApproved        = "event.approved"
+	DomainEventEventDeleted         = "event.deleted"
+	DomainEventEventCoHostsChanged  = "event.cohosts_changed"
+	DomainEventMediaUploaded        = "media.uploaded"
+	DomainEventMediaDeleted         = "media.deleted"
+	DomainEventDonationCreated      = "donation.created"
+	DomainEventDonationUpdated      = "donation.updated"
+	DomainEventDonationDeleted      = "donation.deleted"
+)
+
+// DomainEventTypes lists every domain event type
+func DomainEventTypes() []string {
+	return []string{
+		DomainEventEventCreated, DomainEventEventStatusChanged, DomainEventEventWorkflowChanged,
+		DomainEventEventApproved, DomainEventEventDeleted, DomainEventEventCoHostsChanged,
+		DomainEventMediaUploaded, DomainEventMediaDeleted,
+		DomainEventDonationCreated, DomainEventDonationUpdated, DomainEventDonationDeleted,
+	}
+}
+
+// Aggregates the domain events are about
+const (
+	AggregateEvent      = "event"
+	AggregateEventMedia = "event_media"
+	AggregateDonation   = "donation"
+)
+
+// Sources of a created event
+const (
+	EventSourceAPI    = "api"
+	EventSourceImport = "import"
+	EventSourceSeries = "series"
+)
+
+// DomainEvent is a typed fact about a change, serialized as the outbox payload
+type DomainEvent interface {
+	// DomainEventType returns one of the DomainEvent* constants
+	DomainEventType() string
+	// Aggregate returns the type and ID of the record the event is about
+	Aggregate() (string, uint)
+}
+
+// EventCreated announces a new event
+type EventCreated struct {
+	EventID       uint   `json:"event_id"`
+	BranchID      *uint  `json:"branch_id,omitempty"`
+	Status        string `json:"status,omitempty"`
+	WorkflowState string `json:"workflow_state,omitempty"`
+	Source        string `json:"source"`
+	CreatedBy     string `json:"created_by,omitempty"`
+}
+
+func (EventCreated) DomainEventType() string     { return DomainEventEventCreated }
+func (e EventCreated) Aggregate() (string, uint) { return AggregateEvent, e.EventID }
+
+// EventStatusChanged announces a change of the report status (complete/incomplete)
+type EventStatusChanged struct {
+	EventID    uint   `json:"event_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+}
+
+func (EventStatusChanged) DomainEventType() string     { return DomainEventEventStatusChanged }
+func (e EventStatusChanged) Aggregate() (string, uint) { return AggregateEvent, e.EventID }
+
+// EventWorkflowChanged announces a review workflow transition
+type EventWorkflowChanged struct {
+	EventID    uint   `json:"event_id"`
+	FromState  string `json:"from_state"`
+	ToState    string `json:"to_state"`
+	Comment    string `json:"comment,omitempty"`
+	ActorID    uint   `json:"actor_id"`
+	ActorEmail string `json:"actor_email,omitempty"`
+}
+
+func (EventWorkflowChanged) DomainEventType() string     { return DomainEventEventWorkflowChanged }
+func (e EventWorkflowChanged) Aggregate() (string, uint) { return AggregateEvent, e.EventID }
+
+// EventApproved announces that a reviewer approved an event (in addition to its EventWorkflowChanged)
+type EventApproved struct {
+	EventID    uint   `json:"event_id"`
+	ApproverID uint   `json:"approver_id"`
+	ApprovedBy string `json:"approved_by,omitempty"`
+}
+
+func (EventApproved) DomainEventType() string     { return DomainEventEventApproved }
+func (e EventApproved) Aggregate() (string, uint) { return AggregateEvent, e.EventID }
+
+// EventDeleted announces that an event was moved to the trash
+type EventDeleted struct {
+	EventID   uint   `json:"event_id"`
+	DeletedBy string `json:"deleted_by,omitempty"`
+}
+
+func (EventDeleted) DomainEventType() string     { return DomainEventEventDeleted }
+func (e EventDeleted) Aggregate() (string, uint) { return AggregateEvent, e.EventID }
+
+// EventCoHostsChanged announces a change of the co-hosting branches of an event; CoHosts is the
+// new list
+type EventCoHostsChanged struct {
+	EventID      uint               `json:"event_id"`
+	LeadBranchID *uint              `json:"lead_branch_id"`
+	CoHosts      []EventCoHostInput `json:"co_hosts"`
+	ChangedBy    string             `json:"changed_by,omitempty"`
+}
+
+func (EventCoHostsChanged) DomainEventType() string     { return DomainEventEventCoHostsChanged }
+func (e EventCoHostsChanged) Aggregate() (string, uint) { return AggregateEvent, e.EventID }
+
+// MediaUploaded announces a new media record of an event
+type MediaUploaded struct {
+	MediaID          uint   `json:"media_id"`
+	EventID          uint   `json:"event_id"`
+	FileType         string `json:"file_type,omitempty"`
+	OriginalFilename string `json:"original_filename,omitempty"`
+	S3Key            string `json:"s3_key,omitempty"`
+}
+
+func (MediaUploaded) DomainEventType() string     { return DomainEventMediaUploaded }
+func (e MediaUploaded) Aggregate() (string, uint) { return AggregateEventMedia, e.MediaID }
+
+// MediaDeleted announces that a media record was moved to the trash
+type MediaDeleted struct {
+	MediaID   uint   `json:"media_id"`
+	EventID   uint   `json:"event_id"`
+	DeletedBy string `json:"deleted_by,omitempty"`
+}
+
+func (MediaDeleted) DomainEventType() string     { return DomainEventMediaDeleted }
+func (e MediaDeleted) Aggregate() (string, uint) { return AggregateEventMedia, e.MediaID }
+
+// DonationCreated announces a new donation
+type DonationCreated struct {
+	DonationID   uint    `json:"donation_id"`
+	EventID      uint    `json:"event_id"`
+	BranchID     uint    `json:"branch_id"`
+	DonationType string  `json:"donation_type,omitempty"`
+	Amount       float64 `json:"amount"`
+}
+
+func (DonationCreated) DomainEventType() string     { return DomainEventDonationCreated }
+func (e DonationCreated) Aggregate() (string, uint) { return AggregateDonation, e.DonationID }
+
+// DonationUpdated announces a change of a donation; Fields lists the changed columns
+type DonationUpdated struct {
+	DonationID uint     `json:"donation_id"`
+	EventID    uint     `json:"event_id"`
+	Fields     []string `json:"fields"`
+}
+
+func (DonationUpdated) DomainEventType() string     { return DomainEventDonationUpdated }
+func (e DonationUpdated) Aggregate() (string, uint) { return AggregateDonation, e.DonationID }
+
+// DonationDeleted announces that a donation was moved to the trash
+type DonationDeleted struct {
+	DonationID uint   `json:"donation_id"`
+	EventID    uint   `json:"event_id"`
+	DeletedBy  string `json:"deleted_by,omitempty"`
+}
+
+func (DonationDeleted) DomainEventType() string     { return DomainEventDonationDeleted }
+func (e DonationDeleted) Aggregate() (string, uint) { return AggregateDonation, e.DonationID }
+
+// recordEventsDeleted writes an event.deleted domain event for each of the events
+func recordEventsDeleted(tx *gorm.DB, eventIDs []uint, deletedBy string) error {
+	for _, id := range eventIDs {
+		if err := RecordDomainEvent(tx, EventDeleted{EventID: id, DeletedBy: deletedBy}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordDomainEvent writes a domain event to the outbox using tx. Call it in the transaction of
+// the change it announces: the event is delivered if and only if the change is committed.
+func RecordDomainEvent(tx *gorm.DB, event DomainEvent) error {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var payload models.JSONB
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return err
+	}
+
+	aggregateType, aggregateID := event.Aggregate()
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		EventType:     event.DomainEventType(),
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       payload,
+		Status:        models.OutboxStatusPending,
+		NextAttemptOn: now,
+		OccurredOn:    now,
+	}).Error
+}
@@ -2,20 +2,35 @@ package services
 
 import (
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
 	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
 )
 
-// CreateDonation creates a new donation
+// CreateDonation creates a new donation and announces it with a donation.created domain event
 func CreateDonation(donation *models.Donation) error {
 	donation.CreatedOn = time.Now()
 
-	if err := config.DB.Create(donation).Error; err != nil {
-		return err
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(donation).Error; err != nil {
+			return err
+		}
+		return RecordDomainEvent(tx, donationCreatedDomainEvent(donation))
+	})
+}
+
+// donationCreatedDomainEvent describes a newly created donation
+func donationCreatedDomainEvent(donation *models.Donation) DonationCreated {
+	return DonationCreated{
+		DonationID:   donation.ID,
+		EventID:      donation.EventID,
+		BranchID:     donation.BranchID,
+		DonationType: donation.DonationType,
+		Amount:       donation.Amount,
 	}
-	return nil
 }
 
 // GetAllDonations retrieves all donation entries
@@ -48,20 +63,37 @@ func UpdateDonation(id uint, updateData map[string]interface{}) error {
 		return errors.New("donation not found")
 	}
 
+	fields := make([]string, 0, len(updateData))
+	for field := range updateData {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
 	now := time.Now()
 	updateData["updated_on"] = &now
 
-	if err := config.DB.Model(&donation).Updates(updateData).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&donation).Updates(updateData).Error; err != nil {
+			return err
+		}
+		return RecordDomainEvent(tx, DonationUpdated{DonationID: donation.ID, EventID: donation.EventID, Fields: fields})
+	})
 }
 
-// DeleteDonation deletes a donation
-func DeleteDonation(id uint) error {
-	if err := config.DB.Delete(&models.Donation{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+// DeleteDonation moves a donation to the trash
+func DeleteDonation(id uint, deletedBy string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var donation models.Donation
+		if err := tx.Select("id", "event_id").First(&donation, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if _, err := softDeleteWhere(tx, &models.Donation{}, deletedBy, trashTimestamp(), "id = ?", id); err != nil {
+			return err
+		}
+		return RecordDomainEvent(tx, DonationDeleted{DonationID: id, EventID: donation.EventID, DeletedBy: deletedBy})
+	})
 }
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidAnalyticsGroupBy = errors.New("invalid group_by")
+
+// impactGroupings maps each supported group_by to its key and label expressions
+var impactGroupings = map[string]struct {
+	key   string
+	label string
+}{
+	"month":          {"to_char(e.start_date, 'YYYY-MM')", "to_char(e.start_date, 'Mon YYYY')"},
+	"quarter":        {"to_char(e.start_date, 'YYYY-\"Q\"Q')", "to_char(e.start_date, 'YYYY \"Q\"Q')"},
+	"year":           {"to_char(e.start_date, 'YYYY')", "to_char(e.start_date, 'YYYY')"},
+	"branch":         {"COALESCE(b.id::text, '')", "COALESCE(b.name, 'Unassigned')"},
+	"region":         {"COALESCE(b.region_id::text, '')", "COALESCE('Region ' || b.region_id::text, 'Unassigned')"},
+	"state":          {"COALESCE(NULLIF(e.state, ''), '')", "COALESCE(NULLIF(e.state, ''), 'Unspecified')"},
+	"event_type":     {"COALESCE(t.id::text, '')", "COALESCE(t.name, 'Unspecified')"},
+	"event_category": {"COALESCE(ec.id::text, '')", "COALESCE(ec.name, 'Unspecified')"},
+	"language":       {"COALESCE(NULLIF(e.language, ''), '')", "COALESCE(NULLIF(e.language, ''), 'Unspecified')"},
+}
+
+// isTimeGrouping reports whether groups form a time series
+func isTimeGrouping(groupBy string) bool {
+	return groupBy == "month" || groupBy == "quarter" || groupBy == "year"
+}
+
+// ImpactAnalyticsQuery selects the events that count towards the impact analytics
+type ImpactAnalyticsQuery struct {
+	GroupBy        string
+	From           *time.Time // start_date, inclusive
+	To             *time.Time // start_date, exclusive
+	Statuses       []string   // only events with one of these statuses count; empty means all
+	WorkflowStates []string   // only events in one of these workflow states count; empty means all
+	BranchID       uint       // restrict to the events led or co-hosted by a branch and its descendants
+	SeriesID       uint       // restrict to the occurrences of a recurring series
+	Compare        bool       // compare with the preceding period of the same length
+	TopN           int
+}
+
+// ImpactTotals are the aggregated beneficiary and initiation numbers
+type ImpactTotals struct {
+	Events           int64 `json:"events"`
+	BeneficiaryMen   int64 `json:"beneficiary_men"`
+	BeneficiaryWomen int64 `json:"beneficiary_women"`
+	BeneficiaryChild int64 `json:"beneficiary_child"`
+	BeneficiaryTotal int64 `json:"beneficiary_total"`
+	InitiationMen    int64 `json:"initiation_men"`
+	InitiationWomen  int64 `json:"initiation_women"`
+	InitiationChild  int64 `json:"initiation_child"`
+	InitiationTotal  int64 `json:"initiation_total"`
+}
+
+// ImpactChange is the percentage change between two periods (nil when the earlier value is zero)
+type ImpactChange struct {
+	Events           *float64 `json:"events"`
+	BeneficiaryTotal *float64 `json:"beneficiary_total"`
+	InitiationTotal  *float64 `json:"initiation_total"`
+}
+
+// ImpactGroup is one row of the grouped analytics
+type ImpactGroup struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	ImpactTotals
+	Previous *ImpactTotals `json:"previous,omitempty"`
+	Change   *ImpactChange `json:"change,omitempty"`
+}
+
+// ImpactAnalytics is the result of GetImpactAnalytics
+type ImpactAnalytics struct {
+	GroupBy        string        `json:"group_by"`
+	From           *time.Time    `json:"from,omitempty"`
+	To             *time.Time    `json:"to,omitempty"`
+	Statuses       []string      `json:"statuses,omitempty"`
+	WorkflowStates []string      `json:"workflow_states,omitempty"`
+	Totals         ImpactTotals  `json:"totals"`
+	PreviousFrom   *time.Time    `json:"previous_from,omitempty"`
+	PreviousTo     *time.Time    `json:"previous_to,omitempty"`
+	PreviousTotals *ImpactTotals `json:"previous_totals,omitempty"`
+	Change         *ImpactChange `json:"change,omitempty"`
+	Groups         []ImpactGroup `json:"groups"`
+	TopBranches    []ImpactGroup `json:"top_branches"`
+}
+
+// impactRow is the raw aggregated row scanned from the database
+type impactRow struct {
+	Key              string
+	Label            string
+	Events           int64
+	BeneficiaryMen   int64
+	BeneficiaryWomen int64
+	BeneficiaryChild int64
+	InitiationMen    int64
+	InitiationWomen  int64
+	InitiationChild  int64
+}
+
+func (r impactRow) totals() ImpactTotals {
+	return ImpactTotals{
+		Events:           r.Events,
+		BeneficiaryMen:   r.BeneficiaryMen,
+		BeneficiaryWomen: r.BeneficiaryWomen,
+		BeneficiaryChild: r.BeneficiaryChild,
+		BeneficiaryTotal: r.BeneficiaryMen + r.BeneficiaryWomen + r.BeneficiaryChild,
+		InitiationMen:    r.InitiationMen,
+		InitiationWomen:  r.InitiationWomen,
+		InitiationChild:  r.InitiationChild,
+		InitiationTotal:  r.InitiationMen + r.InitiationWomen + r.InitiationChild,
+	}
+}
+
+const impactSumsSQL = `COUNT(*) AS events,
+	COALESCE(SUM(e.beneficiary_men), 0) AS beneficiary_men,
+	COALESCE(SUM(e.beneficiary_women), 0) AS beneficiary_women,
+	COALESCE(SUM(e.beneficiary_child), 0) AS beneficiary_child,
+	COALESCE(SUM(e.initiation_men), 0) AS initiation_men,
+	COALESCE(SUM(e.initiation_women), 0) AS initiation_women,
+	COALESCE(SUM(e.initiation_child), 0) AS initiation_child`
+
+// impactHostSumsSQL aggregates per hosting branch (see eventHostsSQL): an event counts once for
+// each of its hosts, which are credited with their share of its beneficiaries and initiations
+const impactHostSumsSQL = `COUNT(DISTINCT e.id) AS events,
+	COALESCE(ROUND(SUM(e.beneficiary_men * h.share)), 0)::bigint AS beneficiary_men,
+	COALESCE(ROUND(SUM(e.beneficiary_women * h.share)), 0)::bigint AS beneficiary_women,
+	COALESCE(ROUND(SUM(e.beneficiary_child * h.share)), 0)::bigint AS beneficiary_child,
+	COALESCE(ROUND(SUM(e.initiation_men * h.share)), 0)::bigint AS initiation_men,
+	COALESCE(ROUND(SUM(e.initiation_women * h.share)), 0)::bigint AS initiation_women,
+	COALESCE(ROUND(SUM(e.initiation_child * h.share)), 0)::bigint AS initiation_child`
+
+// impactByHost reports whether events are attributed to their hosting branches rather than
+// counted once: when grouping by branch or region and when restricted to a branch
+func impactByHost(q ImpactAnalyticsQuery, groupBy string) bool {
+	return q.BranchID > 0 || groupBy == "branch" || groupBy == "region"
+}
+
+// impactSums returns the aggregate columns matching impactBaseQuery
+func impactSums(byHost bool) string {
+	if byHost {
+		return impactHostSumsSQL
+	}
+	return impactSumsSQL
+}
+
+// impactBaseQuery selects the counted events of q within [from, to). With byHost there is a row
+// per hosting branch (h) of each event and b is that branch; otherwise b is the lead branch.
+func impactBaseQuery(q ImpactAnalyticsQuery, from, to *time.Time, byHost bool) *gorm.DB {
+	db := config.DB.Table("event_details e")
+	if byHost {
+		db = db.Joins("JOIN (" + eventHostsSQL + ") h ON h.event_id = e.id").
+			Joins("LEFT JOIN branches b ON b.id = h.branch_id")
+	} else {
+		db = db.Joins("LEFT JOIN branches b ON b.id = e.branch_id")
+	}
+	db = db.Joins("LEFT JOIN event_types t ON t.id = e.event_type_id").
+		Joins("LEFT JOIN event_categories ec ON ec.id = e.event_category_id").
+		Where("e.deleted_at IS NULL")
+
+	if len(q.Statuses) > 0 {
+		db = db.Where("e.status IN ?", q.Statuses)
+	}
+	if len(q.WorkflowStates) > 0 {
+		db = db.Where("e.workflow_state IN ?", q.WorkflowStates)
+	}
+	if from != nil {
+		db = db.Where("e.start_date >= ?", *from)
+	}
+	if to != nil {
+		db = db.Where("e.start_date < ?", *to)
+	}
+	if q.BranchID > 0 {
+		// Only the hosts within the branch tree are credited
+		db = db.Where(`h.branch_id IN (
+			WITH RECURSIVE branch_tree AS (
+				SELECT id FROM branches WHERE id = ?
+				UNION ALL
+				SELECT br.id FROM branches br JOIN branch_tree bt ON br.parent_branch_id = bt.id
+			)
+			SELECT id FROM branch_tree)`, q.BranchID)
+	}
+	if q.SeriesID > 0 {
+		db = db.Where("e.series_id = ?", q.SeriesID)
+	}
+	return db
+}
+
+// impactGrouped aggregates the counted events of q within [from, to) by groupBy
+func impactGrouped(q ImpactAnalyticsQuery, groupBy string, from, to *time.Time) ([]impactRow, error) {
+	grouping := impactGroupings[groupBy]
+	byHost := impactByHost(q, groupBy)
+	var rows []impactRow
+	err := impactBaseQuery(q, from, to, byHost).
+		Select(fmt.Sprintf("%s AS key, MIN(%s) AS label, %s", grouping.key, grouping.label, impactSums(byHost))).
+		Group(grouping.key).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// impactTotals aggregates the counted events of q within [from, to)
+func impactTotals(q ImpactAnalyticsQuery, from, to *time.Time) (ImpactTotals, error) {
+	var row impactRow
+	byHost := q.BranchID > 0
+	err := impactBaseQuery(q, from, to, byHost).Select(impactSums(byHost)).Scan(&row).Error
+	return row.totals(), err
+}
+
+// GetImpactAnalytics aggregates beneficiary and initiation numbers of the counted events by the
+// requested dimension, with the totals of the preceding period of equal length and the top-N branches
+func GetImpactAnalytics(q ImpactAnalyticsQuery) (*ImpactAnalytics, error) {
+	if _, ok := impactGroupings[q.GroupBy]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidAnalyticsGroupBy, q.GroupBy)
+	}
+	if q.TopN <= 0 {
+		q.TopN = 10
+	}
+
+	result := &ImpactAnalytics{
+		GroupBy:        q.GroupBy,
+		From:           q.From,
+		To:             q.To,
+		Statuses:       q.Statuses,
+		WorkflowStates: q.WorkflowStates,
+		Groups:         []ImpactGroup{},
+		TopBranches:    []ImpactGroup{},
+	}
+
+	totals, err := impactTotals(q, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	result.Totals = totals
+
+	rows, err := impactGrouped(q, q.GroupBy, q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result.Groups = append(result.Groups, ImpactGroup{Key: row.Key, Label: row.Label, ImpactTotals: row.totals()})
+	}
+	sortImpactGroups(result.Groups, isTimeGrouping(q.GroupBy))
+
+	// Period-over-period: the window of the same length right before [From, To)
+	if q.Compare && q.From != nil && q.To != nil {
+		previousTo := *q.From
+		previousFrom := previousTo.Add(-q.To.Sub(*q.From))
+		result.PreviousFrom, result.PreviousTo = &previousFrom, &previousTo
+
+		previous, err := impactTotals(q, &previousFrom, &previousTo)
+		if err != nil {
+			return nil, err
+		}
+		result.PreviousTotals = &previous
+		result.Change = impactChange(previous, totals)
+
+		if isTimeGrouping(q.GroupBy) {
+			// Each bucket is compared with the bucket before it in the series
+			for i := 1; i < len(result.Groups); i++ {
+				prev := result.Groups[i-1].ImpactTotals
+				result.Groups[i].Previous = &prev
+				result.Groups[i].Change = impactChange(prev, result.Groups[i].ImpactTotals)
+			}
+		} else {
+			previousRows, err := impactGrouped(q, q.GroupBy, &previousFrom, &previousTo)
+			if err != nil {
+				return nil, err
+			}
+			previousByKey := make(map[string]ImpactTotals, len(previousRows))
+			for _, row := range previousRows {
+				previousByKey[row.Key] = row.totals()
+			}
+			for i := range result.Groups {
+				prev := previousByKey[result.Groups[i].Key]
+				result.Groups[i].Previous = &prev
+				result.Groups[i].Change = impactChange(prev, result.Groups[i].ImpactTotals)
+			}
+		}
+	}
+
+	branchRows, err := impactGrouped(q, "branch", q.From, q.To)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range branchRows {
+		if row.Key == "" {
+			continue
+		}
+		result.TopBranches = append(result.TopBranches, ImpactGroup{Key: row.Key, Label: row.Label, ImpactTotals: row.totals()})
+	}
+	sortImpactGroups(result.TopBranches, false)
+	if len(result.TopBranches) > q.TopN {
+		result.TopBranches = result.TopBranches[:q.TopN]
+	}
+
+	return result, nil
+}
+
+// sortImpactGroups orders a time series chronologically and other groupings by beneficiaries, descending
+func sortImpactGroups(groups []ImpactGroup, chronological bool) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if chronological {
+			return a.Key < b.Key
+		}
+		if a.BeneficiaryTotal != b.BeneficiaryTotal {
+			return a.BeneficiaryTotal > b.BeneficiaryTotal
+		}
+		return a.Label < b.Label
+	})
+}
+
+func impactChange(previous, current ImpactTotals) *ImpactChange {
+	percent := func(from, to int64) *float64 {
+		if from == 0 {
+			return nil
+		}
+		change := float64(to-from) / float64(from) * 100
+		return &change
+	}
+	return &ImpactChange{
+		Events:           percent(previous.Events, current.Events),
+		BeneficiaryTotal: percent(previous.BeneficiaryTotal, current.BeneficiaryTotal),
+		InitiationTotal:  percent(previous.InitiationTotal, current.InitiationTotal),
+	}
+}
+
+// ExportImpactAnalyticsToExcel writes the analytics as a workbook with a summary sheet,
+// a sheet for the grouping and a top branches sheet
+func ExportImpactAnalyticsToExcel(analytics *ImpactAnalytics) (*bytes.Buffer, error) {
+	f := excelize.NewFile()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 12, Color: "#FFFFFF", Family: "Arial"},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create header style: %v", err)
+	}
+
+	writeRow := func(sheet string, row int, values []interface{}) {
+		for i, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(i+1, row)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+	writeHeader := func(sheet string, headers []string) {
+		values := make([]interface{}, len(headers))
+		for i, header := range headers {
+			values[i] = header
+		}
+		writeRow(sheet, 1, values)
+		last, _ := excelize.CoordinatesToCellName(len(headers), 1)
+		f.SetCellStyle(sheet, "A1", last, headerStyle)
+		lastCol, _ := excelize.ColumnNumberToName(len(headers))
+		f.SetColWidth(sheet, "A", lastCol, 18)
+	}
+	totalsValues := func(t ImpactTotals) []interface{} {
+		return []interface{}{t.Events, t.BeneficiaryMen, t.BeneficiaryWomen, t.BeneficiaryChild, t.BeneficiaryTotal,
+			t.InitiationMen, t.InitiationWomen, t.InitiationChild, t.InitiationTotal}
+	}
+	totalsHeaders := []string{"Events", "Beneficiaries - Men", "Beneficiaries - Women", "Beneficiaries - Children", "Beneficiaries - Total",
+		"Initiation - Men", "Initiation - Women", "Initiation - Children", "Initiation - Total"}
+	formatPeriod := func(from, to *time.Time) string {
+		if from == nil && to == nil {
+			return "All time"
+		}
+		fromStr, toStr := "…", "…"
+		if from != nil {
+			fromStr = from.Format("2006-01-02")
+		}
+		if to != nil {
+			toStr = to.AddDate(0, 0, -1).Format("2006-01-02")
+		}
+		return fromStr + " to " + toStr
+	}
+
+	// Summary sheet
+	summary := "Summary"
+	f.SetSheetName("Sheet1", summary)
+	writeHeader(summary, append([]string{"Period"}, totalsHeaders...))
+	writeRow(summary, 2, append([]interface{}{formatPeriod(analytics.From, analytics.To)}, totalsValues(analytics.Totals)...))
+	if analytics.PreviousTotals != nil {
+		writeRow(summary, 3, append([]interface{}{formatPeriod(analytics.PreviousFrom, analytics.PreviousTo)}, totalsValues(*analytics.PreviousTotals)...))
+	}
+
+	// Grouped sheet
+	groupSheet := "By " + analytics.GroupBy
+	if _, err := f.NewSheet(groupSheet); err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %v", err)
+	}
+	groupHeaders := append([]string{analytics.GroupBy}, totalsHeaders...)
+	if analytics.PreviousTotals != nil {
+		groupHeaders = append(groupHeaders, "Previous - Beneficiaries", "Previous - Initiation", "Change - Beneficiaries %", "Change - Initiation %")
+	}
+	writeHeader(groupSheet, groupHeaders)
+	for i, group := range analytics.Groups {
+		values := append([]interface{}{group.Label}, totalsValues(group.ImpactTotals)...)
+		if analytics.PreviousTotals != nil {
+			var prevBeneficiaries, prevInitiation, beneficiaryChange, initiationChange interface{}
+			if group.Previous != nil {
+				prevBeneficiaries, prevInitiation = group.Previous.BeneficiaryTotal, group.Previous.InitiationTotal
+			}
+			if group.Change != nil && group.Change.BeneficiaryTotal != nil {
+				beneficiaryChange = fmt.Sprintf("%.1f", *group.Change.BeneficiaryTotal)
+			}
+			if group.Change != nil && group.Change.InitiationTotal != nil {
+				initiationChange = fmt.Sprintf("%.1f", *group.Change.InitiationTotal)
+			}
+			values = append(values, prevBeneficiaries, prevInitiation, beneficiaryChange, initiationChange)
+		}
+		writeRow(groupSheet, i+2, values)
+	}
+
+	// Top branches sheet
+	topSheet := "Top Branches"
+	if _, err := f.NewSheet(topSheet); err != nil {
+		return nil, fmt.Errorf("failed to create sheet: %v", err)
+	}
+	writeHeader(topSheet, append([]string{"Rank", "Branch"}, totalsHeaders...))
+	for i, group := range analytics.TopBranches {
+		writeRow(topSheet, i+2, append([]interface{}{i + 1, group.Label}, totalsValues(group.ImpactTotals)...))
+	}
+
+	f.SetActiveSheet(0)
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write Excel file: %v", err)
+	}
+	return buffer, nil
+}
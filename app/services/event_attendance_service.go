@@ -0,0 +1,366 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAttendanceNotFound   = errors.New("attendance record not found")
+	ErrAttendanceOutOfRange = errors.New("attendance date is outside the event dates")
+	ErrAttendanceDuplicate  = errors.New("attendance for this day and session already exists")
+	ErrInvalidAttendance    = errors.New("invalid attendance")
+)
+
+// AttendanceTotals are summed attendance counts
+type AttendanceTotals struct {
+	Men          int `json:"men"`
+	Women        int `json:"women"`
+	Children     int `json:"children"`
+	Total        int `json:"total"`
+	NewAttendees int `json:"new_attendees"`
+}
+
+func (t *AttendanceTotals) add(record models.EventAttendance) {
+	t.Men += record.Men
+	t.Women += record.Women
+	t.Children += record.Children
+	t.Total += record.Men + record.Women + record.Children
+	t.NewAttendees += record.NewAttendees
+}
+
+// AttendanceDay is one point of the day-wise attendance series (all sessions of the day summed)
+type AttendanceDay struct {
+	Date     time.Time `json:"date" swaggertype:"string" example:"2024-01-15"`
+	Day      int       `json:"day"` // 1 for the first day of the event
+	Recorded bool      `json:"recorded"`
+	Sessions int       `json:"sessions"`
+	AttendanceTotals
+}
+
+// EventAttendanceSummary is the attendance of an event with its day-wise series and totals
+type EventAttendanceSummary struct {
+	EventID      uint                     `json:"event_id"`
+	StartDate    time.Time                `json:"start_date"`
+	EndDate      time.Time                `json:"end_date"`
+	Records      []models.EventAttendance `json:"records"`
+	Days         []AttendanceDay          `json:"days"`
+	Totals       AttendanceTotals         `json:"totals"`
+	DaysRecorded int                      `json:"days_recorded"`
+}
+
+// validateAttendance normalises a record and checks it against the event's dates
+func validateAttendance(event *models.EventDetails, record *models.EventAttendance) error {
+	record.Session = strings.ToLower(strings.TrimSpace(record.Session))
+	if len(record.Session) > 50 {
+		return fmt.Errorf("%w: session must not exceed 50 characters", ErrInvalidAttendance)
+	}
+	if record.AttendanceDate.IsZero() {
+		return fmt.Errorf("%w: attendance_date is required", ErrInvalidAttendance)
+	}
+	if record.Men < 0 || record.Women < 0 || record.Children < 0 || record.NewAttendees < 0 {
+		return fmt.Errorf("%w: counts must be non-negative numbers", ErrInvalidAttendance)
+	}
+	if record.NewAttendees > record.Men+record.Women+record.Children {
+		return fmt.Errorf("%w: new_attendees cannot exceed the total attendance of the day", ErrInvalidAttendance)
+	}
+
+	day := dateOnly(record.AttendanceDate)
+	if day.Before(dateOnly(event.StartDate)) || day.After(dateOnly(event.EndDate)) {
+		return fmt.Errorf("%w (%s to %s)", ErrAttendanceOutOfRange,
+			event.StartDate.Format("2006-01-02"), event.EndDate.Format("2006-01-02"))
+	}
+	record.AttendanceDate = day
+	return nil
+}
+
+// lockAttendanceEvent reads and locks the event whose attendance is changed
+func lockAttendanceEvent(tx *gorm.DB, eventID uint) (*models.EventDetails, error) {
+	var event models.EventDetails
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// checkAttendanceSlot makes sure no other record exists for the same day and session
+func checkAttendanceSlot(tx *gorm.DB, record *models.EventAttendance) error {
+	var count int64
+	db := tx.Model(&models.EventAttendance{}).
+		Where("event_id = ? AND attendance_date = ? AND session = ?", record.EventID, record.AttendanceDate, record.Session)
+	if record.ID > 0 {
+		db = db.Where("id <> ?", record.ID)
+	}
+	if err := db.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAttendanceDuplicate
+	}
+	return nil
+}
+
+// attendanceDerivedColumns are the event_details columns derived from day-wise attendance
+var attendanceDerivedColumns = []string{"beneficiary_men", "beneficiary_women", "beneficiary_child"}
+
+// eventHasAttendance reports whether an event has day-wise attendance records
+func eventHasAttendance(tx *gorm.DB, eventID uint) (bool, error) {
+	var count int64
+	if err := tx.Model(&models.EventAttendance{}).Where("event_id = ?", eventID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// dropAttendanceDerivedCounts removes the beneficiary counts from updates when the event has
+// day-wise attendance: they are derived from it and must not be overwritten. Every write of
+// event_details columns goes through it.
+func dropAttendanceDerivedCounts(tx *gorm.DB, eventID uint, updates map[string]interface{}) error {
+	hasAttendance, err := eventHasAttendance(tx, eventID)
+	if err != nil || !hasAttendance {
+		return err
+	}
+	for _, column := range attendanceDerivedColumns {
+		delete(updates, column)
+	}
+	return nil
+}
+
+// syncAttendanceTotals derives the event's beneficiary counts from its attendance records and
+// records the change in the event's revision history
+func syncAttendanceTotals(tx *gorm.DB, eventID uint, actor Actor) error {
+	var totals AttendanceTotals
+	if err := tx.Model(&models.EventAttendance{}).
+		Where("event_id = ?", eventID).
+		Select("COALESCE(SUM(men), 0) AS men, COALESCE(SUM(women), 0) AS women, COALESCE(SUM(children), 0) AS children").
+		Scan(&totals).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.EventDetails{}).Where("id = ?", eventID).UpdateColumns(map[string]interface{}{
+		"beneficiary_men":   totals.Men,
+		"beneficiary_women": totals.Women,
+		"beneficiary_child": totals.Children,
+		"updated_on":        time.Now(),
+		"updated_by":        actor.Email,
+	}).Error; err != nil {
+		return err
+	}
+	_, err := CaptureEventRevision(tx, eventID, models.RevisionActionUpdate, nil, actor)
+	return err
+}
+
+// changeEventAttendance runs an attendance change in a transaction with the event locked and the
+// totals re-derived afterwards
+func changeEventAttendance(eventID uint, actor Actor, change func(tx *gorm.DB, event *models.EventDetails) error) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		event, err := lockAttendanceEvent(tx, eventID)
+		if err != nil {
+			return err
+		}
+		if err := EnsureBaselineRevision(tx, eventID, actor); err != nil {
+			return err
+		}
+		if err := change(tx, event); err != nil {
+			return err
+		}
+		return syncAttendanceTotals(tx, eventID, actor)
+	})
+}
+
+// GetEventAttendance returns an event's attendance records with the day-wise series over
+// StartDate..EndDate (days without records included) and the totals
+func GetEventAttendance(eventID uint) (*EventAttendanceSummary, error) {
+	var event models.EventDetails
+	if err := config.DB.First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	var records []models.EventAttendance
+	if err := config.DB.Where("event_id = ?", eventID).Order("attendance_date, session, id").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	summary := &EventAttendanceSummary{
+		EventID:   event.ID,
+		StartDate: event.StartDate,
+		EndDate:   event.EndDate,
+		Records:   records,
+		Days:      AttendanceSeries(&event, records),
+	}
+	for _, record := range records {
+		summary.Totals.add(record)
+	}
+	for _, day := range summary.Days {
+		if day.Recorded {
+			summary.DaysRecorded++
+		}
+	}
+	return summary, nil
+}
+
+// AttendanceSeries sums an event's attendance records per day. Every day of the event is
+// included; records outside the event dates (left behind by a date change) are appended.
+func AttendanceSeries(event *models.EventDetails, records []models.EventAttendance) []AttendanceDay {
+	start := dateOnly(event.StartDate)
+	end := dateOnly(event.EndDate)
+
+	var days []AttendanceDay
+	inSeries := map[time.Time]bool{}
+	if !start.IsZero() && !end.Before(start) {
+		for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+			days = append(days, AttendanceDay{Date: d})
+			inSeries[d] = true
+		}
+	}
+	for _, record := range records {
+		if day := dateOnly(record.AttendanceDate); !inSeries[day] {
+			days = append(days, AttendanceDay{Date: day})
+			inSeries[day] = true
+		}
+	}
+	sort.SliceStable(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+
+	byDay := make(map[time.Time]*AttendanceDay, len(days))
+	for i := range days {
+		days[i].Day = int(days[i].Date.Sub(start).Hours()/24) + 1
+		byDay[days[i].Date] = &days[i]
+	}
+	for _, record := range records {
+		day := byDay[dateOnly(record.AttendanceDate)]
+		day.Recorded = true
+		day.Sessions++
+		day.add(record)
+	}
+	return days
+}
+
+// AddEventAttendance records the attendance of one day/session of an event
+func AddEventAttendance(eventID uint, record models.EventAttendance, actor Actor) (*models.EventAttendance, error) {
+	err := changeEventAttendance(eventID, actor, func(tx *gorm.DB, event *models.EventDetails) error {
+		if err := validateAttendance(event, &record); err != nil {
+			return err
+		}
+		record.ID = 0
+		record.EventID = eventID
+		if err := checkAttendanceSlot(tx, &record); err != nil {
+			return err
+		}
+		record.CreatedOn = time.Now()
+		record.CreatedBy = actor.Email
+		record.UpdatedOn = nil
+		record.UpdatedBy = ""
+		return tx.Create(&record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// UpdateEventAttendance replaces the date, session and counts of an attendance record
+func UpdateEventAttendance(eventID uint, attendanceID uint, input models.EventAttendance, actor Actor) (*models.EventAttendance, error) {
+	var record models.EventAttendance
+	err := changeEventAttendance(eventID, actor, func(tx *gorm.DB, event *models.EventDetails) error {
+		if err := tx.Where("id = ? AND event_id = ?", attendanceID, eventID).First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrAttendanceNotFound
+			}
+			return err
+		}
+		if err := validateAttendance(event, &input); err != nil {
+			return err
+		}
+		now := time.Now()
+		record.AttendanceDate = input.AttendanceDate
+		record.Session = input.Session
+		record.Men = input.Men
+		record.Women = input.Women
+		record.Children = input.Children
+		record.NewAttendees = input.NewAttendees
+		record.UpdatedOn = &now
+		record.UpdatedBy = actor.Email
+		if err := checkAttendanceSlot(tx, &record); err != nil {
+			return err
+		}
+		return tx.Save(&record).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// DeleteEventAttendance removes an attendance record
+func DeleteEventAttendance(eventID uint, attendanceID uint, actor Actor) error {
+	return changeEventAttendance(eventID, actor, func(tx *gorm.DB, event *models.EventDetails) error {
+		result := tx.Where("id = ? AND event_id = ?", attendanceID, eventID).Delete(&models.EventAttendance{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAttendanceNotFound
+		}
+		return nil
+	})
+}
+
+// ReplaceEventAttendance replaces all attendance records of an event, as submitted by a
+// day-wise attendance grid. Every record is validated before anything is saved.
+func ReplaceEventAttendance(eventID uint, records []models.EventAttendance, actor Actor) ([]models.EventAttendance, error) {
+	err := changeEventAttendance(eventID, actor, func(tx *gorm.DB, event *models.EventDetails) error {
+		seen := map[string]bool{}
+		now := time.Now()
+		for i := range records {
+			if err := validateAttendance(event, &records[i]); err != nil {
+				return fmt.Errorf("record %d: %w", i+1, err)
+			}
+			slot := records[i].AttendanceDate.Format("2006-01-02") + "|" + records[i].Session
+			if seen[slot] {
+				return fmt.Errorf("record %d: %w", i+1, ErrAttendanceDuplicate)
+			}
+			seen[slot] = true
+			records[i].ID = 0
+			records[i].EventID = eventID
+			records[i].CreatedOn = now
+			records[i].CreatedBy = actor.Email
+			records[i].UpdatedOn = nil
+			records[i].UpdatedBy = ""
+		}
+
+		if err := tx.Where("event_id = ?", eventID).Delete(&models.EventAttendance{}).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		return tx.Create(&records).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// GetAttendanceByEventIDs returns the attendance records of several events, for exports
+func GetAttendanceByEventIDs(eventIDs []uint) ([]models.EventAttendance, error) {
+	var records []models.EventAttendance
+	if len(eventIDs) == 0 {
+		return records, nil
+	}
+	err := config.DB.Where("event_id IN ?", eventIDs).Order("event_id, attendance_date, session, id").Find(&records).Error
+	return records, err
+}
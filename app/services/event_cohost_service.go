@@ -0,0 +1,257 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidCoHost = errors.New("invalid co-host")
+
+// eventHostsSQL lists the hosting branches of every event with the fraction of its beneficiaries
+// credited to each: a co-host gets its share (none when unset) and the lead branch the rest, so
+// the fractions of an event add up to one. An event without a lead branch has one row with a
+// NULL branch.
+const eventHostsSQL = `SELECT he.id AS event_id, he.branch_id, 1 - COALESCE(hs.total_share, 0) / 100.0 AS share
+	FROM event_details he
+	LEFT JOIN (
+		SELECT event_id, SUM(beneficiary_share_percent) AS total_share FROM event_cohost_branches GROUP BY event_id
+	) hs ON hs.event_id = he.id
+	UNION ALL
+	SELECT event_id, branch_id, COALESCE(beneficiary_share_percent, 0) / 100.0 FROM event_cohost_branches`
+
+// EventCoHostInput is a participating branch of an event with its optional beneficiary share
+type EventCoHostInput struct {
+	BranchID                uint     `json:"branch_id"`
+	BeneficiarySharePercent *float64 `json:"beneficiary_share_percent,omitempty"`
+}
+
+// EventHosting is the lead branch of an event, its co-hosts and the resulting beneficiary shares
+type EventHosting struct {
+	EventID          uint                 `json:"event_id"`
+	LeadBranchID     *uint                `json:"lead_branch_id"`
+	LeadBranch       *models.Branch       `json:"lead_branch,omitempty"`
+	LeadSharePercent float64              `json:"lead_share_percent"`
+	CoHosts          []models.EventCoHost `json:"co_hosts"`
+}
+
+// GetEventHosting returns the lead branch and co-hosts of an event
+func GetEventHosting(eventID uint) (*EventHosting, error) {
+	var event models.EventDetails
+	if err := config.DB.Preload("Branch").Select("id", "branch_id").First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	hosting := &EventHosting{
+		EventID:          event.ID,
+		LeadBranchID:     event.BranchID,
+		LeadBranch:       event.Branch,
+		LeadSharePercent: 100,
+		CoHosts:          []models.EventCoHost{},
+	}
+	if err := config.DB.Preload("Branch").
+		Where("event_id = ?", eventID).
+		Order("branch_id").
+		Find(&hosting.CoHosts).Error; err != nil {
+		return nil, err
+	}
+	for _, coHost := range hosting.CoHosts {
+		if coHost.BeneficiarySharePercent != nil {
+			hosting.LeadSharePercent -= *coHost.BeneficiarySharePercent
+		}
+	}
+	hosting.LeadSharePercent = math.Round(hosting.LeadSharePercent*100) / 100
+	return hosting, nil
+}
+
+// SetEventCoHosts replaces the co-hosts of an event. The event needs a lead branch, a co-host
+// cannot be the lead branch or be listed twice, and the shares must not exceed 100% in total.
+// A change moves the event's version and is recorded as a revision and an event.cohosts_changed.
+func SetEventCoHosts(eventID uint, inputs []EventCoHostInput, actor Actor) (*EventHosting, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "branch_id").First(&event, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
+		}
+		if len(inputs) > 0 && event.BranchID == nil {
+			return fmt.Errorf("%w: the event has no lead branch", ErrInvalidCoHost)
+		}
+
+		seen := make(map[uint]bool, len(inputs))
+		branchIDs := make([]uint, 0, len(inputs))
+		totalShare := 0.0
+		for i, input := range inputs {
+			switch {
+			case input.BranchID == 0:
+				return fmt.Errorf("%w: branch_id is required", ErrInvalidCoHost)
+			case input.BranchID == *event.BranchID:
+				return fmt.Errorf("%w: branch %d is the lead branch", ErrInvalidCoHost, input.BranchID)
+			case seen[input.BranchID]:
+				return fmt.Errorf("%w: branch %d is listed twice", ErrInvalidCoHost, input.BranchID)
+			}
+			if share := input.BeneficiarySharePercent; share != nil {
+				if *share < 0 || *share > 100 {
+					return fmt.Errorf("%w: beneficiary_share_percent must be between 0 and 100", ErrInvalidCoHost)
+				}
+				// Stored with two decimals
+				rounded := math.Round(*share*100) / 100
+				inputs[i].BeneficiarySharePercent = &rounded
+				totalShare += rounded
+			}
+			seen[input.BranchID] = true
+			branchIDs = append(branchIDs, input.BranchID)
+		}
+		if totalShare > 100.0001 {
+			return fmt.Errorf("%w: the co-hosts' shares add up to more than 100%%", ErrInvalidCoHost)
+		}
+
+		if len(branchIDs) > 0 {
+			var found int64
+			if err := tx.Model(&models.Branch{}).Where("id IN ?", branchIDs).Count(&found).Error; err != nil {
+				return err
+			}
+			if found != int64(len(branchIDs)) {
+				return fmt.Errorf("%w: unknown branch", ErrInvalidCoHost)
+			}
+		}
+
+		rows := make([]models.EventCoHost, 0, len(inputs))
+		for _, input := range inputs {
+			rows = append(rows, models.EventCoHost{BranchID: input.BranchID, BeneficiarySharePercent: input.BeneficiarySharePercent})
+		}
+		// Make sure the state before this change is kept as a revision
+		if err := EnsureBaselineRevision(tx, eventID, actor); err != nil {
+			return err
+		}
+		changed, err := replaceEventCoHosts(tx, eventID, rows, actor)
+		if err != nil || !changed {
+			return err
+		}
+
+		// The co-hosts are part of the event: a change of only them still moves its version
+		if err := tx.Model(&event).Updates(map[string]interface{}{
+			"updated_on": time.Now(),
+			"updated_by": actor.Email,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		_, err = CaptureEventRevision(tx, eventID, models.RevisionActionUpdate, nil, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetEventHosting(eventID)
+}
+
+// replaceEventCoHosts replaces the co-hosts of an event with rows and announces the change.
+// Co-hosts that stay keep their creation audit. It reports false, and writes nothing, when the
+// event already has exactly these co-hosts.
+func replaceEventCoHosts(tx *gorm.DB, eventID uint, rows []models.EventCoHost, actor Actor) (bool, error) {
+	var current []models.EventCoHost
+	if err := tx.Where("event_id = ?", eventID).Find(&current).Error; err != nil {
+		return false, err
+	}
+	existing := make(map[uint]models.EventCoHost, len(current))
+	for _, row := range current {
+		existing[row.BranchID] = row
+	}
+	if len(rows) == len(current) {
+		same := true
+		for _, row := range rows {
+			old, ok := existing[row.BranchID]
+			if !ok || !sameSharePercent(old.BeneficiarySharePercent, row.BeneficiarySharePercent) {
+				same = false
+				break
+			}
+		}
+		if same {
+			return false, nil
+		}
+	}
+
+	if err := tx.Where("event_id = ?", eventID).Delete(&models.EventCoHost{}).Error; err != nil {
+		return false, err
+	}
+	now := time.Now()
+	for _, row := range rows {
+		row.EventID = eventID
+		row.Branch = nil
+		if old, ok := existing[row.BranchID]; ok {
+			row.CreatedOn, row.CreatedBy = old.CreatedOn, old.CreatedBy
+		} else {
+			row.CreatedOn, row.CreatedBy = now, actor.Email
+		}
+		if err := tx.Create(&row).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, recordEventCoHostsChanged(tx, eventID, actor)
+}
+
+func sameSharePercent(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return math.Abs(*a-*b) < 0.005
+}
+
+// dropLeadBranchCoHosts removes the lead branch of the given events from their co-hosts. Call it
+// after writing branch_id: a co-host that becomes the lead branch would otherwise be counted twice.
+func dropLeadBranchCoHosts(tx *gorm.DB, eventIDs []uint, actor Actor) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	const leadCoHost = "event_id IN ? AND branch_id = (SELECT branch_id FROM event_details WHERE id = event_cohost_branches.event_id)"
+	var affected []uint
+	if err := tx.Model(&models.EventCoHost{}).Where(leadCoHost, eventIDs).Pluck("event_id", &affected).Error; err != nil {
+		return err
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+	if err := tx.Where(leadCoHost, eventIDs).Delete(&models.EventCoHost{}).Error; err != nil {
+		return err
+	}
+	for _, eventID := range affected {
+		if err := recordEventCoHostsChanged(tx, eventID, actor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordEventCoHostsChanged writes an event.cohosts_changed domain event with the current hosts
+func recordEventCoHostsChanged(tx *gorm.DB, eventID uint, actor Actor) error {
+	var event models.EventDetails
+	if err := tx.Select("id", "branch_id").First(&event, eventID).Error; err != nil {
+		return err
+	}
+	var rows []models.EventCoHost
+	if err := tx.Where("event_id = ?", eventID).Order("branch_id").Find(&rows).Error; err != nil {
+		return err
+	}
+	coHosts := make([]EventCoHostInput, 0, len(rows))
+	for _, row := range rows {
+		coHosts = append(coHosts, EventCoHostInput{BranchID: row.BranchID, BeneficiarySharePercent: row.BeneficiarySharePercent})
+	}
+	return RecordDomainEvent(tx, EventCoHostsChanged{
+		EventID:      eventID,
+		LeadBranchID: event.BranchID,
+		CoHosts:      coHosts,
+		ChangedBy:    actor.Email,
+	})
+}
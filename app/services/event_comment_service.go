@@ -0,0 +1,367 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCommentNotFound      = errors.New("comment not found")
+	ErrInvalidComment       = errors.New("invalid comment")
+	ErrCommentNotAuthor     = errors.New("only the author can change this comment")
+	ErrCommentNotThreadRoot = errors.New("only top-level comments can be resolved")
+)
+
+const maxCommentLength = 5000
+
+// mentionPattern matches @user@example.org mentions in a comment body
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// Comment thread filters of GetEventComments
+const (
+	CommentStatusOpen     = "open"
+	CommentStatusResolved = "resolved"
+)
+
+// EventCommentFilter narrows the threads listed for an event
+type EventCommentFilter struct {
+	Section string // only threads anchored to this section
+	Status  string // "", CommentStatusOpen or CommentStatusResolved
+}
+
+// parseMentions returns the distinct e-mail addresses @mentioned in a comment body
+func parseMentions(body string) []string {
+	seen := map[string]bool{}
+	var emails []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(strings.TrimRight(match[1], "."))
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// resolveMentions looks up the mentioned users; unknown addresses are rejected so a mistyped
+// mention does not silently go nowhere
+func resolveMentions(tx *gorm.DB, body string) ([]models.EventCommentMention, error) {
+	emails := parseMentions(body)
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	var users []models.User
+	if err := tx.Select("id", "name", "email").
+		Where("LOWER(email) IN ? AND is_deleted = ?", emails, false).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]models.User, len(users))
+	for _, user := range users {
+		byEmail[strings.ToLower(user.Email)] = user
+	}
+
+	mentions := make([]models.EventCommentMention, 0, len(emails))
+	for _, email := range emails {
+		user, ok := byEmail[email]
+		if !ok {
+			return nil, fmt.Errorf("%w: no user with e-mail %s to mention", ErrInvalidComment, email)
+		}
+		mentions = append(mentions, models.EventCommentMention{UserID: user.ID, UserEmail: user.Email, UserName: user.Name})
+	}
+	return mentions, nil
+}
+
+// validateCommentBody trims and checks a comment body
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: body is required", ErrInvalidComment)
+	}
+	if len(body) > maxCommentLength {
+		return "", fmt.Errorf("%w: body must not exceed %d characters", ErrInvalidComment, maxCommentLength)
+	}
+	return body, nil
+}
+
+// getEventComment reads a comment of an event
+func getEventComment(tx *gorm.DB, eventID, commentID uint, lock bool) (*models.EventComment, error) {
+	var comment models.EventComment
+	db := tx
+	if lock {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := db.Where("id = ? AND event_id = ?", commentID, eventID).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetEventComments returns the comment threads of an event (top-level comments with their
+// replies), oldest first
+func GetEventComments(eventID uint, filter EventCommentFilter) ([]models.EventComment, error) {
+	if err := config.DB.Select("id").First(&models.EventDetails{}, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	db := config.DB.Preload("Mentions").Where("event_id = ? AND parent_id IS NULL", eventID)
+	if filter.Section != "" {
+		db = db.Where("section = ?", filter.Section)
+	}
+	switch filter.Status {
+	case CommentStatusOpen:
+		db = db.Where("resolved = ?", false)
+	case CommentStatusResolved:
+		db = db.Where("resolved = ?", true)
+	}
+	var threads []models.EventComment
+	if err := db.Order("created_on, id").Find(&threads).Error; err != nil {
+		return nil, err
+	}
+	if len(threads) == 0 {
+		return []models.EventComment{}, nil
+	}
+
+	rootIDs := make([]uint, len(threads))
+	for i, thread := range threads {
+		rootIDs[i] = thread.ID
+	}
+	var replies []models.EventComment
+	if err := config.DB.Preload("Mentions").Where("parent_id IN ?", rootIDs).Order("created_on, id").Find(&replies).Error; err != nil {
+		return nil, err
+	}
+	index := make(map[uint]int, len(threads))
+	for i, thread := range threads {
+		index[thread.ID] = i
+	}
+	for _, reply := range replies {
+		i := index[*reply.ParentID]
+		threads[i].Replies = append(threads[i].Replies, reply)
+	}
+	return threads, nil
+}
+
+// AddEventComment adds a comment to an event. A comment with a parent is a reply to that
+// comment's thread; it takes the thread's section and reopens a resolved thread.
+func AddEventComment(eventID uint, comment models.EventComment, actor Actor) (*models.EventComment, error) {
+	body, err := validateCommentBody(comment.Body)
+	if err != nil {
+		return nil, err
+	}
+	comment.Section = strings.TrimSpace(comment.Section)
+	if !models.IsValidCommentSection(comment.Section) {
+		return nil, fmt.Errorf("%w: unknown section %q", ErrInvalidComment, comment.Section)
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.EventDetails{}, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
+		}
+
+		if comment.ParentID != nil {
+			parent, err := getEventComment(tx, eventID, *comment.ParentID, true)
+			if err != nil {
+				return err
+			}
+			// Replies to a reply join the top-level thread
+			if parent.ParentID != nil {
+				if parent, err = getEventComment(tx, eventID, *parent.ParentID, true); err != nil {
+					return err
+				}
+			}
+			comment.ParentID = &parent.ID
+			comment.Section = parent.Section
+			if parent.Resolved {
+				if err := tx.Model(parent).Updates(map[string]interface{}{
+					"resolved": false, "resolved_by": "", "resolved_on": nil,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		mentions, err := resolveMentions(tx, body)
+		if err != nil {
+			return err
+		}
+
+		var author models.User
+		if actor.UserID > 0 {
+			tx.Select("name").First(&author, actor.UserID)
+		}
+		comment = models.EventComment{
+			EventID:     eventID,
+			ParentID:    comment.ParentID,
+			Section:     comment.Section,
+			Body:        body,
+			AuthorID:    actor.UserID,
+			AuthorEmail: actor.Email,
+			AuthorName:  author.Name,
+			Mentions:    mentions,
+		}
+		return tx.Create(&comment).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdateEventComment changes the body of a comment; only its author may edit it
+func UpdateEventComment(eventID, commentID uint, body string, actor Actor) (*models.EventComment, error) {
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var comment *models.EventComment
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if comment, err = getEventComment(tx, eventID, commentID, true); err != nil {
+			return err
+		}
+		if comment.AuthorID != actor.UserID {
+			return ErrCommentNotAuthor
+		}
+		mentions, err := resolveMentions(tx, body)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(comment).Updates(map[string]interface{}{"body": body, "edited_on": &now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.EventCommentMention{}).Error; err != nil {
+			return err
+		}
+		for i := range mentions {
+			mentions[i].CommentID = comment.ID
+		}
+		if len(mentions) > 0 {
+			if err := tx.Create(&mentions).Error; err != nil {
+				return err
+			}
+		}
+		comment.Body = body
+		comment.EditedOn = &now
+		comment.Mentions = mentions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteEventComment deletes a comment (with its replies when it starts a thread). Authors
+// delete their own comments; admins can delete any.
+func DeleteEventComment(eventID, commentID uint, actor Actor) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		comment, err := getEventComment(tx, eventID, commentID, true)
+		if err != nil {
+			return err
+		}
+		if comment.AuthorID != actor.UserID && !actor.IsAdmin() {
+			return ErrCommentNotAuthor
+		}
+		deletedAt := trashTimestamp()
+		if comment.ParentID == nil {
+			if _, err := softDeleteWhere(tx, &models.EventComment{}, actor.Email, deletedAt, "parent_id = ?", comment.ID); err != nil {
+				return err
+			}
+		}
+		_, err = softDeleteWhere(tx, &models.EventComment{}, actor.Email, deletedAt, "id = ?", comment.ID)
+		return err
+	})
+}
+
+// SetEventCommentResolved resolves or reopens a comment thread
+func SetEventCommentResolved(eventID, commentID uint, resolved bool, actor Actor) (*models.EventComment, error) {
+	var comment *models.EventComment
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if comment, err = getEventComment(tx, eventID, commentID, true); err != nil {
+			return err
+		}
+		if comment.ParentID != nil {
+			return ErrCommentNotThreadRoot
+		}
+		if comment.Resolved == resolved {
+			return nil
+		}
+
+		updates := map[string]interface{}{"resolved": resolved, "resolved_by": "", "resolved_on": nil}
+		comment.ResolvedBy, comment.ResolvedOn = "", nil
+		if resolved {
+			now := time.Now()
+			updates["resolved_by"], updates["resolved_on"] = actor.Email, &now
+			comment.ResolvedBy, comment.ResolvedOn = actor.Email, &now
+		}
+		comment.Resolved = resolved
+		return tx.Model(comment).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// GetOpenCommentCounts returns the number of unresolved comment threads per event
+func GetOpenCommentCounts(eventIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(eventIDs))
+	if len(eventIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		EventID uint
+		Open    int64
+	}
+	if err := config.DB.Model(&models.EventComment{}).
+		Select("event_id, COUNT(*) AS open").
+		Where("event_id IN ? AND parent_id IS NULL AND resolved = ?", eventIDs, false).
+		Group("event_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.EventID] = row.Open
+	}
+	return counts, nil
+}
+
+// GetCommentsMentioningUser returns the latest comments that @mention a user, newest first
+func GetCommentsMentioningUser(userID uint, openOnly bool, limit int) ([]models.EventComment, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	db := config.DB.Preload("Mentions").
+		Joins("JOIN event_comment_mentions m ON m.comment_id = event_comments.id").
+		Joins("JOIN event_details e ON e.id = event_comments.event_id AND e.deleted_at IS NULL").
+		Where("m.user_id = ?", userID)
+	if openOnly {
+		// The thread of the comment (itself or its top-level comment) is unresolved
+		db = db.Where(`NOT EXISTS (
+			SELECT 1 FROM event_comments root
+			WHERE root.id = COALESCE(event_comments.parent_id, event_comments.id) AND root.resolved)`)
+	}
+	var comments []models.EventComment
+	err := db.Order("event_comments.created_on DESC, event_comments.id DESC").Limit(limit).Find(&comments).Error
+	return comments, err
+}
@@ -0,0 +1,696 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCustomFieldNotFound is returned when the custom field does not exist
+	ErrCustomFieldNotFound = errors.New("custom field not found")
+	// ErrInvalidCustomField is returned when the fields of a custom field definition are invalid
+	ErrInvalidCustomField = errors.New("invalid custom field")
+	// ErrCustomFieldKeyTaken is returned when an event could get two custom fields with the same key
+	ErrCustomFieldKeyTaken = errors.New("custom field key already in use")
+)
+
+// customFieldKeyPattern is the shape of custom field keys, which name the values in the payload
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+var customFieldTypes = map[string]bool{
+	models.CustomFieldTypeText:        true,
+	models.CustomFieldTypeTextarea:    true,
+	models.CustomFieldTypeNumber:      true,
+	models.CustomFieldTypeInteger:     true,
+	models.CustomFieldTypeBoolean:     true,
+	models.CustomFieldTypeDate:        true,
+	models.CustomFieldTypeSelect:      true,
+	models.CustomFieldTypeMultiselect: true,
+}
+
+// EventCustomFieldInput holds the fields of a custom field to create or update. The category or
+// sub category, the key and the type are set on create only, since recorded values depend on
+// them; on update, omitted fields are left unchanged.
+type EventCustomFieldInput struct {
+	EventCategoryID    *uint                         `json:"event_category_id,omitempty"`
+	EventSubCategoryID *uint                         `json:"event_sub_category_id,omitempty"`
+	Key                string                        `json:"key,omitempty"`
+	Label              string                        `json:"label,omitempty"`
+	FieldType          string                        `json:"field_type,omitempty" enums:"text,textarea,number,integer,boolean,date,select,multiselect"`
+	Required           *bool                         `json:"required,omitempty"`
+	Options            []string                      `json:"options,omitempty"`
+	Validation         *models.CustomFieldValidation `json:"validation,omitempty"`
+	HelpText           *string                       `json:"help_text,omitempty"`
+	SortOrder          *int                          `json:"sort_order,omitempty"`
+	Active             *bool                         `json:"active,omitempty"`
+}
+
+// normalizeCustomFieldOptions trims the options of a choice field and drops blanks and duplicates
+func normalizeCustomFieldOptions(options []string) models.StringList {
+	seen := make(map[string]bool, len(options))
+	list := make(models.StringList, 0, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option != "" && !seen[option] {
+			seen[option] = true
+			list = append(list, option)
+		}
+	}
+	return list
+}
+
+// validateCustomFieldDefinition checks that the options and constraints of a field suit its type
+func validateCustomFieldDefinition(field *models.EventCustomField) error {
+	if field.Label == "" {
+		return fmt.Errorf("%w: label is required", ErrInvalidCustomField)
+	}
+
+	choice := field.FieldType == models.CustomFieldTypeSelect || field.FieldType == models.CustomFieldTypeMultiselect
+	if choice && len(field.Options) == 0 {
+		return fmt.Errorf("%w: a %s field needs options", ErrInvalidCustomField, field.FieldType)
+	}
+	if !choice && len(field.Options) > 0 {
+		return fmt.Errorf("%w: only select and multiselect fields have options", ErrInvalidCustomField)
+	}
+
+	rules := field.Validation
+	numeric := field.FieldType == models.CustomFieldTypeNumber || field.FieldType == models.CustomFieldTypeInteger
+	text := field.FieldType == models.CustomFieldTypeText || field.FieldType == models.CustomFieldTypeTextarea
+	if (rules.Min != nil || rules.Max != nil) && !numeric {
+		return fmt.Errorf("%w: min and max apply to number and integer fields", ErrInvalidCustomField)
+	}
+	if rules.Min != nil && rules.Max != nil && *rules.Min > *rules.Max {
+		return fmt.Errorf("%w: min is greater than max", ErrInvalidCustomField)
+	}
+	if (rules.MinLength != nil || rules.MaxLength != nil || rules.Pattern != "") && !text {
+		return fmt.Errorf("%w: min_length, max_length and pattern apply to text and textarea fields", ErrInvalidCustomField)
+	}
+	if (rules.MinLength != nil && *rules.MinLength < 0) || (rules.MaxLength != nil && *rules.MaxLength < 0) {
+		return fmt.Errorf("%w: lengths cannot be negative", ErrInvalidCustomField)
+	}
+	if rules.MinLength != nil && rules.MaxLength != nil && *rules.MinLength > *rules.MaxLength {
+		return fmt.Errorf("%w: min_length is greater than max_length", ErrInvalidCustomField)
+	}
+	if rules.Pattern != "" {
+		if _, err := regexp.Compile(rules.Pattern); err != nil {
+			return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidCustomField, err)
+		}
+	}
+	return nil
+}
+
+// checkCustomFieldKey makes sure no event can get two fields with the key of field: a category
+// field must not share its key with a field of the category or of any of its sub categories, and
+// a sub category field with a field of the sub category or of its category
+func checkCustomFieldKey(field *models.EventCustomField) error {
+	query := config.DB.Model(&models.EventCustomField{}).Where("key = ? AND id <> ?", field.Key, field.ID)
+	if field.EventCategoryID != nil {
+		query = query.Where("(event_category_id = ? OR event_sub_category_id IN (SELECT id FROM event_sub_categories WHERE event_category_id = ?))",
+			*field.EventCategoryID, *field.EventCategoryID)
+	} else {
+		query = query.Where("(event_sub_category_id = ? OR event_category_id = (SELECT event_category_id FROM event_sub_categories WHERE id = ?))",
+			*field.EventSubCategoryID, *field.EventSubCategoryID)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrCustomFieldKeyTaken, field.Key)
+	}
+	return nil
+}
+
+// CreateEventCustomField defines a custom field for the events of a category or of a sub category
+func CreateEventCustomField(input EventCustomFieldInput, createdBy string) (*models.EventCustomField, error) {
+	if (input.EventCategoryID == nil) == (input.EventSubCategoryID == nil) {
+		return nil, fmt.Errorf("%w: set either event_category_id or event_sub_category_id", ErrInvalidCustomField)
+	}
+	if input.EventCategoryID != nil {
+		var category models.EventCategory
+		if err := config.DB.Select("id").First(&category, *input.EventCategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: unknown event category", ErrInvalidCustomField)
+			}
+			return nil, err
+		}
+	} else {
+		var subCategory models.EventSubCategory
+		if err := config.DB.Select("id").First(&subCategory, *input.EventSubCategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: unknown event sub category", ErrInvalidCustomField)
+			}
+			return nil, err
+		}
+	}
+
+	key := strings.TrimSpace(input.Key)
+	if !customFieldKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: key must start with a lowercase letter and contain only lowercase letters, digits and underscores", ErrInvalidCustomField)
+	}
+	if !customFieldTypes[input.FieldType] {
+		return nil, fmt.Errorf("%w: unknown field_type %q", ErrInvalidCustomField, input.FieldType)
+	}
+
+	field := models.EventCustomField{
+		EventCategoryID:    input.EventCategoryID,
+		EventSubCategoryID: input.EventSubCategoryID,
+		Key:                key,
+		Label:              strings.TrimSpace(input.Label),
+		FieldType:          input.FieldType,
+		Required:           input.Required != nil && *input.Required,
+		Options:            normalizeCustomFieldOptions(input.Options),
+		Active:             input.Active == nil || *input.Active,
+		CreatedOn:          time.Now(),
+		CreatedBy:          createdBy,
+	}
+	if input.Validation != nil {
+		field.Validation = *input.Validation
+	}
+	if input.HelpText != nil {
+		field.HelpText = strings.TrimSpace(*input.HelpText)
+	}
+	if input.SortOrder != nil {
+		field.SortOrder = *input.SortOrder
+	}
+	if err := validateCustomFieldDefinition(&field); err != nil {
+		return nil, err
+	}
+	if err := checkCustomFieldKey(&field); err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Create(&field).Error; err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+// GetEventCustomFields lists custom fields. With a category or sub category it returns the fields
+// an event of it gets (the category's fields, then the sub category's), otherwise every field.
+// Inactive fields are left out unless includeInactive is set.
+func GetEventCustomFields(categoryID uint, subCategoryID *uint, includeInactive bool) ([]models.EventCustomField, error) {
+	if categoryID == 0 && subCategoryID != nil {
+		var subCategory models.EventSubCategory
+		if err := config.DB.Select("id", "event_category_id").First(&subCategory, *subCategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return []models.EventCustomField{}, nil
+			}
+			return nil, err
+		}
+		categoryID = subCategory.EventCategoryID
+	}
+	if categoryID == 0 {
+		fields := []models.EventCustomField{}
+		query := config.DB.Order("event_category_id, event_sub_category_id, sort_order, id")
+		if !includeInactive {
+			query = query.Where("active")
+		}
+		if err := query.Find(&fields).Error; err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+	return eventCustomFields(categoryID, subCategoryID, includeInactive)
+}
+
+// eventCustomFields returns the fields of an event of a category and sub category, the category's
+// first, each group by sort order
+func eventCustomFields(categoryID uint, subCategoryID *uint, includeInactive bool) ([]models.EventCustomField, error) {
+	fields := []models.EventCustomField{}
+	query := config.DB.Order("event_sub_category_id NULLS FIRST, sort_order, id")
+	if subCategoryID != nil && *subCategoryID > 0 {
+		query = query.Where("(event_category_id = ? OR event_sub_category_id = ?)", categoryID, *subCategoryID)
+	} else {
+		query = query.Where("event_category_id = ?", categoryID)
+	}
+	if !includeInactive {
+		query = query.Where("active")
+	}
+	if err := query.Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// GetEventCustomField returns a custom field by ID
+func GetEventCustomField(id uint) (*models.EventCustomField, error) {
+	var field models.EventCustomField
+	if err := config.DB.First(&field, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomFieldNotFound
+		}
+		return nil, err
+	}
+	return &field, nil
+}
+
+// UpdateEventCustomField changes the label, rules, options and presentation of a custom field.
+// Values recorded before a change are checked against the new rules the next time their event
+// is saved.
+func UpdateEventCustomField(id uint, input EventCustomFieldInput, updatedBy string) (*models.EventCustomField, error) {
+	field, err := GetEventCustomField(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case input.EventCategoryID != nil && (field.EventCategoryID == nil || *input.EventCategoryID != *field.EventCategoryID),
+		input.EventSubCategoryID != nil && (field.EventSubCategoryID == nil || *input.EventSubCategoryID != *field.EventSubCategoryID):
+		return nil, fmt.Errorf("%w: the category of a field cannot be changed", ErrInvalidCustomField)
+	case input.Key != "" && input.Key != field.Key:
+		return nil, fmt.Errorf("%w: the key of a field cannot be changed", ErrInvalidCustomField)
+	case input.FieldType != "" && input.FieldType != field.FieldType:
+		return nil, fmt.Errorf("%w: the type of a field cannot be changed", ErrInvalidCustomField)
+	}
+
+	if label := strings.TrimSpace(input.Label); label != "" {
+		field.Label = label
+	}
+	if input.Required != nil {
+		field.Required = *input.Required
+	}
+	if input.Options != nil {
+		field.Options = normalizeCustomFieldOptions(input.Options)
+	}
+	if input.Validation != nil {
+		field.Validation = *input.Validation
+	}
+	if input.HelpText != nil {
+		field.HelpText = strings.TrimSpace(*input.HelpText)
+	}
+	if input.SortOrder != nil {
+		field.SortOrder = *input.SortOrder
+	}
+	if input.Active != nil {
+		field.Active = *input.Active
+	}
+	if err := validateCustomFieldDefinition(field); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(field).Updates(map[string]interface{}{
+		"label":      field.Label,
+		"required":   field.Required,
+		"options":    field.Options,
+		"validation": field.Validation,
+		"help_text":  field.HelpText,
+		"sort_order": field.SortOrder,
+		"active":     field.Active,
+		"updated_on": &now,
+		"updated_by": updatedBy,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return GetEventCustomField(id)
+}
+
+// DeleteEventCustomField deletes a custom field. The values recorded for it stay on the events
+// but are no longer shown or exported; deactivate the field instead to keep them visible.
+func DeleteEventCustomField(id uint) error {
+	result := config.DB.Delete(&models.EventCustomField{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCustomFieldNotFound
+	}
+	return nil
+}
+
+// ApplyEventCustomFields checks the custom field values of a new event against the fields of its
+// category and sub category and stores them, normalized, on the event. Invalid values are
+// reported as a *validators.SchemaError.
+func ApplyEventCustomFields(event *models.EventDetails, values map[string]interface{}) error {
+	customFields, err := resolveCustomFieldValues(event.EventCategoryID, event.EventSubCategoryID, event.Status, values, true)
+	if err != nil {
+		return err
+	}
+	event.CustomFields = customFields
+	return nil
+}
+
+// EventCustomFieldsForUpdate returns the custom field values an event gets from an update, checked
+// against the fields of the category and sub category it has afterwards. updateData holds the
+// columns being changed; when values were not submitted, the event keeps its current values, minus
+// those of fields its new category does not have. Invalid values are reported as a
+// *validators.SchemaError.
+func EventCustomFieldsForUpdate(eventID uint, updateData map[string]interface{}, values map[string]interface{}, submitted bool) (models.JSONB, error) {
+	var current models.EventDetails
+	if err := config.DB.Select("id", "event_category_id", "event_sub_category_id", "status", "custom_fields").
+		First(&current, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	categoryID := current.EventCategoryID
+	if id, ok := updateDataID(updateData["event_category_id"]); ok && id > 0 {
+		categoryID = id
+	}
+	subCategoryID := current.EventSubCategoryID
+	if value, ok := updateData["event_sub_category_id"]; ok {
+		subCategoryID = nil
+		if id, ok := updateDataID(value); ok && id > 0 {
+			subCategoryID = &id
+		}
+	}
+	status := current.Status
+	if s, ok := updateData["status"].(string); ok && s != "" {
+		status = s
+	}
+
+	if !submitted {
+		values = current.CustomFields
+	}
+	return resolveCustomFieldValues(categoryID, subCategoryID, status, values, submitted)
+}
+
+// NormalizeFlatEventCustomFields prepares the custom fields of a flat (column) update: when it sets
+// custom_fields, or changes the category, sub category or status the values are checked against,
+// custom_fields is replaced with the checked values
+func NormalizeFlatEventCustomFields(eventID uint, updateData map[string]interface{}) error {
+	raw, submitted := updateData["custom_fields"]
+	_, categoryChanged := updateData["event_category_id"]
+	_, subCategoryChanged := updateData["event_sub_category_id"]
+	_, statusChanged := updateData["status"]
+	if !submitted && !categoryChanged && !subCategoryChanged && !statusChanged {
+		return nil
+	}
+
+	var values map[string]interface{}
+	if submitted && raw != nil {
+		var ok bool
+		if values, ok = raw.(map[string]interface{}); !ok {
+			return &validators.SchemaError{Errors: []validators.FieldError{{Path: "$.custom_fields", Reason: "must be an object"}}}
+		}
+	}
+	customFields, err := EventCustomFieldsForUpdate(eventID, updateData, values, submitted)
+	if err != nil {
+		return err
+	}
+	updateData["custom_fields"] = customFields
+	return nil
+}
+
+// updateDataID reads an ID from an update map, where it is a uint (mapped payloads) or a float64
+// (decoded JSON)
+func updateDataID(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case uint:
+		return v, true
+	case int:
+		return uint(v), v >= 0
+	case float64:
+		return uint(v), v >= 0 && v == math.Trunc(v)
+	case string:
+		id, err := strconv.ParseUint(v, 10, 64)
+		return uint(id), err == nil
+	}
+	return 0, false
+}
+
+// resolveCustomFieldValues checks values against the fields of a category and sub category and
+// returns them normalized. Empty values are dropped; required fields must be filled once the
+// event is complete. Values of inactive fields are kept as they are. Keys that are not fields of
+// the category are errors when submitted (strict) and dropped otherwise.
+func resolveCustomFieldValues(categoryID uint, subCategoryID *uint, status string, values map[string]interface{}, strict bool) (models.JSONB, error) {
+	customFields := models.JSONB{}
+	if categoryID == 0 {
+		return customFields, nil
+	}
+	fields, err := eventCustomFields(categoryID, subCategoryID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []validators.FieldError
+	add := func(key, reason string) {
+		errs = append(errs, validators.FieldError{Path: "$.customFields." + key, Reason: reason})
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Key] = true
+		value, present := values[field.Key]
+		if !present || isEmptyCustomFieldValue(value) {
+			if field.Active && field.Required && status == "complete" {
+				add(field.Key, "is required")
+			}
+			continue
+		}
+		if !field.Active {
+			customFields[field.Key] = value
+			continue
+		}
+		normalized, reason := normalizeCustomFieldValue(field, value)
+		if reason != "" {
+			add(field.Key, reason)
+			continue
+		}
+		customFields[field.Key] = normalized
+	}
+
+	if strict {
+		unknown := make([]string, 0)
+		for key := range values {
+			if !known[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			add(key, "is not a custom field of this event category")
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &validators.SchemaError{Errors: errs}
+	}
+	return customFields, nil
+}
+
+// isEmptyCustomFieldValue reports whether a value leaves its field unfilled
+func isEmptyCustomFieldValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// normalizeCustomFieldValue converts a value to the stored form of its field type (numbers may
+// be sent as numeric strings, like FlexInt) and checks the field's rules. A non-empty reason
+// means the value is invalid.
+func normalizeCustomFieldValue(field models.EventCustomField, value interface{}) (interface{}, string) {
+	rules := field.Validation
+	switch field.FieldType {
+	case models.CustomFieldTypeText, models.CustomFieldTypeTextarea:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Sprintf("must be a string, got %s", customFieldValueKind(value))
+		}
+		text = strings.TrimSpace(text)
+		length := utf8.RuneCountInString(text)
+		if rules.MinLength != nil && length < *rules.MinLength {
+			return nil, fmt.Sprintf("must be at least %d characters", *rules.MinLength)
+		}
+		if rules.MaxLength != nil && length > *rules.MaxLength {
+			return nil, fmt.Sprintf("must be at most %d characters", *rules.MaxLength)
+		}
+		if rules.Pattern != "" {
+			if re, err := regexp.Compile(rules.Pattern); err == nil && !re.MatchString(text) {
+				return nil, fmt.Sprintf("must match the pattern %s", rules.Pattern)
+			}
+		}
+		return text, ""
+
+	case models.CustomFieldTypeNumber, models.CustomFieldTypeInteger:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, "must be a number"
+			}
+			number = parsed
+		default:
+			return nil, fmt.Sprintf("must be a number, got %s", customFieldValueKind(value))
+		}
+		if math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, "must be a number"
+		}
+		if field.FieldType == models.CustomFieldTypeInteger && number != math.Trunc(number) {
+			return nil, "must be a whole number"
+		}
+		if rules.Min != nil && number < *rules.Min {
+			return nil, fmt.Sprintf("must be at least %v", *rules.Min)
+		}
+		if rules.Max != nil && number > *rules.Max {
+			return nil, fmt.Sprintf("must be at most %v", *rules.Max)
+		}
+		if field.FieldType == models.CustomFieldTypeInteger {
+			return int64(number), ""
+		}
+		return number, ""
+
+	case models.CustomFieldTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, ""
+		case string:
+			if parsed, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return parsed, ""
+			}
+		}
+		return nil, "must be true or false"
+
+	case models.CustomFieldTypeDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Sprintf("must be a date (YYYY-MM-DD), got %s", customFieldValueKind(value))
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(text))
+		if err != nil {
+			return nil, "must be a date (YYYY-MM-DD)"
+		}
+		return date.Format("2006-01-02"), ""
+
+	case models.CustomFieldTypeSelect:
+		text, ok := value.(string)
+		if !ok || !customFieldHasOption(field, text) {
+			return nil, "must be one of: " + strings.Join(field.Options, ", ")
+		}
+		return text, ""
+
+	case models.CustomFieldTypeMultiselect:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Sprintf("must be a list of options, got %s", customFieldValueKind(value))
+		}
+		seen := make(map[string]bool, len(items))
+		selected := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			text, ok := item.(string)
+			if !ok || !customFieldHasOption(field, text) {
+				return nil, "every item must be one of: " + strings.Join(field.Options, ", ")
+			}
+			if !seen[text] {
+				seen[text] = true
+				selected = append(selected, text)
+			}
+		}
+		return selected, ""
+	}
+	return nil, "has an unknown field type"
+}
+
+func customFieldHasOption(field models.EventCustomField, value string) bool {
+	for _, option := range field.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// customFieldValueKind names the JSON type of a decoded value for error messages
+func customFieldValueKind(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// EventCustomFieldDefinitions returns the fields to show for an event: the active fields of its
+// category and sub category, and the inactive ones it has a value for
+func EventCustomFieldDefinitions(event *models.EventDetails) ([]models.EventCustomField, error) {
+	fields, err := eventCustomFields(event.EventCategoryID, event.EventSubCategoryID, true)
+	if err != nil {
+		return nil, err
+	}
+	shown := make([]models.EventCustomField, 0, len(fields))
+	for _, field := range fields {
+		if _, hasValue := event.CustomFields[field.Key]; field.Active || hasValue {
+			shown = append(shown, field)
+		}
+	}
+	return shown, nil
+}
+
+// CustomFieldValue is a custom field of an event with its value formatted for reports
+type CustomFieldValue struct {
+	Label string
+	Value string
+}
+
+// EventCustomFieldValues returns the filled custom fields of an event in display order, formatted
+// for reports
+func EventCustomFieldValues(event *models.EventDetails) ([]CustomFieldValue, error) {
+	if len(event.CustomFields) == 0 {
+		return nil, nil
+	}
+	fields, err := EventCustomFieldDefinitions(event)
+	if err != nil {
+		return nil, err
+	}
+	var values []CustomFieldValue
+	for _, field := range fields {
+		if value, ok := event.CustomFields[field.Key]; ok {
+			values = append(values, CustomFieldValue{Label: field.Label, Value: formatCustomFieldValue(value)})
+		}
+	}
+	return values, nil
+}
+
+// formatCustomFieldValue renders a stored value as text: Yes/No for booleans and comma-separated
+// options for multiselect fields
+func formatCustomFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ", ")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// StartDuplicateScanScheduler starts a background goroutine that scans all events for likely
+// duplicates and records them for admin review
+// It runs once per day at the specified hour (0-23)
+func StartDuplicateScanScheduler(scanHour int) {
+	if scanHour < 0 || scanHour > 23 {
+		log.Printf("Invalid duplicate scan hour %d, defaulting to 4 AM", scanHour)
+		scanHour = 4
+	}
+
+	log.Printf("Starting duplicate scan scheduler: runs daily at %02d:00", scanHour)
+
+	go func() {
+		// Calculate the next run time
+		now := time.Now()
+		nextRun := time.Date(now.Year(), now.Month(), now.Day(), scanHour, 0, 0, 0, now.Location())
+
+		// If the scheduled time has already passed today, schedule for tomorrow
+		if !nextRun.After(now) {
+			nextRun = nextRun.AddDate(0, 0, 1)
+		}
+
+		time.Sleep(time.Until(nextRun))
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		runDuplicateScan()
+		for range ticker.C {
+			runDuplicateScan()
+		}
+	}()
+}
+
+// runDuplicateScan executes the scan and logs the results
+func runDuplicateScan() {
+	startTime := time.Now()
+	found, err := ScanEventDuplicates()
+	duration := time.Since(startTime)
+
+	if err != nil {
+		log.Printf("ERROR: Duplicate scan failed after %v: %v", duration, err)
+		return
+	}
+	log.Printf("✓ Duplicate scan completed in %v: %d suspected duplicate pair(s)", duration, found)
+}
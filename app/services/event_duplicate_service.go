@@ -0,0 +1,355 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrDuplicateSuspectNotFound = errors.New("duplicate suspect not found")
+
+// DuplicateScoreThreshold is the minimum score for two events to be reported as likely duplicates.
+// Same branch, fully overlapping dates and the same type and category give 0.7; some similarity
+// of address or city is needed on top.
+const DuplicateScoreThreshold = 0.75
+
+// Weights of the duplicate score components (they add up to 1)
+const (
+	duplicateWeightOverlap     = 0.30
+	duplicateWeightType        = 0.15
+	duplicateWeightCategory    = 0.20
+	duplicateWeightSubCategory = 0.05
+	duplicateWeightAddress     = 0.20
+	duplicateWeightCity        = 0.10
+)
+
+// DuplicateCandidate is an existing event that likely describes the same programme
+type DuplicateCandidate struct {
+	EventID   uint      `json:"event_id"`
+	Theme     string    `json:"theme,omitempty"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	City      string    `json:"city,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	Score     float64   `json:"score"`
+	Reasons   []string  `json:"reasons"`
+}
+
+// duplicateComponentsRow holds the raw similarity components of one pair of events
+type duplicateComponentsRow struct {
+	EventID           uint
+	CandidateID       uint
+	OverlapRatio      float64
+	SameType          bool
+	SameCategory      bool
+	SameSubCategory   bool
+	AddressSimilarity float64
+	CitySimilarity    float64
+}
+
+// duplicateComponentsSQL compares reference events a (the %s source) with live events b of the same
+// branch whose dates overlap
+const duplicateComponentsSQL = `
+	SELECT a.id AS event_id, b.id AS candidate_id,
+	       (LEAST(a.end_date::date, b.end_date::date) - GREATEST(a.start_date::date, b.start_date::date) + 1)::float
+	         / (GREATEST(a.end_date::date - a.start_date::date, b.end_date::date - b.start_date::date) + 1) AS overlap_ratio,
+	       a.event_type_id = b.event_type_id AS same_type,
+	       a.event_category_id = b.event_category_id AS same_category,
+	       a.event_sub_category_id IS NOT DISTINCT FROM b.event_sub_category_id AS same_sub_category,
+	       similarity(lower(coalesce(a.address, '')), lower(coalesce(b.address, ''))) AS address_similarity,
+	       similarity(lower(coalesce(a.city, '')), lower(coalesce(b.city, ''))) AS city_similarity
+	FROM %s a
+	JOIN event_details b ON b.branch_id = a.branch_id
+	     AND b.id <> a.id
+	     AND b.deleted_at IS NULL
+	     AND b.start_date::date <= a.end_date::date
+	     AND b.end_date::date >= a.start_date::date
+	%s`
+
+// score combines the components into a 0..1 score and the reasons behind it
+func (r duplicateComponentsRow) score() (float64, []string) {
+	overlap := math.Max(0, math.Min(1, r.OverlapRatio))
+	score := duplicateWeightOverlap * overlap
+	reasons := []string{"same branch", fmt.Sprintf("dates overlap (%.0f%%)", overlap*100)}
+
+	if r.SameType {
+		score += duplicateWeightType
+		reasons = append(reasons, "same event type")
+	}
+	if r.SameCategory {
+		score += duplicateWeightCategory
+		reasons = append(reasons, "same category")
+	}
+	if r.SameSubCategory {
+		score += duplicateWeightSubCategory
+	}
+	score += duplicateWeightAddress * r.AddressSimilarity
+	if r.AddressSimilarity >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("similar address (%.0f%%)", r.AddressSimilarity*100))
+	}
+	score += duplicateWeightCity * r.CitySimilarity
+	if r.CitySimilarity >= 0.99 {
+		reasons = append(reasons, "same city")
+	} else if r.CitySimilarity >= 0.5 {
+		reasons = append(reasons, "similar city")
+	}
+
+	return math.Round(score*1000) / 1000, reasons
+}
+
+// FindEventDuplicates returns live events that likely duplicate event (same branch, overlapping
+// dates, same type/category, similar address/city), best match first. excludeID skips the event
+// itself when checking an update.
+func FindEventDuplicates(event *models.EventDetails, excludeID uint) ([]DuplicateCandidate, error) {
+	candidates := []DuplicateCandidate{}
+	if event.BranchID == nil || *event.BranchID == 0 || event.StartDate.IsZero() {
+		return candidates, nil
+	}
+	endDate := event.EndDate
+	if endDate.IsZero() || endDate.Before(event.StartDate) {
+		endDate = event.StartDate
+	}
+
+	reference := `(SELECT CAST(@id AS bigint) AS id, CAST(@branch AS bigint) AS branch_id,
+		CAST(@start AS timestamptz) AS start_date, CAST(@end AS timestamptz) AS end_date,
+		CAST(@type AS bigint) AS event_type_id, CAST(@category AS bigint) AS event_category_id,
+		CAST(@sub_category AS bigint) AS event_sub_category_id,
+		CAST(@address AS text) AS address, CAST(@city AS text) AS city)`
+
+	var rows []duplicateComponentsRow
+	if err := config.DB.Raw(fmt.Sprintf(duplicateComponentsSQL, reference, ""), map[string]interface{}{
+		"id":           excludeID,
+		"branch":       *event.BranchID,
+		"start":        event.StartDate,
+		"end":          endDate,
+		"type":         event.EventTypeID,
+		"category":     event.EventCategoryID,
+		"sub_category": event.EventSubCategoryID,
+		"address":      event.Address,
+		"city":         event.City,
+	}).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error checking for duplicates: %w", err)
+	}
+
+	scores := make(map[uint]float64)
+	reasons := make(map[uint][]string)
+	var ids []uint
+	for _, row := range rows {
+		score, why := row.score()
+		if score < DuplicateScoreThreshold {
+			continue
+		}
+		scores[row.CandidateID] = score
+		reasons[row.CandidateID] = why
+		ids = append(ids, row.CandidateID)
+	}
+	if len(ids) == 0 {
+		return candidates, nil
+	}
+
+	var events []models.EventDetails
+	if err := config.DB.Where("id IN ?", ids).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		candidates = append(candidates, DuplicateCandidate{
+			EventID:   e.ID,
+			Theme:     e.Theme,
+			StartDate: e.StartDate,
+			EndDate:   e.EndDate,
+			City:      e.City,
+			Address:   e.Address,
+			Status:    e.Status,
+			CreatedBy: e.CreatedBy,
+			Score:     scores[e.ID],
+			Reasons:   reasons[e.ID],
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].EventID < candidates[j].EventID
+	})
+	return candidates, nil
+}
+
+// duplicateKeyColumns are the columns the duplicate check looks at
+var duplicateKeyColumns = []string{"branch_id", "start_date", "end_date", "event_type_id", "event_category_id", "event_sub_category_id", "address", "city"}
+
+// FindEventDuplicatesForUpdate checks an event as it would be after applying updates. Nothing is
+// reported when the update does not touch the columns the check looks at, so unrelated edits of
+// an already flagged event are not blocked.
+func FindEventDuplicatesForUpdate(eventID uint, updates map[string]interface{}) ([]DuplicateCandidate, error) {
+	touched := false
+	for _, col := range duplicateKeyColumns {
+		if _, ok := updates[col]; ok {
+			touched = true
+			break
+		}
+	}
+	if !touched {
+		return []DuplicateCandidate{}, nil
+	}
+
+	var event models.EventDetails
+	if err := config.DB.First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	before := event
+
+	for col, value := range updates {
+		switch col {
+		case "branch_id":
+			if id, ok := toUint(value); ok {
+				event.BranchID = &id
+			}
+		case "event_type_id":
+			if id, ok := toUint(value); ok {
+				event.EventTypeID = id
+			}
+		case "event_category_id":
+			if id, ok := toUint(value); ok {
+				event.EventCategoryID = id
+			}
+		case "event_sub_category_id":
+			if id, ok := toUint(value); ok && id > 0 {
+				event.EventSubCategoryID = &id
+			} else if value == nil {
+				event.EventSubCategoryID = nil
+			}
+		case "start_date":
+			if t, ok := value.(time.Time); ok {
+				event.StartDate = t
+			}
+		case "end_date":
+			if t, ok := value.(time.Time); ok {
+				event.EndDate = t
+			}
+		case "address":
+			if s, ok := value.(string); ok {
+				event.Address = s
+			}
+		case "city":
+			if s, ok := value.(string); ok {
+				event.City = s
+			}
+		}
+	}
+	changed := !uintPtrEqual(before.BranchID, event.BranchID) ||
+		before.EventTypeID != event.EventTypeID ||
+		before.EventCategoryID != event.EventCategoryID ||
+		!uintPtrEqual(before.EventSubCategoryID, event.EventSubCategoryID) ||
+		!before.StartDate.Equal(event.StartDate) ||
+		!before.EndDate.Equal(event.EndDate) ||
+		!strings.EqualFold(before.Address, event.Address) ||
+		!strings.EqualFold(before.City, event.City)
+	if !changed {
+		return []DuplicateCandidate{}, nil
+	}
+
+	return FindEventDuplicates(&event, eventID)
+}
+
+func toUint(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case uint:
+		return v, true
+	case *uint:
+		if v != nil {
+			return *v, true
+		}
+	case int:
+		return uint(v), v >= 0
+	case int64:
+		return uint(v), v >= 0
+	case float64:
+		return uint(v), v >= 0
+	}
+	return 0, false
+}
+
+func uintPtrEqual(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ScanEventDuplicates compares all live events and records the likely duplicate pairs. Pairs that
+// are no longer suspicious (edited, deleted) are dropped unless an admin dismissed them.
+func ScanEventDuplicates() (int, error) {
+	var rows []duplicateComponentsRow
+	if err := config.DB.Raw(fmt.Sprintf(duplicateComponentsSQL, "event_details",
+		"WHERE a.deleted_at IS NULL AND a.id < b.id")).Scan(&rows).Error; err != nil {
+		return 0, fmt.Errorf("error scanning for duplicates: %w", err)
+	}
+
+	now := time.Now()
+	found := 0
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		for _, row := range rows {
+			score, reasons := row.score()
+			if score < DuplicateScoreThreshold {
+				continue
+			}
+			suspect := models.EventDuplicateSuspect{
+				EventID:          row.EventID,
+				DuplicateEventID: row.CandidateID,
+				Score:            score,
+				Reasons:          strings.Join(reasons, ", "),
+				DetectedOn:       now,
+				LastSeenOn:       now,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "event_id"}, {Name: "duplicate_event_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"score", "reasons", "last_seen_on"}),
+			}).Create(&suspect).Error; err != nil {
+				return err
+			}
+			found++
+		}
+		return tx.Where("last_seen_on < ? AND dismissed_on IS NULL", now).Delete(&models.EventDuplicateSuspect{}).Error
+	})
+	return found, err
+}
+
+// GetDuplicateSuspects lists suspected duplicate pairs, highest score first
+func GetDuplicateSuspects(includeDismissed bool) ([]models.EventDuplicateSuspect, error) {
+	var suspects []models.EventDuplicateSuspect
+	db := config.DB.
+		Preload("Event").Preload("Event.Branch").
+		Preload("DuplicateEvent").Preload("DuplicateEvent.Branch")
+	if !includeDismissed {
+		db = db.Where("dismissed_on IS NULL")
+	}
+	err := db.Order("score DESC, id ASC").Find(&suspects).Error
+	return suspects, err
+}
+
+// DismissDuplicateSuspect marks a suspected pair as not a duplicate; later scans keep it dismissed
+func DismissDuplicateSuspect(suspectID uint, dismissedBy string) error {
+	result := config.DB.Model(&models.EventDuplicateSuspect{}).
+		Where("id = ? AND dismissed_on IS NULL", suspectID).
+		Updates(map[string]interface{}{"dismissed_on": time.Now(), "dismissed_by": dismissedBy})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicateSuspectNotFound
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -53,15 +54,26 @@ func ExportEventsToExcel(events []models.EventDetails) (*bytes.Buffer, error) {
 		"Initiation - Children",
 		"Branch",
 		"Status",
+		"Workflow State",
 		"Created On",
 		"Updated On",
 		"Created By",
 		"Updated By",
 	}
 
+	// One column per custom field key of the exported events' categories
+	customFieldColumns, err := exportCustomFieldColumns(events)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range customFieldColumns {
+		headers = append(headers, field.Label)
+	}
+
 	// Write headers
+	// Use CoordinatesToCellName so columns beyond Z are addressed as AA, AB, ...
 	for i, header := range headers {
-		cell := fmt.Sprintf("%c1", 'A'+i)
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheetName, cell, header)
 	}
 
@@ -84,7 +96,8 @@ func ExportEventsToExcel(events []models.EventDetails) (*bytes.Buffer, error) {
 		},
 	})
 	if err == nil {
-		f.SetCellStyle(sheetName, "A1", fmt.Sprintf("%c1", 'A'+len(headers)-1), headerStyle)
+		lastHeaderCell, _ := excelize.CoordinatesToCellName(len(headers), 1)
+		f.SetCellStyle(sheetName, "A1", lastHeaderCell, headerStyle)
 	}
 
 	// Write event data
@@ -148,6 +161,7 @@ func ExportEventsToExcel(events []models.EventDetails) (*bytes.Buffer, error) {
 				return "-"
 			}(),
 			getString(event.Status),
+			getString(event.WorkflowState),
 			formatTime(event.CreatedOn),
 			func() interface{} {
 				if event.UpdatedOn != nil {
@@ -158,9 +172,16 @@ func ExportEventsToExcel(events []models.EventDetails) (*bytes.Buffer, error) {
 			getString(event.CreatedBy),
 			getString(event.UpdatedBy),
 		}
+		for _, field := range customFieldColumns {
+			if value, ok := event.CustomFields[field.Key]; ok {
+				values = append(values, formatCustomFieldValue(value))
+			} else {
+				values = append(values, "-")
+			}
+		}
 
 		for colIndex, value := range values {
-			cell := fmt.Sprintf("%c%d", 'A'+colIndex, row)
+			cell, _ := excelize.CoordinatesToCellName(colIndex+1, row)
 			f.SetCellValue(sheetName, cell, value)
 		}
 	}
@@ -181,6 +202,11 @@ func ExportEventsToExcel(events []models.EventDetails) (*bytes.Buffer, error) {
 		f.SetColWidth(sheetName, colName, colName, 15)
 	}
 
+	// Day-wise attendance of the exported events on a second sheet
+	if err := writeAttendanceSheet(f, events, headerStyle); err != nil {
+		return nil, err
+	}
+
 	// Write to buffer
 	var buf bytes.Buffer
 	if err := f.Write(&buf); err != nil {
@@ -195,6 +221,110 @@ func ExportEventsToExcel(events []models.EventDetails) (*bytes.Buffer, error) {
 	return &buf, nil
 }
 
+// exportCustomFieldColumns returns the custom fields to export for the given events, one per key:
+// the active fields of their categories and sub categories, and inactive ones some event has a
+// value for
+func exportCustomFieldColumns(events []models.EventDetails) ([]models.EventCustomField, error) {
+	categoryIDs := make([]uint, 0, len(events))
+	subCategoryIDs := make([]uint, 0, len(events))
+	hasValue := make(map[string]bool)
+	for _, event := range events {
+		categoryIDs = append(categoryIDs, event.EventCategoryID)
+		if event.EventSubCategoryID != nil {
+			subCategoryIDs = append(subCategoryIDs, *event.EventSubCategoryID)
+		}
+		for key := range event.CustomFields {
+			hasValue[key] = true
+		}
+	}
+	if len(categoryIDs) == 0 {
+		return nil, nil
+	}
+
+	var fields []models.EventCustomField
+	query := config.DB.Order("event_sub_category_id NULLS FIRST, sort_order, id")
+	if len(subCategoryIDs) > 0 {
+		query = query.Where("(event_category_id IN ? OR event_sub_category_id IN ?)", categoryIDs, subCategoryIDs)
+	} else {
+		query = query.Where("event_category_id IN ?", categoryIDs)
+	}
+	if err := query.Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to load custom fields: %v", err)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	columns := make([]models.EventCustomField, 0, len(fields))
+	for _, field := range fields {
+		if seen[field.Key] || (!field.Active && !hasValue[field.Key]) {
+			continue
+		}
+		seen[field.Key] = true
+		columns = append(columns, field)
+	}
+	return columns, nil
+}
+
+// writeAttendanceSheet adds an "Attendance" sheet with one row per recorded day/session of the
+// given events. Nothing is added when none of them has attendance records.
+func writeAttendanceSheet(f *excelize.File, events []models.EventDetails, headerStyle int) error {
+	eventIDs := make([]uint, 0, len(events))
+	themes := make(map[uint]string, len(events))
+	for _, event := range events {
+		eventIDs = append(eventIDs, event.ID)
+		themes[event.ID] = event.Theme
+	}
+	records, err := GetAttendanceByEventIDs(eventIDs)
+	if err != nil {
+		return fmt.Errorf("failed to load attendance: %v", err)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	sheetName := "Attendance"
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return fmt.Errorf("failed to create sheet: %v", err)
+	}
+
+	headers := []string{"Event ID", "Theme", "Date", "Session", "Men", "Women", "Children", "Total", "New Attendees"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	if headerStyle != 0 {
+		lastHeaderCell, _ := excelize.CoordinatesToCellName(len(headers), 1)
+		f.SetCellStyle(sheetName, "A1", lastHeaderCell, headerStyle)
+	}
+
+	for rowIndex, record := range records {
+		session := record.Session
+		if session == "" {
+			session = "-"
+		}
+		theme := themes[record.EventID]
+		if theme == "" {
+			theme = "-"
+		}
+		values := []interface{}{
+			record.EventID,
+			theme,
+			record.AttendanceDate.Format("2006-01-02"),
+			session,
+			record.Men,
+			record.Women,
+			record.Children,
+			record.Men + record.Women + record.Children,
+			record.NewAttendees,
+		}
+		for colIndex, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(colIndex+1, rowIndex+2)
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+	f.SetColWidth(sheetName, "A", "I", 15)
+	return nil
+}
+
 // ExportVolunteersToExcel exports volunteers to an Excel file and returns the buffer
 func ExportVolunteersToExcel(volunteers []models.Volunteer, eventName string) (*bytes.Buffer, error) {
 	f := excelize.NewFile()
@@ -0,0 +1,517 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+// MaxEventImportRows bounds the number of data rows accepted in one upload
+const MaxEventImportRows = 5000
+
+// Columns of the events sheet as written by ExportEventsToExcel. ID, workflow state and the audit
+// columns are ignored on import: every row creates a new event in the draft workflow state.
+const (
+	importColEventType     = "Event Type"
+	importColEventCategory = "Event Category"
+	importColScale         = "Scale"
+	importColTheme         = "Theme"
+	importColStartDate     = "Start Date"
+	importColEndDate       = "End Date"
+	importColDailyStart    = "Daily Start Time"
+	importColDailyEnd      = "Daily End Time"
+	importColOrator        = "Spiritual Orator"
+	importColLanguage      = "Language"
+	importColCountry       = "Country"
+	importColState         = "State"
+	importColCity          = "City"
+	importColDistrict      = "District"
+	importColPostOffice    = "Post Office"
+	importColPincode       = "Pincode"
+	importColAddress       = "Address"
+	importColBenMen        = "Beneficiaries - Men"
+	importColBenWomen      = "Beneficiaries - Women"
+	importColBenChild      = "Beneficiaries - Children"
+	importColInitMen       = "Initiation - Men"
+	importColInitWomen     = "Initiation - Women"
+	importColInitChild     = "Initiation - Children"
+	importColBranch        = "Branch"
+	importColStatus        = "Status"
+)
+
+// importRequiredColumns must be present in the header row
+var importRequiredColumns = []string{importColEventType, importColEventCategory, importColStartDate, importColEndDate}
+
+// EventImportRow is the outcome of one spreadsheet row
+type EventImportRow struct {
+	Row     int      `json:"row"` // 1-based sheet row number
+	Valid   bool     `json:"valid"`
+	Errors  []string `json:"errors,omitempty"`
+	EventID uint     `json:"event_id,omitempty"` // set once the row is imported
+	Theme   string   `json:"theme,omitempty"`
+}
+
+// EventImportResult reports the validation and, unless it was a dry run, the import of a sheet
+type EventImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Sheet    string           `json:"sheet"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Invalid  int              `json:"invalid"`
+	Imported int              `json:"imported"`
+	Rows     []EventImportRow `json:"rows"`
+}
+
+// eventImportLookups caches master data by lower-cased name
+type eventImportLookups struct {
+	types      map[string]models.EventType
+	categories map[string][]models.EventCategory
+	branches   map[string][]models.Branch
+	languages  map[string]string
+	// customFields holds the custom fields of each event category by category ID
+	customFields map[uint][]models.EventCustomField
+}
+
+func importKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func loadEventImportLookups() (*eventImportLookups, error) {
+	lookups := &eventImportLookups{
+		types:      map[string]models.EventType{},
+		categories: map[string][]models.EventCategory{},
+		branches:   map[string][]models.Branch{},
+		languages:  map[string]string{},
+
+		customFields: map[uint][]models.EventCustomField{},
+	}
+
+	var types []models.EventType
+	if err := config.DB.Find(&types).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		lookups.types[importKey(t.Name)] = t
+	}
+
+	var categories []models.EventCategory
+	if err := config.DB.Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		key := importKey(category.Name)
+		lookups.categories[key] = append(lookups.categories[key], category)
+	}
+
+	var branches []models.Branch
+	if err := config.DB.Select("id", "name", "branch_code").Find(&branches).Error; err != nil {
+		return nil, err
+	}
+	for _, branch := range branches {
+		key := importKey(branch.Name)
+		lookups.branches[key] = append(lookups.branches[key], branch)
+	}
+
+	var languages []models.Language
+	if err := config.DB.Find(&languages).Error; err != nil {
+		return nil, err
+	}
+	for _, language := range languages {
+		lookups.languages[importKey(language.Name)] = language.Name
+		if language.Code != "" {
+			lookups.languages[importKey(language.Code)] = language.Name
+		}
+	}
+
+	// The sheet has no sub category column, so only the fields of categories apply
+	var fields []models.EventCustomField
+	if err := config.DB.Where("event_category_id IS NOT NULL").Order("sort_order, id").Find(&fields).Error; err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		categoryID := *field.EventCategoryID
+		lookups.customFields[categoryID] = append(lookups.customFields[categoryID], field)
+	}
+	return lookups, nil
+}
+
+// importCustomFieldValues reads the custom field columns of a row, headed by the field label as
+// exported or by the field key. Yes/No and comma-separated options, as the export writes booleans
+// and multiselect values, are read back; everything else is left to ApplyEventCustomFields.
+func importCustomFieldValues(row []string, columns map[string]int, fields []models.EventCustomField) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, field := range fields {
+		value := importCell(row, columns, field.Label)
+		if _, ok := columns[importKey(field.Label)]; !ok {
+			value = importCell(row, columns, field.Key)
+		}
+		if value == "" {
+			continue
+		}
+		switch field.FieldType {
+		case models.CustomFieldTypeBoolean:
+			switch importKey(value) {
+			case "yes":
+				values[field.Key] = true
+			case "no":
+				values[field.Key] = false
+			default:
+				values[field.Key] = value
+			}
+		case models.CustomFieldTypeMultiselect:
+			options := []interface{}{}
+			for _, option := range strings.Split(value, ",") {
+				if option = strings.TrimSpace(option); option != "" {
+					options = append(options, option)
+				}
+			}
+			values[field.Key] = options
+		default:
+			values[field.Key] = value
+		}
+	}
+	return values
+}
+
+// importCell reads a cell by column header; the export writes "-" for empty values
+func importCell(row []string, columns map[string]int, header string) string {
+	index, ok := columns[importKey(header)]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	value := strings.TrimSpace(row[index])
+	if value == "-" {
+		return ""
+	}
+	return value
+}
+
+// parseImportDate accepts the export format (YYYY-MM-DD), the formats of the event form and
+// Excel date serials
+func parseImportDate(value string) (time.Time, error) {
+	if t, err := parseDate(value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006/01/02", "02/01/2006", "2-Jan-2006", "02-Jan-06", "01-02-06"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		if t, err := excelize.ExcelDateToTime(serial, false); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD)", value)
+}
+
+// parseImportTime accepts HH:MM, HH:MM:SS and Excel time fractions
+func parseImportTime(value string) (*models.TimeOnly, error) {
+	if t, err := parseTime(value); err == nil {
+		return &models.TimeOnly{Time: t}, nil
+	}
+	if t, err := time.Parse("15:04:05", value); err == nil {
+		return &models.TimeOnly{Time: t}, nil
+	}
+	if fraction, err := strconv.ParseFloat(value, 64); err == nil && fraction >= 0 && fraction < 1 {
+		seconds := int(math.Round(fraction * 86400))
+		now := time.Now()
+		return &models.TimeOnly{Time: time.Date(now.Year(), now.Month(), now.Day(), seconds/3600, seconds%3600/60, seconds%60, 0, now.Location())}, nil
+	}
+	return nil, fmt.Errorf("invalid time %q (use HH:MM)", value)
+}
+
+// parseImportCount reads a non-negative whole number; empty cells count as 0
+func parseImportCount(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f != math.Trunc(f) {
+		return 0, fmt.Errorf("%q is not a whole number", value)
+	}
+	return int(f), nil
+}
+
+// mapImportRow turns a sheet row into an event, collecting every problem found
+func mapImportRow(row []string, columns map[string]int, lookups *eventImportLookups) (*models.EventDetails, []string) {
+	var problems []string
+	event := &models.EventDetails{
+		Scale:           importCell(row, columns, importColScale),
+		Theme:           importCell(row, columns, importColTheme),
+		SpiritualOrator: importCell(row, columns, importColOrator),
+		Country:         importCell(row, columns, importColCountry),
+		State:           importCell(row, columns, importColState),
+		City:            importCell(row, columns, importColCity),
+		District:        importCell(row, columns, importColDistrict),
+		PostOffice:      importCell(row, columns, importColPostOffice),
+		Pincode:         importCell(row, columns, importColPincode),
+		Address:         importCell(row, columns, importColAddress),
+		Status:          strings.ToLower(importCell(row, columns, importColStatus)),
+		WorkflowState:   models.WorkflowStateDraft,
+	}
+
+	if name := importCell(row, columns, importColEventType); name != "" {
+		if eventType, ok := lookups.types[importKey(name)]; ok {
+			event.EventTypeID = eventType.ID
+		} else {
+			problems = append(problems, fmt.Sprintf("%s: unknown event type %q", importColEventType, name))
+		}
+	}
+	if name := importCell(row, columns, importColEventCategory); name != "" {
+		candidates := lookups.categories[importKey(name)]
+		for _, category := range candidates {
+			if event.EventTypeID == 0 || category.EventTypeID == event.EventTypeID {
+				event.EventCategoryID = category.ID
+				break
+			}
+		}
+		if event.EventCategoryID == 0 {
+			if len(candidates) == 0 {
+				problems = append(problems, fmt.Sprintf("%s: unknown event category %q", importColEventCategory, name))
+			} else {
+				problems = append(problems, fmt.Sprintf("%s: %q does not belong to the event type", importColEventCategory, name))
+			}
+		}
+	}
+	if name := importCell(row, columns, importColBranch); name != "" {
+		switch candidates := lookups.branches[importKey(name)]; len(candidates) {
+		case 0:
+			problems = append(problems, fmt.Sprintf("%s: unknown branch %q", importColBranch, name))
+		case 1:
+			event.BranchID = &candidates[0].ID
+		default:
+			problems = append(problems, fmt.Sprintf("%s: %q matches %d branches", importColBranch, name, len(candidates)))
+		}
+	}
+	if name := importCell(row, columns, importColLanguage); name != "" {
+		if language, ok := lookups.languages[importKey(name)]; ok {
+			event.Language = language
+		} else {
+			problems = append(problems, fmt.Sprintf("%s: unknown language %q", importColLanguage, name))
+		}
+	}
+
+	if value := importCell(row, columns, importColStartDate); value != "" {
+		date, err := parseImportDate(value)
+		if err != nil {
+			problems = append(problems, importColStartDate+": "+err.Error())
+		}
+		event.StartDate = date
+	}
+	if value := importCell(row, columns, importColEndDate); value != "" {
+		date, err := parseImportDate(value)
+		if err != nil {
+			problems = append(problems, importColEndDate+": "+err.Error())
+		}
+		event.EndDate = date
+	}
+	if value := importCell(row, columns, importColDailyStart); value != "" {
+		t, err := parseImportTime(value)
+		if err != nil {
+			problems = append(problems, importColDailyStart+": "+err.Error())
+		}
+		event.DailyStartTime = t
+	}
+	if value := importCell(row, columns, importColDailyEnd); value != "" {
+		t, err := parseImportTime(value)
+		if err != nil {
+			problems = append(problems, importColDailyEnd+": "+err.Error())
+		}
+		event.DailyEndTime = t
+	}
+
+	counts := []struct {
+		header string
+		target *int
+	}{
+		{importColBenMen, &event.BeneficiaryMen},
+		{importColBenWomen, &event.BeneficiaryWomen},
+		{importColBenChild, &event.BeneficiaryChild},
+		{importColInitMen, &event.InitiationMen},
+		{importColInitWomen, &event.InitiationWomen},
+		{importColInitChild, &event.InitiationChild},
+	}
+	for _, count := range counts {
+		n, err := parseImportCount(importCell(row, columns, count.header))
+		if err != nil {
+			problems = append(problems, count.header+": "+err.Error())
+		}
+		*count.target = n
+	}
+
+	if event.Status == "" {
+		event.Status = "incomplete"
+	}
+
+	// Same rules as events created or edited through the API
+	if len(problems) == 0 {
+		if err := validators.ValidateEventInput(event.EventTypeID, event.EventCategoryID, event.StartDate, event.EndDate); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if err := validators.ValidateEventUpdateFields(map[string]interface{}{
+			"scale":             event.Scale,
+			"theme":             event.Theme,
+			"pincode":           event.Pincode,
+			"status":            event.Status,
+			"spiritual_orator":  event.SpiritualOrator,
+			"beneficiary_men":   float64(event.BeneficiaryMen),
+			"beneficiary_women": float64(event.BeneficiaryWomen),
+			"beneficiary_child": float64(event.BeneficiaryChild),
+			"initiation_men":    float64(event.InitiationMen),
+			"initiation_women":  float64(event.InitiationWomen),
+			"initiation_child":  float64(event.InitiationChild),
+		}); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) == 0 {
+		fields := lookups.customFields[event.EventCategoryID]
+		if err := ApplyEventCustomFields(event, importCustomFieldValues(row, columns, fields)); err != nil {
+			problems = append(problems, importCustomFieldProblems(err, fields)...)
+		}
+	}
+	return event, problems
+}
+
+// importCustomFieldProblems lists the invalid custom field values of a row by column label
+func importCustomFieldProblems(err error, fields []models.EventCustomField) []string {
+	var schemaErr *validators.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return []string{err.Error()}
+	}
+	labels := make(map[string]string, len(fields))
+	for _, field := range fields {
+		labels[field.Key] = field.Label
+	}
+	problems := make([]string, 0, len(schemaErr.Errors))
+	for _, fieldErr := range schemaErr.Errors {
+		key := strings.TrimPrefix(fieldErr.Path, "$.customFields.")
+		label, ok := labels[key]
+		if !ok {
+			label = key
+		}
+		problems = append(problems, label+": "+fieldErr.Reason)
+	}
+	return problems
+}
+
+// ImportEventsFromExcel reads an events sheet in the ExportEventsToExcel layout, validates every
+// row and, unless dryRun is set, creates the valid rows in a single transaction. Invalid rows
+// are reported and skipped.
+func ImportEventsFromExcel(r io.Reader, dryRun bool, actor Actor) (*EventImportResult, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	defer f.Close()
+
+	sheet := "Events"
+	if index, err := f.GetSheetIndex(sheet); err != nil || index < 0 {
+		sheet = f.GetSheetName(f.GetActiveSheetIndex())
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the sheet is empty", ErrInvalidImportFile)
+	}
+
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		if key := importKey(header); key != "" {
+			if _, seen := columns[key]; !seen {
+				columns[key] = i
+			}
+		}
+	}
+	var missing []string
+	for _, header := range importRequiredColumns {
+		if _, ok := columns[importKey(header)]; !ok {
+			missing = append(missing, header)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing columns %s", ErrInvalidImportFile, strings.Join(missing, ", "))
+	}
+
+	lookups, err := loadEventImportLookups()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EventImportResult{DryRun: dryRun, Sheet: sheet, Rows: []EventImportRow{}}
+	var events []*models.EventDetails
+	var eventRows []int
+	for i, row := range rows[1:] {
+		if isBlankImportRow(row) {
+			continue
+		}
+		if result.Total == MaxEventImportRows {
+			return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImportFile, MaxEventImportRows)
+		}
+		result.Total++
+
+		event, problems := mapImportRow(row, columns, lookups)
+		outcome := EventImportRow{Row: i + 2, Valid: len(problems) == 0, Errors: problems, Theme: event.Theme}
+		if outcome.Valid {
+			result.Valid++
+			events = append(events, event)
+			eventRows = append(eventRows, len(result.Rows))
+		} else {
+			result.Invalid++
+		}
+		result.Rows = append(result.Rows, outcome)
+	}
+
+	if dryRun || len(events) == 0 {
+		return result, nil
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for i, event := range events {
+			event.CreatedOn = now
+			event.CreatedBy = actor.Email
+			if err := tx.Omit(clause.Associations).Create(event).Error; err != nil {
+				return fmt.Errorf("row %d: %w", result.Rows[eventRows[i]].Row, err)
+			}
+			if _, err := CaptureEventRevision(tx, event.ID, models.RevisionActionCreate, nil, actor); err != nil {
+				return fmt.Errorf("row %d: %w", result.Rows[eventRows[i]].Row, err)
+			}
+			if err := RecordDomainEvent(tx, eventCreatedDomainEvent(event, EventSourceImport)); err != nil {
+				return fmt.Errorf("row %d: %w", result.Rows[eventRows[i]].Row, err)
+			}
+			result.Rows[eventRows[i]].EventID = event.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = len(events)
+	return result, nil
+}
+
+func isBlankImportRow(row []string) bool {
+	for _, cell := range row {
+		if value := strings.TrimSpace(cell); value != "" && value != "-" {
+			return false
+		}
+	}
+	return true
+}
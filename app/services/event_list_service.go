@@ -0,0 +1,296 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidEventSort   = errors.New("invalid sort field")
+	ErrInvalidEventCursor = errors.New("invalid or expired cursor")
+)
+
+// EventListFilter holds the optional filters of the event listing. Zero values mean "no filter".
+type EventListFilter struct {
+	Status              string
+	WorkflowState       string
+	BranchID            uint
+	IncludeDescendants  bool // also match events of the branch's child branches (any depth)
+	EventTypeIDs        []uint
+	EventCategoryIDs    []uint
+	EventSubCategoryIDs []uint
+	StartDateFrom       *time.Time // inclusive
+	StartDateTo         *time.Time // exclusive
+	EndDateFrom         *time.Time // inclusive
+	EndDateTo           *time.Time // exclusive
+	State               string
+	City                string
+	District            string
+	Language            string
+	SpiritualOrator     string // substring match
+	Scale               string
+	SeriesID            uint // occurrences of a recurring series
+}
+
+// EventSort is one column of a multi-column sort
+type EventSort struct {
+	Field string
+	Desc  bool
+}
+
+// EventListResult is a page of events
+type EventListResult struct {
+	Data       []models.EventDetails `json:"data"`
+	Total      int64                 `json:"total"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+	HasMore    bool                  `json:"has_more"`
+}
+
+// eventSortColumns maps the sortable fields to their SQL expression and the value used in cursors.
+// Text columns are coalesced so keyset comparisons never meet NULL.
+var eventSortColumns = map[string]struct {
+	expr  string
+	value func(e *models.EventDetails) interface{}
+}{
+	"start_date":       {"start_date", func(e *models.EventDetails) interface{} { return e.StartDate }},
+	"end_date":         {"end_date", func(e *models.EventDetails) interface{} { return e.EndDate }},
+	"created_on":       {"created_on", func(e *models.EventDetails) interface{} { return e.CreatedOn }},
+	"theme":            {"COALESCE(theme, '')", func(e *models.EventDetails) interface{} { return e.Theme }},
+	"state":            {"COALESCE(state, '')", func(e *models.EventDetails) interface{} { return e.State }},
+	"city":             {"COALESCE(city, '')", func(e *models.EventDetails) interface{} { return e.City }},
+	"district":         {"COALESCE(district, '')", func(e *models.EventDetails) interface{} { return e.District }},
+	"language":         {"COALESCE(language, '')", func(e *models.EventDetails) interface{} { return e.Language }},
+	"spiritual_orator": {"COALESCE(spiritual_orator, '')", func(e *models.EventDetails) interface{} { return e.SpiritualOrator }},
+	"scale":            {"COALESCE(scale, '')", func(e *models.EventDetails) interface{} { return e.Scale }},
+	"id":               {"id", func(e *models.EventDetails) interface{} { return e.ID }},
+}
+
+// eventListCursor is the decoded form of EventListResult.NextCursor
+type eventListCursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// ParseEventSort parses a sort parameter such as "-start_date,theme" (a leading '-' sorts descending)
+func ParseEventSort(param string) ([]EventSort, error) {
+	var sorts []EventSort
+	seen := map[string]bool{}
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		sort := EventSort{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := eventSortColumns[sort.Field]; !ok || seen[sort.Field] {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEventSort, sort.Field)
+		}
+		seen[sort.Field] = true
+		sorts = append(sorts, sort)
+	}
+	if len(sorts) == 0 {
+		sorts = []EventSort{{Field: "created_on", Desc: true}}
+	}
+	// The ID makes the ordering total, which keyset pagination requires
+	if !seen["id"] {
+		sorts = append(sorts, EventSort{Field: "id", Desc: sorts[0].Desc})
+	}
+	return sorts, nil
+}
+
+// ListEvents returns one page of events matching filter, ordered by sorts, after cursor
+// (keyset pagination). Total is the number of matching events across all pages.
+func ListEvents(filter EventListFilter, sorts []EventSort, limit int, cursor string) (*EventListResult, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	query := applyEventListFilter(config.DB.Model(&models.EventDetails{}), filter)
+
+	result := &EventListResult{Data: []models.EventDetails{}}
+	if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	sortKey := eventSortKey(sorts)
+	if cursor != "" {
+		values, err := decodeEventListCursor(cursor, sortKey, len(sorts))
+		if err != nil {
+			return nil, err
+		}
+		clause, args := keysetCondition(sorts, values)
+		query = query.Where(clause, args...)
+	}
+
+	orders := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		orders = append(orders, eventSortColumns[sort.Field].expr+" "+direction)
+	}
+
+	var events []models.EventDetails
+	if err := query.
+		Preload("EventType").
+		Preload("EventCategory").
+		Preload("EventSubCategory").
+		Preload("Branch").
+		Order(strings.Join(orders, ", ")).
+		Limit(limit + 1).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	if len(events) > limit {
+		events = events[:limit]
+		result.HasMore = true
+		result.NextCursor = encodeEventListCursor(sortKey, sorts, &events[len(events)-1])
+	}
+	result.Data = events
+
+	return result, nil
+}
+
+// applyEventListFilter adds the WHERE clauses of filter to db
+func applyEventListFilter(db *gorm.DB, filter EventListFilter) *gorm.DB {
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.WorkflowState != "" {
+		db = db.Where("workflow_state = ?", filter.WorkflowState)
+	}
+	if filter.BranchID > 0 {
+		// Events led or co-hosted by the branch
+		if filter.IncludeDescendants {
+			branchTree := `WITH RECURSIVE branch_tree AS (
+					SELECT id FROM branches WHERE id = ? AND deleted_at IS NULL
+					UNION ALL
+					SELECT b.id FROM branches b JOIN branch_tree t ON b.parent_branch_id = t.id WHERE b.deleted_at IS NULL
+				)
+				SELECT id FROM branch_tree`
+			db = db.Where("(branch_id IN ("+branchTree+") OR id IN (SELECT event_id FROM event_cohost_branches WHERE branch_id IN ("+branchTree+")))",
+				filter.BranchID, filter.BranchID)
+		} else {
+			db = db.Where("(branch_id = ? OR id IN (SELECT event_id FROM event_cohost_branches WHERE branch_id = ?))",
+				filter.BranchID, filter.BranchID)
+		}
+	}
+	if len(filter.EventTypeIDs) > 0 {
+		db = db.Where("event_type_id IN ?", filter.EventTypeIDs)
+	}
+	if len(filter.EventCategoryIDs) > 0 {
+		db = db.Where("event_category_id IN ?", filter.EventCategoryIDs)
+	}
+	if len(filter.EventSubCategoryIDs) > 0 {
+		db = db.Where("event_sub_category_id IN ?", filter.EventSubCategoryIDs)
+	}
+	if filter.StartDateFrom != nil {
+		db = db.Where("start_date >= ?", *filter.StartDateFrom)
+	}
+	if filter.StartDateTo != nil {
+		db = db.Where("start_date < ?", *filter.StartDateTo)
+	}
+	if filter.EndDateFrom != nil {
+		db = db.Where("end_date >= ?", *filter.EndDateFrom)
+	}
+	if filter.EndDateTo != nil {
+		db = db.Where("end_date < ?", *filter.EndDateTo)
+	}
+	if filter.State != "" {
+		db = db.Where("LOWER(state) = LOWER(?)", filter.State)
+	}
+	if filter.City != "" {
+		db = db.Where("LOWER(city) = LOWER(?)", filter.City)
+	}
+	if filter.District != "" {
+		db = db.Where("LOWER(district) = LOWER(?)", filter.District)
+	}
+	if filter.Language != "" {
+		db = db.Where("LOWER(language) = LOWER(?)", filter.Language)
+	}
+	if filter.SpiritualOrator != "" {
+		db = db.Where("spiritual_orator ILIKE ?", "%"+filter.SpiritualOrator+"%")
+	}
+	if filter.Scale != "" {
+		db = db.Where("LOWER(scale) = LOWER(?)", filter.Scale)
+	}
+	if filter.SeriesID > 0 {
+		db = db.Where("series_id = ?", filter.SeriesID)
+	}
+	return db
+}
+
+// keysetCondition builds the "after this row" condition for a multi-column sort with mixed
+// directions: (a > x) OR (a = x AND b < y) OR (a = x AND b = y AND id > z) ...
+func keysetCondition(sorts []EventSort, values []interface{}) (string, []interface{}) {
+	var disjuncts []string
+	var args []interface{}
+	for i := range sorts {
+		var conjuncts []string
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, eventSortColumns[sorts[j].Field].expr+" = ?")
+			args = append(args, values[j])
+		}
+		operator := ">"
+		if sorts[i].Desc {
+			operator = "<"
+		}
+		conjuncts = append(conjuncts, eventSortColumns[sorts[i].Field].expr+" "+operator+" ?")
+		args = append(args, values[i])
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args
+}
+
+// eventSortKey renders sorts back to their parameter form; cursors are only valid for the same sort
+func eventSortKey(sorts []EventSort) string {
+	parts := make([]string, 0, len(sorts))
+	for _, sort := range sorts {
+		if sort.Desc {
+			parts = append(parts, "-"+sort.Field)
+		} else {
+			parts = append(parts, sort.Field)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeEventListCursor(sortKey string, sorts []EventSort, last *models.EventDetails) string {
+	cursor := eventListCursor{Sort: sortKey}
+	for _, sort := range sorts {
+		value := eventSortColumns[sort.Field].value(last)
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.RFC3339Nano)
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeEventListCursor(encoded, sortKey string, size int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidEventCursor
+	}
+	var cursor eventListCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidEventCursor
+	}
+	if cursor.Sort != sortKey || len(cursor.Values) != size {
+		return nil, ErrInvalidEventCursor
+	}
+	return cursor.Values, nil
+}
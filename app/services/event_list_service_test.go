@@ -0,0 +1,161 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+)
+
+func TestEventListCursorRoundTrip(t *testing.T) {
+	createdOn := time.Date(2026, time.March, 1, 10, 30, 15, 500, time.FixedZone("IST", 5*3600+1800))
+	last := &models.EventDetails{ID: 42, Theme: "Health camp", CreatedOn: createdOn}
+
+	tests := []struct {
+		name  string
+		param string
+		want  []interface{}
+	}{
+		{
+			name:  "default sort",
+			param: "",
+			want:  []interface{}{"2026-03-01T10:30:15.0000005+05:30", float64(42)},
+		},
+		{
+			name:  "mixed directions",
+			param: "theme,-created_on",
+			want:  []interface{}{"Health camp", "2026-03-01T10:30:15.0000005+05:30", float64(42)},
+		},
+		{
+			name:  "explicit id",
+			param: "-id",
+			want:  []interface{}{float64(42)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorts, err := ParseEventSort(tt.param)
+			if err != nil {
+				t.Fatalf("ParseEventSort(%q) error: %v", tt.param, err)
+			}
+			sortKey := eventSortKey(sorts)
+			encoded := encodeEventListCursor(sortKey, sorts, last)
+
+			got, err := decodeEventListCursor(encoded, sortKey, len(sorts))
+			if err != nil {
+				t.Fatalf("decodeEventListCursor error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decoded values = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeEventListCursorInvalid(t *testing.T) {
+	sorts, err := ParseEventSort("-start_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sortKey := eventSortKey(sorts)
+	valid := encodeEventListCursor(sortKey, sorts, &models.EventDetails{ID: 7})
+
+	tests := []struct {
+		name    string
+		encoded string
+		sortKey string
+		size    int
+	}{
+		{"not base64", "%%%", sortKey, len(sorts)},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("not json")), sortKey, len(sorts)},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"s":"-start_date,-id","v":["x",1]}`)), sortKey, len(sorts)},
+		{"other sort", valid, "start_date,id", len(sorts)},
+		{"too few values", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"-start_date,-id","v":[1]}`)), sortKey, len(sorts)},
+		{"too many values", valid, sortKey, len(sorts) + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeEventListCursor(tt.encoded, tt.sortKey, tt.size); !errors.Is(err, ErrInvalidEventCursor) {
+				t.Errorf("decodeEventListCursor error = %v, want ErrInvalidEventCursor", err)
+			}
+		})
+	}
+}
+
+func TestParseEventSort(t *testing.T) {
+	tests := []struct {
+		param   string
+		want    []EventSort
+		wantErr bool
+	}{
+		{param: "", want: []EventSort{{Field: "created_on", Desc: true}, {Field: "id", Desc: true}}},
+		{param: "theme", want: []EventSort{{Field: "theme"}, {Field: "id"}}},
+		{param: " -start_date , city ", want: []EventSort{{Field: "start_date", Desc: true}, {Field: "city"}, {Field: "id", Desc: true}}},
+		{param: "id,theme", want: []EventSort{{Field: "id"}, {Field: "theme"}}},
+		{param: "unknown", wantErr: true},
+		{param: "theme,-theme", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			got, err := ParseEventSort(tt.param)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEventSort) {
+					t.Errorf("ParseEventSort(%q) error = %v, want ErrInvalidEventSort", tt.param, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEventSort(%q) error: %v", tt.param, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseEventSort(%q) = %v, want %v", tt.param, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		sorts    []EventSort
+		values   []interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "single ascending column",
+			sorts:    []EventSort{{Field: "id"}},
+			values:   []interface{}{float64(5)},
+			wantSQL:  "((id > ?))",
+			wantArgs: []interface{}{float64(5)},
+		},
+		{
+			name:     "descending with tie breaker",
+			sorts:    []EventSort{{Field: "created_on", Desc: true}, {Field: "id", Desc: true}},
+			values:   []interface{}{"2026-03-01T00:00:00Z", float64(9)},
+			wantSQL:  "((created_on < ?) OR (created_on = ? AND id < ?))",
+			wantArgs: []interface{}{"2026-03-01T00:00:00Z", "2026-03-01T00:00:00Z", float64(9)},
+		},
+		{
+			name:     "mixed directions over coalesced text",
+			sorts:    []EventSort{{Field: "theme"}, {Field: "start_date", Desc: true}, {Field: "id"}},
+			values:   []interface{}{"Camp", "2026-01-01T00:00:00Z", float64(3)},
+			wantSQL:  "((COALESCE(theme, '') > ?) OR (COALESCE(theme, '') = ? AND start_date < ?) OR (COALESCE(theme, '') = ? AND start_date = ? AND id > ?))",
+			wantArgs: []interface{}{"Camp", "Camp", "2026-01-01T00:00:00Z", "Camp", "2026-01-01T00:00:00Z", float64(3)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := keysetCondition(tt.sorts, tt.values)
+			if sql != tt.wantSQL {
+				t.Errorf("condition = %s, want %s", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", args, tt.wantArgs)
+			}
+		})
+	}
+}
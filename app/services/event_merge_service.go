@@ -0,0 +1,580 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var ErrInvalidMerge = errors.New("invalid merge request")
+
+// maxMergeDuplicates bounds the number of events absorbed by one merge
+const maxMergeDuplicates = 20
+
+// mergeSkipColumns are event columns that always keep the survivor's value
+var mergeSkipColumns = map[string]bool{
+	"id": true, "created_on": true, "created_by": true, "updated_on": true, "updated_by": true,
+	"deleted_at": true, "deleted_by": true, "workflow_state": true, "series_id": true, "occurrence_date": true,
+	"version": true,
+}
+
+// mergeChildSkipColumns are left out when comparing child rows for exact duplicates
+var mergeChildSkipColumns = map[string]bool{
+	"id": true, "event_id": true, "branch_id": true, "created_on": true, "created_by": true,
+	"updated_on": true, "updated_by": true, "deleted_at": true, "deleted_by": true,
+	// media files are compared by content instead, see mediaFileIdentities
+	"s3_key": true, "thumbnail_s3_key": true, "file_url": true,
+}
+
+// EventMergeRequest merges DuplicateIDs into SurvivorID. Fields picks, per event column, the
+// event whose value the survivor keeps. Conflicting columns that are not picked keep the
+// survivor's value; columns empty on the survivor are filled from the first duplicate that has one.
+type EventMergeRequest struct {
+	SurvivorID   uint            `json:"survivor_id"`
+	DuplicateIDs []uint          `json:"duplicate_ids"`
+	Fields       map[string]uint `json:"fields,omitempty"`
+}
+
+// EventMergeValue is the value of a column on one of the merged events
+type EventMergeValue struct {
+	EventID uint        `json:"event_id"`
+	Value   interface{} `json:"value"`
+}
+
+// EventMergeConflict is a column set to different values on the merged events
+type EventMergeConflict struct {
+	Field  string            `json:"field"`
+	Values []EventMergeValue `json:"values"`
+}
+
+// EventMergePreview shows what a merge would do before it is carried out
+type EventMergePreview struct {
+	SurvivorID   uint                 `json:"survivor_id"`
+	DuplicateIDs []uint               `json:"duplicate_ids"`
+	Conflicts    []EventMergeConflict `json:"conflicts"`
+	Fields       map[string]uint      `json:"fields"`  // column -> event the survivor would take the value from
+	Moved        map[string]int       `json:"moved"`   // child rows that would be re-parented, per section
+	Removed      map[string]int       `json:"removed"` // exact-duplicate child rows that would be dropped, per section
+}
+
+// EventMergeResult summarises a completed merge
+type EventMergeResult struct {
+	SurvivorID uint            `json:"survivor_id"`
+	MergedIDs  []uint          `json:"merged_ids"`
+	Fields     map[string]uint `json:"fields"`
+	Moved      map[string]int  `json:"moved"`
+	Removed    map[string]int  `json:"removed"`
+	RevisionID uint            `json:"revision_id"`
+	Conflicts  int             `json:"conflicts"`
+}
+
+// childMergePlan is the outcome of comparing the child rows of one section
+type childMergePlan struct {
+	moveIDs    []uint
+	removeIDs  []uint
+	hardDelete []uint // removed rows whose S3 objects are still used by a kept row
+	movedBy    map[uint]int
+	removedBy  map[uint]int
+}
+
+// eventMergePlan holds everything a merge changes
+type eventMergePlan struct {
+	updates   map[string]interface{}
+	taken     map[string]uint
+	conflicts []EventMergeConflict
+	sections  map[string]*childMergePlan
+}
+
+// validateMergeRequest normalises the duplicate IDs and checks the request shape
+func validateMergeRequest(req *EventMergeRequest) error {
+	if req.SurvivorID == 0 {
+		return fmt.Errorf("%w: survivor_id is required", ErrInvalidMerge)
+	}
+	seen := map[uint]bool{}
+	ids := make([]uint, 0, len(req.DuplicateIDs))
+	for _, id := range req.DuplicateIDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		if id == req.SurvivorID {
+			return fmt.Errorf("%w: the survivor cannot also be a duplicate", ErrInvalidMerge)
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("%w: at least one duplicate_id is required", ErrInvalidMerge)
+	}
+	if len(ids) > maxMergeDuplicates {
+		return fmt.Errorf("%w: at most %d duplicates can be merged at once", ErrInvalidMerge, maxMergeDuplicates)
+	}
+	req.DuplicateIDs = ids
+
+	for column, eventID := range req.Fields {
+		if eventID != req.SurvivorID && !seen[eventID] {
+			return fmt.Errorf("%w: field %s refers to event %d, which is not part of the merge", ErrInvalidMerge, column, eventID)
+		}
+	}
+	return nil
+}
+
+// loadMergeEvents reads the survivor and duplicates in request order, locking them when tx is a transaction
+func loadMergeEvents(tx *gorm.DB, req EventMergeRequest, lock bool) (*models.EventDetails, []models.EventDetails, error) {
+	ids := append([]uint{req.SurvivorID}, req.DuplicateIDs...)
+	db := tx.Where("id IN ?", ids).Order("id")
+	if lock {
+		db = db.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var events []models.EventDetails
+	if err := db.Find(&events).Error; err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[uint]models.EventDetails, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+
+	survivor, ok := byID[req.SurvivorID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: event %d", ErrEventNotFound, req.SurvivorID)
+	}
+	duplicates := make([]models.EventDetails, 0, len(req.DuplicateIDs))
+	for _, id := range req.DuplicateIDs {
+		event, ok := byID[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: event %d", ErrEventNotFound, id)
+		}
+		duplicates = append(duplicates, event)
+	}
+	return &survivor, duplicates, nil
+}
+
+// resolveMergeFields decides the survivor's column values and reports the conflicting columns
+func resolveMergeFields(ctx context.Context, sch *schema.Schema, survivor *models.EventDetails, duplicates []models.EventDetails, choices map[string]uint) (map[string]interface{}, map[string]uint, []EventMergeConflict, error) {
+	byColumn := make(map[string]uint, len(choices))
+	for column, source := range choices {
+		field := sch.LookUpField(column)
+		if field == nil || field.DBName == "" || mergeSkipColumns[field.DBName] {
+			return nil, nil, nil, fmt.Errorf("%w: field %s cannot be merged", ErrInvalidMerge, column)
+		}
+		byColumn[field.DBName] = source
+	}
+	choices = byColumn
+
+	events := make(map[uint]reflect.Value, len(duplicates)+1)
+	events[survivor.ID] = reflect.ValueOf(survivor).Elem()
+	for i := range duplicates {
+		events[duplicates[i].ID] = reflect.ValueOf(&duplicates[i]).Elem()
+	}
+	order := []uint{survivor.ID}
+	for _, event := range duplicates {
+		order = append(order, event.ID)
+	}
+
+	updates := map[string]interface{}{}
+	taken := map[string]uint{}
+	conflicts := []EventMergeConflict{}
+	for _, field := range sch.Fields {
+		if field.DBName == "" || mergeSkipColumns[field.DBName] {
+			continue
+		}
+
+		var values []EventMergeValue
+		conflict := false
+		for _, id := range order {
+			value, zero := field.ValueOf(ctx, events[id])
+			if zero {
+				continue
+			}
+			if len(values) > 0 && !mergeValuesEqual(values[0].Value, value) {
+				conflict = true
+			}
+			values = append(values, EventMergeValue{EventID: id, Value: value})
+		}
+		if conflict {
+			conflicts = append(conflicts, EventMergeConflict{Field: field.DBName, Values: values})
+		}
+
+		if source, ok := choices[field.DBName]; ok {
+			if source != survivor.ID {
+				value, _ := field.ValueOf(ctx, events[source])
+				updates[field.DBName] = value
+				taken[field.DBName] = source
+			}
+			continue
+		}
+		if _, zero := field.ValueOf(ctx, events[survivor.ID]); zero && len(values) > 0 {
+			updates[field.DBName] = values[0].Value
+			taken[field.DBName] = values[0].EventID
+		}
+	}
+	return updates, taken, conflicts, nil
+}
+
+// mergeValuesEqual compares two column values; times are compared by instant
+func mergeValuesEqual(a, b interface{}) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// mediaFileIdentities fingerprints the S3 objects of the events' media by content. Objects that
+// cannot be looked up are identified by their key only.
+func mediaFileIdentities(ctx context.Context, eventIDs []uint) map[string]string {
+	var keys []string
+	if err := config.DB.Model(&models.EventMedia{}).
+		Where("event_id IN ? AND s3_key <> ''", eventIDs).
+		Distinct().Pluck("s3_key", &keys).Error; err != nil {
+		log.Printf("Warning: Failed to load media keys for merge: %v", err)
+		return nil
+	}
+	identities := make(map[string]string, len(keys))
+	for _, key := range keys {
+		fingerprint, err := GetObjectFingerprint(ctx, key)
+		if err != nil {
+			continue
+		}
+		identities[key] = "file:" + fingerprint
+	}
+	return identities
+}
+
+// childRowFingerprint identifies a child row by its content columns
+func childRowFingerprint(ctx context.Context, sch *schema.Schema, row reflect.Value, extra string) string {
+	values := make([]interface{}, 0, len(sch.Fields)+1)
+	for _, field := range sch.Fields {
+		if field.DBName == "" || mergeChildSkipColumns[field.DBName] {
+			continue
+		}
+		value, _ := field.ValueOf(ctx, row)
+		values = append(values, value)
+	}
+	values = append(values, extra)
+	raw, _ := json.Marshal(values)
+	return string(raw)
+}
+
+// planChildMerge loads the child rows of the survivor and the duplicates and plans their merge
+func planChildMerge[T any](ctx context.Context, tx *gorm.DB, survivorID uint, duplicateIDs []uint, identity func(*T) string, sharesObject func(kept []*T, row *T) bool) (*childMergePlan, error) {
+	var rows []T
+	if err := tx.Where("event_id IN ?", append([]uint{survivorID}, duplicateIDs...)).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	sch, err := parseModelSchema(tx, new(T))
+	if err != nil {
+		return nil, err
+	}
+	return planChildRows(ctx, sch, rows, survivorID, duplicateIDs, identity, sharesObject), nil
+}
+
+// planChildRows walks the survivor's rows first, then the duplicates' in request order, and
+// marks every duplicate row as moved, or removed when an identical row is already kept
+func planChildRows[T any](ctx context.Context, sch *schema.Schema, rows []T, survivorID uint, duplicateIDs []uint, identity func(*T) string, sharesObject func(kept []*T, row *T) bool) *childMergePlan {
+	eventField := sch.LookUpField("event_id")
+	idField := sch.LookUpField("id")
+
+	rank := map[uint]int{survivorID: 0}
+	for i, id := range duplicateIDs {
+		rank[id] = i + 1
+	}
+	eventOf := func(row *T) uint {
+		value, _ := eventField.ValueOf(ctx, reflect.ValueOf(row).Elem())
+		id, _ := toUint(value)
+		return id
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rank[eventOf(&rows[i])] < rank[eventOf(&rows[j])] })
+
+	plan := &childMergePlan{movedBy: map[uint]int{}, removedBy: map[uint]int{}}
+	seen := map[string][]*T{}
+	for i := range rows {
+		row := &rows[i]
+		value := reflect.ValueOf(row).Elem()
+		extra := ""
+		if identity != nil {
+			extra = identity(row)
+		}
+		fingerprint := childRowFingerprint(ctx, sch, value, extra)
+		eventID := eventOf(row)
+		rowIDValue, _ := idField.ValueOf(ctx, value)
+		rowID, _ := toUint(rowIDValue)
+
+		if kept := seen[fingerprint]; len(kept) > 0 && eventID != survivorID {
+			plan.removeIDs = append(plan.removeIDs, rowID)
+			plan.removedBy[eventID]++
+			if sharesObject != nil && sharesObject(kept, row) {
+				plan.hardDelete = append(plan.hardDelete, rowID)
+			}
+			continue
+		}
+		seen[fingerprint] = append(seen[fingerprint], row)
+		if eventID != survivorID {
+			plan.moveIDs = append(plan.moveIDs, rowID)
+			plan.movedBy[eventID]++
+		}
+	}
+	return plan
+}
+
+// planEventMerge works out the field values and child row changes of a merge
+func planEventMerge(ctx context.Context, tx *gorm.DB, req EventMergeRequest, survivor *models.EventDetails, duplicates []models.EventDetails, fileIdentities map[string]string) (*eventMergePlan, error) {
+	sch, err := parseModelSchema(tx, &models.EventDetails{})
+	if err != nil {
+		return nil, err
+	}
+	updates, taken, conflicts, err := resolveMergeFields(ctx, sch, survivor, duplicates, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	plan := &eventMergePlan{updates: updates, taken: taken, conflicts: conflicts, sections: map[string]*childMergePlan{}}
+
+	if plan.sections["special_guests"], err = planChildMerge[models.SpecialGuest](ctx, tx, req.SurvivorID, req.DuplicateIDs, nil, nil); err != nil {
+		return nil, err
+	}
+	if plan.sections["volunteers"], err = planChildMerge[models.Volunteer](ctx, tx, req.SurvivorID, req.DuplicateIDs, nil, nil); err != nil {
+		return nil, err
+	}
+	mediaIdentity := func(row *models.EventMedia) string {
+		if id, ok := fileIdentities[row.S3Key]; ok {
+			return id
+		}
+		return "key:" + row.S3Key
+	}
+	mediaSharesObject := func(kept []*models.EventMedia, row *models.EventMedia) bool {
+		for _, k := range kept {
+			if row.S3Key != "" && k.S3Key == row.S3Key {
+				return true
+			}
+			if row.ThumbnailS3Key != nil && k.ThumbnailS3Key != nil && *row.ThumbnailS3Key != "" && *k.ThumbnailS3Key == *row.ThumbnailS3Key {
+				return true
+			}
+		}
+		return false
+	}
+	if plan.sections["media"], err = planChildMerge[models.EventMedia](ctx, tx, req.SurvivorID, req.DuplicateIDs, mediaIdentity, mediaSharesObject); err != nil {
+		return nil, err
+	}
+	if plan.sections["donations"], err = planChildMerge[models.Donation](ctx, tx, req.SurvivorID, req.DuplicateIDs, nil, nil); err != nil {
+		return nil, err
+	}
+	if plan.sections["promotion_materials"], err = planChildMerge[models.PromotionMaterialDetails](ctx, tx, req.SurvivorID, req.DuplicateIDs, nil, nil); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// sectionTotals adds up the moved or removed counts of every section
+func (p *eventMergePlan) sectionTotals(removed bool) map[string]int {
+	totals := map[string]int{}
+	for section, child := range p.sections {
+		if removed {
+			totals[section] = len(child.removeIDs)
+		} else {
+			totals[section] = len(child.moveIDs)
+		}
+	}
+	return totals
+}
+
+// PreviewEventMerge reports the conflicting fields and the child rows a merge would move or drop
+func PreviewEventMerge(req EventMergeRequest) (*EventMergePreview, error) {
+	if err := validateMergeRequest(&req); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	survivor, duplicates, err := loadMergeEvents(config.DB, req, false)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planEventMerge(ctx, config.DB, req, survivor, duplicates, mediaFileIdentities(ctx, append([]uint{req.SurvivorID}, req.DuplicateIDs...)))
+	if err != nil {
+		return nil, err
+	}
+	return &EventMergePreview{
+		SurvivorID:   req.SurvivorID,
+		DuplicateIDs: req.DuplicateIDs,
+		Conflicts:    plan.conflicts,
+		Fields:       plan.taken,
+		Moved:        plan.sectionTotals(false),
+		Removed:      plan.sectionTotals(true),
+	}, nil
+}
+
+// MergeEvents merges duplicate events into a surviving event in one transaction: the chosen field
+// values are copied to the survivor, the duplicates' special guests, volunteers, media, donations
+// and promotion materials are re-parented to it (exact duplicates of rows it already has are
+// dropped), the duplicates are moved to the trash and the merge is recorded in the survivor's
+// revision history and in event_merges. S3 objects are not copied; re-parented media keep their keys.
+func MergeEvents(req EventMergeRequest, actor Actor) (*EventMergeResult, error) {
+	if err := validateMergeRequest(&req); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	fileIdentities := mediaFileIdentities(ctx, append([]uint{req.SurvivorID}, req.DuplicateIDs...))
+
+	result := &EventMergeResult{SurvivorID: req.SurvivorID, MergedIDs: req.DuplicateIDs}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		survivor, duplicates, err := loadMergeEvents(tx, req, true)
+		if err != nil {
+			return err
+		}
+
+		// Keep the pre-merge state of every event restorable
+		for _, id := range append([]uint{req.SurvivorID}, req.DuplicateIDs...) {
+			if err := EnsureBaselineRevision(tx, id, actor); err != nil {
+				return err
+			}
+		}
+
+		plan, err := planEventMerge(ctx, tx, req, survivor, duplicates, fileIdentities)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"updated_on": now, "updated_by": actor.Email}
+		for column, value := range plan.updates {
+			updates[column] = value
+		}
+		for _, child := range plan.sections {
+			if len(child.moveIDs) > 0 || len(child.removeIDs) > 0 {
+				// The related rows are part of the event: a change of only them still moves its version
+				updates["version"] = gorm.Expr("version + 1")
+				break
+			}
+		}
+		// Beneficiary counts of events with day-wise attendance are derived from it
+		if err := dropAttendanceDerivedCounts(tx, req.SurvivorID, updates); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.EventDetails{}).Where("id = ?", req.SurvivorID).UpdateColumns(updates).Error; err != nil {
+			return fmt.Errorf("failed to update survivor: %w", err)
+		}
+
+		// Rows dropped as duplicates go to the trash together with their event
+		deletedAt := trashTimestamp()
+		sectionModels := map[string]interface{}{
+			"special_guests":      &models.SpecialGuest{},
+			"volunteers":          &models.Volunteer{},
+			"media":               &models.EventMedia{},
+			"donations":           &models.Donation{},
+			"promotion_materials": &models.PromotionMaterialDetails{},
+		}
+		for section, child := range plan.sections {
+			model := sectionModels[section]
+			if len(child.moveIDs) > 0 {
+				moved := map[string]interface{}{"event_id": req.SurvivorID, "updated_by": actor.Email}
+				if section == "donations" && survivor.BranchID != nil {
+					moved["branch_id"] = *survivor.BranchID
+				}
+				if err := tx.Model(model).Where("id IN ?", child.moveIDs).UpdateColumns(moved).Error; err != nil {
+					return fmt.Errorf("failed to move %s: %w", section, err)
+				}
+			}
+			if len(child.removeIDs) == 0 {
+				continue
+			}
+			// Promotion materials have no trash; removed media sharing an S3 object with a kept
+			// row are deleted for good so purging the trash never removes a file still in use
+			hard := child.hardDelete
+			if section == "promotion_materials" {
+				hard = child.removeIDs
+			}
+			if len(hard) > 0 {
+				if err := tx.Unscoped().Where("id IN ?", hard).Delete(model).Error; err != nil {
+					return fmt.Errorf("failed to remove duplicate %s: %w", section, err)
+				}
+			}
+			if section != "promotion_materials" {
+				if _, err := softDeleteWhere(tx, model, actor.Email, deletedAt, "id IN ?", child.removeIDs); err != nil {
+					return fmt.Errorf("failed to remove duplicate %s: %w", section, err)
+				}
+			}
+		}
+
+		if _, err := softDeleteWhere(tx, &models.EventDetails{}, actor.Email, deletedAt, "id IN ?", req.DuplicateIDs); err != nil {
+			return fmt.Errorf("failed to delete merged events: %w", err)
+		}
+		if err := recordEventsDeleted(tx, req.DuplicateIDs, actor.Email); err != nil {
+			return err
+		}
+		if err := tx.Where("event_id IN ? OR duplicate_event_id IN ?", req.DuplicateIDs, req.DuplicateIDs).
+			Delete(&models.EventDuplicateSuspect{}).Error; err != nil {
+			return err
+		}
+
+		if _, ok := plan.updates["branch_id"]; ok {
+			if err := dropLeadBranchCoHosts(tx, []uint{req.SurvivorID}, actor); err != nil {
+				return err
+			}
+		}
+
+		revision, err := CaptureEventRevision(tx, req.SurvivorID, models.RevisionActionMerge, nil, actor)
+		if err != nil {
+			return err
+		}
+
+		merges := make([]models.EventMerge, 0, len(req.DuplicateIDs))
+		for _, id := range req.DuplicateIDs {
+			fields := models.JSONB{}
+			for column, source := range plan.taken {
+				if source == id {
+					fields[column] = plan.updates[column]
+				}
+			}
+			moved, removed := models.JSONB{}, models.JSONB{}
+			for section, child := range plan.sections {
+				moved[section] = child.movedBy[id]
+				removed[section] = child.removedBy[id]
+			}
+			merges = append(merges, models.EventMerge{
+				SurvivorEventID:    req.SurvivorID,
+				MergedEventID:      id,
+				SurvivorRevisionID: &revision.ID,
+				Fields:             fields,
+				Moved:              moved,
+				Removed:            removed,
+				ActorID:            actor.UserID,
+				ActorEmail:         actor.Email,
+				MergedOn:           now,
+			})
+		}
+		if err := tx.Create(&merges).Error; err != nil {
+			return err
+		}
+
+		result.Fields = plan.taken
+		result.Moved = plan.sectionTotals(false)
+		result.Removed = plan.sectionTotals(true)
+		result.RevisionID = revision.ID
+		result.Conflicts = len(plan.conflicts)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetEventMerges lists the merges an event took part in, as survivor or as merged duplicate, newest first
+func GetEventMerges(eventID uint) ([]models.EventMerge, error) {
+	var merges []models.EventMerge
+	err := config.DB.
+		Where("survivor_event_id = ? OR merged_event_id = ?", eventID, eventID).
+		Order("merged_on DESC, id DESC").
+		Find(&merges).Error
+	return merges, err
+}
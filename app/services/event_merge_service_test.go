@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"gorm.io/gorm/schema"
+)
+
+func mustParseSchema(t *testing.T, model interface{}) *schema.Schema {
+	t.Helper()
+	sch, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sch
+}
+
+func TestChildRowFingerprint(t *testing.T) {
+	ctx := context.Background()
+	sch := mustParseSchema(t, &models.Donation{})
+	base := models.Donation{ID: 1, EventID: 10, BranchID: 3, DonationType: "cash", Amount: 500,
+		CreatedOn: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), CreatedBy: "a@example.com"}
+
+	tests := []struct {
+		name      string
+		other     models.Donation
+		extra     string
+		wantEqual bool
+	}{
+		{
+			name: "bookkeeping columns are ignored",
+			other: models.Donation{ID: 2, EventID: 11, BranchID: 4, DonationType: "cash", Amount: 500,
+				CreatedOn: time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), CreatedBy: "b@example.com", UpdatedBy: "c@example.com"},
+			wantEqual: true,
+		},
+		{
+			name:  "content columns differ",
+			other: models.Donation{ID: 2, EventID: 11, BranchID: 3, DonationType: "cash", Amount: 501},
+		},
+		{
+			name:  "extra identity differs",
+			other: base,
+			extra: "file:abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := childRowFingerprint(ctx, sch, reflect.ValueOf(&base).Elem(), "")
+			b := childRowFingerprint(ctx, sch, reflect.ValueOf(&tt.other).Elem(), tt.extra)
+			if (a == b) != tt.wantEqual {
+				t.Errorf("fingerprints equal = %v, want %v\n%s\n%s", a == b, tt.wantEqual, a, b)
+			}
+		})
+	}
+}
+
+func TestPlanChildRows(t *testing.T) {
+	ctx := context.Background()
+	donationSchema := mustParseSchema(t, &models.Donation{})
+	donation := func(id, eventID uint, kind string, amount float64) models.Donation {
+		return models.Donation{ID: id, EventID: eventID, DonationType: kind, Amount: amount}
+	}
+
+	tests := []struct {
+		name         string
+		rows         []models.Donation
+		duplicateIDs []uint
+		want         childMergePlan
+	}{
+		{
+			name: "identical rows of duplicates are removed, others moved",
+			rows: []models.Donation{
+				donation(1, 10, "cash", 100),
+				donation(2, 20, "cash", 100),
+				donation(3, 20, "kind", 0),
+			},
+			duplicateIDs: []uint{20},
+			want: childMergePlan{
+				moveIDs:   []uint{3},
+				removeIDs: []uint{2},
+				movedBy:   map[uint]int{20: 1},
+				removedBy: map[uint]int{20: 1},
+			},
+		},
+		{
+			name: "identical rows of the survivor are all kept",
+			rows: []models.Donation{
+				donation(1, 10, "cash", 100),
+				donation(2, 10, "cash", 100),
+			},
+			duplicateIDs: []uint{20},
+			want:         childMergePlan{movedBy: map[uint]int{}, removedBy: map[uint]int{}},
+		},
+		{
+			name: "duplicates are walked in request order, not by row ID",
+			rows: []models.Donation{
+				donation(1, 30, "cash", 100),
+				donation(2, 20, "cash", 100),
+			},
+			duplicateIDs: []uint{20, 30},
+			want: childMergePlan{
+				moveIDs:   []uint{2},
+				removeIDs: []uint{1},
+				movedBy:   map[uint]int{20: 1},
+				removedBy: map[uint]int{30: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := planChildRows[models.Donation](ctx, donationSchema, tt.rows, 10, tt.duplicateIDs, nil, nil)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("plan = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanChildRowsMediaIdentity(t *testing.T) {
+	ctx := context.Background()
+	sch := mustParseSchema(t, &models.EventMedia{})
+	rows := []models.EventMedia{
+		{ID: 1, EventID: 10, CompanyName: "Daily News", S3Key: "events/10/a.jpg"},
+		{ID: 2, EventID: 20, CompanyName: "Daily News", S3Key: "events/10/a.jpg"},    // same object
+		{ID: 3, EventID: 20, CompanyName: "Daily News", S3Key: "events/20/copy.jpg"}, // same content, own object
+		{ID: 4, EventID: 20, CompanyName: "Daily News", S3Key: "events/20/b.jpg"},    // other content
+	}
+	identities := map[string]string{
+		"events/10/a.jpg":    "file:aaa",
+		"events/20/copy.jpg": "file:aaa",
+		"events/20/b.jpg":    "file:bbb",
+	}
+	identity := func(row *models.EventMedia) string { return identities[row.S3Key] }
+	sharesObject := func(kept []*models.EventMedia, row *models.EventMedia) bool {
+		for _, k := range kept {
+			if k.S3Key == row.S3Key {
+				return true
+			}
+		}
+		return false
+	}
+
+	got := planChildRows(ctx, sch, rows, 10, []uint{20}, identity, sharesObject)
+	want := childMergePlan{
+		moveIDs:    []uint{4},
+		removeIDs:  []uint{2, 3},
+		hardDelete: []uint{2},
+		movedBy:    map[uint]int{20: 1},
+		removedBy:  map[uint]int{20: 2},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("plan = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,602 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidOverdueConfig = errors.New("invalid overdue reminder configuration")
+	ErrRegionAdminNotFound  = errors.New("region admin not found")
+	ErrRegionAdminExists    = errors.New("user is already an admin of this region")
+)
+
+// OverdueConfig holds the reminder thresholds in days after an event's end date
+type OverdueConfig struct {
+	ReminderDays   []int `json:"reminder_days"`   // remind the creator and branch coordinator
+	EscalationDays int   `json:"escalation_days"` // escalate to the regional admins
+}
+
+// DefaultOverdueConfig reminds after 3, 7 and 14 days and escalates after 21
+var DefaultOverdueConfig = OverdueConfig{ReminderDays: []int{3, 7, 14}, EscalationDays: 21}
+
+var (
+	overdueConfigMu sync.RWMutex
+	overdueConfig   = DefaultOverdueConfig
+)
+
+// Validate checks that the reminder days are positive and the escalation comes after them
+func (c OverdueConfig) Validate() error {
+	if len(c.ReminderDays) == 0 {
+		return fmt.Errorf("%w: at least one reminder threshold is required", ErrInvalidOverdueConfig)
+	}
+	for i, days := range c.ReminderDays {
+		if days <= 0 {
+			return fmt.Errorf("%w: reminder thresholds must be positive", ErrInvalidOverdueConfig)
+		}
+		if i > 0 && days <= c.ReminderDays[i-1] {
+			return fmt.Errorf("%w: reminder thresholds must be increasing", ErrInvalidOverdueConfig)
+		}
+	}
+	if c.EscalationDays <= c.ReminderDays[len(c.ReminderDays)-1] {
+		return fmt.Errorf("%w: escalation threshold must be later than the last reminder", ErrInvalidOverdueConfig)
+	}
+	return nil
+}
+
+// ParseOverdueDays parses a comma-separated list of day thresholds (e.g. "3,7,14")
+func ParseOverdueDays(value string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a number of days", ErrInvalidOverdueConfig, part)
+		}
+		days = append(days, n)
+	}
+	sort.Ints(days)
+	return days, nil
+}
+
+// SetOverdueConfig replaces the thresholds used by the reminder job and the overdue report
+func SetOverdueConfig(cfg OverdueConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	overdueConfigMu.Lock()
+	defer overdueConfigMu.Unlock()
+	overdueConfig = cfg
+	return nil
+}
+
+// GetOverdueConfig returns the thresholds in use
+func GetOverdueConfig() OverdueConfig {
+	overdueConfigMu.RLock()
+	defer overdueConfigMu.RUnlock()
+	return overdueConfig
+}
+
+// ReminderSender delivers overdue report reminders
+type ReminderSender interface {
+	SendReminder(to, subject, body string) error
+}
+
+// LogReminderSender only logs reminders. Replace it with an e-mail service through
+// SetReminderSender; until then reminder runs deliver and record nothing.
+type LogReminderSender struct{}
+
+func (LogReminderSender) SendReminder(to, subject, body string) error {
+	log.Printf("[overdue reminder] to=%s subject=%q", to, subject)
+	return nil
+}
+
+var reminderSender ReminderSender = LogReminderSender{}
+
+// reminderDelivery reports whether reminders reach their recipients, rather than only the log
+func reminderDelivery() bool {
+	switch reminderSender.(type) {
+	case LogReminderSender, *LogReminderSender:
+		return false
+	}
+	return true
+}
+
+// SetReminderSender replaces the reminder delivery
+func SetReminderSender(sender ReminderSender) {
+	if sender != nil {
+		reminderSender = sender
+	}
+}
+
+// Ageing buckets of the overdue report, by days past the end date
+var overdueBuckets = []struct {
+	Key      string
+	From, To int // inclusive; To 0 means no upper bound
+}{
+	{"1-7", 1, 7},
+	{"8-14", 8, 14},
+	{"15-30", 15, 30},
+	{"31-60", 31, 60},
+	{"61-90", 61, 90},
+	{"over_90", 91, 0},
+}
+
+// overdueBucket returns the ageing bucket of a number of days overdue
+func overdueBucket(days int) string {
+	for _, b := range overdueBuckets {
+		if days >= b.From && (b.To == 0 || days <= b.To) {
+			return b.Key
+		}
+	}
+	return ""
+}
+
+// IsValidOverdueBucket checks an ageing bucket name
+func IsValidOverdueBucket(bucket string) bool {
+	for _, b := range overdueBuckets {
+		if b.Key == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// OverdueEvent is an event whose report is still incomplete after its end date
+type OverdueEvent struct {
+	EventID         uint       `json:"event_id"`
+	Theme           string     `json:"theme,omitempty"`
+	EventType       string     `json:"event_type,omitempty"`
+	StartDate       time.Time  `json:"start_date"`
+	EndDate         time.Time  `json:"end_date"`
+	DaysOverdue     int        `json:"days_overdue"`
+	Bucket          string     `json:"bucket"`
+	CreatedBy       string     `json:"created_by,omitempty"`
+	BranchID        *uint      `json:"branch_id,omitempty"`
+	BranchName      string     `json:"branch_name,omitempty"`
+	BranchEmail     string     `json:"branch_email,omitempty"`
+	CoordinatorName string     `json:"coordinator_name,omitempty"`
+	RegionID        *uint      `json:"region_id,omitempty"`
+	RemindersSent   int        `json:"reminders_sent"`
+	LastReminderOn  *time.Time `json:"last_reminder_on,omitempty"`
+	Escalated       bool       `json:"escalated"`
+}
+
+// OverdueBucketCount is the number of overdue reports in an ageing bucket
+type OverdueBucketCount struct {
+	Bucket string `json:"bucket"`
+	Count  int    `json:"count"`
+}
+
+// OverdueBranch groups the overdue reports of a branch
+type OverdueBranch struct {
+	BranchID   *uint                `json:"branch_id"`
+	BranchName string               `json:"branch_name"`
+	RegionID   *uint                `json:"region_id,omitempty"`
+	Count      int                  `json:"count"`
+	Buckets    []OverdueBucketCount `json:"buckets"`
+	Events     []OverdueEvent       `json:"events"`
+}
+
+// OverdueReport lists the overdue reports per branch with ageing buckets
+type OverdueReport struct {
+	AsOf     time.Time            `json:"as_of" swaggertype:"string" example:"2024-01-15"`
+	Total    int                  `json:"total"`
+	Buckets  []OverdueBucketCount `json:"buckets"`
+	Branches []OverdueBranch      `json:"branches"`
+	Config   OverdueConfig        `json:"config"`
+}
+
+// OverdueFilter narrows the overdue report
+type OverdueFilter struct {
+	BranchID uint   // a branch and its child branches
+	RegionID uint   // the branches of a region
+	Bucket   string // one ageing bucket
+}
+
+type overdueRow struct {
+	OverdueEvent
+	Escalations int
+}
+
+// loadOverdueEvents returns the non-deleted incomplete events that ended before today, oldest
+// end date first
+func loadOverdueEvents(filter OverdueFilter, today time.Time) ([]OverdueEvent, error) {
+	reminders := config.DB.Table("event_overdue_reminders").
+		Select(`event_id, COUNT(*) AS reminders_sent, MAX(sent_on) AS last_reminder_on,
+			COUNT(*) FILTER (WHERE level = ?) AS escalations`, models.OverdueLevelEscalation).
+		Group("event_id")
+
+	db := config.DB.Table("event_details e").
+		Joins("LEFT JOIN branches b ON b.id = e.branch_id").
+		Joins("LEFT JOIN event_types t ON t.id = e.event_type_id").
+		Joins("LEFT JOIN (?) r ON r.event_id = e.id", reminders).
+		Where("e.deleted_at IS NULL AND e.status = ? AND e.end_date < ?", "incomplete", today)
+	if filter.BranchID > 0 {
+		db = db.Where(`e.branch_id IN (
+			WITH RECURSIVE branch_tree AS (
+				SELECT id FROM branches WHERE id = ?
+				UNION ALL
+				SELECT br.id FROM branches br JOIN branch_tree bt ON br.parent_branch_id = bt.id
+			)
+			SELECT id FROM branch_tree)`, filter.BranchID)
+	}
+	if filter.RegionID > 0 {
+		db = db.Where("b.region_id = ?", filter.RegionID)
+	}
+
+	var rows []overdueRow
+	err := db.Select(`e.id AS event_id, COALESCE(e.theme, '') AS theme, COALESCE(t.name, '') AS event_type,
+			e.start_date, e.end_date, COALESCE(e.created_by, '') AS created_by,
+			e.branch_id, COALESCE(b.name, '') AS branch_name, COALESCE(b.email, '') AS branch_email,
+			COALESCE(b.coordinator_name, '') AS coordinator_name, b.region_id,
+			COALESCE(r.reminders_sent, 0) AS reminders_sent, r.last_reminder_on,
+			COALESCE(r.escalations, 0) AS escalations`).
+		Order("e.end_date, e.id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]OverdueEvent, 0, len(rows))
+	for _, row := range rows {
+		event := row.OverdueEvent
+		event.DaysOverdue = int(today.Sub(dateOnly(event.EndDate)).Hours() / 24)
+		event.Bucket = overdueBucket(event.DaysOverdue)
+		event.Escalated = row.Escalations > 0
+		if filter.Bucket != "" && event.Bucket != filter.Bucket {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// GetOverdueReport returns the overdue reports grouped per branch (most overdue first) with
+// counts per ageing bucket
+func GetOverdueReport(filter OverdueFilter) (*OverdueReport, error) {
+	today := dateOnly(time.Now())
+	events, err := loadOverdueEvents(filter, today)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &OverdueReport{AsOf: today, Total: len(events), Branches: []OverdueBranch{}, Config: GetOverdueConfig()}
+	totals := map[string]int{}
+	branches := map[uint]*OverdueBranch{} // 0 is events without a branch
+	var order []uint
+	for _, event := range events {
+		key := uint(0)
+		if event.BranchID != nil {
+			key = *event.BranchID
+		}
+		branch, ok := branches[key]
+		if !ok {
+			branch = &OverdueBranch{BranchID: event.BranchID, BranchName: event.BranchName, RegionID: event.RegionID}
+			if event.BranchID == nil {
+				branch.BranchName = "No branch"
+			}
+			branches[key] = branch
+			order = append(order, key)
+		}
+		branch.Count++
+		branch.Events = append(branch.Events, event)
+		totals[event.Bucket]++
+	}
+
+	bucketCounts := func(events []OverdueEvent) []OverdueBucketCount {
+		counts := map[string]int{}
+		for _, event := range events {
+			counts[event.Bucket]++
+		}
+		result := make([]OverdueBucketCount, 0, len(overdueBuckets))
+		for _, b := range overdueBuckets {
+			result = append(result, OverdueBucketCount{Bucket: b.Key, Count: counts[b.Key]})
+		}
+		return result
+	}
+	report.Buckets = bucketCounts(events)
+	for _, key := range order {
+		branch := branches[key]
+		branch.Buckets = bucketCounts(branch.Events)
+		report.Branches = append(report.Branches, *branch)
+	}
+	// Branches with the most overdue reports first; events are already oldest first
+	sort.SliceStable(report.Branches, func(i, j int) bool {
+		return report.Branches[i].Count > report.Branches[j].Count
+	})
+	return report, nil
+}
+
+// OverdueRunResult summarises a reminder run
+type OverdueRunResult struct {
+	Overdue     int `json:"overdue"`
+	Reminders   int `json:"reminders"`   // reminder stages recorded
+	Escalations int `json:"escalations"` // escalation stages recorded
+	Messages    int `json:"messages"`    // digests sent
+	Failed      int `json:"failed"`      // digests that could not be sent
+	Unreachable int `json:"unreachable"` // stages due without any recipient
+	// Undelivered is set while no e-mail sender is configured: digests were only logged and every
+	// stage stays due
+	Undelivered bool `json:"undelivered,omitempty"`
+}
+
+// overdueStage is a reminder stage due for an event
+type overdueStage struct {
+	event     OverdueEvent
+	level     string
+	threshold int
+	sentTo    []string
+}
+
+// validEmail reports whether value is a plain e-mail address
+func validEmail(value string) bool {
+	addr, err := mail.ParseAddress(value)
+	return err == nil && addr.Address == value
+}
+
+// regionAdminEmails returns the e-mail addresses of a region's admins, or of the super admins
+// when the region has none (or the branch has no region)
+func regionAdminEmails(regionID *uint, cache map[uint][]string) ([]string, error) {
+	key := uint(0)
+	if regionID != nil {
+		key = *regionID
+	}
+	if emails, ok := cache[key]; ok {
+		return emails, nil
+	}
+
+	var emails []string
+	if key > 0 {
+		if err := config.DB.Table("region_admins ra").
+			Joins("JOIN users u ON u.id = ra.user_id").
+			Where("ra.region_id = ? AND u.is_deleted = ?", key, false).
+			Order("u.email").
+			Pluck("u.email", &emails).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(emails) == 0 {
+		if fallback, ok := cache[0]; ok && key > 0 {
+			emails = fallback
+		} else if err := config.DB.Table("users u").
+			Joins("JOIN roles ro ON ro.id = u.role_id").
+			Where("ro.name = ? AND u.is_deleted = ?", string(models.RoleTypeSuperAdmin), false).
+			Order("u.email").
+			Pluck("u.email", &emails).Error; err != nil {
+			return nil, err
+		}
+	}
+	cache[key] = emails
+	return emails, nil
+}
+
+// RunOverdueReminders sends the reminder and escalation stages that became due. Every
+// recipient gets one digest per level listing their overdue reports; a stage is recorded once at
+// least one of its recipients got it, so failed deliveries are retried on the next run. While only
+// the LogReminderSender is set nothing is recorded and the run is reported as undelivered.
+func RunOverdueReminders() (*OverdueRunResult, error) {
+	cfg := GetOverdueConfig()
+	events, err := loadOverdueEvents(OverdueFilter{}, dateOnly(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	result := &OverdueRunResult{Overdue: len(events)}
+	if len(events) == 0 {
+		return result, nil
+	}
+
+	eventIDs := make([]uint, len(events))
+	for i, event := range events {
+		eventIDs[i] = event.EventID
+	}
+	var sent []models.EventOverdueReminder
+	if err := config.DB.Select("event_id", "level", "threshold_days").
+		Where("event_id IN ?", eventIDs).Find(&sent).Error; err != nil {
+		return nil, err
+	}
+	sentStages := make(map[string]bool, len(sent))
+	for _, s := range sent {
+		sentStages[fmt.Sprintf("%d|%s|%d", s.EventID, s.Level, s.ThresholdDays)] = true
+	}
+
+	// Collect the due stages with their recipients
+	var stages []*overdueStage
+	digests := map[string]map[string][]*overdueStage{} // level -> recipient -> stages
+	adminCache := map[uint][]string{}
+	addStage := func(event OverdueEvent, level string, threshold int, recipients []string) {
+		if sentStages[fmt.Sprintf("%d|%s|%d", event.EventID, level, threshold)] {
+			return
+		}
+		stage := &overdueStage{event: event, level: level, threshold: threshold}
+		seen := map[string]bool{}
+		for _, recipient := range recipients {
+			recipient = strings.ToLower(strings.TrimSpace(recipient))
+			if recipient == "" || seen[recipient] || !validEmail(recipient) {
+				continue
+			}
+			seen[recipient] = true
+			if digests[level] == nil {
+				digests[level] = map[string][]*overdueStage{}
+			}
+			digests[level][recipient] = append(digests[level][recipient], stage)
+		}
+		if len(seen) == 0 {
+			result.Unreachable++
+			return
+		}
+		stages = append(stages, stage)
+	}
+
+	for _, event := range events {
+		// Only the latest reminder threshold reached is sent; earlier ones are skipped when the
+		// job was not running
+		reminder := 0
+		for _, days := range cfg.ReminderDays {
+			if event.DaysOverdue >= days {
+				reminder = days
+			}
+		}
+		if reminder > 0 {
+			addStage(event, models.OverdueLevelReminder, reminder, []string{event.CreatedBy, event.BranchEmail})
+		}
+		if event.DaysOverdue >= cfg.EscalationDays {
+			admins, err := regionAdminEmails(event.RegionID, adminCache)
+			if err != nil {
+				return nil, err
+			}
+			addStage(event, models.OverdueLevelEscalation, cfg.EscalationDays, admins)
+		}
+	}
+
+	// Send one digest per level and recipient
+	delivered := reminderDelivery()
+	for _, level := range []string{models.OverdueLevelReminder, models.OverdueLevelEscalation} {
+		recipients := make([]string, 0, len(digests[level]))
+		for recipient := range digests[level] {
+			recipients = append(recipients, recipient)
+		}
+		sort.Strings(recipients)
+		for _, recipient := range recipients {
+			due := digests[level][recipient]
+			subject, body := overdueDigest(level, due, cfg)
+			if err := reminderSender.SendReminder(recipient, subject, body); err != nil {
+				log.Printf("ERROR: Failed to send overdue %s to %s: %v", level, recipient, err)
+				result.Failed++
+				continue
+			}
+			if !delivered {
+				continue
+			}
+			result.Messages++
+			for _, stage := range due {
+				stage.sentTo = append(stage.sentTo, recipient)
+			}
+		}
+	}
+
+	if !delivered {
+		result.Undelivered = true
+		return result, nil
+	}
+
+	// Record the delivered stages
+	var records []models.EventOverdueReminder
+	for _, stage := range stages {
+		if len(stage.sentTo) == 0 {
+			continue
+		}
+		records = append(records, models.EventOverdueReminder{
+			EventID:       stage.event.EventID,
+			Level:         stage.level,
+			ThresholdDays: stage.threshold,
+			DaysOverdue:   stage.event.DaysOverdue,
+			Recipients:    strings.Join(stage.sentTo, ","),
+		})
+		if stage.level == models.OverdueLevelEscalation {
+			result.Escalations++
+		} else {
+			result.Reminders++
+		}
+	}
+	if len(records) > 0 {
+		if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&records, 500).Error; err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// overdueDigest renders the subject and text of a reminder digest
+func overdueDigest(level string, stages []*overdueStage, cfg OverdueConfig) (string, string) {
+	var b strings.Builder
+	var subject string
+	if level == models.OverdueLevelEscalation {
+		subject = fmt.Sprintf("Escalation: %d event report(s) overdue for more than %d days", len(stages), cfg.EscalationDays)
+		b.WriteString("The following event reports in your region are still incomplete long after the event ended:\n\n")
+	} else {
+		subject = fmt.Sprintf("Reminder: %d event report(s) awaiting completion", len(stages))
+		b.WriteString("The following events have ended but their reports are still incomplete:\n\n")
+	}
+	for _, stage := range stages {
+		e := stage.event
+		theme := e.Theme
+		if theme == "" {
+			theme = e.EventType
+		}
+		branch := e.BranchName
+		if branch == "" {
+			branch = "no branch"
+		}
+		fmt.Fprintf(&b, "- #%d %s (%s), ended %s, %d day(s) overdue\n",
+			e.EventID, theme, branch, e.EndDate.Format("2006-01-02"), e.DaysOverdue)
+	}
+	b.WriteString("\nPlease complete the reports in the event reporting portal.\n")
+	return subject, b.String()
+}
+
+// GetRegionAdmins returns the admins of a region (all regions when regionID is 0)
+func GetRegionAdmins(regionID uint) ([]models.RegionAdmin, error) {
+	var admins []models.RegionAdmin
+	db := config.DB.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name", "email", "role_id")
+	})
+	if regionID > 0 {
+		db = db.Where("region_id = ?", regionID)
+	}
+	err := db.Order("region_id, id").Find(&admins).Error
+	return admins, err
+}
+
+// AddRegionAdmin makes a user an admin of a region
+func AddRegionAdmin(regionID, userID uint, createdBy string) (*models.RegionAdmin, error) {
+	var user models.User
+	if err := config.DB.Select("id", "name", "email", "role_id").
+		Where("id = ? AND is_deleted = ?", userID, false).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	var count int64
+	if err := config.DB.Model(&models.RegionAdmin{}).
+		Where("region_id = ? AND user_id = ?", regionID, userID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrRegionAdminExists
+	}
+
+	admin := &models.RegionAdmin{RegionID: regionID, UserID: userID, CreatedBy: createdBy}
+	if err := config.DB.Omit(clause.Associations).Create(admin).Error; err != nil {
+		return nil, err
+	}
+	admin.User = &user
+	return admin, nil
+}
+
+// RemoveRegionAdmin removes a region admin assignment
+func RemoveRegionAdmin(id uint) error {
+	result := config.DB.Delete(&models.RegionAdmin{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRegionAdminNotFound
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// snapshotAssociationKeys are nested association objects removed from snapshots,
+// so that a revision only holds the event's own columns and its child rows
+var snapshotAssociationKeys = []string{
+	"event", "branch", "event_type", "event_category", "event_sub_category",
+	"media_coverage_type", "promotion_material",
+}
+
+// revisionDiffIgnoredFields are fields that change on every save and are left out of diffs
+var revisionDiffIgnoredFields = map[string]bool{
+	"updated_on": true,
+	"version":    true,
+}
+
+// loadEventSnapshot reads an event and all of its related rows using the given DB handle
+func loadEventSnapshot(tx *gorm.DB, eventID uint) (*models.EventSnapshot, error) {
+	var snapshot models.EventSnapshot
+
+	if err := tx.First(&snapshot.Event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	if err := tx.Where("event_id = ?", eventID).Order("id").Find(&snapshot.SpecialGuests).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("event_id = ?", eventID).Order("id").Find(&snapshot.Volunteers).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("event_id = ?", eventID).Order("id").Find(&snapshot.Media).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("event_id = ?", eventID).Order("id").Find(&snapshot.Donations).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("event_id = ?", eventID).Order("id").Find(&snapshot.PromotionMaterials).Error; err != nil {
+		return nil, err
+	}
+	snapshot.CoHosts = []models.EventCoHost{}
+	if err := tx.Where("event_id = ?", eventID).Order("branch_id").Find(&snapshot.CoHosts).Error; err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// snapshotToJSONB converts a typed snapshot to the JSONB stored on a revision,
+// stripping nested association objects
+func snapshotToJSONB(snapshot *models.EventSnapshot) (models.JSONB, error) {
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var data models.JSONB
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+
+	stripAssociations := func(v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			for _, key := range snapshotAssociationKeys {
+				delete(m, key)
+			}
+		}
+	}
+	stripAssociations(data["event"])
+	for _, section := range []string{"special_guests", "volunteers", "media", "donations", "promotion_materials", "co_hosts"} {
+		if rows, ok := data[section].([]interface{}); ok {
+			for _, row := range rows {
+				stripAssociations(row)
+			}
+		}
+	}
+
+	return data, nil
+}
+
+// jsonbToSnapshot converts a revision's JSONB snapshot back to its typed form
+func jsonbToSnapshot(data models.JSONB) (*models.EventSnapshot, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.EventSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// CaptureEventRevision stores the current state of an event and its related rows as a new revision.
+// Pass a transaction to make the snapshot part of the same unit of work as the change it records.
+func CaptureEventRevision(tx *gorm.DB, eventID uint, action string, restoredFrom *uint, actor Actor) (*models.EventRevision, error) {
+	if tx == nil {
+		tx = config.DB
+	}
+
+	snapshot, err := loadEventSnapshot(tx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := snapshotToJSONB(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialise event snapshot: %w", err)
+	}
+
+	var lastNumber int
+	if err := tx.Model(&models.EventRevision{}).
+		Where("event_id = ?", eventID).
+		Select("COALESCE(MAX(revision_number), 0)").
+		Scan(&lastNumber).Error; err != nil {
+		return nil, err
+	}
+
+	revision := &models.EventRevision{
+		EventID:        eventID,
+		RevisionNumber: lastNumber + 1,
+		Action:         action,
+		RestoredFrom:   restoredFrom,
+		Snapshot:       data,
+		ActorID:        actor.UserID,
+		ActorEmail:     actor.Email,
+		CreatedOn:      time.Now(),
+	}
+	if err := tx.Create(revision).Error; err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// EnsureBaselineRevision captures the current state of an event as its first revision
+// if it has none yet, so that events created before revision tracking can be restored
+func EnsureBaselineRevision(tx *gorm.DB, eventID uint, actor Actor) error {
+	if tx == nil {
+		tx = config.DB
+	}
+
+	var count int64
+	if err := tx.Model(&models.EventRevision{}).Where("event_id = ?", eventID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err := CaptureEventRevision(tx, eventID, models.RevisionActionCreate, nil, actor)
+	return err
+}
+
+// GetEventRevisions lists the revisions of an event, newest first, without their snapshots
+func GetEventRevisions(eventID uint) ([]models.EventRevision, error) {
+	var revisions []models.EventRevision
+	if err := config.DB.
+		Omit("snapshot").
+		Where("event_id = ?", eventID).
+		Order("revision_number DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// GetEventRevision retrieves a single revision of an event including its snapshot
+func GetEventRevision(eventID uint, revisionID uint) (*models.EventRevision, error) {
+	var revision models.EventRevision
+	if err := config.DB.
+		Where("event_id = ? AND id = ?", eventID, revisionID).
+		First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// DiffEventRevisions returns the field-level differences between two revisions of an event
+func DiffEventRevisions(eventID uint, fromRevisionID uint, toRevisionID uint) ([]models.RevisionFieldChange, error) {
+	from, err := GetEventRevision(eventID, fromRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := GetEventRevision(eventID, toRevisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffSnapshots(from.Snapshot, to.Snapshot), nil
+}
+
+// diffSnapshots flattens both snapshots to path -> value and compares them
+func diffSnapshots(from, to models.JSONB) []models.RevisionFieldChange {
+	fromFlat := map[string]interface{}{}
+	toFlat := map[string]interface{}{}
+	flattenSnapshotValue("", map[string]interface{}(from), fromFlat)
+	flattenSnapshotValue("", map[string]interface{}(to), toFlat)
+
+	paths := make([]string, 0, len(fromFlat)+len(toFlat))
+	seen := map[string]bool{}
+	for p := range fromFlat {
+		paths = append(paths, p)
+		seen[p] = true
+	}
+	for p := range toFlat {
+		if !seen[p] {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []models.RevisionFieldChange{}
+	for _, p := range paths {
+		fromVal, inFrom := fromFlat[p]
+		toVal, inTo := toFlat[p]
+		switch {
+		case inFrom && !inTo:
+			changes = append(changes, models.RevisionFieldChange{Path: p, Change: "removed", From: fromVal})
+		case !inFrom && inTo:
+			changes = append(changes, models.RevisionFieldChange{Path: p, Change: "added", To: toVal})
+		case !reflect.DeepEqual(fromVal, toVal):
+			changes = append(changes, models.RevisionFieldChange{Path: p, Change: "changed", From: fromVal, To: toVal})
+		}
+	}
+	return changes
+}
+
+// flattenSnapshotValue walks a decoded JSON value and records leaf values by path.
+// Rows in related-data arrays are keyed by their ID (e.g. volunteers[id=12].contact), co-hosts
+// by their branch (co_hosts[branch_id=3]), so reordering does not show up as a change.
+func flattenSnapshotValue(prefix string, value interface{}, out map[string]interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if revisionDiffIgnoredFields[key] {
+				continue
+			}
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			flattenSnapshotValue(path, child, out)
+		}
+	case []interface{}:
+		for i, item := range v {
+			itemPath := fmt.Sprintf("%s[%d]", prefix, i)
+			if m, ok := item.(map[string]interface{}); ok {
+				if id, ok := m["id"]; ok {
+					itemPath = fmt.Sprintf("%s[id=%v]", prefix, id)
+				} else if branchID, ok := m["branch_id"]; ok && prefix == "co_hosts" {
+					itemPath = fmt.Sprintf("%s[branch_id=%v]", prefix, branchID)
+				}
+			}
+			flattenSnapshotValue(itemPath, item, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+// RestoreEventRevision reinstates an event and its related rows exactly as captured in the given
+// revision. The review workflow state is left untouched. The restore itself is recorded as a new
+// revision. Everything happens in one transaction.
+func RestoreEventRevision(eventID uint, revisionID uint, actor Actor) (*models.EventRevision, error) {
+	revision, err := GetEventRevision(eventID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := jsonbToSnapshot(revision.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revision snapshot: %w", err)
+	}
+
+	var restored *models.EventRevision
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
+		}
+
+		// Make sure the state being replaced can itself be restored later
+		if err := EnsureBaselineRevision(tx, eventID, actor); err != nil {
+			return err
+		}
+
+		omitted := []string{clause.Associations, "id", "created_on", "created_by", "workflow_state", "deleted_at", "deleted_by"}
+		// Beneficiary counts of events with day-wise attendance are derived from it
+		hasAttendance, err := eventHasAttendance(tx, eventID)
+		if err != nil {
+			return err
+		}
+		if hasAttendance {
+			omitted = append(omitted, attendanceDerivedColumns...)
+		}
+
+		now := time.Now()
+		event := snapshot.Event
+		event.ID = eventID
+		event.UpdatedOn = &now
+		event.UpdatedBy = actor.Email
+		if err := tx.Model(&current).
+			Select("*").
+			Omit(omitted...).
+			Updates(&event).Error; err != nil {
+			return fmt.Errorf("failed to restore event: %w", err)
+		}
+
+		if err := replaceEventRelatedRows(tx, eventID, snapshot, actor.Email); err != nil {
+			return err
+		}
+		if err := restoreEventCoHosts(tx, eventID, snapshot.CoHosts, actor); err != nil {
+			return err
+		}
+
+		restored, err = CaptureEventRevision(tx, eventID, models.RevisionActionRestore, &revision.ID, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// replaceEventRelatedRows replaces the current child rows of an event with the rows from the
+// snapshot, re-inserted with their original IDs. Current rows that are not part of the snapshot
+// are moved to the trash.
+func replaceEventRelatedRows(tx *gorm.DB, eventID uint, snapshot *models.EventSnapshot, deletedBy string) error {
+	guestIDs := make([]uint, 0, len(snapshot.SpecialGuests))
+	for _, row := range snapshot.SpecialGuests {
+		guestIDs = append(guestIDs, row.ID)
+	}
+	foreignGuests, err := clearRowsForRestore(tx, &models.SpecialGuest{}, eventID, guestIDs, deletedBy)
+	if err != nil {
+		return errors.New("failed to delete special guests: " + err.Error())
+	}
+	volunteerIDs := make([]uint, 0, len(snapshot.Volunteers))
+	for _, row := range snapshot.Volunteers {
+		volunteerIDs = append(volunteerIDs, row.ID)
+	}
+	foreignVolunteers, err := clearRowsForRestore(tx, &models.Volunteer{}, eventID, volunteerIDs, deletedBy)
+	if err != nil {
+		return errors.New("failed to delete volunteers: " + err.Error())
+	}
+	mediaIDs := make([]uint, 0, len(snapshot.Media))
+	for _, row := range snapshot.Media {
+		mediaIDs = append(mediaIDs, row.ID)
+	}
+	foreignMedia, err := clearRowsForRestore(tx, &models.EventMedia{}, eventID, mediaIDs, deletedBy)
+	if err != nil {
+		return errors.New("failed to delete event media: " + err.Error())
+	}
+	donationIDs := make([]uint, 0, len(snapshot.Donations))
+	for _, row := range snapshot.Donations {
+		donationIDs = append(donationIDs, row.ID)
+	}
+	foreignDonations, err := clearRowsForRestore(tx, &models.Donation{}, eventID, donationIDs, deletedBy)
+	if err != nil {
+		return errors.New("failed to delete donations: " + err.Error())
+	}
+	if err := tx.Where("event_id = ?", eventID).Delete(&models.PromotionMaterialDetails{}).Error; err != nil {
+		return errors.New("failed to delete promotion materials: " + err.Error())
+	}
+
+	if len(snapshot.SpecialGuests) > 0 {
+		for i := range snapshot.SpecialGuests {
+			snapshot.SpecialGuests[i].EventID = eventID
+			if foreignGuests[snapshot.SpecialGuests[i].ID] {
+				snapshot.SpecialGuests[i].ID = 0
+			}
+		}
+		if err := tx.Omit(clause.Associations).Create(&snapshot.SpecialGuests).Error; err != nil {
+			return errors.New("failed to restore special guests: " + err.Error())
+		}
+	}
+	if len(snapshot.Volunteers) > 0 {
+		for i := range snapshot.Volunteers {
+			snapshot.Volunteers[i].EventID = eventID
+			if foreignVolunteers[snapshot.Volunteers[i].ID] {
+				snapshot.Volunteers[i].ID = 0
+			}
+		}
+		if err := tx.Omit(clause.Associations).Create(&snapshot.Volunteers).Error; err != nil {
+			return errors.New("failed to restore volunteers: " + err.Error())
+		}
+	}
+	if len(snapshot.Media) > 0 {
+		for i := range snapshot.Media {
+			snapshot.Media[i].EventID = eventID
+			if foreignMedia[snapshot.Media[i].ID] {
+				snapshot.Media[i].ID = 0
+			}
+		}
+		if err := tx.Omit(clause.Associations).Create(&snapshot.Media).Error; err != nil {
+			return errors.New("failed to restore event media: " + err.Error())
+		}
+	}
+	if len(snapshot.Donations) > 0 {
+		for i := range snapshot.Donations {
+			snapshot.Donations[i].EventID = eventID
+			if foreignDonations[snapshot.Donations[i].ID] {
+				snapshot.Donations[i].ID = 0
+			}
+		}
+		if err := tx.Omit(clause.Associations).Create(&snapshot.Donations).Error; err != nil {
+			return errors.New("failed to restore donations: " + err.Error())
+		}
+	}
+	if len(snapshot.PromotionMaterials) > 0 {
+		for i := range snapshot.PromotionMaterials {
+			snapshot.PromotionMaterials[i].EventID = eventID
+		}
+		if err := tx.Omit(clause.Associations).Create(&snapshot.PromotionMaterials).Error; err != nil {
+			return errors.New("failed to restore promotion materials: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// restoreEventCoHosts reinstates the co-hosts of a snapshot, leaving out branches that are gone
+// or that are now the lead branch. Snapshots without co-hosts (taken before they were recorded)
+// leave the current co-hosts in place, apart from the lead branch.
+func restoreEventCoHosts(tx *gorm.DB, eventID uint, coHosts []models.EventCoHost, actor Actor) error {
+	if coHosts != nil {
+		var event models.EventDetails
+		if err := tx.Select("id", "branch_id").First(&event, eventID).Error; err != nil {
+			return err
+		}
+		branchIDs := make([]uint, 0, len(coHosts))
+		for _, row := range coHosts {
+			branchIDs = append(branchIDs, row.BranchID)
+		}
+		var live []uint
+		if len(branchIDs) > 0 {
+			if err := tx.Model(&models.Branch{}).Where("id IN ?", branchIDs).Pluck("id", &live).Error; err != nil {
+				return err
+			}
+		}
+		exists := make(map[uint]bool, len(live))
+		for _, id := range live {
+			exists[id] = true
+		}
+		rows := make([]models.EventCoHost, 0, len(coHosts))
+		for _, row := range coHosts {
+			if exists[row.BranchID] && (event.BranchID == nil || row.BranchID != *event.BranchID) {
+				rows = append(rows, row)
+			}
+		}
+		if _, err := replaceEventCoHosts(tx, eventID, rows, actor); err != nil {
+			return fmt.Errorf("failed to restore co-hosts: %w", err)
+		}
+	}
+	return dropLeadBranchCoHosts(tx, []uint{eventID}, actor)
+}
+
+// clearRowsForRestore makes room for re-inserting snapshot rows: the event's rows with a snapshot
+// ID are removed for good (live or trashed), its remaining live rows go to the trash. Snapshot IDs
+// now owned by another event (e.g. moved there by a merge) are returned so they are inserted fresh
+func clearRowsForRestore(tx *gorm.DB, model interface{}, eventID uint, snapshotIDs []uint, deletedBy string) (map[uint]bool, error) {
+	foreign := make(map[uint]bool)
+	if len(snapshotIDs) > 0 {
+		var taken []uint
+		if err := tx.Unscoped().Model(model).Where("event_id <> ? AND id IN ?", eventID, snapshotIDs).Pluck("id", &taken).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range taken {
+			foreign[id] = true
+		}
+		if err := tx.Unscoped().Where("event_id = ? AND id IN ?", eventID, snapshotIDs).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	_, err := softDeleteWhere(tx, model, deletedBy, trashTimestamp(), "event_id = ?", eventID)
+	return foreign, err
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+)
+
+const (
+	// eventSearchFuzzyThreshold is the minimum trigram similarity for a misspelled place name to match
+	eventSearchFuzzyThreshold = 0.3
+	// eventSearchFuzzyWeight scales trigram similarity relative to the full-text rank
+	eventSearchFuzzyWeight = 0.5
+
+	eventSearchHighlightStart = "<mark>"
+	eventSearchHighlightStop  = "</mark>"
+)
+
+// EventSearchHit is a single ranked search result
+type EventSearchHit struct {
+	Event      models.EventDetails `json:"event"`
+	Rank       float64             `json:"rank"`
+	Highlights map[string]string   `json:"highlights,omitempty"`
+}
+
+// EventSearchResult is a page of ranked search results
+type EventSearchResult struct {
+	Results []EventSearchHit `json:"results"`
+	Total   int64            `json:"total"`
+	Page    int              `json:"page"`
+	Limit   int              `json:"limit"`
+}
+
+// eventSearchRow is the raw ranked row returned by the search query
+type eventSearchRow struct {
+	ID                 uint
+	Rank               float64
+	ThemeHeadline      string
+	OratorHeadline     string
+	CityHeadline       string
+	DistrictHeadline   string
+	AddressHeadline    string
+	BranchNameHeadline string
+}
+
+// SearchEvents runs a ranked full-text search over theme, orator, city, district, address,
+// branch name and event type/category, with trigram matching for misspelled place names.
+// An empty search lists all events, newest first. No hits yields an empty result, not an error.
+func SearchEvents(search string, page, limit int) (*EventSearchResult, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	offset := (page - 1) * limit
+
+	result := &EventSearchResult{Results: []EventSearchHit{}, Page: page, Limit: limit}
+	search = strings.TrimSpace(search)
+
+	if search == "" {
+		var events []models.EventDetails
+		db := config.DB.Model(&models.EventDetails{})
+		if err := db.Count(&result.Total).Error; err != nil {
+			return nil, fmt.Errorf("error counting events: %w", err)
+		}
+		if err := config.DB.
+			Preload("EventType").
+			Preload("EventCategory").
+			Preload("EventSubCategory").
+			Preload("Branch").
+			Order("created_on DESC, id DESC").
+			Limit(limit).Offset(offset).
+			Find(&events).Error; err != nil {
+			return nil, fmt.Errorf("error fetching events: %w", err)
+		}
+		for _, event := range events {
+			result.Results = append(result.Results, EventSearchHit{Event: event})
+		}
+		return result, nil
+	}
+
+	// Shared FROM/WHERE; the query text is bound as @q, the fuzzy threshold as @threshold
+	const matchSQL = `
+		FROM event_details e
+		LEFT JOIN branches b ON b.id = e.branch_id AND b.deleted_at IS NULL,
+		websearch_to_tsquery('simple', @q) query
+		WHERE e.deleted_at IS NULL
+		  AND (
+		    e.search_vector @@ query
+		    OR similarity(coalesce(e.city, ''), @q) >= @threshold
+		    OR similarity(coalesce(e.district, ''), @q) >= @threshold
+		    OR word_similarity(@q, coalesce(e.address, '')) >= @threshold
+		    OR similarity(coalesce(b.name, ''), @q) >= @threshold
+		  )`
+
+	args := map[string]interface{}{
+		"q":         search,
+		"threshold": eventSearchFuzzyThreshold,
+		"fuzzy":     eventSearchFuzzyWeight,
+		"opts":      fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=20, MinWords=5", eventSearchHighlightStart, eventSearchHighlightStop),
+		"limit":     limit,
+		"offset":    offset,
+	}
+
+	if err := config.DB.Raw("SELECT COUNT(*) "+matchSQL, args).Scan(&result.Total).Error; err != nil {
+		return nil, fmt.Errorf("error counting search results: %w", err)
+	}
+	if result.Total == 0 {
+		return result, nil
+	}
+
+	var rows []eventSearchRow
+	if err := config.DB.Raw(`
+		SELECT e.id,
+		       ts_rank_cd(e.search_vector, query) + @fuzzy * GREATEST(
+		           similarity(coalesce(e.city, ''), @q),
+		           similarity(coalesce(e.district, ''), @q),
+		           word_similarity(@q, coalesce(e.address, '')),
+		           similarity(coalesce(b.name, ''), @q)
+		       ) AS rank,
+		       ts_headline('simple', coalesce(e.theme, ''), query, @opts) AS theme_headline,
+		       ts_headline('simple', coalesce(e.spiritual_orator, ''), query, @opts) AS orator_headline,
+		       ts_headline('simple', coalesce(e.city, ''), query, @opts) AS city_headline,
+		       ts_headline('simple', coalesce(e.district, ''), query, @opts) AS district_headline,
+		       ts_headline('simple', coalesce(e.address, ''), query, @opts) AS address_headline,
+		       ts_headline('simple', coalesce(b.name, ''), query, @opts) AS branch_name_headline
+		`+matchSQL+`
+		ORDER BY rank DESC, e.start_date DESC, e.id DESC
+		LIMIT @limit OFFSET @offset`, args).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error searching events: %w", err)
+	}
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var events []models.EventDetails
+	if err := config.DB.
+		Preload("EventType").
+		Preload("EventCategory").
+		Preload("EventSubCategory").
+		Preload("Branch").
+		Where("id IN ?", ids).
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("error fetching events: %w", err)
+	}
+	eventsByID := make(map[uint]models.EventDetails, len(events))
+	for _, event := range events {
+		eventsByID[event.ID] = event
+	}
+
+	// Keep the ranked order of the search query
+	for _, row := range rows {
+		event, ok := eventsByID[row.ID]
+		if !ok {
+			continue
+		}
+		hit := EventSearchHit{Event: event, Rank: row.Rank, Highlights: map[string]string{}}
+		addSearchHighlight(hit.Highlights, "theme", row.ThemeHeadline)
+		addSearchHighlight(hit.Highlights, "spiritual_orator", row.OratorHeadline)
+		addSearchHighlight(hit.Highlights, "city", row.CityHeadline)
+		addSearchHighlight(hit.Highlights, "district", row.DistrictHeadline)
+		addSearchHighlight(hit.Highlights, "address", row.AddressHeadline)
+		addSearchHighlight(hit.Highlights, "branch_name", row.BranchNameHeadline)
+		result.Results = append(result.Results, hit)
+	}
+
+	return result, nil
+}
+
+// addSearchHighlight keeps a headline only when it actually contains a highlighted term
+func addSearchHighlight(highlights map[string]string, field, headline string) {
+	if strings.Contains(headline, eventSearchHighlightStart) {
+		highlights[field] = headline
+	}
+}
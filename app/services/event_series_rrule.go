@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidRRule = errors.New("invalid recurrence rule")
+
+// rruleWeekdays maps RFC 5545 day codes to weekdays
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// rruleWeekday is a BYDAY entry; Nth selects the nth (negative: from the end) weekday of the month, 0 means every
+type rruleWeekday struct {
+	Weekday time.Weekday
+	Nth     int
+}
+
+// recurrenceRule is the supported subset of an RFC 5545 RRULE
+type recurrenceRule struct {
+	Freq       string // WEEKLY or MONTHLY
+	Interval   int
+	ByDay      []rruleWeekday
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+// parseRRule parses rules such as "FREQ=WEEKLY;BYDAY=SU", "FREQ=MONTHLY;BYDAY=1SU,3SU" or
+// "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20271231". An optional "RRULE:" prefix is accepted.
+func parseRRule(value string) (*recurrenceRule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(strings.ToUpper(value)), "RRULE:")
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRRule)
+	}
+
+	rule := &recurrenceRule{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRRule, part)
+		}
+		switch name {
+		case "FREQ":
+			if val != "WEEKLY" && val != "MONTHLY" {
+				return nil, fmt.Errorf("%w: FREQ must be WEEKLY or MONTHLY", ErrInvalidRRule)
+			}
+			rule.Freq = val
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRRule)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRRule)
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := time.Parse("20060102", val[:min(len(val), 8)])
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be a date (YYYYMMDD)", ErrInvalidRRule)
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				code := day[max(len(day)-2, 0):]
+				weekday, ok := rruleWeekdays[code]
+				if !ok {
+					return nil, fmt.Errorf("%w: unknown BYDAY %q", ErrInvalidRRule, day)
+				}
+				nth := 0
+				if prefix := strings.TrimSuffix(day, code); prefix != "" {
+					n, err := strconv.Atoi(prefix)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRRule, day)
+					}
+					nth = n
+				}
+				rule.ByDay = append(rule.ByDay, rruleWeekday{Weekday: weekday, Nth: nth})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				n, err := strconv.Atoi(day)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("%w: invalid BYMONTHDAY %q", ErrInvalidRRule, day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		case "WKST":
+			if val != "MO" {
+				return nil, fmt.Errorf("%w: only WKST=MO is supported", ErrInvalidRRule)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %s", ErrInvalidRRule, name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRRule)
+	}
+	if rule.Freq == "WEEKLY" {
+		if len(rule.ByMonthDay) > 0 {
+			return nil, fmt.Errorf("%w: BYMONTHDAY requires FREQ=MONTHLY", ErrInvalidRRule)
+		}
+		for _, day := range rule.ByDay {
+			if day.Nth != 0 {
+				return nil, fmt.Errorf("%w: ordinal BYDAY requires FREQ=MONTHLY", ErrInvalidRRule)
+			}
+		}
+	}
+	return rule, nil
+}
+
+// occurrences returns the dates of the rule anchored at dtstart that fall within [from, to].
+// COUNT counts every instance from dtstart, including ones outside the window.
+func (r *recurrenceRule) occurrences(dtstart, from, to time.Time) []time.Time {
+	dtstart, from, to = dateOnly(dtstart), dateOnly(from), dateOnly(to)
+	if r.Until != nil && r.Until.Before(to) {
+		to = *r.Until
+	}
+
+	var dates []time.Time
+	emitted := 0
+	for period := 0; ; period += r.Interval {
+		var candidates []time.Time
+		var periodStart time.Time
+		if r.Freq == "WEEKLY" {
+			// Weeks start on Monday
+			offset := (int(dtstart.Weekday()) + 6) % 7
+			periodStart = dtstart.AddDate(0, 0, -offset+7*period)
+			candidates = r.weeklyCandidates(dtstart, periodStart)
+		} else {
+			periodStart = time.Date(dtstart.Year(), dtstart.Month()+time.Month(period), 1, 0, 0, 0, 0, time.UTC)
+			candidates = r.monthlyCandidates(dtstart, periodStart)
+		}
+		if periodStart.After(to) {
+			return dates
+		}
+
+		for _, date := range candidates {
+			if date.Before(dtstart) {
+				continue
+			}
+			if date.After(to) {
+				return dates
+			}
+			emitted++
+			if !date.Before(from) {
+				dates = append(dates, date)
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return dates
+			}
+		}
+	}
+}
+
+func (r *recurrenceRule) weeklyCandidates(dtstart, weekStart time.Time) []time.Time {
+	days := r.ByDay
+	if len(days) == 0 {
+		days = []rruleWeekday{{Weekday: dtstart.Weekday()}}
+	}
+	var dates []time.Time
+	for _, day := range days {
+		dates = append(dates, weekStart.AddDate(0, 0, (int(day.Weekday)+6)%7))
+	}
+	return sortUniqueDates(dates)
+}
+
+func (r *recurrenceRule) monthlyCandidates(dtstart, monthStart time.Time) []time.Time {
+	daysInMonth := monthStart.AddDate(0, 1, -1).Day()
+
+	var monthDays []time.Time
+	for _, n := range r.ByMonthDay {
+		day := n
+		if n < 0 {
+			day = daysInMonth + n + 1
+		}
+		if day >= 1 && day <= daysInMonth {
+			monthDays = append(monthDays, monthStart.AddDate(0, 0, day-1))
+		}
+	}
+
+	var weekdays []time.Time
+	for _, byDay := range r.ByDay {
+		var matches []time.Time
+		for d := 0; d < daysInMonth; d++ {
+			date := monthStart.AddDate(0, 0, d)
+			if date.Weekday() == byDay.Weekday {
+				matches = append(matches, date)
+			}
+		}
+		switch {
+		case byDay.Nth == 0:
+			weekdays = append(weekdays, matches...)
+		case byDay.Nth > 0 && byDay.Nth <= len(matches):
+			weekdays = append(weekdays, matches[byDay.Nth-1])
+		case byDay.Nth < 0 && -byDay.Nth <= len(matches):
+			weekdays = append(weekdays, matches[len(matches)+byDay.Nth])
+		}
+	}
+
+	switch {
+	case len(r.ByMonthDay) > 0 && len(r.ByDay) > 0:
+		// Both limit the month: "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13" is every Friday the 13th
+		var dates []time.Time
+		for _, date := range sortUniqueDates(monthDays) {
+			for _, match := range weekdays {
+				if date.Equal(match) {
+					dates = append(dates, date)
+					break
+				}
+			}
+		}
+		return dates
+	case len(r.ByMonthDay) > 0:
+		return sortUniqueDates(monthDays)
+	case len(r.ByDay) > 0:
+		return sortUniqueDates(weekdays)
+	}
+
+	// Without BYDAY/BYMONTHDAY the series repeats on the day of month of its start (months without it are skipped)
+	if dtstart.Day() <= daysInMonth {
+		return []time.Time{monthStart.AddDate(0, 0, dtstart.Day()-1)}
+	}
+	return nil
+}
+
+func sortUniqueDates(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	unique := dates[:0]
+	for i, date := range dates {
+		if i == 0 || !date.Equal(dates[i-1]) {
+			unique = append(unique, date)
+		}
+	}
+	return unique
+}
+
+// dateOnly truncates t to its calendar date in UTC
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
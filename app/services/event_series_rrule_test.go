@@ -0,0 +1,222 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRRule(t *testing.T) {
+	until := utcDate(2027, time.December, 31)
+	tests := []struct {
+		name  string
+		value string
+		want  *recurrenceRule
+	}{
+		{
+			name:  "weekly by day",
+			value: "FREQ=WEEKLY;BYDAY=SU",
+			want:  &recurrenceRule{Freq: "WEEKLY", Interval: 1, ByDay: []rruleWeekday{{Weekday: time.Sunday}}},
+		},
+		{
+			name:  "prefix, lower case and ordinal days",
+			value: "RRULE:freq=monthly;byday=1SU,-1FR;count=5",
+			want: &recurrenceRule{Freq: "MONTHLY", Interval: 1, Count: 5,
+				ByDay: []rruleWeekday{{Weekday: time.Sunday, Nth: 1}, {Weekday: time.Friday, Nth: -1}}},
+		},
+		{
+			name:  "last day of the month until a date",
+			value: "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20271231",
+			want:  &recurrenceRule{Freq: "MONTHLY", Interval: 1, ByMonthDay: []int{-1}, Until: &until},
+		},
+		{
+			name:  "until with a time is cut to its date",
+			value: "FREQ=MONTHLY;UNTIL=20271231T235959Z",
+			want:  &recurrenceRule{Freq: "MONTHLY", Interval: 1, Until: &until},
+		},
+		{
+			name:  "interval and week start",
+			value: "FREQ=WEEKLY;INTERVAL=2;WKST=MO;",
+			want:  &recurrenceRule{Freq: "WEEKLY", Interval: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRRule(tt.value)
+			if err != nil {
+				t.Fatalf("parseRRule(%q) error: %v", tt.value, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRRule(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRRuleInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", "  "},
+		{"missing FREQ", "BYDAY=SU"},
+		{"unsupported FREQ", "FREQ=DAILY"},
+		{"malformed part", "FREQ"},
+		{"zero interval", "FREQ=WEEKLY;INTERVAL=0"},
+		{"negative count", "FREQ=WEEKLY;COUNT=-1"},
+		{"short until", "FREQ=WEEKLY;UNTIL=2027"},
+		{"count and until", "FREQ=WEEKLY;COUNT=2;UNTIL=20270101"},
+		{"unknown day", "FREQ=MONTHLY;BYDAY=XX"},
+		{"ordinal out of range", "FREQ=MONTHLY;BYDAY=6SU"},
+		{"ordinal day in a weekly rule", "FREQ=WEEKLY;BYDAY=1SU"},
+		{"month day in a weekly rule", "FREQ=WEEKLY;BYMONTHDAY=1"},
+		{"month day out of range", "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{"zero month day", "FREQ=MONTHLY;BYMONTHDAY=0"},
+		{"other week start", "FREQ=WEEKLY;WKST=SU"},
+		{"unsupported part", "FREQ=WEEKLY;BYHOUR=9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRRule(tt.value); !errors.Is(err, ErrInvalidRRule) {
+				t.Errorf("parseRRule(%q) error = %v, want ErrInvalidRRule", tt.value, err)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		from    time.Time
+		to      time.Time
+		want    []time.Time
+	}{
+		{
+			name:    "weekly by day with count",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5",
+			dtstart: utcDate(2026, time.January, 7), // a Wednesday; the Monday before is skipped
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.December, 31),
+			want: []time.Time{utcDate(2026, time.January, 7), utcDate(2026, time.January, 12), utcDate(2026, time.January, 14),
+				utcDate(2026, time.January, 19), utcDate(2026, time.January, 21)},
+		},
+		{
+			name:    "count includes instances before the window",
+			rule:    "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=5",
+			dtstart: utcDate(2026, time.January, 7),
+			from:    utcDate(2026, time.January, 13),
+			to:      utcDate(2026, time.December, 31),
+			want:    []time.Time{utcDate(2026, time.January, 14), utcDate(2026, time.January, 19), utcDate(2026, time.January, 21)},
+		},
+		{
+			name:    "weekly until is inclusive",
+			rule:    "FREQ=WEEKLY;BYDAY=SU;UNTIL=20260201",
+			dtstart: utcDate(2026, time.January, 4),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.December, 31),
+			want: []time.Time{utcDate(2026, time.January, 4), utcDate(2026, time.January, 11), utcDate(2026, time.January, 18),
+				utcDate(2026, time.January, 25), utcDate(2026, time.February, 1)},
+		},
+		{
+			name:    "every other week on the start weekday",
+			rule:    "FREQ=WEEKLY;INTERVAL=2",
+			dtstart: utcDate(2026, time.January, 6),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.February, 5),
+			want:    []time.Time{utcDate(2026, time.January, 6), utcDate(2026, time.January, 20), utcDate(2026, time.February, 3)},
+		},
+		{
+			name:    "last day of the month",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=-1",
+			dtstart: utcDate(2026, time.January, 31),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.April, 30),
+			want: []time.Time{utcDate(2026, time.January, 31), utcDate(2026, time.February, 28), utcDate(2026, time.March, 31),
+				utcDate(2026, time.April, 30)},
+		},
+		{
+			name:    "month day 31 skips shorter months",
+			rule:    "FREQ=MONTHLY;BYMONTHDAY=31",
+			dtstart: utcDate(2026, time.January, 31),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.May, 31),
+			want:    []time.Time{utcDate(2026, time.January, 31), utcDate(2026, time.March, 31), utcDate(2026, time.May, 31)},
+		},
+		{
+			name:    "monthly on the start day skips months without it",
+			rule:    "FREQ=MONTHLY",
+			dtstart: utcDate(2026, time.January, 30),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.April, 30),
+			want:    []time.Time{utcDate(2026, time.January, 30), utcDate(2026, time.March, 30), utcDate(2026, time.April, 30)},
+		},
+		{
+			name:    "first and third Sunday with count",
+			rule:    "FREQ=MONTHLY;BYDAY=1SU,3SU;COUNT=4",
+			dtstart: utcDate(2026, time.January, 1),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.December, 31),
+			want: []time.Time{utcDate(2026, time.January, 4), utcDate(2026, time.January, 18), utcDate(2026, time.February, 1),
+				utcDate(2026, time.February, 15)},
+		},
+		{
+			name:    "last Friday until a date",
+			rule:    "FREQ=MONTHLY;BYDAY=-1FR;UNTIL=20260331",
+			dtstart: utcDate(2026, time.January, 1),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2026, time.December, 31),
+			want:    []time.Time{utcDate(2026, time.January, 30), utcDate(2026, time.February, 27), utcDate(2026, time.March, 27)},
+		},
+		{
+			name:    "month day and weekday both have to match",
+			rule:    "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			dtstart: utcDate(2026, time.January, 1),
+			from:    utcDate(2026, time.January, 1),
+			to:      utcDate(2027, time.December, 31),
+			want: []time.Time{utcDate(2026, time.February, 13), utcDate(2026, time.March, 13), utcDate(2026, time.November, 13),
+				utcDate(2027, time.August, 13)},
+		},
+		{
+			name:    "weekly across the start of daylight saving time",
+			rule:    "FREQ=WEEKLY;BYDAY=SU",
+			dtstart: time.Date(2026, time.March, 1, 0, 30, 0, 0, newYork),
+			from:    time.Date(2026, time.March, 1, 0, 30, 0, 0, newYork),
+			to:      time.Date(2026, time.March, 22, 23, 30, 0, 0, newYork),
+			want: []time.Time{utcDate(2026, time.March, 1), utcDate(2026, time.March, 8), utcDate(2026, time.March, 15),
+				utcDate(2026, time.March, 22)},
+		},
+		{
+			name:    "local calendar date is kept when UTC is a day ahead",
+			rule:    "FREQ=WEEKLY",
+			dtstart: time.Date(2026, time.March, 7, 23, 30, 0, 0, newYork), // a Saturday; Sunday in UTC
+			from:    time.Date(2026, time.March, 7, 23, 30, 0, 0, newYork),
+			to:      time.Date(2026, time.March, 14, 23, 30, 0, 0, newYork),
+			want:    []time.Time{utcDate(2026, time.March, 7), utcDate(2026, time.March, 14)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRRule(tt.rule)
+			if err != nil {
+				t.Fatalf("parseRRule(%q) error: %v", tt.rule, err)
+			}
+			got := rule.occurrences(tt.dtstart, tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
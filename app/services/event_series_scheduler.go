@@ -0,0 +1,59 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// StartSeriesGenerationScheduler starts a background goroutine that generates the occurrences of
+// every active recurring series up to horizonDays ahead
+// It runs once at startup and then daily at the specified hour (0-23)
+func StartSeriesGenerationScheduler(generationHour int, horizonDays int) {
+	if generationHour < 0 || generationHour > 23 {
+		log.Printf("Invalid series generation hour %d, defaulting to 1 AM", generationHour)
+		generationHour = 1
+	}
+
+	if horizonDays <= 0 {
+		log.Printf("Invalid series horizon %d, defaulting to %d days", horizonDays, DefaultSeriesHorizonDays)
+		horizonDays = DefaultSeriesHorizonDays
+	}
+
+	log.Printf("Starting series generation scheduler: runs daily at %02d:00, generates occurrences %d days ahead", generationHour, horizonDays)
+
+	go func() {
+		runSeriesGeneration(horizonDays)
+
+		// Calculate the next run time
+		now := time.Now()
+		nextRun := time.Date(now.Year(), now.Month(), now.Day(), generationHour, 0, 0, 0, now.Location())
+
+		// If the scheduled time has already passed today, schedule for tomorrow
+		if !nextRun.After(now) {
+			nextRun = nextRun.AddDate(0, 0, 1)
+		}
+
+		time.Sleep(time.Until(nextRun))
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		runSeriesGeneration(horizonDays)
+		for range ticker.C {
+			runSeriesGeneration(horizonDays)
+		}
+	}()
+}
+
+// runSeriesGeneration generates occurrences and logs the results
+func runSeriesGeneration(horizonDays int) {
+	startTime := time.Now()
+	created, err := GenerateAllSeriesOccurrences(horizonDays)
+	duration := time.Since(startTime)
+
+	if err != nil {
+		log.Printf("ERROR: Series generation finished with errors after %v (created %d occurrence(s)): %v", duration, created, err)
+		return
+	}
+	log.Printf("✓ Series generation completed in %v: created %d occurrence(s)", duration, created)
+}
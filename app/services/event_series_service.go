@@ -0,0 +1,675 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEventSeriesNotFound       = errors.New("event series not found")
+	ErrSeriesOccurrenceNotFound  = errors.New("occurrence not found in this series")
+	ErrSeriesExceptionNotFound   = errors.New("exception not found in this series")
+	ErrSeriesExceptionOutOfRange = errors.New("exception date is before the series start")
+)
+
+// DefaultSeriesHorizonDays is how far ahead occurrences are generated when no horizon is given
+const DefaultSeriesHorizonDays = 60
+
+// seriesTemplateColumns are the series columns copied into occurrences (same names on event_details)
+var seriesTemplateColumns = []string{
+	"branch_id", "event_type_id", "event_category_id", "event_sub_category_id", "scale", "theme",
+	"daily_start_time", "daily_end_time", "spiritual_orator", "language", "country", "state", "city",
+	"district", "post_office", "pincode", "address", "address_type", "police_station", "area_covered",
+}
+
+// seriesScheduleColumns change which dates a series occurs on
+var seriesScheduleColumns = []string{"rrule", "start_date", "end_date", "duration_days"}
+
+// SeriesOccurrenceReport is the beneficiary report of a single occurrence
+type SeriesOccurrenceReport struct {
+	BeneficiaryMen   int    `json:"beneficiary_men"`
+	BeneficiaryWomen int    `json:"beneficiary_women"`
+	BeneficiaryChild int    `json:"beneficiary_child"`
+	InitiationMen    int    `json:"initiation_men"`
+	InitiationWomen  int    `json:"initiation_women"`
+	InitiationChild  int    `json:"initiation_child"`
+	Status           string `json:"status"` // defaults to complete
+	// Values of the custom fields of the occurrence's category by key; omitted keeps the current
+	// ones. Required fields must be filled for a complete report.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// SeriesGenerationResult reports what a generation run changed
+type SeriesGenerationResult struct {
+	Created        int        `json:"created"`
+	GeneratedUntil *time.Time `json:"generated_until,omitempty"`
+}
+
+// EventSeriesRollup aggregates the occurrences of a series
+type EventSeriesRollup struct {
+	SeriesID         uint          `json:"series_id"`
+	Occurrences      int64         `json:"occurrences"`
+	Reported         int64         `json:"reported"`
+	Pending          int64         `json:"pending"`  // past or current, not yet reported
+	Upcoming         int64         `json:"upcoming"` // in the future
+	Exceptions       int64         `json:"exceptions"`
+	Totals           ImpactTotals  `json:"totals"` // reported occurrences only
+	AvgBeneficiaries float64       `json:"avg_beneficiaries"`
+	AvgInitiations   float64       `json:"avg_initiations"`
+	FirstOccurrence  *time.Time    `json:"first_occurrence,omitempty"`
+	LastReported     *time.Time    `json:"last_reported,omitempty"`
+	NextOccurrence   *time.Time    `json:"next_occurrence,omitempty"`
+	ByMonth          []ImpactGroup `json:"by_month"`
+}
+
+// validateEventSeries checks the recurrence fields of a series
+func validateEventSeries(series *models.EventSeries) error {
+	if series.Name == "" {
+		return errors.New("name is required")
+	}
+	if series.StartDate.IsZero() {
+		return errors.New("start_date is required")
+	}
+	if series.EventTypeID == 0 || series.EventCategoryID == 0 {
+		return errors.New("event_type_id and event_category_id are required")
+	}
+	if series.EndDate != nil && series.EndDate.Before(series.StartDate) {
+		return errors.New("end_date must be on or after start_date")
+	}
+	if series.DurationDays < 1 {
+		return errors.New("duration_days must be at least 1")
+	}
+	_, err := parseRRule(series.RRule)
+	return err
+}
+
+// CreateEventSeries creates a series; occurrences are generated separately
+func CreateEventSeries(series *models.EventSeries, actor Actor) error {
+	if series.DurationDays == 0 {
+		series.DurationDays = 1
+	}
+	if err := validateEventSeries(series); err != nil {
+		return err
+	}
+	series.ID = 0
+	series.Active = true
+	series.GeneratedUntil = nil
+	series.CreatedOn = time.Now()
+	series.CreatedBy = actor.Email
+	return config.DB.Omit(clause.Associations).Create(series).Error
+}
+
+// GetAllEventSeries lists series, optionally of one branch
+func GetAllEventSeries(branchID uint) ([]models.EventSeries, error) {
+	var series []models.EventSeries
+	db := config.DB.Preload("EventType").Preload("EventCategory").Preload("Branch")
+	if branchID > 0 {
+		db = db.Where("branch_id = ?", branchID)
+	}
+	err := db.Order("name ASC, id ASC").Find(&series).Error
+	return series, err
+}
+
+// GetEventSeriesByID returns a series with its exceptions
+func GetEventSeriesByID(id uint) (*models.EventSeries, error) {
+	var series models.EventSeries
+	err := config.DB.
+		Preload("EventType").
+		Preload("EventCategory").
+		Preload("EventSubCategory").
+		Preload("Branch").
+		Preload("Exceptions", func(db *gorm.DB) *gorm.DB { return db.Order("date ASC") }).
+		First(&series, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventSeriesNotFound
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+// UpdateEventSeries replaces the name, schedule and template of a series. Template changes are
+// applied to upcoming occurrences nobody has touched yet; schedule changes re-align upcoming
+// untouched occurrences with the new rule. Occurrences that have been edited or reported are never changed.
+func UpdateEventSeries(id uint, input models.EventSeries, actor Actor) (*models.EventSeries, error) {
+	if input.DurationDays == 0 {
+		input.DurationDays = 1
+	}
+	if err := validateEventSeries(&input); err != nil {
+		return nil, err
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		series, err := lockEventSeries(tx, id)
+		if err != nil {
+			return err
+		}
+
+		sch, err := parseModelSchema(tx, series)
+		if err != nil {
+			return err
+		}
+		input.ID = series.ID
+		changes := changedColumns(context.Background(), sch, series, &input, map[string]bool{
+			"id": true, "active": true, "generated_until": true, "created_on": true, "created_by": true,
+			"updated_on": true, "updated_by": true,
+		})
+		if len(changes) == 0 {
+			return nil
+		}
+		now := time.Now()
+		changes["updated_on"] = &now
+		changes["updated_by"] = actor.Email
+		if err := tx.Model(series).Updates(changes).Error; err != nil {
+			return err
+		}
+		if err := tx.First(series, id).Error; err != nil {
+			return err
+		}
+
+		today := dateOnly(time.Now())
+		template := make(map[string]interface{})
+		for _, col := range seriesTemplateColumns {
+			if value, ok := changes[col]; ok {
+				template[col] = value
+			}
+		}
+		if len(template) > 0 {
+			// A co-host of an occurrence that becomes its lead branch stops being a co-host
+			var rehosted []uint
+			if _, ok := template["branch_id"]; ok {
+				if err := untouchedOccurrences(tx, series.ID, today).Pluck("id", &rehosted).Error; err != nil {
+					return err
+				}
+			}
+			if err := untouchedOccurrences(tx, series.ID, today).Updates(template).Error; err != nil {
+				return err
+			}
+			if err := dropLeadBranchCoHosts(tx, rehosted, actor); err != nil {
+				return err
+			}
+		}
+
+		for _, col := range seriesScheduleColumns {
+			if _, ok := changes[col]; !ok {
+				continue
+			}
+			if _, err := pruneSeriesOccurrences(tx, series, today, actor); err != nil {
+				return err
+			}
+			if series.GeneratedUntil != nil {
+				from := today
+				if series.StartDate.After(from) {
+					from = series.StartDate
+				}
+				if _, err := generateSeriesOccurrences(tx, series, from, *series.GeneratedUntil, actor); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetEventSeriesByID(id)
+}
+
+// DeleteEventSeries deletes a series. Upcoming untouched occurrences are moved to the trash;
+// all other occurrences stay as one-off events.
+func DeleteEventSeries(id uint, actor Actor) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		series, err := lockEventSeries(tx, id)
+		if err != nil {
+			return err
+		}
+		var ids []uint
+		if err := untouchedOccurrences(tx, series.ID, dateOnly(time.Now())).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			if _, err := softDeleteWhere(tx, &models.EventDetails{}, actor.Email, trashTimestamp(), "id IN ?", ids); err != nil {
+				return err
+			}
+			if err := recordEventsDeleted(tx, ids, actor.Email); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(series).Error
+	})
+}
+
+// AddSeriesException marks a date on which the series does not take place. An untouched
+// occurrence already generated for that date is moved to the trash.
+func AddSeriesException(seriesID uint, date time.Time, reason string, actor Actor) (*models.EventSeriesException, error) {
+	exception := &models.EventSeriesException{
+		SeriesID:  seriesID,
+		Date:      dateOnly(date),
+		Reason:    reason,
+		CreatedOn: time.Now(),
+		CreatedBy: actor.Email,
+	}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		series, err := lockEventSeries(tx, seriesID)
+		if err != nil {
+			return err
+		}
+		if exception.Date.Before(dateOnly(series.StartDate)) {
+			return ErrSeriesExceptionOutOfRange
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "series_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"reason"}),
+		}).Create(exception).Error; err != nil {
+			return err
+		}
+		_, err = pruneSeriesOccurrences(tx, series, exception.Date, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exception, nil
+}
+
+// RemoveSeriesException reinstates a date; its occurrence is generated again when the date has
+// already been generated
+func RemoveSeriesException(seriesID uint, exceptionID uint, actor Actor) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		series, err := lockEventSeries(tx, seriesID)
+		if err != nil {
+			return err
+		}
+		var exception models.EventSeriesException
+		if err := tx.Where("id = ? AND series_id = ?", exceptionID, seriesID).First(&exception).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSeriesExceptionNotFound
+			}
+			return err
+		}
+		if err := tx.Delete(&exception).Error; err != nil {
+			return err
+		}
+		if series.GeneratedUntil != nil && !exception.Date.After(*series.GeneratedUntil) {
+			_, err = generateSeriesOccurrences(tx, series, exception.Date, exception.Date, actor)
+		}
+		return err
+	})
+}
+
+// GenerateSeriesOccurrences creates the occurrences of a series up to horizonDays from today,
+// continuing after the last generated date
+func GenerateSeriesOccurrences(seriesID uint, horizonDays int, actor Actor) (*SeriesGenerationResult, error) {
+	if horizonDays <= 0 {
+		horizonDays = DefaultSeriesHorizonDays
+	}
+	result := &SeriesGenerationResult{}
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		series, err := lockEventSeries(tx, seriesID)
+		if err != nil {
+			return err
+		}
+		if !series.Active {
+			result.GeneratedUntil = series.GeneratedUntil
+			return nil
+		}
+
+		from := dateOnly(series.StartDate)
+		if series.GeneratedUntil != nil {
+			from = dateOnly(*series.GeneratedUntil).AddDate(0, 0, 1)
+		}
+		to := dateOnly(time.Now()).AddDate(0, 0, horizonDays)
+		if series.EndDate != nil && series.EndDate.Before(to) {
+			to = dateOnly(*series.EndDate)
+		}
+		if from.After(to) {
+			result.GeneratedUntil = series.GeneratedUntil
+			return nil
+		}
+
+		if result.Created, err = generateSeriesOccurrences(tx, series, from, to, actor); err != nil {
+			return err
+		}
+		result.GeneratedUntil = &to
+		return tx.Model(series).UpdateColumn("generated_until", to).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenerateAllSeriesOccurrences runs GenerateSeriesOccurrences for every active series
+func GenerateAllSeriesOccurrences(horizonDays int) (int, error) {
+	var ids []uint
+	if err := config.DB.Model(&models.EventSeries{}).Where("active = ?", true).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	created := 0
+	var errs []error
+	for _, id := range ids {
+		result, err := GenerateSeriesOccurrences(id, horizonDays, Actor{Email: "system"})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("series %d: %w", id, err))
+			continue
+		}
+		created += result.Created
+	}
+	return created, errors.Join(errs...)
+}
+
+// GetSeriesOccurrences lists the live occurrences of a series by date
+func GetSeriesOccurrences(seriesID uint) ([]models.EventDetails, error) {
+	if _, err := GetEventSeriesByID(seriesID); err != nil {
+		return nil, err
+	}
+	var events []models.EventDetails
+	err := config.DB.
+		Preload("EventType").
+		Preload("EventCategory").
+		Preload("Branch").
+		Where("series_id = ?", seriesID).
+		Order("occurrence_date ASC, id ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// ReportSeriesOccurrence records the beneficiary numbers of one occurrence
+func ReportSeriesOccurrence(seriesID uint, eventID uint, report SeriesOccurrenceReport, actor Actor) (*models.EventDetails, error) {
+	if report.Status == "" {
+		report.Status = "complete"
+	}
+	if report.Status != "complete" && report.Status != "incomplete" {
+		return nil, errors.New("status must be complete or incomplete")
+	}
+	if report.BeneficiaryMen < 0 || report.BeneficiaryWomen < 0 || report.BeneficiaryChild < 0 ||
+		report.InitiationMen < 0 || report.InitiationWomen < 0 || report.InitiationChild < 0 {
+		return nil, errors.New("beneficiary and initiation numbers cannot be negative")
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND series_id = ?", eventID, seriesID).
+			First(&event).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSeriesOccurrenceNotFound
+			}
+			return err
+		}
+		if err := EnsureBaselineRevision(tx, eventID, actor); err != nil {
+			return err
+		}
+		customFields, err := EventCustomFieldsForUpdate(eventID, map[string]interface{}{"status": report.Status},
+			report.CustomFields, report.CustomFields != nil)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		updates := map[string]interface{}{
+			"beneficiary_men":   report.BeneficiaryMen,
+			"beneficiary_women": report.BeneficiaryWomen,
+			"beneficiary_child": report.BeneficiaryChild,
+			"initiation_men":    report.InitiationMen,
+			"initiation_women":  report.InitiationWomen,
+			"initiation_child":  report.InitiationChild,
+			"status":            report.Status,
+			"custom_fields":     customFields,
+			"updated_on":        &now,
+			"updated_by":        actor.Email,
+		}
+		// Beneficiary counts of events with day-wise attendance are derived from it
+		if err := dropAttendanceDerivedCounts(tx, eventID, updates); err != nil {
+			return err
+		}
+		if err := tx.Model(&event).Updates(updates).Error; err != nil {
+			return err
+		}
+		_, err = CaptureEventRevision(tx, eventID, models.RevisionActionUpdate, nil, actor)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetEventByID(eventID)
+}
+
+// GetEventSeriesRollup aggregates the occurrences of a series. Beneficiary totals and averages
+// count reported (complete) occurrences only.
+func GetEventSeriesRollup(seriesID uint) (*EventSeriesRollup, error) {
+	if _, err := GetEventSeriesByID(seriesID); err != nil {
+		return nil, err
+	}
+	rollup := &EventSeriesRollup{SeriesID: seriesID}
+	today := dateOnly(time.Now())
+
+	var counts struct {
+		Occurrences     int64
+		Reported        int64
+		Pending         int64
+		Upcoming        int64
+		FirstOccurrence *time.Time
+		LastReported    *time.Time
+		NextOccurrence  *time.Time
+	}
+	if err := config.DB.Raw(`
+		SELECT COUNT(*) AS occurrences,
+		       COUNT(*) FILTER (WHERE status = 'complete') AS reported,
+		       COUNT(*) FILTER (WHERE status <> 'complete' AND occurrence_date <= @today) AS pending,
+		       COUNT(*) FILTER (WHERE status <> 'complete' AND occurrence_date > @today) AS upcoming,
+		       MIN(occurrence_date) AS first_occurrence,
+		       MAX(occurrence_date) FILTER (WHERE status = 'complete') AS last_reported,
+		       MIN(occurrence_date) FILTER (WHERE occurrence_date >= @today) AS next_occurrence
+		FROM event_details
+		WHERE series_id = @series AND deleted_at IS NULL`,
+		map[string]interface{}{"series": seriesID, "today": today}).Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	rollup.Occurrences, rollup.Reported = counts.Occurrences, counts.Reported
+	rollup.Pending, rollup.Upcoming = counts.Pending, counts.Upcoming
+	rollup.FirstOccurrence, rollup.LastReported, rollup.NextOccurrence = counts.FirstOccurrence, counts.LastReported, counts.NextOccurrence
+
+	if err := config.DB.Model(&models.EventSeriesException{}).Where("series_id = ?", seriesID).Count(&rollup.Exceptions).Error; err != nil {
+		return nil, err
+	}
+
+	analytics, err := GetImpactAnalytics(ImpactAnalyticsQuery{GroupBy: "month", Statuses: []string{"complete"}, SeriesID: seriesID})
+	if err != nil {
+		return nil, err
+	}
+	rollup.Totals = analytics.Totals
+	rollup.ByMonth = analytics.Groups
+	if rollup.Reported > 0 {
+		rollup.AvgBeneficiaries = float64(rollup.Totals.BeneficiaryTotal) / float64(rollup.Reported)
+		rollup.AvgInitiations = float64(rollup.Totals.InitiationTotal) / float64(rollup.Reported)
+	}
+	return rollup, nil
+}
+
+func lockEventSeries(tx *gorm.DB, id uint) (*models.EventSeries, error) {
+	var series models.EventSeries
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&series, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventSeriesNotFound
+		}
+		return nil, err
+	}
+	return &series, nil
+}
+
+// untouchedOccurrences selects live occurrences on or after from that were generated but never
+// edited, reported or submitted
+func untouchedOccurrences(tx *gorm.DB, seriesID uint, from time.Time) *gorm.DB {
+	return tx.Model(&models.EventDetails{}).
+		Where("series_id = ? AND occurrence_date >= ?", seriesID, from).
+		Where("status <> ? AND workflow_state = ? AND updated_on IS NULL", "complete", models.WorkflowStateDraft)
+}
+
+// seriesExceptionDates returns the exception dates of a series as a set of YYYY-MM-DD keys
+func seriesExceptionDates(tx *gorm.DB, seriesID uint) (map[string]bool, error) {
+	var dates []time.Time
+	if err := tx.Model(&models.EventSeriesException{}).Where("series_id = ?", seriesID).Pluck("date", &dates).Error; err != nil {
+		return nil, err
+	}
+	set := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		set[date.Format("2006-01-02")] = true
+	}
+	return set, nil
+}
+
+// seriesDates returns the scheduled (non-exception) dates of a series within [from, to]
+func seriesDates(tx *gorm.DB, series *models.EventSeries, from, to time.Time) ([]time.Time, error) {
+	rule, err := parseRRule(series.RRule)
+	if err != nil {
+		return nil, err
+	}
+	if series.EndDate != nil && series.EndDate.Before(to) {
+		to = *series.EndDate
+	}
+	exceptions, err := seriesExceptionDates(tx, series.ID)
+	if err != nil {
+		return nil, err
+	}
+	var dates []time.Time
+	for _, date := range rule.occurrences(series.StartDate, from, to) {
+		if !exceptions[date.Format("2006-01-02")] {
+			dates = append(dates, date)
+		}
+	}
+	return dates, nil
+}
+
+// generateSeriesOccurrences creates the missing occurrences of a series within [from, to],
+// pre-filled from the series template
+func generateSeriesOccurrences(tx *gorm.DB, series *models.EventSeries, from, to time.Time, actor Actor) (int, error) {
+	dates, err := seriesDates(tx, series, dateOnly(from), dateOnly(to))
+	if err != nil || len(dates) == 0 {
+		return 0, err
+	}
+
+	var existing []time.Time
+	if err := tx.Model(&models.EventDetails{}).
+		Where("series_id = ? AND occurrence_date BETWEEN ? AND ?", series.ID, dates[0], dates[len(dates)-1]).
+		Pluck("occurrence_date", &existing).Error; err != nil {
+		return 0, err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, date := range existing {
+		have[date.Format("2006-01-02")] = true
+	}
+
+	createdBy := actor.Email
+	if createdBy == "" {
+		createdBy = "system"
+	}
+	created := 0
+	for _, date := range dates {
+		if have[date.Format("2006-01-02")] {
+			continue
+		}
+		occurrenceDate := date
+		event := models.EventDetails{
+			EventTypeID:        series.EventTypeID,
+			EventCategoryID:    series.EventCategoryID,
+			EventSubCategoryID: series.EventSubCategoryID,
+			Scale:              series.Scale,
+			Theme:              series.Theme,
+			StartDate:          date,
+			EndDate:            date.AddDate(0, 0, series.DurationDays-1),
+			DailyStartTime:     series.DailyStartTime,
+			DailyEndTime:       series.DailyEndTime,
+			SpiritualOrator:    series.SpiritualOrator,
+			Language:           series.Language,
+			Country:            series.Country,
+			State:              series.State,
+			City:               series.City,
+			District:           series.District,
+			PostOffice:         series.PostOffice,
+			Pincode:            series.Pincode,
+			Address:            series.Address,
+			AddressType:        series.AddressType,
+			PoliceStation:      series.PoliceStation,
+			AreaCovered:        series.AreaCovered,
+			BranchID:           series.BranchID,
+			Status:             "incomplete",
+			WorkflowState:      models.WorkflowStateDraft,
+			SeriesID:           &series.ID,
+			OccurrenceDate:     &occurrenceDate,
+			CreatedOn:          time.Now(),
+			CreatedBy:          createdBy,
+		}
+		// Occurrences start without custom field values; they are filled in when reported
+		if err := ApplyEventCustomFields(&event, nil); err != nil {
+			return created, err
+		}
+		if err := tx.Omit(clause.Associations).Create(&event).Error; err != nil {
+			return created, err
+		}
+		if _, err := CaptureEventRevision(tx, event.ID, models.RevisionActionCreate, nil, actor); err != nil {
+			return created, err
+		}
+		if err := RecordDomainEvent(tx, eventCreatedDomainEvent(&event, EventSourceSeries)); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// pruneSeriesOccurrences moves untouched occurrences on or after from that no longer match the
+// schedule (rule changed, exception added) to the trash
+func pruneSeriesOccurrences(tx *gorm.DB, series *models.EventSeries, from time.Time, actor Actor) (int, error) {
+	var occurrences []models.EventDetails
+	if err := untouchedOccurrences(tx, series.ID, dateOnly(from)).
+		Select("id", "occurrence_date", "end_date").
+		Find(&occurrences).Error; err != nil {
+		return 0, err
+	}
+	if len(occurrences) == 0 {
+		return 0, nil
+	}
+
+	last := dateOnly(from)
+	for _, occurrence := range occurrences {
+		if occurrence.OccurrenceDate != nil && occurrence.OccurrenceDate.After(last) {
+			last = dateOnly(*occurrence.OccurrenceDate)
+		}
+	}
+	dates, err := seriesDates(tx, series, dateOnly(from), last)
+	if err != nil {
+		return 0, err
+	}
+	scheduled := make(map[string]bool, len(dates))
+	for _, date := range dates {
+		scheduled[date.Format("2006-01-02")] = true
+	}
+
+	var stale []uint
+	for _, occurrence := range occurrences {
+		if occurrence.OccurrenceDate == nil || !scheduled[occurrence.OccurrenceDate.Format("2006-01-02")] {
+			stale = append(stale, occurrence.ID)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+	if _, err := softDeleteWhere(tx, &models.EventDetails{}, actor.Email, trashTimestamp(), "id IN ?", stale); err != nil {
+		return 0, err
+	}
+	if err := recordEventsDeleted(tx, stale, actor.Email); err != nil {
+		return 0, err
+	}
+	log.Printf("Removed %d unscheduled occurrence(s) of series %d", len(stale), series.ID)
+	return len(stale), nil
+}
@@ -2,197 +2,137 @@ package services
 
 import (
 	"errors"
-	// "log"
+	"log"
 	"time"
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
 	"github.com/followCode/djjs-event-reporting-backend/config"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// Create a new event
-func CreateEvent(event *models.EventDetails) error {
+// Create a new event with the related rows of the payload, announce it with an event.created
+// domain event and record its initial state as the first revision, in one transaction
+func CreateEvent(event *models.EventDetails, payload EventFrontendPayload, actor Actor) error {
 	event.CreatedOn = time.Now()
 	event.UpdatedOn = nil
 
-	if err := config.DB.Create(event).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-// Get all events with type + category
-// statusFilter can be "complete", "incomplete", or empty string for all
-func GetAllEvents(statusFilter string) ([]models.EventDetails, error) {
-	var events []models.EventDetails
-
-	db := config.DB.
-		Preload("EventType").
-		Preload("EventCategory").
-		Preload("EventSubCategory").
-		Preload("Branch")
-
-	// Apply status filter if provided
-	if statusFilter != "" {
-		db = db.Where("status = ?", statusFilter)
-	}
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		if err := RecordDomainEvent(tx, eventCreatedDomainEvent(event, EventSourceAPI)); err != nil {
+			return err
+		}
 
-	if err := db.Find(&events).Error; err != nil {
-		return nil, err
-	}
+		// Create related records (media, special guests, volunteers, donations, etc.)
+		if err := CreateEventRelatedData(tx, event.ID, payload); err != nil {
+			// Log error but don't fail event creation; related data can be added later
+			log.Printf("Warning: Failed to create related data for event %d: %v", event.ID, err)
+		}
 
-	return events, nil
+		_, err := CaptureEventRevision(tx, event.ID, models.RevisionActionCreate, nil, actor)
+		return err
+	})
 }
 
-// Search events by type, category, or theme
-func SearchEvents(search string) ([]models.EventDetails, error) {
-	var events []models.EventDetails
-
-	db := config.DB.Preload("EventType").Preload("EventCategory").Preload("Branch")
-
-	if search != "" {
-		db = db.Where(`
-			LOWER(theme) LIKE LOWER(?) OR
-			LOWER(scale) LIKE LOWER(?)`,
-			"%"+search+"%", "%"+search+"%",
-		)
+// eventCreatedDomainEvent describes a newly created event
+func eventCreatedDomainEvent(event *models.EventDetails, source string) EventCreated {
+	return EventCreated{
+		EventID:       event.ID,
+		BranchID:      event.BranchID,
+		Status:        event.Status,
+		WorkflowState: event.WorkflowState,
+		Source:        source,
+		CreatedBy:     event.CreatedBy,
 	}
-
-	if err := db.Find(&events).Error; err != nil {
-		return nil, errors.New("error fetching events")
-	}
-
-	if len(events) == 0 {
-		return nil, errors.New("no events found")
-	}
-
-	return events, nil
 }
 
 var ErrEventNotFound = errors.New("event not found")
 
-// Update event
-func UpdateEvent(eventID uint, updatedData map[string]interface{}) error {
-	var event models.EventDetails
-
-	if err := config.DB.First(&event, eventID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return ErrEventNotFound
+// Update event columns and record the result as a revision in the same transaction
+// expectedVersion (optional) is the version the change is based on; ErrVersionConflict is returned when it is stale
+func UpdateEvent(eventID uint, updatedData map[string]interface{}, actor Actor, expectedVersion *int) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
 		}
-		return err
-	}
-
-	now := time.Now()
-	updatedData["updated_on"] = &now
-
-	if err := config.DB.Model(&event).Updates(updatedData).Error; err != nil {
-		return err
-	}
-
-	return nil
-}
-
-// Delete event and all related data
-func DeleteEvent(eventID uint) error {
-	// Start a transaction to ensure all deletions succeed or none do
-	tx := config.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+		// The row is locked, so the version cannot change before the update below
+		if err := checkRowVersion(event.Version, expectedVersion); err != nil {
+			return err
 		}
-	}()
-
-	// Delete all special guests for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.SpecialGuest{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete special guests: " + err.Error())
-	}
-
-	// Delete all volunteers for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.Volunteer{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete volunteers: " + err.Error())
-	}
 
-	// Delete all event media for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.EventMedia{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete event media: " + err.Error())
-	}
-
-	// Delete all donations for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.Donation{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete donations: " + err.Error())
-	}
-
-	// Delete all promotion material details for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.PromotionMaterialDetails{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete promotion materials: " + err.Error())
-	}
+		// Make sure the state before this update is kept as a revision
+		if err := EnsureBaselineRevision(tx, eventID, actor); err != nil {
+			return err
+		}
 
-	// Delete the event itself
-	if err := tx.Delete(&models.EventDetails{}, eventID).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete event: " + err.Error())
-	}
+		// Beneficiary counts of events with day-wise attendance are derived from it
+		if err := dropAttendanceDerivedCounts(tx, eventID, updatedData); err != nil {
+			return err
+		}
 
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		return errors.New("failed to commit transaction: " + err.Error())
-	}
+		now := time.Now()
+		updatedData["updated_on"] = &now
+		if actor.Email != "" {
+			updatedData["updated_by"] = actor.Email
+		}
+		fromStatus := event.Status
+		if err := tx.Model(&event).Updates(updatedData).Error; err != nil {
+			return err
+		}
+		if err := recordEventStatusChange(tx, eventID, fromStatus, updatedData); err != nil {
+			return err
+		}
+		if _, ok := updatedData["branch_id"]; ok {
+			if err := dropLeadBranchCoHosts(tx, []uint{eventID}, actor); err != nil {
+				return err
+			}
+		}
 
-	return nil
+		_, err := CaptureEventRevision(tx, eventID, models.RevisionActionUpdate, nil, actor)
+		return err
+	})
 }
 
-// DeleteEventRelatedData deletes all related data for an event (without deleting the event itself)
-// This is used when updating an event to replace old related data with new ones
-func DeleteEventRelatedData(eventID uint) error {
-	// Start a transaction to ensure all deletions succeed or none do
-	tx := config.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+// DeleteEvent moves an event and its related data to the trash
+// Everything deleted here shares one deleted_at so that restoring the event brings it all back
+func DeleteEvent(eventID uint, deletedBy string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Select("id").First(&event, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
 		}
-	}()
-
-	// Delete all special guests for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.SpecialGuest{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete special guests: " + err.Error())
-	}
 
-	// Delete all volunteers for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.Volunteer{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete volunteers: " + err.Error())
-	}
-
-	// Delete all event media for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.EventMedia{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete event media: " + err.Error())
-	}
+		deletedAt := trashTimestamp()
 
-	// Delete all donations for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.Donation{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete donations: " + err.Error())
-	}
-
-	// Delete all promotion material details for this event
-	if err := tx.Where("event_id = ?", eventID).Delete(&models.PromotionMaterialDetails{}).Error; err != nil {
-		tx.Rollback()
-		return errors.New("failed to delete promotion materials: " + err.Error())
-	}
-
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		return errors.New("failed to commit transaction: " + err.Error())
-	}
+		if _, err := softDeleteWhere(tx, &models.SpecialGuest{}, deletedBy, deletedAt, "event_id = ?", eventID); err != nil {
+			return errors.New("failed to delete special guests: " + err.Error())
+		}
+		if _, err := softDeleteWhere(tx, &models.Volunteer{}, deletedBy, deletedAt, "event_id = ?", eventID); err != nil {
+			return errors.New("failed to delete volunteers: " + err.Error())
+		}
+		if _, err := softDeleteWhere(tx, &models.EventMedia{}, deletedBy, deletedAt, "event_id = ?", eventID); err != nil {
+			return errors.New("failed to delete event media: " + err.Error())
+		}
+		if _, err := softDeleteWhere(tx, &models.Donation{}, deletedBy, deletedAt, "event_id = ?", eventID); err != nil {
+			return errors.New("failed to delete donations: " + err.Error())
+		}
+		// Promotion material details have no trash of their own; they stay attached to the
+		// deleted event and are removed when it is purged
 
-	return nil
+		if _, err := softDeleteWhere(tx, &models.EventDetails{}, deletedBy, deletedAt, "id = ?", eventID); err != nil {
+			return errors.New("failed to delete event: " + err.Error())
+		}
+		return RecordDomainEvent(tx, EventDeleted{EventID: eventID, DeletedBy: deletedBy})
+	})
 }
 
 // GetEventByID retrieves an event by ID with all related data
@@ -215,7 +155,10 @@ func GetEventByID(eventID uint) (*models.EventDetails, error) {
 }
 
 // UpdateEventStatus updates the status of an event
-func UpdateEventStatus(eventID uint, status string) error {
+// expectedVersion (optional) is the version the change is based on; ErrVersionConflict is returned when it is stale
+// The custom field values are checked against the new status: an event missing required values
+// cannot become complete (*validators.SchemaError)
+func UpdateEventStatus(eventID uint, status string, expectedVersion *int) error {
 	var event models.EventDetails
 
 	if err := config.DB.First(&event, eventID).Error; err != nil {
@@ -224,24 +167,43 @@ func UpdateEventStatus(eventID uint, status string) error {
 		}
 		return err
 	}
+	if err := checkRowVersion(event.Version, expectedVersion); err != nil {
+		return err
+	}
 
 	now := time.Now()
 	updateData := map[string]interface{}{
 		"status":     status,
 		"updated_on": &now,
 	}
-
-	if err := config.DB.Model(&event).Updates(updateData).Error; err != nil {
+	if err := NormalizeFlatEventCustomFields(eventID, updateData); err != nil {
 		return err
 	}
+	fromStatus := event.Status
 
-	return nil
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := updateVersioned(tx, &event, expectedVersion, updateData); err != nil {
+			return err
+		}
+		return recordEventStatusChange(tx, eventID, fromStatus, updateData)
+	})
+}
+
+// recordEventStatusChange writes an event.status_changed domain event when updatedData, applied
+// to an event whose status was fromStatus, changed the status
+func recordEventStatusChange(tx *gorm.DB, eventID uint, fromStatus string, updatedData map[string]interface{}) error {
+	toStatus, ok := updatedData["status"].(string)
+	if !ok || toStatus == fromStatus {
+		return nil
+	}
+	return RecordDomainEvent(tx, EventStatusChanged{EventID: eventID, FromStatus: fromStatus, ToStatus: toStatus})
 }
 
 // GetEventsByDateRange retrieves events within a date range filtered by created_on date
 // startDate and endDate are optional - if nil, no date filtering is applied
 // dateFilterType is always "created_on" - filters by when the event was created
-func GetEventsByDateRange(startDate *time.Time, endDate *time.Time, statusFilter string, dateFilterType string) ([]models.EventDetails, error) {
+// workflowStateFilter restricts results to a review workflow state, or empty string for all
+func GetEventsByDateRange(startDate *time.Time, endDate *time.Time, statusFilter string, workflowStateFilter string, dateFilterType string) ([]models.EventDetails, error) {
 	var events []models.EventDetails
 
 	db := config.DB.
@@ -255,6 +217,11 @@ func GetEventsByDateRange(startDate *time.Time, endDate *time.Time, statusFilter
 		db = db.Where("status = ?", statusFilter)
 	}
 
+	// Apply workflow state filter if provided
+	if workflowStateFilter != "" {
+		db = db.Where("workflow_state = ?", workflowStateFilter)
+	}
+
 	// Apply date range filter
 	// Find events where the created_on date falls within the selected date range
 	// Since startDate is 00:00:00 UTC and endDate is 23:59:59.999 UTC, we can compare timestamps directly
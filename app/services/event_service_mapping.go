@@ -248,6 +248,19 @@ func MapFrontendPayloadToEvent(generalDetails map[string]interface{}, involvedPa
 		}
 	}
 
+	// Coordinates
+	for _, source := range []map[string]interface{}{venue, generalDetails} {
+		if source == nil || event.Latitude != nil || event.Longitude != nil {
+			continue
+		}
+		if latitude, ok := source["latitude"].(float64); ok {
+			event.Latitude = &latitude
+		}
+		if longitude, ok := source["longitude"].(float64); ok {
+			event.Longitude = &longitude
+		}
+	}
+
 	// Address Type
 	if addressType, ok := generalDetails["addressType"].(string); ok && addressType != "" {
 		event.AddressType = addressType
@@ -277,6 +290,11 @@ func MapFrontendPayloadToEvent(generalDetails map[string]interface{}, involvedPa
 		// Also handle snake_case for backward compatibility
 		branchIDUint := uint(branchId)
 		event.BranchID = &branchIDUint
+	} else if branchRef, ok := generalDetails["branchId"].(string); ok && branchRef != "" {
+		// Branch code (or an ID sent as a string)
+		if branchIDUint := resolveBranchRef(config.DB, branchRef); branchIDUint > 0 {
+			event.BranchID = &branchIDUint
+		}
 	}
 
 	// Map involved participants
@@ -321,6 +339,108 @@ func MapFrontendPayloadToEventWithStatus(generalDetails map[string]interface{},
 	return event, nil
 }
 
+// EventUpdateData converts an event mapped from a nested frontend payload to the column
+// updates of UpdateEventWithRelatedData. Empty text and zero counts leave the stored value
+// unchanged; the sub category and address type are always written so they can be cleared.
+func EventUpdateData(event *models.EventDetails) map[string]interface{} {
+	updateData := make(map[string]interface{})
+	if event.EventTypeID > 0 {
+		updateData["event_type_id"] = event.EventTypeID
+	}
+	if event.EventCategoryID > 0 {
+		updateData["event_category_id"] = event.EventCategoryID
+	}
+	if event.EventSubCategoryID != nil && *event.EventSubCategoryID > 0 {
+		updateData["event_sub_category_id"] = *event.EventSubCategoryID
+	} else {
+		// If eventSubCategoryID is nil or 0, set it to NULL in database
+		updateData["event_sub_category_id"] = nil
+	}
+	if event.Scale != "" {
+		updateData["scale"] = event.Scale
+	}
+	if event.Theme != "" {
+		updateData["theme"] = event.Theme
+	}
+	if !event.StartDate.IsZero() {
+		updateData["start_date"] = event.StartDate
+	}
+	if !event.EndDate.IsZero() {
+		updateData["end_date"] = event.EndDate
+	}
+	if event.DailyStartTime != nil {
+		updateData["daily_start_time"] = event.DailyStartTime
+	}
+	if event.DailyEndTime != nil {
+		updateData["daily_end_time"] = event.DailyEndTime
+	}
+	if event.SpiritualOrator != "" {
+		updateData["spiritual_orator"] = event.SpiritualOrator
+	}
+	if event.Language != "" {
+		updateData["language"] = event.Language
+	}
+	if event.Country != "" {
+		updateData["country"] = event.Country
+	}
+	if event.State != "" {
+		updateData["state"] = event.State
+	}
+	if event.District != "" {
+		updateData["district"] = event.District
+	}
+	if event.City != "" {
+		updateData["city"] = event.City
+	}
+	if event.Pincode != "" {
+		updateData["pincode"] = event.Pincode
+	}
+	if event.PostOffice != "" {
+		updateData["post_office"] = event.PostOffice
+	}
+	if event.Address != "" {
+		updateData["address"] = event.Address
+	}
+	// Always update address_type (even if empty) to allow clearing it
+	updateData["address_type"] = event.AddressType
+	if event.PoliceStation != "" {
+		updateData["police_station"] = event.PoliceStation
+	}
+	if event.AreaCovered != "" {
+		updateData["area_covered"] = event.AreaCovered
+	}
+	if event.Latitude != nil || event.Longitude != nil {
+		updateData["latitude"] = event.Latitude
+		updateData["longitude"] = event.Longitude
+	}
+	if event.BeneficiaryMen > 0 {
+		updateData["beneficiary_men"] = event.BeneficiaryMen
+	}
+	if event.BeneficiaryWomen > 0 {
+		updateData["beneficiary_women"] = event.BeneficiaryWomen
+	}
+	if event.BeneficiaryChild > 0 {
+		updateData["beneficiary_child"] = event.BeneficiaryChild
+	}
+	if event.InitiationMen > 0 {
+		updateData["initiation_men"] = event.InitiationMen
+	}
+	if event.InitiationWomen > 0 {
+		updateData["initiation_women"] = event.InitiationWomen
+	}
+	if event.InitiationChild > 0 {
+		updateData["initiation_child"] = event.InitiationChild
+	}
+	if event.BranchID != nil && *event.BranchID > 0 {
+		updateData["branch_id"] = *event.BranchID
+	}
+	if event.Status != "" {
+		updateData["status"] = event.Status
+	}
+
+	return updateData
+}
+
 // Helper functions for parsing dates and times
 func parseDate(dateStr string) (time.Time, error) {
 	// Try different date formats
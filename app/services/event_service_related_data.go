@@ -6,11 +6,11 @@ import (
 	"strconv"
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
-	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
 )
 
-// CreateEventRelatedData creates related data for an event (media, guests, volunteers, donations)
-func CreateEventRelatedData(eventID uint, payload struct {
+// EventFrontendPayload is the nested payload the frontend sends when creating or updating an event
+type EventFrontendPayload struct {
 	GeneralDetails       map[string]interface{} `json:"generalDetails"`
 	MediaPromotion       map[string]interface{} `json:"mediaPromotion"`
 	InvolvedParticipants map[string]interface{} `json:"involvedParticipants"`
@@ -19,10 +19,117 @@ func CreateEventRelatedData(eventID uint, payload struct {
 	SpecialGuests        []interface{}          `json:"specialGuests"`
 	Volunteers           []interface{}          `json:"volunteers"`
 	UploadedFiles        map[string]interface{} `json:"uploadedFiles"`
+	CustomFields         map[string]interface{} `json:"customFields"`
 	DraftID              *uint                  `json:"draftId,omitempty"`
 	Status               string                 `json:"status,omitempty"`
-}) error {
+}
+
+// CreateEventRelatedData creates related data for an event (media, guests, volunteers, donations)
+// using tx. Each row is inserted under a savepoint, so a bad row is skipped without aborting the
+// transaction; a failing donation stops the remaining donations and is returned. New media and
+// donations are announced with media.uploaded and donation.created domain events.
+func CreateEventRelatedData(tx *gorm.DB, eventID uint, payload EventFrontendPayload) error {
 	// Create Event Media records
+	for _, media := range buildEventMediaRows(tx, eventID, payload) {
+		clearPayloadRowID(&media)
+		if err := createRelatedRow(tx, &media, func() DomainEvent { return mediaUploadedDomainEvent(&media) }); err != nil {
+			// Log error but continue processing other media items
+			// This prevents one bad record from blocking all others
+			log.Printf("Error creating event media for event %d: %v", eventID, err)
+		} else {
+			log.Printf("Successfully created event media for event %d: company=%s, person=%s %s",
+				eventID, media.CompanyName, media.FirstName, media.LastName)
+		}
+	}
+
+	// Create Promotion Material Details
+	for _, material := range buildPromotionMaterialRows(tx, eventID, payload) {
+		clearPayloadRowID(&material)
+		_ = createRelatedRow(tx, &material, nil)
+	}
+
+	// Create Special Guests
+	for _, guest := range buildSpecialGuestRows(eventID, payload) {
+		clearPayloadRowID(&guest)
+		if err := createRelatedRow(tx, &guest, nil); err != nil {
+			// Log error but continue processing other guests
+			// This prevents one bad record from blocking all others
+		}
+	}
+
+	// Create Volunteers
+	for _, volunteer := range buildVolunteerRows(tx, eventID, payload) {
+		clearPayloadRowID(&volunteer)
+		_ = createRelatedRow(tx, &volunteer, nil)
+	}
+
+	// Create Donations
+	for _, donation := range buildDonationRows(tx, eventID, payload) {
+		clearPayloadRowID(&donation)
+		if err := createRelatedRow(tx, &donation, func() DomainEvent { return donationCreatedDomainEvent(&donation) }); err != nil {
+			// Return error will be logged by caller
+			return err
+		}
+	}
+
+	// Process uploaded files from frontend
+	// Note: Files are uploaded to S3 via separate API call after event creation
+	// This section can be used to create EventMedia records for files uploaded during event creation
+	// The uploadedFiles map contains file references that will be processed by the frontend
+	// after event creation, or we can process them here if needed
+
+	return nil
+}
+
+// createRelatedRow inserts one related row, and the domain event announce returns for it when
+// announce is set, under a savepoint, which keeps tx usable when the insert fails
+func createRelatedRow(tx *gorm.DB, row interface{}, announce func() DomainEvent) error {
+	tx.SavePoint("related_row")
+	err := tx.Create(row).Error
+	if err == nil && announce != nil {
+		err = RecordDomainEvent(tx, announce())
+	}
+	if err != nil {
+		tx.RollbackTo("related_row")
+		return err
+	}
+	return nil
+}
+
+// payloadRowID reads the optional "id" of an existing related row from a payload item
+func payloadRowID(item map[string]interface{}) uint {
+	switch v := item["id"].(type) {
+	case float64:
+		if v > 0 {
+			return uint(v)
+		}
+	case string:
+		if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return uint(id)
+		}
+	}
+	return 0
+}
+
+// clearPayloadRowID drops the ID a payload entry carried over from an existing row (a re-submitted
+// edit form, a draft cloned from an event) so the row is inserted as a new one with a sequence ID
+func clearPayloadRowID(row interface{}) {
+	switch r := row.(type) {
+	case *models.EventMedia:
+		r.ID = 0
+	case *models.PromotionMaterialDetails:
+		r.ID = 0
+	case *models.SpecialGuest:
+		r.ID = 0
+	case *models.Volunteer:
+		r.ID = 0
+	case *models.Donation:
+		r.ID = 0
+	}
+}
+
+// buildEventMediaRows maps the media coverage entries of a payload to EventMedia rows
+func buildEventMediaRows(db *gorm.DB, eventID uint, payload EventFrontendPayload) []models.EventMedia {
 	// Check both "eventMediaList" (from frontend) and "eventMedia" (legacy)
 	var eventMediaList []interface{}
 	if list, ok := payload.MediaPromotion["eventMediaList"].([]interface{}); ok {
@@ -32,113 +139,88 @@ func CreateEventRelatedData(eventID uint, payload struct {
 		eventMediaList = list
 		log.Printf("Found eventMedia (legacy) with %d items for event %d", len(eventMediaList), eventID)
 	} else {
-		log.Printf("No eventMediaList or eventMedia found in MediaPromotion for event %d. MediaPromotion keys: %v", 
+		log.Printf("No eventMediaList or eventMedia found in MediaPromotion for event %d. MediaPromotion keys: %v",
 			eventID, getMapKeys(payload.MediaPromotion))
 	}
 
-	if len(eventMediaList) > 0 {
-		for _, mediaItem := range eventMediaList {
-			if mediaMap, ok := mediaItem.(map[string]interface{}); ok {
-				media := models.EventMedia{
-					EventID: eventID,
-				}
+	var rows []models.EventMedia
+	for _, mediaItem := range eventMediaList {
+		mediaMap, ok := mediaItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		media := models.EventMedia{
+			ID:      payloadRowID(mediaMap),
+			EventID: eventID,
+		}
 
-				// Get media coverage type (required field)
-				mediaCoverageTypeSet := false
-				if mediaTypeName, ok := mediaMap["mediaCoverageType"].(string); ok && mediaTypeName != "" {
-					var mediaType models.MediaCoverageType
-					if err := config.DB.Where("media_type = ?", mediaTypeName).First(&mediaType).Error; err == nil {
-						media.MediaCoverageTypeID = mediaType.ID
-						mediaCoverageTypeSet = true
-					}
-				}
+		// Get media coverage type (required field)
+		mediaCoverageTypeSet := false
+		if mediaTypeName, ok := mediaMap["mediaCoverageType"].(string); ok && mediaTypeName != "" {
+			var mediaType models.MediaCoverageType
+			if err := db.Where("media_type = ?", mediaTypeName).First(&mediaType).Error; err == nil {
+				media.MediaCoverageTypeID = mediaType.ID
+				mediaCoverageTypeSet = true
+			}
+		}
 
-				// Company details
-				if val, ok := mediaMap["companyName"].(string); ok {
-					media.CompanyName = val
-				}
-				if val, ok := mediaMap["companyEmail"].(string); ok {
-					media.CompanyEmail = val
-				}
-				if val, ok := mediaMap["companyWebsite"].(string); ok {
-					media.CompanyWebsite = val
-				}
+		// Company details
+		if val, ok := mediaMap["companyName"].(string); ok {
+			media.CompanyName = val
+		}
+		if val, ok := mediaMap["companyEmail"].(string); ok {
+			media.CompanyEmail = val
+		}
+		if val, ok := mediaMap["companyWebsite"].(string); ok {
+			media.CompanyWebsite = val
+		}
 
-				// Media person details
-				if mediaPerson, ok := mediaMap["mediaPerson"].(map[string]interface{}); ok {
-					if val, ok := mediaPerson["gender"].(string); ok {
-						media.Gender = val
-					}
-					if val, ok := mediaPerson["prefix"].(string); ok {
-						media.Prefix = val
-					}
-					if val, ok := mediaPerson["firstName"].(string); ok {
-						media.FirstName = val
-					}
-					if val, ok := mediaPerson["middleName"].(string); ok {
-						media.MiddleName = val
-					}
-					if val, ok := mediaPerson["lastName"].(string); ok {
-						media.LastName = val
-					}
-					if val, ok := mediaPerson["designation"].(string); ok {
-						media.Designation = val
-					}
-					if val, ok := mediaPerson["contact"].(string); ok {
-						media.Contact = val
-					}
-					if val, ok := mediaPerson["email"].(string); ok {
-						media.Email = val
-					}
-				} else {
-					// Fallback: check direct fields (for backward compatibility)
-					if val, ok := mediaMap["gender"].(string); ok {
-						media.Gender = val
-					}
-					if val, ok := mediaMap["prefix"].(string); ok {
-						media.Prefix = val
-					}
-					if val, ok := mediaMap["firstName"].(string); ok {
-						media.FirstName = val
-					}
-					if val, ok := mediaMap["middleName"].(string); ok {
-						media.MiddleName = val
-					}
-					if val, ok := mediaMap["lastName"].(string); ok {
-						media.LastName = val
-					}
-					if val, ok := mediaMap["designation"].(string); ok {
-						media.Designation = val
-					}
-					if val, ok := mediaMap["contact"].(string); ok {
-						media.Contact = val
-					}
-					if val, ok := mediaMap["email"].(string); ok {
-						media.Email = val
-					}
-				}
+		// Media person details
+		// Fallback to direct fields (for backward compatibility)
+		person := mediaMap
+		if mediaPerson, ok := mediaMap["mediaPerson"].(map[string]interface{}); ok {
+			person = mediaPerson
+		}
+		if val, ok := person["gender"].(string); ok {
+			media.Gender = val
+		}
+		if val, ok := person["prefix"].(string); ok {
+			media.Prefix = val
+		}
+		if val, ok := person["firstName"].(string); ok {
+			media.FirstName = val
+		}
+		if val, ok := person["middleName"].(string); ok {
+			media.MiddleName = val
+		}
+		if val, ok := person["lastName"].(string); ok {
+			media.LastName = val
+		}
+		if val, ok := person["designation"].(string); ok {
+			media.Designation = val
+		}
+		if val, ok := person["contact"].(string); ok {
+			media.Contact = val
+		}
+		if val, ok := person["email"].(string); ok {
+			media.Email = val
+		}
 
-				// Validate required fields: MediaCoverageTypeID, CompanyName, FirstName, LastName
-				// All are required by database NOT NULL constraints
-				if mediaCoverageTypeSet && media.CompanyName != "" && media.FirstName != "" && media.LastName != "" {
-					if err := config.DB.Create(&media).Error; err != nil {
-						// Log error but continue processing other media items
-						// This prevents one bad record from blocking all others
-						log.Printf("Error creating event media for event %d: %v", eventID, err)
-					} else {
-						log.Printf("Successfully created event media for event %d: company=%s, person=%s %s", 
-							eventID, media.CompanyName, media.FirstName, media.LastName)
-					}
-				} else {
-					// Log validation failure for debugging
-					log.Printf("Event media validation failed for event %d: mediaCoverageTypeSet=%v, companyName=%v, firstName=%v, lastName=%v", 
-						eventID, mediaCoverageTypeSet, media.CompanyName != "", media.FirstName != "", media.LastName != "")
-				}
-			}
+		// Validate required fields: MediaCoverageTypeID, CompanyName, FirstName, LastName
+		// All are required by database NOT NULL constraints
+		if mediaCoverageTypeSet && media.CompanyName != "" && media.FirstName != "" && media.LastName != "" {
+			rows = append(rows, media)
+		} else {
+			// Log validation failure for debugging
+			log.Printf("Event media validation failed for event %d: mediaCoverageTypeSet=%v, companyName=%v, firstName=%v, lastName=%v",
+				eventID, mediaCoverageTypeSet, media.CompanyName != "", media.FirstName != "", media.LastName != "")
 		}
 	}
+	return rows
+}
 
-	// Create Promotion Material Details
+// buildPromotionMaterialRows maps the promotion materials of a payload to PromotionMaterialDetails rows
+func buildPromotionMaterialRows(db *gorm.DB, eventID uint, payload EventFrontendPayload) []models.PromotionMaterialDetails {
 	// Handle materialTypes from top-level payload or from mediaPromotion.promotionalMaterials
 	var materialTypes []interface{}
 	if len(payload.MaterialTypes) > 0 {
@@ -147,176 +229,197 @@ func CreateEventRelatedData(eventID uint, payload struct {
 		materialTypes = promoMaterials
 	}
 
-	if len(materialTypes) > 0 {
-		for _, materialItem := range materialTypes {
-			if materialMap, ok := materialItem.(map[string]interface{}); ok {
-				material := models.PromotionMaterialDetails{
-					EventID: eventID,
-				}
+	var rows []models.PromotionMaterialDetails
+	for _, materialItem := range materialTypes {
+		materialMap, ok := materialItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		material := models.PromotionMaterialDetails{
+			ID:      payloadRowID(materialMap),
+			EventID: eventID,
+		}
 
-				// Get promotion material type
-				if materialTypeName, ok := materialMap["materialType"].(string); ok && materialTypeName != "" {
-					var promoType models.PromotionMaterial
-					if err := config.DB.Where("material_type = ?", materialTypeName).First(&promoType).Error; err == nil {
-						material.PromotionMaterialID = promoType.ID
-					}
-				}
+		// Get promotion material type
+		if materialTypeName, ok := materialMap["materialType"].(string); ok && materialTypeName != "" {
+			var promoType models.PromotionMaterial
+			if err := db.Where("material_type = ?", materialTypeName).First(&promoType).Error; err == nil {
+				material.PromotionMaterialID = promoType.ID
+			}
+		}
 
-				// Quantity
-				if val, ok := materialMap["quantity"].(string); ok {
-					if qty, err := strconv.Atoi(val); err == nil {
-						material.Quantity = qty
-					}
-				} else if val, ok := materialMap["quantity"].(float64); ok {
-					material.Quantity = int(val)
-				}
+		// Quantity
+		if val, ok := materialMap["quantity"].(string); ok {
+			if qty, err := strconv.Atoi(val); err == nil {
+				material.Quantity = qty
+			}
+		} else if val, ok := materialMap["quantity"].(float64); ok {
+			material.Quantity = int(val)
+		}
 
-				// Size
-				if val, ok := materialMap["size"].(string); ok {
-					material.Size = val
-				}
+		// Size
+		if val, ok := materialMap["size"].(string); ok {
+			material.Size = val
+		}
 
-				// Custom dimensions
-				if val, ok := materialMap["customHeight"].(float64); ok {
-					material.DimensionHeight = val
-				}
-				if val, ok := materialMap["customWidth"].(float64); ok {
-					material.DimensionWidth = val
-				}
+		// Custom dimensions
+		if val, ok := materialMap["customHeight"].(float64); ok {
+			material.DimensionHeight = val
+		}
+		if val, ok := materialMap["customWidth"].(float64); ok {
+			material.DimensionWidth = val
+		}
 
-				if material.PromotionMaterialID > 0 && material.Quantity > 0 {
-					_ = config.DB.Create(&material)
-				}
-			}
+		if material.PromotionMaterialID > 0 && material.Quantity > 0 {
+			rows = append(rows, material)
 		}
 	}
+	return rows
+}
 
-	// Create Special Guests
+// buildSpecialGuestRows maps the special guests of a payload to SpecialGuest rows
+func buildSpecialGuestRows(eventID uint, payload EventFrontendPayload) []models.SpecialGuest {
+	var rows []models.SpecialGuest
 	for _, guestItem := range payload.SpecialGuests {
-		if guestMap, ok := guestItem.(map[string]interface{}); ok {
-			guest := models.SpecialGuest{
-				EventID: eventID,
-			}
+		guestMap, ok := guestItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		guest := models.SpecialGuest{
+			ID:      payloadRowID(guestMap),
+			EventID: eventID,
+		}
 
-			if val, ok := guestMap["gender"].(string); ok {
-				guest.Gender = val
-			}
-			if val, ok := guestMap["prefix"].(string); ok {
-				guest.Prefix = val
-			}
-			if val, ok := guestMap["firstName"].(string); ok {
-				guest.FirstName = val
-			}
-			if val, ok := guestMap["middleName"].(string); ok {
-				guest.MiddleName = val
-			}
-			if val, ok := guestMap["lastName"].(string); ok {
-				guest.LastName = val
-			}
-			if val, ok := guestMap["designation"].(string); ok {
-				guest.Designation = val
-			}
-			if val, ok := guestMap["organization"].(string); ok {
-				guest.Organization = val
-			}
-			if val, ok := guestMap["email"].(string); ok {
-				guest.Email = val
-			}
-			if val, ok := guestMap["city"].(string); ok {
-				guest.City = val
-			}
-			if val, ok := guestMap["state"].(string); ok {
-				guest.State = val
-			}
-			if val, ok := guestMap["personalNumber"].(string); ok {
-				guest.PersonalNumber = val
-			}
-			if val, ok := guestMap["contactPerson"].(string); ok {
-				guest.ContactPerson = val
-			}
-			if val, ok := guestMap["contactPersonNumber"].(string); ok {
-				guest.ContactPersonNumber = val
-			}
-			if val, ok := guestMap["referenceBranchId"].(string); ok {
-				guest.ReferenceBranchID = val
-			}
-			if val, ok := guestMap["referenceVolunteerId"].(string); ok {
-				guest.ReferenceVolunteerID = val
-			}
-			if val, ok := guestMap["referencePersonName"].(string); ok {
-				guest.ReferencePersonName = val
-			}
+		if val, ok := guestMap["gender"].(string); ok {
+			guest.Gender = val
+		}
+		if val, ok := guestMap["prefix"].(string); ok {
+			guest.Prefix = val
+		}
+		if val, ok := guestMap["firstName"].(string); ok {
+			guest.FirstName = val
+		}
+		if val, ok := guestMap["middleName"].(string); ok {
+			guest.MiddleName = val
+		}
+		if val, ok := guestMap["lastName"].(string); ok {
+			guest.LastName = val
+		}
+		if val, ok := guestMap["designation"].(string); ok {
+			guest.Designation = val
+		}
+		if val, ok := guestMap["organization"].(string); ok {
+			guest.Organization = val
+		}
+		if val, ok := guestMap["email"].(string); ok {
+			guest.Email = val
+		}
+		if val, ok := guestMap["city"].(string); ok {
+			guest.City = val
+		}
+		if val, ok := guestMap["state"].(string); ok {
+			guest.State = val
+		}
+		if val, ok := guestMap["personalNumber"].(string); ok {
+			guest.PersonalNumber = val
+		}
+		if val, ok := guestMap["contactPerson"].(string); ok {
+			guest.ContactPerson = val
+		}
+		if val, ok := guestMap["contactPersonNumber"].(string); ok {
+			guest.ContactPersonNumber = val
+		}
+		if val, ok := guestMap["referenceBranchId"].(string); ok {
+			guest.ReferenceBranchID = val
+		}
+		if val, ok := guestMap["referenceVolunteerId"].(string); ok {
+			guest.ReferenceVolunteerID = val
+		}
+		if val, ok := guestMap["referencePersonName"].(string); ok {
+			guest.ReferencePersonName = val
+		}
 
-			// Validate required fields: Prefix is required by database NOT NULL constraint
-			// Also require at least one identifying field (FirstName, LastName, or Organization)
-			if guest.Prefix != "" && (guest.FirstName != "" || guest.LastName != "" || guest.Organization != "") {
-				if err := config.DB.Create(&guest).Error; err != nil {
-					// Log error but continue processing other guests
-					// This prevents one bad record from blocking all others
-				}
-			}
+		// Validate required fields: Prefix is required by database NOT NULL constraint
+		// Also require at least one identifying field (FirstName, LastName, or Organization)
+		if guest.Prefix != "" && (guest.FirstName != "" || guest.LastName != "" || guest.Organization != "") {
+			rows = append(rows, guest)
 		}
 	}
+	return rows
+}
 
-	// Create Volunteers
+// resolveBranchRef resolves a branch reference that is either a numeric ID or a branch code
+func resolveBranchRef(db *gorm.DB, val string) uint {
+	// First try to parse as numeric ID
+	if branchID, err := strconv.ParseUint(val, 10, 64); err == nil {
+		return uint(branchID)
+	}
+	// If not numeric, treat as branch code and look it up
+	var branch models.Branch
+	if err := db.Where("branch_code = ?", val).First(&branch).Error; err == nil {
+		return branch.ID
+	}
+	return 0
+}
+
+// buildVolunteerRows maps the volunteers of a payload to Volunteer rows
+func buildVolunteerRows(db *gorm.DB, eventID uint, payload EventFrontendPayload) []models.Volunteer {
+	var rows []models.Volunteer
 	for _, volunteerItem := range payload.Volunteers {
-		if volMap, ok := volunteerItem.(map[string]interface{}); ok {
-			volunteer := models.Volunteer{
-				EventID: eventID,
-			}
+		volMap, ok := volunteerItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		volunteer := models.Volunteer{
+			ID:      payloadRowID(volMap),
+			EventID: eventID,
+		}
 
-			// Branch ID - try to parse from string or number, or look up by branch code
-			if val, ok := volMap["branchId"].(string); ok && val != "" {
-				// First try to parse as numeric ID
-				if branchID, err := strconv.ParseUint(val, 10, 64); err == nil {
-					volunteer.BranchID = uint(branchID)
-				} else {
-					// If not numeric, treat as branch code and look it up
-					var branch models.Branch
-					if err := config.DB.Where("branch_code = ?", val).First(&branch).Error; err == nil {
-						volunteer.BranchID = branch.ID
-					}
-				}
-			} else if val, ok := volMap["branchId"].(float64); ok {
-				volunteer.BranchID = uint(val)
-			} else if val, ok := volMap["branch_code"].(string); ok && val != "" {
-				// Also check for branch_code field directly
-				var branch models.Branch
-				if err := config.DB.Where("branch_code = ?", val).First(&branch).Error; err == nil {
-					volunteer.BranchID = branch.ID
-				}
+		// Branch ID - try to parse from string or number, or look up by branch code
+		if val, ok := volMap["branchId"].(string); ok && val != "" {
+			volunteer.BranchID = resolveBranchRef(db, val)
+		} else if val, ok := volMap["branchId"].(float64); ok {
+			volunteer.BranchID = uint(val)
+		} else if val, ok := volMap["branch_code"].(string); ok && val != "" {
+			// Also check for branch_code field directly
+			var branch models.Branch
+			if err := db.Where("branch_code = ?", val).First(&branch).Error; err == nil {
+				volunteer.BranchID = branch.ID
 			}
+		}
 
-			if val, ok := volMap["name"].(string); ok {
-				volunteer.VolunteerName = val
-			}
-			if val, ok := volMap["contact"].(string); ok {
-				volunteer.Contact = val
-			}
-			// Handle days field - can be number (float64) or string
-			if val, ok := volMap["days"].(float64); ok {
-				volunteer.NumberOfDays = int(val)
-			} else if val, ok := volMap["days"].(string); ok && val != "" {
-				if daysInt, err := strconv.Atoi(val); err == nil {
-					volunteer.NumberOfDays = daysInt
-				}
-			} else if val, ok := volMap["days"].(int); ok {
-				volunteer.NumberOfDays = val
-			}
-			if val, ok := volMap["seva"].(string); ok {
-				volunteer.SevaInvolved = val
-			}
-			if val, ok := volMap["mentionSeva"].(string); ok {
-				volunteer.MentionSeva = val
-			}
+		if val, ok := volMap["name"].(string); ok {
+			volunteer.VolunteerName = val
+		}
+		if val, ok := volMap["contact"].(string); ok {
+			volunteer.Contact = val
+		}
+		// Handle days field - can be number (float64) or string
+		if val, ok := volMap["days"].(float64); ok {
+			volunteer.NumberOfDays = int(val)
+		} else if val, ok := volMap["days"].(string); ok && val != "" {
+			if daysInt, err := strconv.Atoi(val); err == nil {
+				volunteer.NumberOfDays = daysInt
+			}
+		} else if val, ok := volMap["days"].(int); ok {
+			volunteer.NumberOfDays = val
+		}
+		if val, ok := volMap["seva"].(string); ok {
+			volunteer.SevaInvolved = val
+		}
+		if val, ok := volMap["mentionSeva"].(string); ok {
+			volunteer.MentionSeva = val
+		}
 
-			if volunteer.BranchID > 0 && volunteer.VolunteerName != "" {
-				_ = config.DB.Create(&volunteer)
-			}
+		if volunteer.BranchID > 0 && volunteer.VolunteerName != "" {
+			rows = append(rows, volunteer)
 		}
 	}
+	return rows
+}
 
-	// Create Donations
+// buildDonationRows maps the donations of a payload to Donation rows
+func buildDonationRows(db *gorm.DB, eventID uint, payload EventFrontendPayload) []models.Donation {
 	// Handle donations from generalDetails.donations or donationTypes
 	var donations []interface{}
 	if len(payload.DonationTypes) > 0 {
@@ -325,89 +428,66 @@ func CreateEventRelatedData(eventID uint, payload struct {
 		donations = donationList
 	}
 
+	var rows []models.Donation
 	for _, donationItem := range donations {
-		if donationMap, ok := donationItem.(map[string]interface{}); ok {
-			donation := models.Donation{
-				EventID: eventID,
-			}
+		donationMap, ok := donationItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		donation := models.Donation{
+			ID:      payloadRowID(donationMap),
+			EventID: eventID,
+		}
 
-			// Get branch ID from donation payload - try multiple sources
-			// First check if branchId is in the donation item itself
-			if val, ok := donationMap["branchId"].(string); ok && val != "" {
-				// First try to parse as numeric ID
-				if branchID, err := strconv.ParseUint(val, 10, 64); err == nil {
-					donation.BranchID = uint(branchID)
-				} else {
-					// If not numeric, treat as branch code and look it up
-					var branch models.Branch
-					if err := config.DB.Where("branch_code = ?", val).First(&branch).Error; err == nil {
-						donation.BranchID = branch.ID
-					}
-				}
-			} else if val, ok := donationMap["branchId"].(float64); ok {
-				donation.BranchID = uint(val)
-			} else if val, ok := donationMap["branch_id"].(float64); ok {
-				donation.BranchID = uint(val)
-			} else if val, ok := donationMap["branch_code"].(string); ok && val != "" {
-				// Also check for branch_code field directly
-				var branch models.Branch
-				if err := config.DB.Where("branch_code = ?", val).First(&branch).Error; err == nil {
-					donation.BranchID = branch.ID
-				}
-			} else if branchIdVal, ok := payload.GeneralDetails["branchId"]; ok {
-				// Fallback: try to get branchId from generalDetails
-				if branchIdStr, ok := branchIdVal.(string); ok && branchIdStr != "" {
-					if branchID, err := strconv.ParseUint(branchIdStr, 10, 64); err == nil {
-						donation.BranchID = uint(branchID)
-					} else {
-						var branch models.Branch
-						if err := config.DB.Where("branch_code = ?", branchIdStr).First(&branch).Error; err == nil {
-							donation.BranchID = branch.ID
-						}
-					}
-				} else if branchIdFloat, ok := branchIdVal.(float64); ok {
-					donation.BranchID = uint(branchIdFloat)
-				}
+		// Get branch ID from donation payload - try multiple sources
+		// First check if branchId is in the donation item itself
+		if val, ok := donationMap["branchId"].(string); ok && val != "" {
+			donation.BranchID = resolveBranchRef(db, val)
+		} else if val, ok := donationMap["branchId"].(float64); ok {
+			donation.BranchID = uint(val)
+		} else if val, ok := donationMap["branch_id"].(float64); ok {
+			donation.BranchID = uint(val)
+		} else if val, ok := donationMap["branch_code"].(string); ok && val != "" {
+			// Also check for branch_code field directly
+			var branch models.Branch
+			if err := db.Where("branch_code = ?", val).First(&branch).Error; err == nil {
+				donation.BranchID = branch.ID
+			}
+		} else if branchIdVal, ok := payload.GeneralDetails["branchId"]; ok {
+			// Fallback: try to get branchId from generalDetails
+			if branchIdStr, ok := branchIdVal.(string); ok && branchIdStr != "" {
+				donation.BranchID = resolveBranchRef(db, branchIdStr)
+			} else if branchIdFloat, ok := branchIdVal.(float64); ok {
+				donation.BranchID = uint(branchIdFloat)
 			}
+		}
 
-			if val, ok := donationMap["type"].(string); ok {
-				donation.DonationType = val
-			}
+		if val, ok := donationMap["type"].(string); ok {
+			donation.DonationType = val
+		}
 
-			if donation.DonationType == "cash" {
-				if val, ok := donationMap["amount"].(float64); ok {
-					donation.Amount = val
-				}
-			} else if donation.DonationType == "in-kind" {
-				// Store tags as JSON in KindType
-				if tags, ok := donationMap["tags"].([]interface{}); ok {
-					if tagsJSON, err := json.Marshal(tags); err == nil {
-						donation.KindType = string(tagsJSON)
-					}
-				}
-				if val, ok := donationMap["materialValue"].(float64); ok {
-					donation.Amount = val
+		if donation.DonationType == "cash" {
+			if val, ok := donationMap["amount"].(float64); ok {
+				donation.Amount = val
+			}
+		} else if donation.DonationType == "in-kind" {
+			// Store tags as JSON in KindType
+			if tags, ok := donationMap["tags"].([]interface{}); ok {
+				if tagsJSON, err := json.Marshal(tags); err == nil {
+					donation.KindType = string(tagsJSON)
 				}
 			}
-
-			// Only create donation if we have required fields
-			if donation.DonationType != "" && donation.BranchID > 0 {
-				if err := config.DB.Create(&donation).Error; err != nil {
-					// Log error but continue processing other donations
-					// Return error will be logged by caller
-					return err
-				}
+			if val, ok := donationMap["materialValue"].(float64); ok {
+				donation.Amount = val
 			}
 		}
-	}
-
-	// Process uploaded files from frontend
-	// Note: Files are uploaded to S3 via separate API call after event creation
-	// This section can be used to create EventMedia records for files uploaded during event creation
-	// The uploadedFiles map contains file references that will be processed by the frontend
-	// after event creation, or we can process them here if needed
 
-	return nil
+		// Only create donation if we have required fields
+		if donation.DonationType != "" && donation.BranchID > 0 {
+			rows = append(rows, donation)
+		}
+	}
+	return rows
 }
 
 // Helper function to get map keys for logging
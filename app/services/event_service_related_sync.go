@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// syncIgnoredColumns are never compared or overwritten when syncing related rows
+var syncIgnoredColumns = map[string]bool{
+	"id":         true,
+	"event_id":   true,
+	"created_on": true,
+	"created_by": true,
+	"updated_on": true,
+	"updated_by": true,
+	"deleted_at": true,
+	"deleted_by": true,
+}
+
+// eventMediaFileColumns link an EventMedia row to its uploaded S3 object. They are managed by
+// the file upload endpoints and are not part of the event payload, so a sync must leave them alone.
+var eventMediaFileColumns = []string{"file_url", "s3_key", "original_filename", "thumbnail_s3_key", "file_type"}
+
+// UpdateEventWithRelatedData updates an event and reconciles its special guests, volunteers, media,
+// donations and promotion materials with the payload in a single transaction.
+// Related rows are matched by ID: changed rows are updated, rows without a known ID are inserted and
+// rows missing from the payload are moved to the trash. Rows keep their IDs and CreatedOn/CreatedBy.
+// Media rows backed by an uploaded file are only removed through the file endpoints.
+// A revision is recorded in the same transaction.
+func UpdateEventWithRelatedData(eventID uint, updatedData map[string]interface{}, payload EventFrontendPayload, actor Actor, expectedVersion *int) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
+		}
+		// The row is locked, so the version cannot change before the update below
+		if err := checkRowVersion(event.Version, expectedVersion); err != nil {
+			return err
+		}
+
+		if err := EnsureBaselineRevision(tx, eventID, actor); err != nil {
+			return err
+		}
+
+		// Beneficiary counts of events with day-wise attendance are derived from it
+		if err := dropAttendanceDerivedCounts(tx, eventID, updatedData); err != nil {
+			return err
+		}
+
+		synced, err := SyncEventRelatedData(tx, eventID, payload, actor.Email)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updatedData["updated_on"] = &now
+		if actor.Email != "" {
+			updatedData["updated_by"] = actor.Email
+		}
+		if synced {
+			// The related rows are part of the event: a change of only them still moves its version
+			updatedData["version"] = gorm.Expr("version + 1")
+		}
+		fromStatus := event.Status
+		if err := tx.Model(&event).Updates(updatedData).Error; err != nil {
+			return err
+		}
+		if err := recordEventStatusChange(tx, eventID, fromStatus, updatedData); err != nil {
+			return err
+		}
+		if _, ok := updatedData["branch_id"]; ok {
+			if err := dropLeadBranchCoHosts(tx, []uint{eventID}, actor); err != nil {
+				return err
+			}
+		}
+
+		_, err = CaptureEventRevision(tx, eventID, models.RevisionActionUpdate, nil, actor)
+		return err
+	})
+}
+
+// SyncEventRelatedData reconciles the related rows of an event with the payload using tx and
+// reports whether any row was inserted, updated or removed
+func SyncEventRelatedData(tx *gorm.DB, eventID uint, payload EventFrontendPayload, updatedBy string) (bool, error) {
+	guests, err := syncEventRows(tx, eventID, buildSpecialGuestRows(eventID, payload),
+		func(g *models.SpecialGuest) uint { return g.ID }, nil, updatedBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync special guests: %w", err)
+	}
+
+	volunteers, err := syncEventRows(tx, eventID, buildVolunteerRows(tx, eventID, payload),
+		func(v *models.Volunteer) uint { return v.ID }, nil, updatedBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync volunteers: %w", err)
+	}
+
+	media, err := syncEventRows(tx, eventID, buildEventMediaRows(tx, eventID, payload),
+		func(m *models.EventMedia) uint { return m.ID },
+		func(m *models.EventMedia) bool { return m.S3Key != "" || m.FileURL != "" },
+		updatedBy, eventMediaFileColumns...)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync event media: %w", err)
+	}
+
+	donations, err := syncEventRows(tx, eventID, buildDonationRows(tx, eventID, payload),
+		func(d *models.Donation) uint { return d.ID }, nil, updatedBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync donations: %w", err)
+	}
+
+	promotions, err := syncEventRows(tx, eventID, buildPromotionMaterialRows(tx, eventID, payload),
+		func(p *models.PromotionMaterialDetails) uint { return p.ID }, nil, updatedBy)
+	if err != nil {
+		return false, fmt.Errorf("failed to sync promotion materials: %w", err)
+	}
+
+	// Media and donations changed here are announced like those changed through their own endpoints
+	if err := recordSyncedMediaEvents(tx, eventID, media, updatedBy); err != nil {
+		return false, err
+	}
+	if err := recordSyncedDonationEvents(tx, eventID, donations, updatedBy); err != nil {
+		return false, err
+	}
+
+	changed := guests.changed() || volunteers.changed() || media.changed() || donations.changed() || promotions.changed()
+	return changed, nil
+}
+
+// recordSyncedMediaEvents writes media.uploaded and media.deleted domain events for the synced
+// media rows; like the media endpoints, edits of a media record are not announced
+func recordSyncedMediaEvents(tx *gorm.DB, eventID uint, synced syncedRows[models.EventMedia], deletedBy string) error {
+	for i := range synced.inserted {
+		if err := RecordDomainEvent(tx, mediaUploadedDomainEvent(&synced.inserted[i])); err != nil {
+			return err
+		}
+	}
+	for _, id := range synced.removed {
+		if err := RecordDomainEvent(tx, MediaDeleted{MediaID: id, EventID: eventID, DeletedBy: deletedBy}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordSyncedDonationEvents writes donation.created, donation.updated and donation.deleted domain
+// events for the synced donation rows
+func recordSyncedDonationEvents(tx *gorm.DB, eventID uint, synced syncedRows[models.Donation], deletedBy string) error {
+	for i := range synced.inserted {
+		if err := RecordDomainEvent(tx, donationCreatedDomainEvent(&synced.inserted[i])); err != nil {
+			return err
+		}
+	}
+	ids := make([]uint, 0, len(synced.updated))
+	for id := range synced.updated {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if err := RecordDomainEvent(tx, DonationUpdated{DonationID: id, EventID: eventID, Fields: synced.updated[id]}); err != nil {
+			return err
+		}
+	}
+	for _, id := range synced.removed {
+		if err := RecordDomainEvent(tx, DonationDeleted{DonationID: id, EventID: eventID, DeletedBy: deletedBy}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncedRows lists what syncEventRows changed in one related table
+type syncedRows[T any] struct {
+	inserted []T               // with the IDs they were given
+	updated  map[uint][]string // changed columns by row ID
+	removed  []uint
+}
+
+func (s syncedRows[T]) changed() bool {
+	return len(s.inserted) > 0 || len(s.updated) > 0 || len(s.removed) > 0
+}
+
+// syncEventRows reconciles the rows of one related table of an event with incoming.
+// keepUnlisted marks existing rows that must survive even when absent from incoming;
+// preserved lists extra columns that are never overwritten on update.
+func syncEventRows[T any](tx *gorm.DB, eventID uint, incoming []T, idOf func(*T) uint, keepUnlisted func(*T) bool, updatedBy string, preserved ...string) (syncedRows[T], error) {
+	var synced syncedRows[T]
+	var existing []T
+	if err := tx.Where("event_id = ?", eventID).Find(&existing).Error; err != nil {
+		return synced, err
+	}
+
+	sch, err := parseModelSchema(tx, new(T))
+	if err != nil {
+		return synced, err
+	}
+
+	skip := make(map[string]bool, len(syncIgnoredColumns)+len(preserved))
+	for col := range syncIgnoredColumns {
+		skip[col] = true
+	}
+	for _, col := range preserved {
+		skip[col] = true
+	}
+
+	existingByID := make(map[uint]*T, len(existing))
+	for i := range existing {
+		existingByID[idOf(&existing[i])] = &existing[i]
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	seen := make(map[uint]bool, len(incoming))
+	var inserts []T
+
+	for i := range incoming {
+		row := &incoming[i]
+		id := idOf(row)
+		current, ok := existingByID[id]
+		if id == 0 || !ok || seen[id] {
+			// Unknown IDs (new rows, or IDs belonging to another event) are inserted fresh
+			if field := sch.LookUpField("id"); field != nil {
+				if err := field.Set(ctx, reflect.ValueOf(row).Elem(), uint(0)); err != nil {
+					return synced, err
+				}
+			}
+			setSyncAuditField(ctx, sch, row, "created_on", now)
+			setSyncAuditField(ctx, sch, row, "created_by", updatedBy)
+			inserts = append(inserts, *row)
+			continue
+		}
+		seen[id] = true
+
+		changes := changedColumns(ctx, sch, current, row, skip)
+		if len(changes) == 0 {
+			continue
+		}
+		columns := make([]string, 0, len(changes))
+		for column := range changes {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		changes["updated_on"] = now
+		if updatedBy != "" && sch.LookUpField("updated_by") != nil {
+			changes["updated_by"] = updatedBy
+		}
+		if err := tx.Model(new(T)).Where("id = ? AND event_id = ?", id, eventID).Updates(changes).Error; err != nil {
+			return synced, err
+		}
+		if synced.updated == nil {
+			synced.updated = make(map[uint][]string)
+		}
+		synced.updated[id] = columns
+	}
+
+	var removed []uint
+	for i := range existing {
+		id := idOf(&existing[i])
+		if seen[id] || (keepUnlisted != nil && keepUnlisted(&existing[i])) {
+			continue
+		}
+		removed = append(removed, id)
+	}
+	if len(removed) > 0 {
+		// Removed rows go to the trash where the model supports it
+		if sch.LookUpField("deleted_at") != nil {
+			if _, err := softDeleteWhere(tx, new(T), updatedBy, trashTimestamp(), "event_id = ? AND id IN ?", eventID, removed); err != nil {
+				return synced, err
+			}
+		} else if err := tx.Where("event_id = ? AND id IN ?", eventID, removed).Delete(new(T)).Error; err != nil {
+			return synced, err
+		}
+		synced.removed = removed
+	}
+
+	if len(inserts) > 0 {
+		if err := tx.Omit(clause.Associations).Create(&inserts).Error; err != nil {
+			return synced, err
+		}
+		synced.inserted = inserts
+	}
+
+	return synced, nil
+}
+
+// parseModelSchema returns the gorm schema of model using the naming strategy of db
+func parseModelSchema(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// changedColumns returns the column values of incoming that differ from current
+func changedColumns[T any](ctx context.Context, sch *schema.Schema, current, incoming *T, skip map[string]bool) map[string]interface{} {
+	changes := make(map[string]interface{})
+	currentValue := reflect.ValueOf(current).Elem()
+	incomingValue := reflect.ValueOf(incoming).Elem()
+	for _, field := range sch.Fields {
+		if field.DBName == "" || skip[field.DBName] {
+			continue
+		}
+		from, _ := field.ValueOf(ctx, currentValue)
+		to, _ := field.ValueOf(ctx, incomingValue)
+		if !reflect.DeepEqual(from, to) {
+			changes[field.DBName] = to
+		}
+	}
+	return changes
+}
+
+// setSyncAuditField sets an audit column on a new row when the model has it
+func setSyncAuditField[T any](ctx context.Context, sch *schema.Schema, row *T, column string, value interface{}) {
+	field := sch.LookUpField(column)
+	if field == nil {
+		return
+	}
+	if field.FieldType.Kind() == reflect.Ptr {
+		if t, ok := value.(time.Time); ok {
+			value = &t
+		}
+	}
+	_ = field.Set(ctx, reflect.ValueOf(row).Elem(), value)
+}
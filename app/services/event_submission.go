@@ -0,0 +1,430 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+)
+
+// LatestEventSchemaVersion is the submission schema version used when a payload does not name one
+const LatestEventSchemaVersion = 1
+
+var ErrUnknownEventSchemaVersion = errors.New("unknown event schema version")
+
+func init() {
+	validators.RegisterSchemaPattern("eventDuration",
+		`^\d{1,2} [A-Za-z]{3} \d{4} - \d{1,2} [A-Za-z]{3} \d{4}$`,
+		"dd MMM yyyy - dd MMM yyyy, e.g. 01 Jan 2024 - 05 Jan 2024")
+	validators.RegisterSchemaPattern("eventDate",
+		`^(\d{2} [A-Za-z]{3} \d{4}|\d{2}-\d{2}-\d{4}|\d{4}-\d{2}-\d{2}|\d{2}/\d{2}/\d{4})$`,
+		"dd MMM yyyy, dd-mm-yyyy, yyyy-mm-dd or mm/dd/yyyy")
+	validators.RegisterSchemaPattern("timeOfDay", `^([01]?\d|2[0-3]):[0-5]\d$`, "HH:mm")
+}
+
+// FlexInt is a non-negative whole number the frontend may send as a number or a numeric string
+type FlexInt int
+
+// UnmarshalJSON accepts 3 and "3". Other values are left zero: the schema check of
+// DecodeEventSubmission reports them, and decoding goes on so the remaining rules can run.
+func (n *FlexInt) UnmarshalJSON(data []byte) error {
+	if value, err := strconv.Atoi(strings.Trim(string(data), `"`)); err == nil && value >= 0 {
+		*n = FlexInt(value)
+	}
+	return nil
+}
+
+// JSONSchema describes FlexInt as an integer or a string of digits
+func (FlexInt) JSONSchema() *validators.Schema {
+	zero := 0.0
+	return &validators.Schema{OneOf: []*validators.Schema{
+		{Type: "integer", Minimum: &zero},
+		{Type: "string", Pattern: `^\d+$`, Description: "digits only"},
+	}}
+}
+
+// BranchRef refers to a branch by ID (a number or a numeric string) or by branch code
+type BranchRef string
+
+// UnmarshalJSON accepts a positive number or a non-empty string (other values are left
+// empty, like FlexInt)
+func (b *BranchRef) UnmarshalJSON(data []byte) error {
+	var code string
+	var id uint64
+	if err := json.Unmarshal(data, &code); err == nil {
+		*b = BranchRef(code)
+	} else if err := json.Unmarshal(data, &id); err == nil {
+		*b = BranchRef(strconv.FormatUint(id, 10))
+	}
+	return nil
+}
+
+// MarshalJSON writes branch IDs as numbers and branch codes as strings, the shapes the
+// payload mappers resolve
+func (b BranchRef) MarshalJSON() ([]byte, error) {
+	if id, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+		return []byte(strconv.FormatUint(id, 10)), nil
+	}
+	return json.Marshal(string(b))
+}
+
+// JSONSchema describes BranchRef as a branch ID or a branch code
+func (BranchRef) JSONSchema() *validators.Schema {
+	one, minLength := 1.0, 1
+	return &validators.Schema{OneOf: []*validators.Schema{
+		{Type: "integer", Minimum: &one},
+		{Type: "string", MinLength: &minLength},
+	}}
+}
+
+// EventVenueV1 is the location block of the general details
+type EventVenueV1 struct {
+	Country    string   `json:"country,omitempty" schema:"maxLength=100"`
+	State      string   `json:"state,omitempty" schema:"maxLength=100"`
+	District   string   `json:"district,omitempty" schema:"maxLength=100"`
+	City       string   `json:"city,omitempty" schema:"maxLength=100"`
+	Pincode    string   `json:"pincode,omitempty" schema:"maxLength=20"`
+	PostOffice string   `json:"postOffice,omitempty" schema:"maxLength=100"`
+	Address    string   `json:"address,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty" schema:"min=-90,max=90"`
+	Longitude  *float64 `json:"longitude,omitempty" schema:"min=-180,max=180"`
+}
+
+// EventGeneralDetailsV1 is the "generalDetails" section. Master data (type, category, sub
+// category) is referenced by name; the snake_case keys are accepted for older clients.
+type EventGeneralDetailsV1 struct {
+	EventType           string            `json:"eventType,omitempty" schema:"description=Event type name (required unless type is given)"`
+	Type                string            `json:"type,omitempty" schema:"description=Alias of eventType"`
+	EventCategory       string            `json:"eventCategory,omitempty" schema:"description=Event category name (required unless eventName is given)"`
+	EventName           string            `json:"eventName,omitempty" schema:"description=Alias of eventCategory"`
+	EventSubCategory    string            `json:"eventSubCategory,omitempty"`
+	EventSubCategoryOld string            `json:"event_sub_category,omitempty"`
+	Scale               string            `json:"scale,omitempty" schema:"maxLength=100"`
+	Theme               string            `json:"theme,omitempty"`
+	Duration            string            `json:"duration,omitempty" schema:"pattern=eventDuration"`
+	StartDate           string            `json:"start_date,omitempty" schema:"pattern=eventDate"`
+	EndDate             string            `json:"end_date,omitempty" schema:"pattern=eventDate"`
+	DailyStartTime      string            `json:"dailyStartTime,omitempty" schema:"pattern=timeOfDay"`
+	DailyEndTime        string            `json:"dailyEndTime,omitempty" schema:"pattern=timeOfDay"`
+	SpiritualOrator     string            `json:"spiritualOrator,omitempty" schema:"maxLength=200"`
+	Language            string            `json:"language,omitempty" schema:"maxLength=100"`
+	Venue               *EventVenueV1     `json:"venue,omitempty"`
+	Country             string            `json:"country,omitempty" schema:"maxLength=100"`
+	State               string            `json:"state,omitempty" schema:"maxLength=100"`
+	District            string            `json:"district,omitempty" schema:"maxLength=100"`
+	City                string            `json:"city,omitempty" schema:"maxLength=100"`
+	Pincode             string            `json:"pincode,omitempty" schema:"maxLength=20"`
+	PostOffice          string            `json:"postOffice,omitempty" schema:"maxLength=100"`
+	Address             string            `json:"address,omitempty"`
+	Latitude            *float64          `json:"latitude,omitempty" schema:"min=-90,max=90"`
+	Longitude           *float64          `json:"longitude,omitempty" schema:"min=-180,max=180"`
+	AddressType         string            `json:"addressType,omitempty"`
+	AddressTypeOld      string            `json:"address_type,omitempty"`
+	PoliceStation       string            `json:"policeStation,omitempty"`
+	PoliceStationOld    string            `json:"police_station,omitempty"`
+	AreaCovered         string            `json:"areaCovered,omitempty"`
+	AreaCoveredOld      string            `json:"area_covered,omitempty"`
+	BranchID            *BranchRef        `json:"branchId,omitempty"`
+	BranchIDOld         *uint             `json:"branch_id,omitempty"`
+	Donations           []EventDonationV1 `json:"donations,omitempty" schema:"description=Used when donationTypes is empty"`
+}
+
+// EventParticipantsV1 is the "involvedParticipants" section
+type EventParticipantsV1 struct {
+	BeneficiariesMen      int `json:"beneficiariesMen" schema:"min=0"`
+	BeneficiariesWomen    int `json:"beneficiariesWomen" schema:"min=0"`
+	BeneficiariesChildren int `json:"beneficiariesChildren" schema:"min=0"`
+	InitiationMen         int `json:"initiationMen" schema:"min=0"`
+	InitiationWomen       int `json:"initiationWomen" schema:"min=0"`
+	InitiationChildren    int `json:"initiationChildren" schema:"min=0"`
+}
+
+// EventMediaPersonV1 is the contact person of a media coverage entry
+type EventMediaPersonV1 struct {
+	Gender      string `json:"gender,omitempty"`
+	Prefix      string `json:"prefix,omitempty"`
+	FirstName   string `json:"firstName,omitempty"`
+	MiddleName  string `json:"middleName,omitempty"`
+	LastName    string `json:"lastName,omitempty"`
+	Designation string `json:"designation,omitempty"`
+	Contact     string `json:"contact,omitempty"`
+	Email       string `json:"email,omitempty"`
+}
+
+// EventMediaV1 is a media coverage entry; the person may be nested or given inline
+type EventMediaV1 struct {
+	ID                *FlexInt            `json:"id,omitempty" schema:"description=Existing row ID when updating"`
+	MediaCoverageType string              `json:"mediaCoverageType" schema:"required,minLength=1"`
+	CompanyName       string              `json:"companyName" schema:"required,minLength=1"`
+	CompanyEmail      string              `json:"companyEmail,omitempty"`
+	CompanyWebsite    string              `json:"companyWebsite,omitempty"`
+	MediaPerson       *EventMediaPersonV1 `json:"mediaPerson,omitempty"`
+	EventMediaPersonV1
+}
+
+// EventMediaPromotionV1 is the "mediaPromotion" section
+type EventMediaPromotionV1 struct {
+	EventMediaList       []EventMediaV1             `json:"eventMediaList,omitempty"`
+	EventMedia           []EventMediaV1             `json:"eventMedia,omitempty" schema:"description=Legacy alias of eventMediaList"`
+	PromotionalMaterials []EventPromotionMaterialV1 `json:"promotionalMaterials,omitempty" schema:"description=Used when materialTypes is empty"`
+}
+
+// EventPromotionMaterialV1 is a promotion material entry
+type EventPromotionMaterialV1 struct {
+	ID           *FlexInt `json:"id,omitempty"`
+	MaterialType string   `json:"materialType" schema:"required,minLength=1"`
+	Quantity     FlexInt  `json:"quantity" schema:"required"`
+	Size         string   `json:"size,omitempty"`
+	CustomHeight *float64 `json:"customHeight,omitempty" schema:"min=0"`
+	CustomWidth  *float64 `json:"customWidth,omitempty" schema:"min=0"`
+}
+
+// EventSpecialGuestV1 is a special guest entry
+type EventSpecialGuestV1 struct {
+	ID                   *FlexInt `json:"id,omitempty"`
+	Gender               string   `json:"gender,omitempty"`
+	Prefix               string   `json:"prefix" schema:"required,minLength=1"`
+	FirstName            string   `json:"firstName,omitempty"`
+	MiddleName           string   `json:"middleName,omitempty"`
+	LastName             string   `json:"lastName,omitempty"`
+	Designation          string   `json:"designation,omitempty"`
+	Organization         string   `json:"organization,omitempty"`
+	Email                string   `json:"email,omitempty"`
+	City                 string   `json:"city,omitempty"`
+	State                string   `json:"state,omitempty"`
+	PersonalNumber       string   `json:"personalNumber,omitempty"`
+	ContactPerson        string   `json:"contactPerson,omitempty"`
+	ContactPersonNumber  string   `json:"contactPersonNumber,omitempty"`
+	ReferenceBranchID    string   `json:"referenceBranchId,omitempty"`
+	ReferenceVolunteerID string   `json:"referenceVolunteerId,omitempty"`
+	ReferencePersonName  string   `json:"referencePersonName,omitempty"`
+}
+
+// EventVolunteerV1 is a volunteer entry
+type EventVolunteerV1 struct {
+	ID          *FlexInt   `json:"id,omitempty"`
+	BranchID    *BranchRef `json:"branchId,omitempty" schema:"description=Branch ID or branch code (required unless branch_code is given)"`
+	BranchCode  string     `json:"branch_code,omitempty"`
+	Name        string     `json:"name" schema:"required,minLength=1"`
+	Contact     string     `json:"contact,omitempty"`
+	Days        *FlexInt   `json:"days,omitempty"`
+	Seva        string     `json:"seva,omitempty"`
+	MentionSeva string     `json:"mentionSeva,omitempty"`
+}
+
+// EventDonationV1 is a donation entry; cash donations carry amount, in-kind ones tags and materialValue
+type EventDonationV1 struct {
+	ID            *FlexInt   `json:"id,omitempty"`
+	Type          string     `json:"type" schema:"required,enum=cash|in-kind"`
+	BranchID      *BranchRef `json:"branchId,omitempty" schema:"description=Branch ID or branch code (defaults to generalDetails.branchId)"`
+	BranchIDOld   *uint      `json:"branch_id,omitempty"`
+	BranchCode    string     `json:"branch_code,omitempty"`
+	Amount        *float64   `json:"amount,omitempty" schema:"min=0"`
+	Tags          []string   `json:"tags,omitempty"`
+	MaterialValue *float64   `json:"materialValue,omitempty" schema:"min=0"`
+}
+
+// EventSubmissionV1 is version 1 of the event create/update payload
+type EventSubmissionV1 struct {
+	SchemaVersion        int                        `json:"schemaVersion,omitempty" schema:"enum=1"`
+	GeneralDetails       EventGeneralDetailsV1      `json:"generalDetails" schema:"required"`
+	MediaPromotion       *EventMediaPromotionV1     `json:"mediaPromotion,omitempty"`
+	InvolvedParticipants *EventParticipantsV1       `json:"involvedParticipants,omitempty"`
+	DonationTypes        []EventDonationV1          `json:"donationTypes,omitempty"`
+	MaterialTypes        []EventPromotionMaterialV1 `json:"materialTypes,omitempty"`
+	SpecialGuests        []EventSpecialGuestV1      `json:"specialGuests,omitempty"`
+	Volunteers           []EventVolunteerV1         `json:"volunteers,omitempty"`
+	UploadedFiles        map[string]interface{}     `json:"uploadedFiles,omitempty" schema:"description=File references, uploaded separately"`
+	CustomFields         map[string]interface{}     `json:"customFields" schema:"description=Values of the custom fields of the event category and sub category by field key (see GET /api/event-custom-fields); on update, leave out to keep the current values"`
+	DraftID              *uint                      `json:"draftId,omitempty" schema:"description=Draft to delete once the event is submitted"`
+	Status               string                     `json:"status,omitempty" schema:"enum=complete|incomplete"`
+}
+
+// checkRules reports the cross-field rules a JSON Schema cannot express
+func (s *EventSubmissionV1) checkRules() []validators.FieldError {
+	var errs []validators.FieldError
+	add := func(path, reason string) {
+		errs = append(errs, validators.FieldError{Path: path, Reason: reason})
+	}
+
+	g := s.GeneralDetails
+	if g.EventType == "" && g.Type == "" {
+		add("$.generalDetails.eventType", "is required")
+	}
+	if g.EventCategory == "" && g.EventName == "" {
+		add("$.generalDetails.eventCategory", "is required")
+	}
+	if g.Duration == "" && (g.StartDate == "" || g.EndDate == "") {
+		add("$.generalDetails.duration", "is required (or both start_date and end_date)")
+	}
+	for prefix, venue := range map[string]*EventVenueV1{
+		"$.generalDetails":       {Latitude: g.Latitude, Longitude: g.Longitude},
+		"$.generalDetails.venue": g.Venue,
+	} {
+		if venue != nil && (venue.Latitude == nil) != (venue.Longitude == nil) {
+			add(prefix+".longitude", "latitude and longitude must be given together")
+		}
+	}
+	for i, v := range s.Volunteers {
+		if v.BranchID == nil && v.BranchCode == "" {
+			add(fmt.Sprintf("$.volunteers[%d].branchId", i), "is required (or branch_code)")
+		}
+	}
+	for i, guest := range s.SpecialGuests {
+		if guest.FirstName == "" && guest.LastName == "" && guest.Organization == "" {
+			add(fmt.Sprintf("$.specialGuests[%d].firstName", i), "firstName, lastName or organization is required")
+		}
+	}
+	media := []EventMediaV1(nil)
+	mediaKey := "eventMediaList"
+	if s.MediaPromotion != nil {
+		media = s.MediaPromotion.EventMediaList
+		if media == nil {
+			media, mediaKey = s.MediaPromotion.EventMedia, "eventMedia"
+		}
+	}
+	for i, m := range media {
+		person := m.EventMediaPersonV1
+		if m.MediaPerson != nil {
+			person = *m.MediaPerson
+		}
+		if person.FirstName == "" || person.LastName == "" {
+			add(fmt.Sprintf("$.mediaPromotion.%s[%d].mediaPerson", mediaKey, i), "firstName and lastName are required")
+		}
+	}
+
+	return errs
+}
+
+// eventSubmission is implemented by every submission schema version
+type eventSubmission interface {
+	checkRules() []validators.FieldError
+}
+
+// eventSubmissionVersions lists the supported submission schema versions
+var eventSubmissionVersions = map[int]func() eventSubmission{
+	1: func() eventSubmission { return &EventSubmissionV1{} },
+}
+
+// EventSchemaVersions returns the supported submission schema versions, oldest first
+func EventSchemaVersions() []int {
+	versions := make([]int, 0, len(eventSubmissionVersions))
+	for version := range eventSubmissionVersions {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+var (
+	eventSchemaMu    sync.Mutex
+	eventSchemaCache = map[int]*validators.Schema{}
+)
+
+// EventSubmissionSchema returns the JSON Schema of a submission schema version
+func EventSubmissionSchema(version int) (*validators.Schema, error) {
+	newSubmission, ok := eventSubmissionVersions[version]
+	if !ok {
+		return nil, ErrUnknownEventSchemaVersion
+	}
+
+	eventSchemaMu.Lock()
+	defer eventSchemaMu.Unlock()
+	if schema, ok := eventSchemaCache[version]; ok {
+		return schema, nil
+	}
+	schema := validators.SchemaFor(newSubmission())
+	schema.Dialect = validators.JSONSchemaDialect
+	schema.ID = fmt.Sprintf("/api/events/schema/%d", version)
+	schema.Title = fmt.Sprintf("Event submission v%d", version)
+	schema.Description = "Payload of POST /api/events and nested PUT /api/events/{event_id}"
+	eventSchemaCache[version] = schema
+	return schema, nil
+}
+
+// DecodeEventSubmission strictly decodes an event create/update body. The schema version is
+// read from "schemaVersion" (default LatestEventSchemaVersion). Every unknown field, mistyped
+// value and broken rule is reported in a *validators.SchemaError; the valid payload is returned
+// normalized to the shape the payload mappers read.
+func DecodeEventSubmission(body []byte) (EventFrontendPayload, int, error) {
+	var payload EventFrontendPayload
+
+	raw, err := validators.DecodeJSONValue(body)
+	if err != nil {
+		return payload, 0, &validators.SchemaError{Errors: []validators.FieldError{{Path: "$", Reason: "is not valid JSON: " + err.Error()}}}
+	}
+	doc, ok := raw.(map[string]interface{})
+	if !ok {
+		return payload, 0, &validators.SchemaError{Errors: []validators.FieldError{{Path: "$", Reason: "must be an object"}}}
+	}
+
+	version := LatestEventSchemaVersion
+	if v, ok := doc["schemaVersion"]; ok {
+		n, isNumber := v.(json.Number)
+		parsed, err := strconv.Atoi(string(n))
+		if !isNumber || err != nil {
+			return payload, 0, &validators.SchemaError{Errors: []validators.FieldError{{Path: "$.schemaVersion", Reason: "must be an integer"}}}
+		}
+		version = parsed
+	}
+	newSubmission, ok := eventSubmissionVersions[version]
+	if !ok {
+		return payload, version, &validators.SchemaError{Errors: []validators.FieldError{{
+			Path:   "$.schemaVersion",
+			Reason: fmt.Sprintf("unsupported version %d (supported: %v)", version, EventSchemaVersions()),
+		}}}
+	}
+
+	schema, err := EventSubmissionSchema(version)
+	if err != nil {
+		return payload, version, err
+	}
+	submission := newSubmission()
+	errs := validators.ValidateValue(doc, schema)
+	err = json.Unmarshal(body, submission)
+	var typeErr *json.UnmarshalTypeError
+	if err == nil || errors.As(err, &typeErr) {
+		// encoding/json keeps decoding after type errors, so the rules can still be checked
+		errs = mergeFieldErrors(errs, submission.checkRules())
+	} else if len(errs) == 0 {
+		errs = []validators.FieldError{{Path: "$", Reason: err.Error()}}
+	}
+	if len(errs) > 0 {
+		return payload, version, &validators.SchemaError{Errors: errs}
+	}
+
+	// Re-encode the typed submission so the mappers only ever see validated, normalized values
+	normalized, err := json.Marshal(submission)
+	if err != nil {
+		return payload, version, err
+	}
+	if err := json.Unmarshal(normalized, &payload); err != nil {
+		return payload, version, err
+	}
+	return payload, version, nil
+}
+
+// mergeFieldErrors adds the rule errors to the schema errors, skipping paths (and their
+// children) that already have a schema error, and orders the result by path
+func mergeFieldErrors(schemaErrs, ruleErrs []validators.FieldError) []validators.FieldError {
+	merged := schemaErrs
+	for _, ruleErr := range ruleErrs {
+		covered := false
+		for _, schemaErr := range schemaErrs {
+			if ruleErr.Path == schemaErr.Path || strings.HasPrefix(ruleErr.Path, schemaErr.Path+".") || strings.HasPrefix(ruleErr.Path, schemaErr.Path+"[") {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			merged = append(merged, ruleErr)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Path < merged[j].Path })
+	return merged
+}
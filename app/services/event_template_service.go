@@ -0,0 +1,455 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEventTemplateNotFound  = errors.New("event template not found")
+	ErrEventTemplateNameTaken = errors.New("a template with this name already exists for this branch and category")
+	ErrInvalidCloneSection    = errors.New("invalid clone section")
+)
+
+// Sections of an event that can be cloned into a draft. General details are always cloned.
+const (
+	CloneSectionMedia         = "media"
+	CloneSectionPromotion     = "promotion"
+	CloneSectionSpecialGuests = "special_guests"
+	CloneSectionVolunteers    = "volunteers"
+	CloneSectionDonations     = "donations"
+)
+
+// CloneSections lists the optional sections in the order of the event form
+var CloneSections = []string{
+	CloneSectionMedia, CloneSectionPromotion, CloneSectionSpecialGuests, CloneSectionVolunteers, CloneSectionDonations,
+}
+
+// EventTemplateFilter narrows the template listing. A branch or category filter also returns the
+// templates that are not tied to any branch or category.
+type EventTemplateFilter struct {
+	BranchID        uint
+	EventCategoryID uint
+	Search          string
+}
+
+// loadEventForDraft reads an event with the lookups needed to express it in draft form
+func loadEventForDraft(eventID uint) (*models.EventDetails, error) {
+	var event models.EventDetails
+	if err := config.DB.
+		Preload("EventType").
+		Preload("EventCategory").
+		Preload("EventSubCategory").
+		First(&event, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// eventGeneralDetailsDraft expresses an event in the shape of the generalDetails step, leaving
+// out the dates and participant counts that belong to a single occurrence of the programme
+func eventGeneralDetailsDraft(event *models.EventDetails) models.JSONB {
+	draft := models.JSONB{}
+	set := func(key, value string) {
+		if value != "" {
+			draft[key] = value
+		}
+	}
+	set("eventType", event.EventType.Name)
+	set("eventCategory", event.EventCategory.Name)
+	if event.EventSubCategory != nil {
+		set("eventSubCategory", event.EventSubCategory.Name)
+	}
+	set("scale", event.Scale)
+	set("theme", event.Theme)
+	if event.DailyStartTime != nil && !event.DailyStartTime.IsZero() {
+		draft["dailyStartTime"] = event.DailyStartTime.Format("15:04")
+	}
+	if event.DailyEndTime != nil && !event.DailyEndTime.IsZero() {
+		draft["dailyEndTime"] = event.DailyEndTime.Format("15:04")
+	}
+	set("spiritualOrator", event.SpiritualOrator)
+	set("language", event.Language)
+	set("country", event.Country)
+	set("state", event.State)
+	set("city", event.City)
+	set("district", event.District)
+	set("postOffice", event.PostOffice)
+	set("pincode", event.Pincode)
+	set("address", event.Address)
+	set("addressType", event.AddressType)
+	set("policeStation", event.PoliceStation)
+	set("areaCovered", event.AreaCovered)
+	if event.BranchID != nil {
+		draft["branchId"] = *event.BranchID
+	}
+	return draft
+}
+
+// eventMediaPromotionDraft expresses an event's media coverage contacts and promotion materials in
+// the shape of the mediaPromotion step. Uploaded files stay with the original event.
+func eventMediaPromotionDraft(eventID uint, withMedia, withPromotion bool) (models.JSONB, error) {
+	draft := models.JSONB{}
+	if withMedia {
+		var media []models.EventMedia
+		if err := config.DB.Preload("MediaCoverageType").
+			Where("event_id = ? AND company_name <> ''", eventID).
+			Order("id").Find(&media).Error; err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, len(media))
+		for _, item := range media {
+			list = append(list, map[string]interface{}{
+				"mediaCoverageType": item.MediaCoverageType.MediaType,
+				"companyName":       item.CompanyName,
+				"companyEmail":      item.CompanyEmail,
+				"companyWebsite":    item.CompanyWebsite,
+				"mediaPerson": map[string]interface{}{
+					"gender":      item.Gender,
+					"prefix":      item.Prefix,
+					"firstName":   item.FirstName,
+					"middleName":  item.MiddleName,
+					"lastName":    item.LastName,
+					"designation": item.Designation,
+					"contact":     item.Contact,
+					"email":       item.Email,
+				},
+			})
+		}
+		draft["eventMediaList"] = list
+	}
+	if withPromotion {
+		var materials []models.PromotionMaterialDetails
+		if err := config.DB.Preload("PromotionMaterial").
+			Where("event_id = ?", eventID).
+			Order("id").Find(&materials).Error; err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, len(materials))
+		for _, item := range materials {
+			list = append(list, map[string]interface{}{
+				"materialType": item.PromotionMaterial.MaterialType,
+				"quantity":     item.Quantity,
+				"size":         item.Size,
+				"customHeight": item.DimensionHeight,
+				"customWidth":  item.DimensionWidth,
+			})
+		}
+		draft["promotionalMaterials"] = list
+	}
+	return draft, nil
+}
+
+// eventSpecialGuestsDraft expresses an event's special guests in the shape of the specialGuests step.
+// Emails are left out: they are unique across special guests, so a copied email would stop the
+// guest from being saved with the new event.
+func eventSpecialGuestsDraft(eventID uint) (models.JSONB, error) {
+	var guests []models.SpecialGuest
+	if err := config.DB.Where("event_id = ?", eventID).Order("id").Find(&guests).Error; err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, 0, len(guests))
+	for _, guest := range guests {
+		list = append(list, map[string]interface{}{
+			"gender":               guest.Gender,
+			"prefix":               guest.Prefix,
+			"firstName":            guest.FirstName,
+			"middleName":           guest.MiddleName,
+			"lastName":             guest.LastName,
+			"designation":          guest.Designation,
+			"organization":         guest.Organization,
+			"city":                 guest.City,
+			"state":                guest.State,
+			"personalNumber":       guest.PersonalNumber,
+			"contactPerson":        guest.ContactPerson,
+			"contactPersonNumber":  guest.ContactPersonNumber,
+			"referenceBranchId":    guest.ReferenceBranchID,
+			"referenceVolunteerId": guest.ReferenceVolunteerID,
+			"referencePersonName":  guest.ReferencePersonName,
+		})
+	}
+	return models.JSONB{"specialGuests": list}, nil
+}
+
+// eventVolunteersDraft expresses an event's volunteers in the shape of the volunteers step
+func eventVolunteersDraft(eventID uint) (models.JSONB, error) {
+	var volunteers []models.Volunteer
+	if err := config.DB.Where("event_id = ?", eventID).Order("id").Find(&volunteers).Error; err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, 0, len(volunteers))
+	for _, volunteer := range volunteers {
+		list = append(list, map[string]interface{}{
+			"branchId":    strconv.FormatUint(uint64(volunteer.BranchID), 10),
+			"name":        volunteer.VolunteerName,
+			"contact":     volunteer.Contact,
+			"days":        volunteer.NumberOfDays,
+			"seva":        volunteer.SevaInvolved,
+			"mentionSeva": volunteer.MentionSeva,
+		})
+	}
+	return models.JSONB{"volunteers": list}, nil
+}
+
+// eventDonationsDraft expresses an event's donations in the shape of the donations step
+func eventDonationsDraft(eventID uint) (models.JSONB, error) {
+	var donations []models.Donation
+	if err := config.DB.Where("event_id = ?", eventID).Order("id").Find(&donations).Error; err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, 0, len(donations))
+	for _, donation := range donations {
+		item := map[string]interface{}{
+			"type":     donation.DonationType,
+			"branchId": strconv.FormatUint(uint64(donation.BranchID), 10),
+		}
+		if donation.DonationType == "in-kind" {
+			item["materialValue"] = donation.Amount
+			item["tags"] = decodeKindTags(donation.KindType)
+		} else {
+			item["amount"] = donation.Amount
+		}
+		list = append(list, item)
+	}
+	return models.JSONB{"donationTypes": list}, nil
+}
+
+// decodeKindTags reads the in-kind tags stored as a JSON array in KindType
+func decodeKindTags(kindType string) []interface{} {
+	tags := []interface{}{}
+	if kindType == "" {
+		return tags
+	}
+	if err := json.Unmarshal([]byte(kindType), &tags); err != nil {
+		return []interface{}{kindType}
+	}
+	return tags
+}
+
+// CloneEventToDraft copies an event's general details and the chosen related sections into a new
+// draft owned by userEmail. Dates, participant counts and uploaded files are not copied.
+func CloneEventToDraft(eventID uint, sections []string, userEmail string) (*models.EventDraft, error) {
+	chosen := map[string]bool{}
+	for _, section := range sections {
+		valid := false
+		for _, known := range CloneSections {
+			if section == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %s (use %s)", ErrInvalidCloneSection, section, strings.Join(CloneSections, ", "))
+		}
+		chosen[section] = true
+	}
+
+	event, err := loadEventForDraft(eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	draft := &models.EventDraft{
+		GeneralDetailsDraft: eventGeneralDetailsDraft(event),
+		UserEmail:           userEmail,
+		CreatedOn:           now,
+		UpdatedOn:           &now,
+	}
+	if chosen[CloneSectionMedia] || chosen[CloneSectionPromotion] {
+		if draft.MediaPromotionDraft, err = eventMediaPromotionDraft(eventID, chosen[CloneSectionMedia], chosen[CloneSectionPromotion]); err != nil {
+			return nil, err
+		}
+	}
+	if chosen[CloneSectionSpecialGuests] {
+		if draft.SpecialGuestsDraft, err = eventSpecialGuestsDraft(eventID); err != nil {
+			return nil, err
+		}
+	}
+	if chosen[CloneSectionVolunteers] {
+		if draft.VolunteersDraft, err = eventVolunteersDraft(eventID); err != nil {
+			return nil, err
+		}
+	}
+	if chosen[CloneSectionDonations] {
+		if draft.DonationsDraft, err = eventDonationsDraft(eventID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.DB.Create(draft).Error; err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// checkEventTemplateName makes sure no other template in the same scope has the name
+func checkEventTemplateName(template *models.EventTemplate) error {
+	db := config.DB.Model(&models.EventTemplate{}).
+		Where("LOWER(name) = LOWER(?)", template.Name).
+		Where("COALESCE(branch_id, 0) = ? AND COALESCE(event_category_id, 0) = ?", uintValue(template.BranchID), uintValue(template.EventCategoryID))
+	if template.ID > 0 {
+		db = db.Where("id <> ?", template.ID)
+	}
+	var count int64
+	if err := db.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEventTemplateNameTaken
+	}
+	return nil
+}
+
+func uintValue(v *uint) uint {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// CreateEventTemplate stores a new template. When fromEventID is set, the template sections are
+// taken from that event (general details plus media contacts and promotion materials).
+func CreateEventTemplate(template *models.EventTemplate, fromEventID uint) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return errors.New("name is required")
+	}
+	if fromEventID > 0 {
+		event, err := loadEventForDraft(fromEventID)
+		if err != nil {
+			return err
+		}
+		template.GeneralDetails = eventGeneralDetailsDraft(event)
+		if template.MediaPromotion, err = eventMediaPromotionDraft(fromEventID, true, true); err != nil {
+			return err
+		}
+		if template.BranchID == nil {
+			template.BranchID = event.BranchID
+		}
+	}
+	if err := checkEventTemplateName(template); err != nil {
+		return err
+	}
+	template.ID = 0
+	template.CreatedOn = time.Now()
+	template.UpdatedOn = nil
+	return config.DB.Create(template).Error
+}
+
+// GetEventTemplates lists templates by name
+func GetEventTemplates(filter EventTemplateFilter) ([]models.EventTemplate, error) {
+	db := config.DB.Preload("Branch").Preload("EventCategory")
+	if filter.BranchID > 0 {
+		db = db.Where("branch_id = ? OR branch_id IS NULL", filter.BranchID)
+	}
+	if filter.EventCategoryID > 0 {
+		db = db.Where("event_category_id = ? OR event_category_id IS NULL", filter.EventCategoryID)
+	}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		db = db.Where("name ILIKE ?", "%"+search+"%")
+	}
+	var templates []models.EventTemplate
+	err := db.Order("LOWER(name) ASC, id ASC").Find(&templates).Error
+	return templates, err
+}
+
+// GetEventTemplate retrieves a template by ID
+func GetEventTemplate(id uint) (*models.EventTemplate, error) {
+	var template models.EventTemplate
+	if err := config.DB.Preload("Branch").Preload("EventCategory").First(&template, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateEventTemplate replaces the name, scope and sections of a template
+func UpdateEventTemplate(id uint, input *models.EventTemplate, updatedBy string) (*models.EventTemplate, error) {
+	template, err := GetEventTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	input.ID = id
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := checkEventTemplateName(input); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := config.DB.Model(&models.EventTemplate{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":              input.Name,
+		"description":       input.Description,
+		"branch_id":         input.BranchID,
+		"event_category_id": input.EventCategoryID,
+		"general_details":   input.GeneralDetails,
+		"media_promotion":   input.MediaPromotion,
+		"updated_on":        now,
+		"updated_by":        updatedBy,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return GetEventTemplate(template.ID)
+}
+
+// DeleteEventTemplate removes a template; drafts created from it are not affected
+func DeleteEventTemplate(id uint) error {
+	result := config.DB.Delete(&models.EventTemplate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEventTemplateNotFound
+	}
+	return nil
+}
+
+// CreateDraftFromTemplate starts a new draft pre-populated with a template's general details and
+// media/promotion sections
+func CreateDraftFromTemplate(templateID uint, userEmail string) (*models.EventDraft, error) {
+	template, err := GetEventTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	general := models.JSONB{}
+	for key, value := range template.GeneralDetails {
+		general[key] = value
+	}
+	if _, ok := general["branchId"]; !ok && template.BranchID != nil {
+		general["branchId"] = *template.BranchID
+	}
+	if _, ok := general["eventCategory"]; !ok && template.EventCategory != nil {
+		general["eventCategory"] = template.EventCategory.Name
+	}
+
+	now := time.Now()
+	draft := &models.EventDraft{
+		GeneralDetailsDraft: general,
+		MediaPromotionDraft: template.MediaPromotion,
+		UserEmail:           userEmail,
+		CreatedOn:           now,
+		UpdatedOn:           &now,
+	}
+	if err := config.DB.Create(draft).Error; err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
@@ -0,0 +1,450 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/app/validators"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Steps of an event submission dry run, in pipeline order
+const (
+	ValidationStepSchema      = "schema"       // typed decoding against the schema version
+	ValidationStepMasterData  = "master_data"  // type, category, sub category and branch resolution
+	ValidationStepValidation  = "validation"   // event validators
+	ValidationStepDuplicates  = "duplicates"   // likely duplicate events
+	ValidationStepEvent       = "event"        // writing the event row
+	ValidationStepRelatedData = "related_data" // mapping and writing guests, volunteers, media, donations, materials
+)
+
+// Outcomes of a dry run step
+const (
+	ValidationStatusPassed  = "passed"
+	ValidationStatusWarning = "warning"
+	ValidationStatusFailed  = "failed"
+	ValidationStatusSkipped = "skipped"
+)
+
+// errDryRunRollback rolls back the transaction of a dry run once all writes were tried
+var errDryRunRollback = errors.New("dry run")
+
+// ValidationIssue is one error or warning of a dry run step, addressed by JSON path when it
+// concerns a single field or entry
+type ValidationIssue struct {
+	Path    string `json:"path,omitempty" example:"$.volunteers[1]"`
+	Message string `json:"message" example:"volunteer would be skipped: branch not found or name missing"`
+}
+
+// ValidationStep is the outcome of one step of the pipeline
+type ValidationStep struct {
+	Name     string            `json:"name"`
+	Status   string            `json:"status"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// EventValidationReport is the result of a dry run: Valid is true when no step failed
+// (warnings do not block saving; likely duplicates need create_anyway=true)
+type EventValidationReport struct {
+	Valid         bool                 `json:"valid"`
+	SchemaVersion int                  `json:"schema_version,omitempty"`
+	Steps         []*ValidationStep    `json:"steps"`
+	Duplicates    []DuplicateCandidate `json:"duplicates,omitempty"`
+}
+
+// newEventValidationReport starts a report with every step pending (skipped until it runs)
+func newEventValidationReport() *EventValidationReport {
+	report := &EventValidationReport{}
+	for _, name := range []string{ValidationStepSchema, ValidationStepMasterData, ValidationStepValidation,
+		ValidationStepDuplicates, ValidationStepEvent, ValidationStepRelatedData} {
+		report.Steps = append(report.Steps, &ValidationStep{
+			Name:     name,
+			Status:   ValidationStatusSkipped,
+			Errors:   []ValidationIssue{},
+			Warnings: []ValidationIssue{},
+		})
+	}
+	return report
+}
+
+// step returns the named step and marks it as run
+func (r *EventValidationReport) step(name string) *ValidationStep {
+	for _, s := range r.Steps {
+		if s.Name == name {
+			s.Status = ValidationStatusPassed
+			return s
+		}
+	}
+	panic("unknown validation step " + name)
+}
+
+// finish sets the status of a step that ran and reports whether it passed
+func (s *ValidationStep) finish() bool {
+	switch {
+	case len(s.Errors) > 0:
+		s.Status = ValidationStatusFailed
+	case len(s.Warnings) > 0:
+		s.Status = ValidationStatusWarning
+	}
+	return len(s.Errors) == 0
+}
+
+func (s *ValidationStep) addError(path, message string) {
+	s.Errors = append(s.Errors, ValidationIssue{Path: path, Message: message})
+}
+
+func (s *ValidationStep) addWarning(path, message string) {
+	s.Warnings = append(s.Warnings, ValidationIssue{Path: path, Message: message})
+}
+
+func (r *EventValidationReport) close() *EventValidationReport {
+	r.Valid = true
+	for _, s := range r.Steps {
+		if s.Status == ValidationStatusFailed {
+			r.Valid = false
+		}
+	}
+	return r
+}
+
+// ValidateEventCreate runs the create pipeline for a payload without saving anything: schema
+// decoding, master data resolution, validators, the duplicate check and the inserts of the event
+// and its related rows inside a transaction that is rolled back.
+func ValidateEventCreate(body []byte) *EventValidationReport {
+	report := newEventValidationReport()
+	payload, event, ok := validateSubmission(report, body)
+	if !ok {
+		return report.close()
+	}
+
+	validation := report.step(ValidationStepValidation)
+	if err := validators.ValidateEventInput(event.EventTypeID, event.EventCategoryID, event.StartDate, event.EndDate); err != nil {
+		validation.addError("$.generalDetails", err.Error())
+	}
+	if err := validators.ValidateCoordinates(event.Latitude, event.Longitude); err != nil {
+		validation.addError("$.generalDetails", err.Error())
+	}
+	addCustomFieldErrors(validation, ApplyEventCustomFields(event, payload.CustomFields))
+	valid := validation.finish()
+
+	duplicates, err := FindEventDuplicates(event, 0)
+	reportDuplicates(report, duplicates, err)
+
+	if !valid {
+		return report.close()
+	}
+
+	eventStep := report.step(ValidationStepEvent)
+	related := report.step(ValidationStepRelatedData)
+	event.CreatedOn = time.Now()
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(event).Error; err != nil {
+			eventStep.addError("", "event could not be saved: "+err.Error())
+			return errDryRunRollback
+		}
+		for _, item := range relatedPayloadItems(payload) {
+			row := item.build(tx, event.ID)
+			if row == nil {
+				related.addWarning(item.path, item.label+" would be skipped: "+item.skipReason)
+				continue
+			}
+			clearPayloadRowID(row)
+			// A savepoint per row keeps the transaction usable after a failing insert
+			tx.SavePoint("dry_run_row")
+			if err := tx.Create(row).Error; err != nil {
+				related.addError(item.path, item.label+" could not be saved: "+err.Error())
+				tx.RollbackTo("dry_run_row")
+			}
+		}
+		return errDryRunRollback
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		eventStep.addError("", err.Error())
+	}
+	eventStep.finish()
+	related.finish()
+	return report.close()
+}
+
+// ValidateEventUpdate runs the nested update pipeline of an event without saving anything,
+// like ValidateEventCreate. ErrEventNotFound is returned for unknown events.
+func ValidateEventUpdate(eventID uint, body []byte, actor Actor) (*EventValidationReport, error) {
+	var existing models.EventDetails
+	if err := config.DB.Select("id").First(&existing, eventID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+
+	report := newEventValidationReport()
+	payload, event, ok := validateSubmission(report, body)
+	if !ok {
+		return report.close(), nil
+	}
+
+	updateData := EventUpdateData(event)
+	validation := report.step(ValidationStepValidation)
+	if err := validators.ValidateEventUpdateFields(updateData); err != nil {
+		validation.addError("$.generalDetails", err.Error())
+	}
+	customFields, err := EventCustomFieldsForUpdate(eventID, updateData, payload.CustomFields, payload.CustomFields != nil)
+	addCustomFieldErrors(validation, err)
+	if err == nil {
+		updateData["custom_fields"] = customFields
+	}
+	valid := validation.finish()
+
+	duplicates, err := FindEventDuplicatesForUpdate(eventID, updateData)
+	reportDuplicates(report, duplicates, err)
+
+	if !valid {
+		return report.close(), nil
+	}
+
+	eventStep := report.step(ValidationStepEvent)
+	related := report.step(ValidationStepRelatedData)
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, eventID).Error; err != nil {
+			eventStep.addError("", err.Error())
+			return errDryRunRollback
+		}
+		if err := dropAttendanceDerivedCounts(tx, eventID, updateData); err != nil {
+			eventStep.addError("", err.Error())
+			return errDryRunRollback
+		}
+		if err := tx.Model(&current).Updates(updateData).Error; err != nil {
+			eventStep.addError("", "event could not be saved: "+err.Error())
+			return errDryRunRollback
+		}
+		for _, item := range relatedPayloadItems(payload) {
+			if item.build(tx, eventID) == nil {
+				related.addWarning(item.path, item.label+" would be skipped: "+item.skipReason)
+			}
+		}
+		if _, err := SyncEventRelatedData(tx, eventID, payload, actor.Email); err != nil {
+			related.addError("", err.Error())
+		}
+		return errDryRunRollback
+	})
+	if err != nil && !errors.Is(err, errDryRunRollback) {
+		eventStep.addError("", err.Error())
+	}
+	eventStep.finish()
+	related.finish()
+	return report.close(), nil
+}
+
+// addCustomFieldErrors adds the outcome of a custom field check to the validation step
+func addCustomFieldErrors(step *ValidationStep, err error) {
+	var schemaErr *validators.SchemaError
+	switch {
+	case err == nil:
+	case errors.As(err, &schemaErr):
+		for _, fieldErr := range schemaErr.Errors {
+			step.addError(fieldErr.Path, fieldErr.Reason)
+		}
+	default:
+		step.addError("$.customFields", err.Error())
+	}
+}
+
+// validateSubmission runs the schema and master data steps and returns the mapped event
+func validateSubmission(report *EventValidationReport, body []byte) (EventFrontendPayload, *models.EventDetails, bool) {
+	schemaStep := report.step(ValidationStepSchema)
+	payload, version, err := DecodeEventSubmission(body)
+	report.SchemaVersion = version
+	if err != nil {
+		var schemaErr *validators.SchemaError
+		if errors.As(err, &schemaErr) {
+			for _, fe := range schemaErr.Errors {
+				schemaStep.addError(fe.Path, fe.Reason)
+			}
+		} else {
+			schemaStep.addError("$", err.Error())
+		}
+	}
+	if !schemaStep.finish() {
+		return payload, nil, false
+	}
+
+	masterData := report.step(ValidationStepMasterData)
+	checkMasterData(masterData, payload.GeneralDetails)
+	if !masterData.finish() {
+		return payload, nil, false
+	}
+	event, err := MapFrontendPayloadToEventWithStatus(payload.GeneralDetails, payload.InvolvedParticipants, payload.Status)
+	if err != nil {
+		masterData.addError("$.generalDetails", err.Error())
+	}
+	if !masterData.finish() {
+		return payload, nil, false
+	}
+	return payload, event, true
+}
+
+// checkMasterData resolves every master data reference of the general details, so all unknown
+// names are reported at once (the mapper stops at the first one)
+func checkMasterData(step *ValidationStep, general map[string]interface{}) {
+	named := func(keys ...string) (string, string) {
+		for _, key := range keys {
+			if val, ok := general[key].(string); ok && val != "" {
+				return "$.generalDetails." + key, val
+			}
+		}
+		return "", ""
+	}
+
+	if path, name := named("eventType", "type"); name != "" {
+		if config.DB.Where("name = ?", name).First(&models.EventType{}).Error != nil {
+			step.addError(path, fmt.Sprintf("event type '%s' not found", name))
+		}
+	}
+	var category models.EventCategory
+	if path, name := named("eventCategory", "eventName"); name != "" {
+		if config.DB.Where("name = ?", name).First(&category).Error != nil {
+			step.addError(path, fmt.Sprintf("event category '%s' not found", name))
+		}
+	}
+	if path, name := named("eventSubCategory", "event_sub_category"); name != "" && category.ID > 0 {
+		if config.DB.Where("name = ? AND event_category_id = ?", name, category.ID).First(&models.EventSubCategory{}).Error != nil {
+			step.addWarning(path, fmt.Sprintf("event sub category '%s' not found for this category; it will be left empty", name))
+		}
+	}
+
+	for _, key := range []string{"branchId", "branch_id"} {
+		ref, ok := general[key]
+		if !ok {
+			continue
+		}
+		var branchID uint
+		switch v := ref.(type) {
+		case float64:
+			branchID = uint(v)
+		case string:
+			branchID = resolveBranchRef(config.DB, v)
+		}
+		if branchID == 0 || config.DB.Select("id").First(&models.Branch{}, branchID).Error != nil {
+			step.addError("$.generalDetails."+key, fmt.Sprintf("branch '%v' not found", ref))
+		}
+		break
+	}
+}
+
+// reportDuplicates fills the duplicates step: likely duplicates are warnings, since the
+// client may save anyway with create_anyway=true
+func reportDuplicates(report *EventValidationReport, duplicates []DuplicateCandidate, err error) {
+	step := report.step(ValidationStepDuplicates)
+	if err != nil {
+		step.addWarning("", "duplicate check failed: "+err.Error())
+	} else if len(duplicates) > 0 {
+		report.Duplicates = duplicates
+		step.addWarning("", fmt.Sprintf("%d similar event(s) exist for this branch and dates; save with create_anyway=true to keep it anyway", len(duplicates)))
+	}
+	step.finish()
+}
+
+// relatedPayloadItem is one related data entry of a payload
+type relatedPayloadItem struct {
+	path       string
+	label      string
+	skipReason string
+	// build maps the entry like CreateEventRelatedData does and returns a pointer to the row,
+	// or nil when the entry would be dropped
+	build func(db *gorm.DB, eventID uint) interface{}
+}
+
+// firstRow returns a pointer to the only row built from a single entry, or nil
+func firstRow[T any](rows []T) interface{} {
+	if len(rows) == 0 {
+		return nil
+	}
+	return &rows[0]
+}
+
+// relatedPayloadItems splits the related data of a payload into single entries, reading the
+// same sections (and fallbacks) as the row builders
+func relatedPayloadItems(payload EventFrontendPayload) []relatedPayloadItem {
+	var items []relatedPayloadItem
+
+	for i, guest := range payload.SpecialGuests {
+		single := EventFrontendPayload{SpecialGuests: []interface{}{guest}}
+		items = append(items, relatedPayloadItem{
+			path: "$.specialGuests[" + strconv.Itoa(i) + "]", label: "special guest",
+			skipReason: "prefix and a name or organization are required",
+			build: func(_ *gorm.DB, eventID uint) interface{} {
+				return firstRow(buildSpecialGuestRows(eventID, single))
+			},
+		})
+	}
+
+	for i, volunteer := range payload.Volunteers {
+		single := EventFrontendPayload{Volunteers: []interface{}{volunteer}}
+		items = append(items, relatedPayloadItem{
+			path: "$.volunteers[" + strconv.Itoa(i) + "]", label: "volunteer",
+			skipReason: "branch not found or name missing",
+			build: func(db *gorm.DB, eventID uint) interface{} {
+				return firstRow(buildVolunteerRows(db, eventID, single))
+			},
+		})
+	}
+
+	mediaKey := "eventMediaList"
+	mediaList, _ := payload.MediaPromotion["eventMediaList"].([]interface{})
+	if _, ok := payload.MediaPromotion["eventMediaList"].([]interface{}); !ok {
+		mediaKey = "eventMedia"
+		mediaList, _ = payload.MediaPromotion["eventMedia"].([]interface{})
+	}
+	for i, media := range mediaList {
+		single := EventFrontendPayload{MediaPromotion: map[string]interface{}{"eventMediaList": []interface{}{media}}}
+		items = append(items, relatedPayloadItem{
+			path: fmt.Sprintf("$.mediaPromotion.%s[%d]", mediaKey, i), label: "media coverage",
+			skipReason: "media coverage type not found, or company or person name missing",
+			build: func(db *gorm.DB, eventID uint) interface{} {
+				return firstRow(buildEventMediaRows(db, eventID, single))
+			},
+		})
+	}
+
+	materialsPath, materials := "$.materialTypes", payload.MaterialTypes
+	if len(materials) == 0 {
+		materialsPath = "$.mediaPromotion.promotionalMaterials"
+		materials, _ = payload.MediaPromotion["promotionalMaterials"].([]interface{})
+	}
+	for i, material := range materials {
+		single := EventFrontendPayload{MaterialTypes: []interface{}{material}}
+		items = append(items, relatedPayloadItem{
+			path: fmt.Sprintf("%s[%d]", materialsPath, i), label: "promotion material",
+			skipReason: "material type not found or quantity missing",
+			build: func(db *gorm.DB, eventID uint) interface{} {
+				return firstRow(buildPromotionMaterialRows(db, eventID, single))
+			},
+		})
+	}
+
+	donationsPath, donations := "$.donationTypes", payload.DonationTypes
+	if len(donations) == 0 {
+		donationsPath = "$.generalDetails.donations"
+		donations, _ = payload.GeneralDetails["donations"].([]interface{})
+	}
+	for i, donation := range donations {
+		// The general details stay attached for the branch fallback of donations
+		single := EventFrontendPayload{DonationTypes: []interface{}{donation}, GeneralDetails: payload.GeneralDetails}
+		items = append(items, relatedPayloadItem{
+			path: fmt.Sprintf("%s[%d]", donationsPath, i), label: "donation",
+			skipReason: "type missing or branch not found",
+			build: func(db *gorm.DB, eventID uint) interface{} {
+				return firstRow(buildDonationRows(db, eventID, single))
+			},
+		})
+	}
+
+	return items
+}
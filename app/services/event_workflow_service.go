@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidWorkflowState      = errors.New("invalid workflow state")
+	ErrInvalidWorkflowTransition = errors.New("workflow transition not allowed")
+	ErrWorkflowRoleNotAllowed    = errors.New("role not allowed to perform this transition")
+	ErrWorkflowCommentRequired   = errors.New("a comment is required for this transition")
+)
+
+// workflowTransition describes an allowed move between two workflow states
+type workflowTransition struct {
+	From            string
+	To              string
+	Roles           []models.RoleType
+	CommentRequired bool
+}
+
+var reporterRoles = []models.RoleType{
+	models.RoleTypeStaff,
+	models.RoleTypeCoordinator,
+	models.RoleTypeAdmin,
+	models.RoleTypeSuperAdmin,
+}
+
+var reviewerRoles = []models.RoleType{
+	models.RoleTypeAdmin,
+	models.RoleTypeSuperAdmin,
+}
+
+// workflowTransitions is the event review state machine:
+// draft -> submitted -> under_review -> approved / returned_with_comments -> resubmitted -> under_review
+var workflowTransitions = []workflowTransition{
+	{From: models.WorkflowStateDraft, To: models.WorkflowStateSubmitted, Roles: reporterRoles},
+	{From: models.WorkflowStateSubmitted, To: models.WorkflowStateUnderReview, Roles: reviewerRoles},
+	{From: models.WorkflowStateUnderReview, To: models.WorkflowStateApproved, Roles: reviewerRoles},
+	{From: models.WorkflowStateUnderReview, To: models.WorkflowStateReturnedWithComments, Roles: reviewerRoles, CommentRequired: true},
+	{From: models.WorkflowStateReturnedWithComments, To: models.WorkflowStateResubmitted, Roles: reporterRoles},
+	{From: models.WorkflowStateResubmitted, To: models.WorkflowStateUnderReview, Roles: reviewerRoles},
+}
+
+// findWorkflowTransition returns the transition definition for from -> to, if any
+func findWorkflowTransition(from, to string) *workflowTransition {
+	for i := range workflowTransitions {
+		if workflowTransitions[i].From == from && workflowTransitions[i].To == to {
+			return &workflowTransitions[i]
+		}
+	}
+	return nil
+}
+
+// GetAllowedWorkflowTransitions lists the states the given role can move an event to from its current state
+func GetAllowedWorkflowTransitions(currentState string, role string) []string {
+	allowed := []string{}
+	for _, t := range workflowTransitions {
+		if t.From != currentState {
+			continue
+		}
+		for _, r := range t.Roles {
+			if string(r) == role {
+				allowed = append(allowed, t.To)
+				break
+			}
+		}
+	}
+	return allowed
+}
+
+// TransitionEventWorkflow moves an event to a new workflow state after checking the
+// state machine and the actor's role, and records the transition in the status history
+func TransitionEventWorkflow(eventID uint, toState string, comment string, actor Actor) (*models.EventStatusHistory, error) {
+	if !models.IsValidWorkflowState(toState) {
+		return nil, ErrInvalidWorkflowState
+	}
+
+	var history *models.EventStatusHistory
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&event, eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrEventNotFound
+			}
+			return err
+		}
+
+		fromState := event.WorkflowState
+		if fromState == "" {
+			fromState = models.WorkflowStateDraft
+		}
+
+		transition := findWorkflowTransition(fromState, toState)
+		if transition == nil {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidWorkflowTransition, fromState, toState)
+		}
+
+		roleAllowed := false
+		for _, r := range transition.Roles {
+			if string(r) == actor.Role {
+				roleAllowed = true
+				break
+			}
+		}
+		if !roleAllowed {
+			return ErrWorkflowRoleNotAllowed
+		}
+
+		comment = strings.TrimSpace(comment)
+		if transition.CommentRequired && comment == "" {
+			return ErrWorkflowCommentRequired
+		}
+
+		now := time.Now()
+		if err := tx.Model(&event).Updates(map[string]interface{}{
+			"workflow_state": toState,
+			"updated_on":     &now,
+			"updated_by":     actor.Email,
+		}).Error; err != nil {
+			return err
+		}
+
+		history = &models.EventStatusHistory{
+			EventID:    eventID,
+			FromState:  fromState,
+			ToState:    toState,
+			Comment:    comment,
+			ActorID:    actor.UserID,
+			ActorEmail: actor.Email,
+			ActorRole:  actor.Role,
+			CreatedOn:  now,
+		}
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		if err := RecordDomainEvent(tx, EventWorkflowChanged{
+			EventID:    eventID,
+			FromState:  fromState,
+			ToState:    toState,
+			Comment:    comment,
+			ActorID:    actor.UserID,
+			ActorEmail: actor.Email,
+		}); err != nil {
+			return err
+		}
+		if toState == models.WorkflowStateApproved {
+			return RecordDomainEvent(tx, EventApproved{EventID: eventID, ApproverID: actor.UserID, ApprovedBy: actor.Email})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetEventStatusHistory returns the workflow transitions of an event, oldest first
+func GetEventStatusHistory(eventID uint) ([]models.EventStatusHistory, error) {
+	var history []models.EventStatusHistory
+	if err := config.DB.
+		Where("event_id = ?", eventID).
+		Order("created_on ASC, id ASC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
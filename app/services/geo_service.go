@@ -0,0 +1,483 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidGeoQuery = errors.New("invalid map query")
+
+const (
+	earthRadiusKm = 6371.0
+
+	// MaxMapFeatures caps the points of one map response; zoomed-out views should cluster
+	MaxMapFeatures = 5000
+
+	// GeoClusterDistrict clusters map points by (state, district)
+	GeoClusterDistrict = "district"
+)
+
+// BoundingBox is a map viewport in decimal degrees. MinLng > MaxLng means the box crosses
+// the antimeridian.
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+// GeoCircle is a radius search around a point
+type GeoCircle struct {
+	Lat      float64
+	Lng      float64
+	RadiusKm float64
+}
+
+// GeoQuery selects the events or branches shown on a map
+type GeoQuery struct {
+	BBox        *BoundingBox
+	Circle      *GeoCircle
+	Cluster     string     // "" for individual points, GeoClusterDistrict for district clusters
+	From        *time.Time // events starting on or after, inclusive
+	To          *time.Time // events starting before, exclusive
+	Statuses    []string
+	BranchID    uint // events: restrict to a branch and its child branches
+	EventTypeID uint
+}
+
+// Validate checks the viewport, radius and cluster mode
+func (q GeoQuery) Validate() error {
+	if q.BBox != nil {
+		b := q.BBox
+		if b.MinLat < -90 || b.MaxLat > 90 || b.MinLat > b.MaxLat {
+			return fmt.Errorf("%w: bbox latitudes must be within -90..90 with min <= max", ErrInvalidGeoQuery)
+		}
+		if b.MinLng < -180 || b.MinLng > 180 || b.MaxLng < -180 || b.MaxLng > 180 {
+			return fmt.Errorf("%w: bbox longitudes must be within -180..180", ErrInvalidGeoQuery)
+		}
+	}
+	if q.Circle != nil {
+		c := q.Circle
+		if c.Lat < -90 || c.Lat > 90 || c.Lng < -180 || c.Lng > 180 {
+			return fmt.Errorf("%w: lat must be within -90..90 and lng within -180..180", ErrInvalidGeoQuery)
+		}
+		if c.RadiusKm <= 0 || c.RadiusKm > math.Pi*earthRadiusKm {
+			return fmt.Errorf("%w: radius_km must be greater than 0 and at most %.0f", ErrInvalidGeoQuery, math.Pi*earthRadiusKm)
+		}
+	}
+	if q.Cluster != "" && q.Cluster != GeoClusterDistrict {
+		return fmt.Errorf("%w: cluster must be '%s'", ErrInvalidGeoQuery, GeoClusterDistrict)
+	}
+	return nil
+}
+
+// GeoJSONGeometry is a GeoJSON Point ([longitude, latitude])
+type GeoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+// GeoJSONFeature is a GeoJSON Feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONFeatureCollection is a GeoJSON FeatureCollection. Truncated is set when more than
+// MaxMapFeatures points matched.
+type GeoJSONFeatureCollection struct {
+	Type      string           `json:"type"`
+	Features  []GeoJSONFeature `json:"features"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+func newFeatureCollection() *GeoJSONFeatureCollection {
+	return &GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+}
+
+func pointFeature(id interface{}, lat, lng float64, properties map[string]interface{}) GeoJSONFeature {
+	return GeoJSONFeature{
+		Type:       "Feature",
+		ID:         id,
+		Geometry:   GeoJSONGeometry{Type: "Point", Coordinates: []float64{lng, lat}},
+		Properties: properties,
+	}
+}
+
+// haversineSQL is the great-circle distance in km between the row (alias.latitude,
+// alias.longitude) and a point; it takes the point's lat, lat, lng as arguments
+func haversineSQL(alias string) string {
+	return fmt.Sprintf(`(2 * %g * ASIN(SQRT(
+		POWER(SIN(RADIANS(%[2]s.latitude - ?) / 2), 2) +
+		COS(RADIANS(?)) * COS(RADIANS(%[2]s.latitude)) * POWER(SIN(RADIANS(%[2]s.longitude - ?) / 2), 2))))`,
+		earthRadiusKm, alias)
+}
+
+// circleBounds is the bounding box around a circle, used to narrow the distance filter
+func circleBounds(c GeoCircle) BoundingBox {
+	dLat := c.RadiusKm / earthRadiusKm * 180 / math.Pi
+	minLat, maxLat := c.Lat-dLat, c.Lat+dLat
+	if minLat <= -90 || maxLat >= 90 {
+		// A circle over a pole covers every longitude
+		return BoundingBox{MinLng: -180, MinLat: math.Max(minLat, -90), MaxLng: 180, MaxLat: math.Min(maxLat, 90)}
+	}
+	dLng := math.Asin(math.Min(1, math.Sin(dLat*math.Pi/180)/math.Cos(c.Lat*math.Pi/180))) * 180 / math.Pi
+	if dLng >= 180 {
+		return BoundingBox{MinLng: -180, MinLat: minLat, MaxLng: 180, MaxLat: maxLat}
+	}
+	minLng, maxLng := c.Lng-dLng, c.Lng+dLng
+	if minLng < -180 {
+		minLng += 360
+	}
+	if maxLng > 180 {
+		maxLng -= 360
+	}
+	return BoundingBox{MinLng: minLng, MinLat: minLat, MaxLng: maxLng, MaxLat: maxLat}
+}
+
+// applyGeoArea restricts alias rows to located rows inside the query's bbox and circle
+func applyGeoArea(db *gorm.DB, alias string, q GeoQuery) *gorm.DB {
+	db = db.Where(alias + ".latitude IS NOT NULL AND " + alias + ".longitude IS NOT NULL")
+
+	boxes := []BoundingBox{}
+	if q.BBox != nil {
+		boxes = append(boxes, *q.BBox)
+	}
+	if q.Circle != nil {
+		boxes = append(boxes, circleBounds(*q.Circle))
+	}
+	for _, b := range boxes {
+		db = db.Where(alias+".latitude BETWEEN ? AND ?", b.MinLat, b.MaxLat)
+		if b.MinLng <= b.MaxLng {
+			db = db.Where(alias+".longitude BETWEEN ? AND ?", b.MinLng, b.MaxLng)
+		} else {
+			db = db.Where("("+alias+".longitude >= ? OR "+alias+".longitude <= ?)", b.MinLng, b.MaxLng)
+		}
+	}
+	if q.Circle != nil {
+		db = db.Where(haversineSQL(alias)+" <= ?", q.Circle.Lat, q.Circle.Lat, q.Circle.Lng, q.Circle.RadiusKm)
+	}
+	return db
+}
+
+// geoEventsBaseQuery selects the located, non-deleted events matching q
+func geoEventsBaseQuery(q GeoQuery) *gorm.DB {
+	db := config.DB.Table("event_details e").
+		Joins("LEFT JOIN branches b ON b.id = e.branch_id").
+		Joins("LEFT JOIN event_types t ON t.id = e.event_type_id").
+		Joins("LEFT JOIN event_categories ec ON ec.id = e.event_category_id").
+		Where("e.deleted_at IS NULL")
+	db = applyGeoArea(db, "e", q)
+
+	if q.From != nil {
+		db = db.Where("e.start_date >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where("e.start_date < ?", *q.To)
+	}
+	if len(q.Statuses) > 0 {
+		db = db.Where("e.status IN ?", q.Statuses)
+	}
+	if q.EventTypeID > 0 {
+		db = db.Where("e.event_type_id = ?", q.EventTypeID)
+	}
+	if q.BranchID > 0 {
+		branchTree := `WITH RECURSIVE branch_tree AS (
+				SELECT id FROM branches WHERE id = ?
+				UNION ALL
+				SELECT br.id FROM branches br JOIN branch_tree bt ON br.parent_branch_id = bt.id
+			)
+			SELECT id FROM branch_tree`
+		// Events led or co-hosted by the branch tree
+		db = db.Where("(e.branch_id IN ("+branchTree+") OR e.id IN (SELECT event_id FROM event_cohost_branches WHERE branch_id IN ("+branchTree+")))",
+			q.BranchID, q.BranchID)
+	}
+	return db
+}
+
+// distanceSelect adds the distance from the circle's centre to the selected columns
+func distanceSelect(alias string, q GeoQuery, columns string) (string, []interface{}) {
+	if q.Circle == nil {
+		return columns + ", NULL AS distance_km", nil
+	}
+	return columns + ", " + haversineSQL(alias) + " AS distance_km",
+		[]interface{}{q.Circle.Lat, q.Circle.Lat, q.Circle.Lng}
+}
+
+type eventMapRow struct {
+	ID               uint
+	Theme            string
+	StartDate        time.Time
+	EndDate          time.Time
+	Status           string
+	WorkflowState    string
+	EventType        string
+	EventCategory    string
+	BranchID         *uint
+	BranchName       *string
+	State            string
+	District         string
+	City             string
+	Latitude         float64
+	Longitude        float64
+	BeneficiaryMen   int64
+	BeneficiaryWomen int64
+	BeneficiaryChild int64
+	DistanceKm       *float64
+}
+
+// geoClusterRow is one district cluster of events or branches
+type geoClusterRow struct {
+	State            string
+	District         string
+	Points           int64
+	Latitude         float64
+	Longitude        float64
+	MinLat           float64
+	MinLng           float64
+	MaxLat           float64
+	MaxLng           float64
+	BeneficiaryMen   int64
+	BeneficiaryWomen int64
+	BeneficiaryChild int64
+}
+
+func clusterFeature(row geoClusterRow, countKey string) GeoJSONFeature {
+	district := row.District
+	if district == "" {
+		district = "Unknown"
+	}
+	return pointFeature(row.State+"/"+district, row.Latitude, row.Longitude, map[string]interface{}{
+		"cluster":           true,
+		"state":             row.State,
+		"district":          district,
+		countKey:            row.Points,
+		"beneficiary_men":   row.BeneficiaryMen,
+		"beneficiary_women": row.BeneficiaryWomen,
+		"beneficiary_child": row.BeneficiaryChild,
+		"beneficiary_total": row.BeneficiaryMen + row.BeneficiaryWomen + row.BeneficiaryChild,
+		"bbox":              []float64{row.MinLng, row.MinLat, row.MaxLng, row.MaxLat},
+	})
+}
+
+// GetEventsMap returns the located events matching q as a GeoJSON FeatureCollection, either one
+// point per event or one point per district (centroid of its events) with summed totals
+func GetEventsMap(q GeoQuery) (*GeoJSONFeatureCollection, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	collection := newFeatureCollection()
+
+	if q.Cluster == GeoClusterDistrict {
+		var rows []geoClusterRow
+		err := geoEventsBaseQuery(q).
+			Select(`COALESCE(e.state, '') AS state, COALESCE(e.district, '') AS district,
+				COUNT(*) AS points, AVG(e.latitude) AS latitude, AVG(e.longitude) AS longitude,
+				MIN(e.latitude) AS min_lat, MIN(e.longitude) AS min_lng,
+				MAX(e.latitude) AS max_lat, MAX(e.longitude) AS max_lng,
+				COALESCE(SUM(e.beneficiary_men), 0) AS beneficiary_men,
+				COALESCE(SUM(e.beneficiary_women), 0) AS beneficiary_women,
+				COALESCE(SUM(e.beneficiary_child), 0) AS beneficiary_child`).
+			Group("COALESCE(e.state, ''), COALESCE(e.district, '')").
+			Order("points DESC").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collection.Features = append(collection.Features, clusterFeature(row, "event_count"))
+		}
+		return collection, nil
+	}
+
+	columns, args := distanceSelect("e", q, `e.id, COALESCE(e.theme, '') AS theme, e.start_date, e.end_date,
+		COALESCE(e.status, '') AS status, COALESCE(e.workflow_state, '') AS workflow_state,
+		COALESCE(t.name, '') AS event_type, COALESCE(ec.name, '') AS event_category, e.branch_id, b.name AS branch_name,
+		COALESCE(e.state, '') AS state, COALESCE(e.district, '') AS district, COALESCE(e.city, '') AS city,
+		e.latitude, e.longitude,
+		e.beneficiary_men, e.beneficiary_women, e.beneficiary_child`)
+	order := "e.start_date DESC, e.id DESC"
+	if q.Circle != nil {
+		order = "distance_km, e.id"
+	}
+
+	var rows []eventMapRow
+	if err := geoEventsBaseQuery(q).Select(columns, args...).Order(order).Limit(MaxMapFeatures + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxMapFeatures {
+		rows = rows[:MaxMapFeatures]
+		collection.Truncated = true
+	}
+
+	for _, row := range rows {
+		properties := map[string]interface{}{
+			"theme":             row.Theme,
+			"start_date":        row.StartDate.Format("2006-01-02"),
+			"end_date":          row.EndDate.Format("2006-01-02"),
+			"status":            row.Status,
+			"workflow_state":    row.WorkflowState,
+			"event_type":        row.EventType,
+			"event_category":    row.EventCategory,
+			"branch_id":         row.BranchID,
+			"branch_name":       row.BranchName,
+			"state":             row.State,
+			"district":          row.District,
+			"city":              row.City,
+			"beneficiary_men":   row.BeneficiaryMen,
+			"beneficiary_women": row.BeneficiaryWomen,
+			"beneficiary_child": row.BeneficiaryChild,
+			"beneficiary_total": row.BeneficiaryMen + row.BeneficiaryWomen + row.BeneficiaryChild,
+		}
+		if row.DistanceKm != nil {
+			properties["distance_km"] = math.Round(*row.DistanceKm*100) / 100
+		}
+		collection.Features = append(collection.Features, pointFeature(row.ID, row.Latitude, row.Longitude, properties))
+	}
+	return collection, nil
+}
+
+type branchMapRow struct {
+	ID               uint
+	Name             string
+	BranchCode       string
+	CoordinatorName  string
+	ContactNumber    string
+	ParentBranchID   *uint
+	State            string
+	District         string
+	City             string
+	Latitude         float64
+	Longitude        float64
+	Events           int64
+	BeneficiaryMen   int64
+	BeneficiaryWomen int64
+	BeneficiaryChild int64
+	DistanceKm       *float64
+}
+
+// geoBranchesBaseQuery selects the located, non-deleted branches matching q, joined with the
+// totals of the events they lead or co-host (within the query's date range and statuses), the
+// beneficiaries of co-hosted events split by the hosts' shares
+func geoBranchesBaseQuery(q GeoQuery) *gorm.DB {
+	eventTotals := config.DB.Table("event_details ev").
+		Joins("JOIN (" + eventHostsSQL + ") h ON h.event_id = ev.id").
+		Select(`h.branch_id, COUNT(DISTINCT ev.id) AS events,
+			COALESCE(ROUND(SUM(ev.beneficiary_men * h.share)), 0)::bigint AS beneficiary_men,
+			COALESCE(ROUND(SUM(ev.beneficiary_women * h.share)), 0)::bigint AS beneficiary_women,
+			COALESCE(ROUND(SUM(ev.beneficiary_child * h.share)), 0)::bigint AS beneficiary_child`).
+		Where("ev.deleted_at IS NULL AND h.branch_id IS NOT NULL").
+		Group("h.branch_id")
+	if q.From != nil {
+		eventTotals = eventTotals.Where("ev.start_date >= ?", *q.From)
+	}
+	if q.To != nil {
+		eventTotals = eventTotals.Where("ev.start_date < ?", *q.To)
+	}
+	if len(q.Statuses) > 0 {
+		eventTotals = eventTotals.Where("ev.status IN ?", q.Statuses)
+	}
+	if q.EventTypeID > 0 {
+		eventTotals = eventTotals.Where("ev.event_type_id = ?", q.EventTypeID)
+	}
+
+	db := config.DB.Table("branches b").
+		Joins("LEFT JOIN states s ON s.id = b.state_id").
+		Joins("LEFT JOIN districts d ON d.id = b.district_id").
+		Joins("LEFT JOIN cities ci ON ci.id = b.city_id").
+		Joins("LEFT JOIN (?) et ON et.branch_id = b.id", eventTotals).
+		Where("b.deleted_at IS NULL")
+	db = applyGeoArea(db, "b", q)
+	if q.BranchID > 0 {
+		db = db.Where(`b.id IN (
+			WITH RECURSIVE branch_tree AS (
+				SELECT id FROM branches WHERE id = ?
+				UNION ALL
+				SELECT br.id FROM branches br JOIN branch_tree bt ON br.parent_branch_id = bt.id
+			)
+			SELECT id FROM branch_tree)`, q.BranchID)
+	}
+	return db
+}
+
+// GetBranchesMap returns the located branches matching q as a GeoJSON FeatureCollection with
+// the totals of their events as properties, either one point per branch or per district
+func GetBranchesMap(q GeoQuery) (*GeoJSONFeatureCollection, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	collection := newFeatureCollection()
+
+	if q.Cluster == GeoClusterDistrict {
+		var rows []geoClusterRow
+		err := geoBranchesBaseQuery(q).
+			Select(`COALESCE(s.name, '') AS state, COALESCE(d.name, '') AS district,
+				COUNT(*) AS points, AVG(b.latitude) AS latitude, AVG(b.longitude) AS longitude,
+				MIN(b.latitude) AS min_lat, MIN(b.longitude) AS min_lng,
+				MAX(b.latitude) AS max_lat, MAX(b.longitude) AS max_lng,
+				COALESCE(SUM(et.beneficiary_men), 0) AS beneficiary_men,
+				COALESCE(SUM(et.beneficiary_women), 0) AS beneficiary_women,
+				COALESCE(SUM(et.beneficiary_child), 0) AS beneficiary_child`).
+			Group("COALESCE(s.name, ''), COALESCE(d.name, '')").
+			Order("points DESC").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			collection.Features = append(collection.Features, clusterFeature(row, "branch_count"))
+		}
+		return collection, nil
+	}
+
+	columns, args := distanceSelect("b", q, `b.id, b.name, COALESCE(b.branch_code, '') AS branch_code,
+		COALESCE(b.coordinator_name, '') AS coordinator_name, COALESCE(b.contact_number, '') AS contact_number,
+		b.parent_branch_id, COALESCE(s.name, '') AS state, COALESCE(d.name, '') AS district, COALESCE(ci.name, '') AS city,
+		b.latitude, b.longitude, COALESCE(et.events, 0) AS events,
+		COALESCE(et.beneficiary_men, 0) AS beneficiary_men,
+		COALESCE(et.beneficiary_women, 0) AS beneficiary_women,
+		COALESCE(et.beneficiary_child, 0) AS beneficiary_child`)
+	order := "b.name, b.id"
+	if q.Circle != nil {
+		order = "distance_km, b.id"
+	}
+
+	var rows []branchMapRow
+	if err := geoBranchesBaseQuery(q).Select(columns, args...).Order(order).Limit(MaxMapFeatures + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxMapFeatures {
+		rows = rows[:MaxMapFeatures]
+		collection.Truncated = true
+	}
+
+	for _, row := range rows {
+		properties := map[string]interface{}{
+			"name":              row.Name,
+			"branch_code":       row.BranchCode,
+			"coordinator_name":  row.CoordinatorName,
+			"contact_number":    row.ContactNumber,
+			"parent_branch_id":  row.ParentBranchID,
+			"state":             row.State,
+			"district":          row.District,
+			"city":              row.City,
+			"event_count":       row.Events,
+			"beneficiary_men":   row.BeneficiaryMen,
+			"beneficiary_women": row.BeneficiaryWomen,
+			"beneficiary_child": row.BeneficiaryChild,
+			"beneficiary_total": row.BeneficiaryMen + row.BeneficiaryWomen + row.BeneficiaryChild,
+		}
+		if row.DistanceKm != nil {
+			properties["distance_km"] = math.Round(*row.DistanceKm*100) / 100
+		}
+		collection.Features = append(collection.Features, pointFeature(row.ID, row.Latitude, row.Longitude, properties))
+	}
+	return collection, nil
+}
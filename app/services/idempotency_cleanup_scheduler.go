@@ -0,0 +1,45 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// StartIdempotencyCleanupScheduler starts a background goroutine that deletes expired idempotency
+// keys once per day at the specified hour (0-23)
+func StartIdempotencyCleanupScheduler(cleanupHour int) {
+	if cleanupHour < 0 || cleanupHour > 23 {
+		log.Printf("Invalid idempotency cleanup hour %d, defaulting to 5 AM", cleanupHour)
+		cleanupHour = 5
+	}
+
+	log.Printf("Starting idempotency key cleanup scheduler: runs daily at %02d:00, keys expire after %v", cleanupHour, idempotencyTTL)
+
+	go func() {
+		now := time.Now()
+		nextRun := time.Date(now.Year(), now.Month(), now.Day(), cleanupHour, 0, 0, 0, now.Location())
+		if !nextRun.After(now) {
+			nextRun = nextRun.AddDate(0, 0, 1)
+		}
+		log.Printf("Idempotency key cleanup scheduler: first run scheduled for %s", nextRun.Format("2006-01-02 15:04:05"))
+		time.Sleep(time.Until(nextRun))
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		runIdempotencyCleanup()
+		for range ticker.C {
+			runIdempotencyCleanup()
+		}
+	}()
+}
+
+// runIdempotencyCleanup purges expired keys and logs the result
+func runIdempotencyCleanup() {
+	deleted, err := PurgeExpiredIdempotencyKeys()
+	if err != nil {
+		log.Printf("Idempotency key cleanup failed: %v", err)
+		return
+	}
+	log.Printf("Idempotency key cleanup completed: %d expired key(s) deleted", deleted)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still being processed")
+	ErrIdempotencyKeyReused     = errors.New("this Idempotency-Key was already used for a different request")
+)
+
+// DefaultIdempotencyTTL is how long a stored response is replayed for retries
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyLockTimeout is how long a key stays claimed by a request that has not answered yet.
+// Requests time out after 30 seconds, so an older claim belongs to a request that died and a retry
+// may take it over.
+const IdempotencyLockTimeout = 2 * time.Minute
+
+var idempotencyTTL = DefaultIdempotencyTTL
+
+// SetIdempotencyTTL changes how long stored responses are kept
+func SetIdempotencyTTL(ttl time.Duration) {
+	if ttl > 0 {
+		idempotencyTTL = ttl
+	}
+}
+
+// BeginIdempotentRequest claims an idempotency key for a request. It returns the new in-progress
+// record, or the completed record of an earlier identical request whose response must be replayed.
+// ErrIdempotencyKeyReused is returned when the key was used with a different request hash and
+// ErrIdempotencyKeyInProgress while the first request is still running. Expired records and
+// abandoned claims are replaced.
+func BeginIdempotentRequest(userID uint, key, method, route, requestHash string) (*models.IdempotencyKey, error) {
+	now := time.Now()
+	claim := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Method:      method,
+		Route:       route,
+		RequestHash: requestHash,
+		Status:      models.IdempotencyStatusInProgress,
+		CreatedOn:   now,
+		ExpiresOn:   now.Add(idempotencyTTL),
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&claim)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return &claim, nil
+		}
+
+		var existing models.IdempotencyKey
+		if err := config.DB.
+			Where("user_id = ? AND idempotency_key = ? AND method = ? AND route = ?", userID, key, method, route).
+			First(&existing).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue // removed in the meantime, claim again
+			}
+			return nil, err
+		}
+
+		abandoned := existing.Status == models.IdempotencyStatusInProgress && existing.CreatedOn.Before(now.Add(-IdempotencyLockTimeout))
+		if existing.ExpiresOn.Before(now) || abandoned {
+			// Only this exact record, in case another retry replaced it first
+			if err := config.DB.Where("id = ? AND status = ?", existing.ID, existing.Status).
+				Delete(&models.IdempotencyKey{}).Error; err != nil {
+				return nil, err
+			}
+			claim.ID = 0
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if existing.Status == models.IdempotencyStatusInProgress {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+		return &existing, nil
+	}
+	return nil, ErrIdempotencyKeyInProgress
+}
+
+// CompleteIdempotentRequest stores the response of a claimed request for replay
+func CompleteIdempotentRequest(id uint, status int, contentType string, body []byte) error {
+	now := time.Now()
+	return config.DB.Model(&models.IdempotencyKey{}).
+		Where("id = ? AND status = ?", id, models.IdempotencyStatusInProgress).
+		Updates(map[string]interface{}{
+			"status":                models.IdempotencyStatusCompleted,
+			"response_status":       status,
+			"response_content_type": contentType,
+			"response_body":         body,
+			"completed_on":          now,
+		}).Error
+}
+
+// ReleaseIdempotentRequest drops the claim of a request that failed (server error or panic), so a
+// retry with the same key runs again instead of replaying the failure
+func ReleaseIdempotentRequest(id uint) error {
+	return config.DB.Where("id = ? AND status = ?", id, models.IdempotencyStatusInProgress).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// PurgeExpiredIdempotencyKeys deletes the records whose replay window has passed
+func PurgeExpiredIdempotencyKeys() (int64, error) {
+	result := config.DB.Where("expires_on < ?", time.Now()).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
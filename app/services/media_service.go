@@ -10,11 +10,28 @@ import (
 
 	"github.com/followCode/djjs-event-reporting-backend/app/models"
 	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
 )
 
-// CreateEventMedia creates a new EventMedia record
+// CreateEventMedia creates a new EventMedia record and announces it with a media.uploaded domain event
 func CreateEventMedia(media *models.EventMedia) error {
-	return config.DB.Create(media).Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(media).Error; err != nil {
+			return err
+		}
+		return RecordDomainEvent(tx, mediaUploadedDomainEvent(media))
+	})
+}
+
+// mediaUploadedDomainEvent describes a newly created media record
+func mediaUploadedDomainEvent(media *models.EventMedia) MediaUploaded {
+	return MediaUploaded{
+		MediaID:          media.ID,
+		EventID:          media.EventID,
+		FileType:         media.FileType,
+		OriginalFilename: media.OriginalFilename,
+		S3Key:            media.S3Key,
+	}
 }
 
 // GetAllEventMedia retrieves all EventMedia records with related Event and MediaCoverageType
@@ -192,13 +209,27 @@ func UpdateEventMedia(media *models.EventMedia) error {
 	return config.DB.Model(&existing).Updates(updates).Error
 }
 
-// DeleteEventMedia deletes an EventMedia record by ID
-func DeleteEventMedia(id uint) error {
-	result := config.DB.Delete(&models.EventMedia{}, id)
-	if result.RowsAffected == 0 {
-		return errors.New("record not found")
-	}
-	return result.Error
+// DeleteEventMedia moves an EventMedia record to the trash by ID
+// The S3 object is kept until the record is purged from the trash
+func DeleteEventMedia(id uint, deletedBy string) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var media models.EventMedia
+		if err := tx.Select("id", "event_id").First(&media, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("record not found")
+			}
+			return err
+		}
+
+		affected, err := softDeleteWhere(tx, &models.EventMedia{}, deletedBy, trashTimestamp(), "id = ?", id)
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errors.New("record not found")
+		}
+		return RecordDomainEvent(tx, MediaDeleted{MediaID: id, EventID: media.EventID, DeletedBy: deletedBy})
+	})
 }
 
 // ConvertEventMediaToPresignedURLs converts EventMedia items to include presigned URLs
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultOutboxPollInterval is how often the dispatcher looks for undelivered events
+	DefaultOutboxPollInterval = 5 * time.Second
+	// OutboxMaxAttempts is the number of delivery rounds after which an event is marked failed
+	OutboxMaxAttempts = 10
+
+	outboxBatchSize      = 100
+	outboxLease          = 2 * time.Minute
+	outboxHandlerTimeout = 30 * time.Second
+	outboxMaxBackoff     = time.Hour
+	outboxRetention      = 7 * 24 * time.Hour
+	outboxPurgeInterval  = time.Hour
+	outboxErrorMaxLength = 2000
+)
+
+// DomainEventMessage is a domain event as handed to subscribers
+type DomainEventMessage struct {
+	ID            uint            `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uint            `json:"aggregate_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredOn    time.Time       `json:"occurred_on"`
+	// Attempt is 1 on the first delivery round and grows with every retry
+	Attempt int `json:"attempt"`
+}
+
+// Decode unmarshals the payload into the typed event (e.g. EventCreated)
+func (m DomainEventMessage) Decode(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// DomainEventHandler handles a domain event. Delivery is at least once, so handlers must be
+// idempotent (DomainEventMessage.ID identifies the event); a returned error makes it retry later.
+type DomainEventHandler func(ctx context.Context, msg DomainEventMessage) error
+
+type outboxSubscriber struct {
+	name       string
+	eventTypes map[string]bool
+	handler    DomainEventHandler
+}
+
+var (
+	outboxSubscribersMu sync.RWMutex
+	outboxSubscribers   []outboxSubscriber
+)
+
+// SubscribeDomainEvents registers handler under a unique, stable name (deliveries are recorded
+// per name) for the given event types, or for all events when none are given. Subscribers must
+// be registered before the dispatcher starts.
+func SubscribeDomainEvents(name string, handler DomainEventHandler, eventTypes ...string) {
+	outboxSubscribersMu.Lock()
+	defer outboxSubscribersMu.Unlock()
+
+	for _, s := range outboxSubscribers {
+		if s.name == name {
+			panic("domain event subscriber registered twice: " + name)
+		}
+	}
+	var types map[string]bool
+	if len(eventTypes) > 0 {
+		types = make(map[string]bool, len(eventTypes))
+		for _, t := range eventTypes {
+			types[t] = true
+		}
+	}
+	outboxSubscribers = append(outboxSubscribers, outboxSubscriber{name: name, eventTypes: types, handler: handler})
+}
+
+// subscribersFor returns the subscribers interested in an event type
+func subscribersFor(eventType string) []outboxSubscriber {
+	outboxSubscribersMu.RLock()
+	defer outboxSubscribersMu.RUnlock()
+
+	var subscribers []outboxSubscriber
+	for _, s := range outboxSubscribers {
+		if s.eventTypes == nil || s.eventTypes[eventType] {
+			subscribers = append(subscribers, s)
+		}
+	}
+	return subscribers
+}
+
+// StartOutboxDispatcher starts a background goroutine that delivers outbox events to the
+// subscribers every pollInterval and purges delivered events after a week
+func StartOutboxDispatcher(pollInterval time.Duration) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultOutboxPollInterval
+	}
+	log.Printf("Starting outbox dispatcher: polls every %v", pollInterval)
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		lastPurge := time.Time{}
+		for range ticker.C {
+			// Drain the backlog batch by batch before waiting for the next tick
+			for {
+				claimed, err := DispatchOutboxEvents(context.Background())
+				if err != nil {
+					log.Printf("ERROR: Outbox dispatch failed: %v", err)
+					break
+				}
+				if claimed < outboxBatchSize {
+					break
+				}
+			}
+
+			if time.Since(lastPurge) >= outboxPurgeInterval {
+				lastPurge = time.Now()
+				if purged, err := PurgeDispatchedOutboxEvents(time.Now().Add(-outboxRetention)); err != nil {
+					log.Printf("ERROR: Outbox purge failed: %v", err)
+				} else if purged > 0 {
+					log.Printf("✓ Outbox purge removed %d delivered event(s)", purged)
+				}
+			}
+		}
+	}()
+}
+
+// DispatchOutboxEvents claims one batch of due outbox events and delivers them. It returns the
+// number of events claimed. Claimed events are leased, so several instances can dispatch
+// concurrently and events of a crashed instance are picked up again when the lease runs out.
+func DispatchOutboxEvents(ctx context.Context) (int, error) {
+	events, err := claimOutboxEvents(outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i := range events {
+		if err := deliverOutboxEvent(ctx, &events[i]); err != nil {
+			log.Printf("ERROR: Failed to record delivery state of outbox event %d: %v", events[i].ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// claimOutboxEvents locks due pending events and pushes their next attempt past the lease
+func claimOutboxEvents(limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_on <= ?", models.OutboxStatusPending, now).
+			Order("id").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).
+			Update("next_attempt_on", now.Add(outboxLease)).Error
+	})
+	return events, err
+}
+
+// deliverOutboxEvent hands an event to every interested subscriber that has not handled it yet
+// and records the outcome: dispatched when all succeeded, otherwise a retry with backoff
+func deliverOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	var delivered []string
+	if err := config.DB.Model(&models.OutboxDelivery{}).
+		Where("outbox_event_id = ?", event.ID).
+		Pluck("subscriber", &delivered).Error; err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(delivered))
+	for _, name := range delivered {
+		done[name] = true
+	}
+
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	msg := DomainEventMessage{
+		ID:            event.ID,
+		Type:          event.EventType,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		Payload:       payload,
+		OccurredOn:    event.OccurredOn,
+		Attempt:       event.Attempts + 1,
+	}
+
+	var failures []string
+	for _, s := range subscribersFor(event.EventType) {
+		if done[s.name] {
+			continue
+		}
+		if err := callDomainEventHandler(ctx, s, msg); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+			continue
+		}
+		if err := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.OutboxDelivery{
+			OutboxEventID: event.ID,
+			Subscriber:    s.name,
+			DeliveredOn:   time.Now(),
+		}).Error; err != nil {
+			// The handler ran; without the record it runs again on the retry (at least once)
+			failures = append(failures, fmt.Sprintf("%s: failed to record delivery: %v", s.name, err))
+		}
+	}
+
+	now := time.Now()
+	if len(failures) == 0 {
+		return config.DB.Model(event).Updates(map[string]interface{}{
+			"status":        models.OutboxStatusDispatched,
+			"attempts":      event.Attempts + 1,
+			"dispatched_on": &now,
+			"last_error":    "",
+		}).Error
+	}
+
+	sort.Strings(failures)
+	lastError := strings.Join(failures, "; ")
+	if len(lastError) > outboxErrorMaxLength {
+		lastError = lastError[:outboxErrorMaxLength]
+	}
+	attempts := event.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_error":      lastError,
+		"next_attempt_on": now.Add(outboxBackoff(attempts)),
+	}
+	if attempts >= OutboxMaxAttempts {
+		updates["status"] = models.OutboxStatusFailed
+		log.Printf("ERROR: Giving up on outbox event %d (%s) after %d attempts: %s", event.ID, event.EventType, attempts, lastError)
+	}
+	return config.DB.Model(event).Updates(updates).Error
+}
+
+// callDomainEventHandler runs a subscriber with a timeout, turning a panic into an error
+func callDomainEventHandler(ctx context.Context, s outboxSubscriber, msg DomainEventMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, outboxHandlerTimeout)
+	defer cancel()
+	return s.handler(ctx, msg)
+}
+
+// outboxBackoff returns the wait before the next delivery round: 10s, 20s, 40s, ... up to an hour
+func outboxBackoff(attempts int) time.Duration {
+	backoff := 10 * time.Second
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
+
+// PurgeDispatchedOutboxEvents deletes events delivered before the given time. Failed events are
+// kept for inspection.
+func PurgeDispatchedOutboxEvents(before time.Time) (int64, error) {
+	result := config.DB.Where("status = ? AND dispatched_on < ?", models.OutboxStatusDispatched, before).
+		Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
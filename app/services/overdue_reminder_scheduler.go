@@ -0,0 +1,62 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// StartOverdueReminderScheduler starts a background goroutine that sends reminders for event
+// reports still incomplete after the event ended, and escalates the oldest to regional admins
+// It runs once per day at the specified hour (0-23) with the given thresholds
+func StartOverdueReminderScheduler(reminderHour int, cfg OverdueConfig) {
+	if reminderHour < 0 || reminderHour > 23 {
+		log.Printf("Invalid overdue reminder hour %d, defaulting to 6 AM", reminderHour)
+		reminderHour = 6
+	}
+	if err := SetOverdueConfig(cfg); err != nil {
+		log.Printf("Invalid overdue reminder thresholds (%v), using defaults", err)
+		SetOverdueConfig(DefaultOverdueConfig)
+	}
+
+	cfg = GetOverdueConfig()
+	log.Printf("Starting overdue reminder scheduler: runs daily at %02d:00, reminders after %v days, escalation after %d days",
+		reminderHour, cfg.ReminderDays, cfg.EscalationDays)
+
+	go func() {
+		// Calculate the next run time
+		now := time.Now()
+		nextRun := time.Date(now.Year(), now.Month(), now.Day(), reminderHour, 0, 0, 0, now.Location())
+
+		// If the scheduled time has already passed today, schedule for tomorrow
+		if !nextRun.After(now) {
+			nextRun = nextRun.AddDate(0, 0, 1)
+		}
+
+		time.Sleep(time.Until(nextRun))
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		runOverdueReminders()
+		for range ticker.C {
+			runOverdueReminders()
+		}
+	}()
+}
+
+// runOverdueReminders executes the reminder run and logs the results
+func runOverdueReminders() {
+	startTime := time.Now()
+	result, err := RunOverdueReminders()
+	duration := time.Since(startTime)
+
+	if err != nil {
+		log.Printf("ERROR: Overdue reminder run failed after %v: %v", duration, err)
+		return
+	}
+	log.Printf("✓ Overdue reminder run completed in %v: %d overdue report(s), %d reminder(s), %d escalation(s), %d message(s) sent, %d failed",
+		duration, result.Overdue, result.Reminders, result.Escalations, result.Messages, result.Failed)
+	if result.Undelivered {
+		log.Printf("Warning: No e-mail sender is configured for overdue reminders; they were only logged and stay due")
+	}
+}
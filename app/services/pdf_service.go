@@ -14,7 +14,8 @@ import (
 // GenerateEventPDF generates a PDF document for event details
 func GenerateEventPDF(event *models.EventDetails, specialGuests []models.SpecialGuest, 
 	volunteers []models.Volunteer, mediaList []models.EventMedia, 
-	promotionMaterials []models.PromotionMaterialDetails, donations []models.Donation) ([]byte, error) {
+	promotionMaterials []models.PromotionMaterialDetails, donations []models.Donation,
+	attendance []models.EventAttendance) ([]byte, error) {
 	
 	pdf := gofpdf.New("P", "mm", "A4", "")
 	pdf.SetAutoPageBreak(true, 25)
@@ -56,6 +57,24 @@ func GenerateEventPDF(event *models.EventDetails, specialGuests []models.Special
 	}
 	pdf.Ln(3)
 
+	// Custom fields of the event's category
+	customFieldValues, err := EventCustomFieldValues(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load custom fields: %v", err)
+	}
+	if len(customFieldValues) > 0 {
+		pdf.SetFont("Arial", "B", 14)
+		pdf.SetFillColor(240, 240, 240)
+		pdf.CellFormat(0, 8, "Additional Details", "", 1, "L", true, 0, "")
+		pdf.SetFillColor(255, 255, 255)
+		pdf.Ln(2)
+		pdf.SetFont("Arial", "", 10)
+		for _, field := range customFieldValues {
+			addField(pdf, field.Label, field.Value, 45, 6)
+		}
+		pdf.Ln(3)
+	}
+
 	// Venue Information
 	pdf.SetFont("Arial", "B", 14)
 	pdf.SetFillColor(240, 240, 240)
@@ -124,6 +143,67 @@ func GenerateEventPDF(event *models.EventDetails, specialGuests []models.Special
 	pdf.CellFormat(95, 6, fmt.Sprintf("Total: %d", event.InitiationMen+event.InitiationWomen+event.InitiationChild), "", 0, "L", false, 0, "")
 	pdf.Ln(8)
 
+	// Day-wise Attendance Table (sessions of a day summed)
+	if len(attendance) > 0 {
+		days := AttendanceSeries(event, attendance)
+		addTableSection(pdf, "Day-wise Attendance", len(days))
+		headers := []string{"Day", "Date", "Sessions", "Men", "Women", "Children", "Total", "New Attendees"}
+		colWidths := []float64{15, 30, 25, 22, 22, 22, 22, 32}
+
+		pdf.SetFont("Arial", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		for i, header := range headers {
+			pdf.CellFormat(colWidths[i], 7, header, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Arial", "", 7)
+		pdf.SetFillColor(255, 255, 255)
+		var totals AttendanceTotals
+		for _, day := range days {
+			if pdf.GetY() > 270 {
+				pdf.AddPage()
+			}
+			sessions := "Not recorded"
+			if day.Recorded {
+				sessions = fmt.Sprintf("%d", day.Sessions)
+			}
+			row := []string{
+				fmt.Sprintf("%d", day.Day),
+				day.Date.Format("2006-01-02"),
+				sessions,
+				fmt.Sprintf("%d", day.Men),
+				fmt.Sprintf("%d", day.Women),
+				fmt.Sprintf("%d", day.Children),
+				fmt.Sprintf("%d", day.Total),
+				fmt.Sprintf("%d", day.NewAttendees),
+			}
+			for i, cell := range row {
+				pdf.CellFormat(colWidths[i], 6, cell, "1", 0, "L", false, 0, "")
+			}
+			pdf.Ln(-1)
+			totals.Men += day.Men
+			totals.Women += day.Women
+			totals.Children += day.Children
+			totals.Total += day.Total
+			totals.NewAttendees += day.NewAttendees
+		}
+
+		pdf.SetFont("Arial", "B", 7)
+		row := []string{"", "Total", "",
+			fmt.Sprintf("%d", totals.Men),
+			fmt.Sprintf("%d", totals.Women),
+			fmt.Sprintf("%d", totals.Children),
+			fmt.Sprintf("%d", totals.Total),
+			fmt.Sprintf("%d", totals.NewAttendees),
+		}
+		for i, cell := range row {
+			pdf.CellFormat(colWidths[i], 6, cell, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.Ln(5)
+	}
+
 	// Special Guests Table
 	if len(specialGuests) > 0 {
 		addTableSection(pdf, "Special Guests", len(specialGuests))
@@ -371,7 +451,7 @@ func GenerateEventPDF(event *models.EventDetails, specialGuests []models.Special
 
 	// Generate PDF bytes using buffer
 	var buf bytes.Buffer
-	err := pdf.Output(&buf)
+	err = pdf.Output(&buf)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when an update was based on a version of the record that is no longer current
+var ErrVersionConflict = errors.New("the record was changed by someone else since it was loaded; reload it and apply your changes again")
+
+// checkRowVersion compares the current version of a record with the version the client's change is
+// based on. A nil expected version makes the update unconditional.
+func checkRowVersion(current int, expected *int) error {
+	if expected != nil && *expected != current {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// updateVersioned applies updates to the loaded row. With an expected version the update only
+// happens while the row still has it, so a change committed after the row was loaded is detected too.
+func updateVersioned(db *gorm.DB, row interface{}, expected *int, updates interface{}) error {
+	query := db.Model(row)
+	if expected != nil {
+		query = query.Where("version = ?", *expected)
+	}
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if expected != nil && result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+// GetEventVersion returns the current version of an event
+func GetEventVersion(eventID uint) (int, error) {
+	return currentRowVersion(&models.EventDetails{}, eventID)
+}
+
+// GetBranchMemberVersion returns the current version of a branch member
+func GetBranchMemberVersion(memberID uint) (int, error) {
+	return currentRowVersion(&models.BranchMember{}, memberID)
+}
+
+func currentRowVersion(model interface{}, id uint) (int, error) {
+	var version int
+	if err := config.DB.Model(model).Select("version").Where("id = ?", id).Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// bumpRowVersion increments the version of a row whose related rows changed while its own columns
+// did not; the version trigger keeps explicit bumps
+func bumpRowVersion(db *gorm.DB, model interface{}, id uint) error {
+	return db.Model(model).Where("id = ?", id).UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
+
+// BumpBranchVersion increments the version of a branch after its infrastructure, child branches
+// or members changed
+func BumpBranchVersion(branchID uint) error {
+	return bumpRowVersion(config.DB, &models.Branch{}, branchID)
+}
@@ -400,6 +400,26 @@ func GetObjectMetadata(ctx context.Context, s3Key string) (map[string]string, er
 	return metadata, nil
 }
 
+// GetObjectFingerprint identifies the content of an S3 object by its ETag and size, so that
+// the same file uploaded twice under different keys can be recognised
+func GetObjectFingerprint(ctx context.Context, s3Key string) (string, error) {
+	if S3Client == nil {
+		if err := InitializeS3(); err != nil {
+			return "", fmt.Errorf("failed to initialize S3: %w", err)
+		}
+	}
+
+	result, err := S3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(S3BucketName),
+		Key:    aws.String(s3Key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get object metadata: %w", err)
+	}
+
+	return fmt.Sprintf("%s:%d", aws.ToString(result.ETag), aws.ToInt64(result.ContentLength)), nil
+}
+
 // GetOriginalFilename retrieves the original filename from S3 object metadata
 func GetOriginalFilename(ctx context.Context, s3Key string) string {
 	metadata, err := GetObjectMetadata(ctx, s3Key)
@@ -65,13 +65,13 @@ func UpdateSpecialGuest(sgID uint, updatedData map[string]interface{}) error {
 	return nil
 }
 
-// DeleteSpecialGuest deletes a special guest
-func DeleteSpecialGuest(sgID uint) error {
-	result := config.DB.Delete(&models.SpecialGuest{}, sgID)
-	if result.Error != nil {
-		return result.Error
+// DeleteSpecialGuest moves a special guest to the trash
+func DeleteSpecialGuest(sgID uint, deletedBy string) error {
+	affected, err := softDeleteWhere(config.DB, &models.SpecialGuest{}, deletedBy, trashTimestamp(), "id = ?", sgID)
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
+	if affected == 0 {
 		return ErrSpecialGuestNotFound
 	}
 	return nil
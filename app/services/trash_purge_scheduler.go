@@ -0,0 +1,57 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// StartTrashPurgeScheduler starts a background goroutine that permanently removes records
+// that have been in the trash for longer than retentionDays
+// It runs once per day at the specified hour (0-23)
+func StartTrashPurgeScheduler(purgeHour int, retentionDays int) {
+	if purgeHour < 0 || purgeHour > 23 {
+		log.Printf("Invalid trash purge hour %d, defaulting to 3 AM", purgeHour)
+		purgeHour = 3
+	}
+
+	if retentionDays <= 0 {
+		log.Printf("Invalid trash retention %d, defaulting to 30 days", retentionDays)
+		retentionDays = 30
+	}
+
+	log.Printf("Starting trash purge scheduler: runs daily at %02d:00, purges records deleted more than %d days ago", purgeHour, retentionDays)
+
+	go func() {
+		// Calculate the next run time
+		now := time.Now()
+		nextRun := time.Date(now.Year(), now.Month(), now.Day(), purgeHour, 0, 0, 0, now.Location())
+
+		// If the scheduled time has already passed today, schedule for tomorrow
+		if !nextRun.After(now) {
+			nextRun = nextRun.AddDate(0, 0, 1)
+		}
+
+		time.Sleep(time.Until(nextRun))
+
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+
+		runTrashPurge(retentionDays)
+		for range ticker.C {
+			runTrashPurge(retentionDays)
+		}
+	}()
+}
+
+// runTrashPurge executes the purge and logs the results
+func runTrashPurge(retentionDays int) {
+	startTime := time.Now()
+	purged, err := PurgeTrash(retentionDays)
+	duration := time.Since(startTime)
+
+	if err != nil {
+		log.Printf("ERROR: Trash purge failed after %v (purged %d record(s) before failing): %v", duration, purged, err)
+		return
+	}
+	log.Printf("✓ Trash purge completed in %v: purged %d record(s) deleted more than %d days ago", duration, purged, retentionDays)
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownTrashResource = errors.New("unknown trash resource")
+	ErrNotInTrash           = errors.New("record not found in trash")
+	ErrTrashParentDeleted   = errors.New("the record it belongs to is deleted; restore that first")
+)
+
+// TrashResources lists the resources that support soft delete, keyed by the name used in
+// the trash endpoints (which matches the RBAC resource name)
+var TrashResources = map[string]models.ResourceType{
+	string(models.ResourceEvent):        models.ResourceEvent,
+	string(models.ResourceSpecialGuest): models.ResourceSpecialGuest,
+	string(models.ResourceVolunteer):    models.ResourceVolunteer,
+	string(models.ResourceDonation):     models.ResourceDonation,
+	string(models.ResourceMedia):        models.ResourceMedia,
+	string(models.ResourceBranch):       models.ResourceBranch,
+}
+
+// softDeleteWhere moves the live rows of model matching query to the trash.
+// Rows deleted together share deletedAt, which is how a restore finds them again.
+func softDeleteWhere(tx *gorm.DB, model interface{}, deletedBy string, deletedAt time.Time, query interface{}, args ...interface{}) (int64, error) {
+	result := tx.Model(model).Where(query, args...).UpdateColumns(map[string]interface{}{
+		"deleted_at": deletedAt,
+		"deleted_by": deletedBy,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// restoreWhere brings the trashed rows of model matching query back
+func restoreWhere(tx *gorm.DB, model interface{}, query interface{}, args ...interface{}) (int64, error) {
+	result := tx.Unscoped().Model(model).Where("deleted_at IS NOT NULL").Where(query, args...).UpdateColumns(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": "",
+	})
+	return result.RowsAffected, result.Error
+}
+
+// trashTimestamp returns the deletion time used for a soft delete, truncated to the
+// precision Postgres stores so it compares equal when read back
+func trashTimestamp() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// ListTrash returns the soft-deleted rows of a resource, most recently deleted first
+func ListTrash(resource string) (interface{}, error) {
+	db := config.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC")
+
+	switch resource {
+	case string(models.ResourceEvent):
+		var events []models.EventDetails
+		err := db.Preload("EventType").Preload("EventCategory").Preload("EventSubCategory").Find(&events).Error
+		return events, err
+	case string(models.ResourceSpecialGuest):
+		var guests []models.SpecialGuest
+		err := db.Find(&guests).Error
+		return guests, err
+	case string(models.ResourceVolunteer):
+		var volunteers []models.Volunteer
+		err := db.Find(&volunteers).Error
+		return volunteers, err
+	case string(models.ResourceDonation):
+		var donations []models.Donation
+		err := db.Find(&donations).Error
+		return donations, err
+	case string(models.ResourceMedia):
+		var media []models.EventMedia
+		err := db.Preload("MediaCoverageType").Find(&media).Error
+		return media, err
+	case string(models.ResourceBranch):
+		var branches []models.Branch
+		err := db.Find(&branches).Error
+		return branches, err
+	}
+	return nil, ErrUnknownTrashResource
+}
+
+// RestoreFromTrash restores a soft-deleted row. Restoring an event or branch also restores
+// the rows that were deleted together with it.
+func RestoreFromTrash(resource string, id uint) error {
+	switch resource {
+	case string(models.ResourceEvent):
+		return restoreEvent(id)
+	case string(models.ResourceBranch):
+		return restoreBranch(id)
+	case string(models.ResourceSpecialGuest):
+		return restoreEventChild(&models.SpecialGuest{}, id)
+	case string(models.ResourceVolunteer):
+		return restoreEventChild(&models.Volunteer{}, id)
+	case string(models.ResourceDonation):
+		return restoreEventChild(&models.Donation{}, id)
+	case string(models.ResourceMedia):
+		return restoreEventChild(&models.EventMedia{}, id)
+	}
+	return ErrUnknownTrashResource
+}
+
+func restoreEvent(eventID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var event models.EventDetails
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", eventID).First(&event).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotInTrash
+			}
+			return err
+		}
+
+		if event.BranchID != nil {
+			var count int64
+			if err := tx.Model(&models.Branch{}).Where("id = ?", *event.BranchID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrTrashParentDeleted
+			}
+		}
+
+		// Children deleted along with the event share its deleted_at; children that were
+		// deleted individually before stay in the trash
+		deletedAt := event.DeletedAt.Time
+		for _, model := range []interface{}{&models.SpecialGuest{}, &models.Volunteer{}, &models.EventMedia{}, &models.Donation{}} {
+			if _, err := restoreWhere(tx, model, "event_id = ? AND deleted_at = ?", eventID, deletedAt); err != nil {
+				return err
+			}
+		}
+
+		_, err := restoreWhere(tx, &models.EventDetails{}, "id = ?", eventID)
+		return err
+	})
+}
+
+func restoreEventChild(model interface{}, id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var eventID uint
+		if err := tx.Unscoped().Model(model).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Select("event_id").Take(&eventID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotInTrash
+			}
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.EventDetails{}).Where("id = ?", eventID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrTrashParentDeleted
+		}
+
+		_, err := restoreWhere(tx, model, "id = ?", id)
+		return err
+	})
+}
+
+func restoreBranch(branchID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var branch models.Branch
+		if err := tx.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", branchID).First(&branch).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotInTrash
+			}
+			return err
+		}
+
+		if branch.ParentBranchID != nil {
+			var count int64
+			if err := tx.Model(&models.Branch{}).Where("id = ?", *branch.ParentBranchID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return ErrTrashParentDeleted
+			}
+		}
+
+		// Restore the child branches that were deleted together with this one
+		deletedAt := branch.DeletedAt.Time
+		ids := []uint{branchID}
+		for parents := ids; len(parents) > 0; {
+			var children []uint
+			if err := tx.Unscoped().Model(&models.Branch{}).
+				Where("parent_branch_id IN ? AND deleted_at = ?", parents, deletedAt).
+				Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+			parents = children
+		}
+
+		_, err := restoreWhere(tx, &models.Branch{}, "id IN ?", ids)
+		return err
+	})
+}
+
+// softDeleteBranchTree moves a branch and all its descendant branches to the trash
+func softDeleteBranchTree(tx *gorm.DB, branchID uint, deletedBy string) error {
+	deletedAt := trashTimestamp()
+	ids := []uint{branchID}
+	for parents := ids; len(parents) > 0; {
+		var children []uint
+		if err := tx.Model(&models.Branch{}).Where("parent_branch_id IN ?", parents).Pluck("id", &children).Error; err != nil {
+			return err
+		}
+		ids = append(ids, children...)
+		parents = children
+	}
+	_, err := softDeleteWhere(tx, &models.Branch{}, deletedBy, deletedAt, "id IN ?", ids)
+	return err
+}
+
+// PurgeTrash permanently removes rows that have been in the trash for longer than
+// retentionDays, including the S3 objects of their media. It returns the number of
+// purged rows. Rows whose S3 objects cannot be removed are kept for the next run.
+func PurgeTrash(retentionDays int) (int64, error) {
+	ctx := context.Background()
+	cutoff := time.Now().AddDate(0, 0, -retentionDays)
+	var purged int64
+
+	// Events, together with all their related rows
+	var eventIDs []uint
+	if err := config.DB.Unscoped().Model(&models.EventDetails{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Pluck("id", &eventIDs).Error; err != nil {
+		return purged, err
+	}
+	for _, eventID := range eventIDs {
+		var media []models.EventMedia
+		if err := config.DB.Unscoped().Where("event_id = ?", eventID).Find(&media).Error; err != nil {
+			return purged, err
+		}
+		if err := deleteEventMediaObjects(ctx, media); err != nil {
+			log.Printf("Trash purge: keeping event %d, failed to delete its S3 objects: %v", eventID, err)
+			continue
+		}
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			for _, model := range []interface{}{&models.SpecialGuest{}, &models.Volunteer{}, &models.EventMedia{}, &models.Donation{}, &models.PromotionMaterialDetails{}} {
+				if err := tx.Unscoped().Where("event_id = ?", eventID).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Unscoped().Delete(&models.EventDetails{}, eventID).Error
+		})
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge event %d: %w", eventID, err)
+		}
+		purged++
+	}
+
+	// Media deleted on their own
+	var media []models.EventMedia
+	if err := config.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&media).Error; err != nil {
+		return purged, err
+	}
+	for _, item := range media {
+		if err := deleteEventMediaObjects(ctx, []models.EventMedia{item}); err != nil {
+			log.Printf("Trash purge: keeping media %d, failed to delete its S3 objects: %v", item.ID, err)
+			continue
+		}
+		if err := config.DB.Unscoped().Delete(&models.EventMedia{}, item.ID).Error; err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	// Guests, volunteers and donations deleted on their own
+	for _, model := range []interface{}{&models.SpecialGuest{}, &models.Volunteer{}, &models.Donation{}} {
+		result := config.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Delete(model)
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+	}
+
+	// Branches, deepest first. A branch still referenced by an event, event series, volunteer,
+	// donation or child branch is kept so that purging never cascades into live data.
+	for {
+		var branchIDs []uint
+		if err := config.DB.Unscoped().Model(&models.Branch{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Where("NOT EXISTS (SELECT 1 FROM branches child WHERE child.parent_branch_id = branches.id)").
+			Where("NOT EXISTS (SELECT 1 FROM event_details e WHERE e.branch_id = branches.id)").
+			Where("NOT EXISTS (SELECT 1 FROM event_series s WHERE s.branch_id = branches.id)").
+			Where("NOT EXISTS (SELECT 1 FROM volunteers v WHERE v.branch_id = branches.id)").
+			Where("NOT EXISTS (SELECT 1 FROM donations d WHERE d.branch_id = branches.id)").
+			Pluck("id", &branchIDs).Error; err != nil {
+			return purged, err
+		}
+
+		progressed := false
+		for _, branchID := range branchIDs {
+			var branchMedia []models.BranchMedia
+			if err := config.DB.Where("branch_id = ?", branchID).Find(&branchMedia).Error; err != nil {
+				return purged, err
+			}
+			failed := false
+			for _, item := range branchMedia {
+				if item.S3Key == "" {
+					continue
+				}
+				if err := DeleteFile(ctx, item.S3Key); err != nil {
+					log.Printf("Trash purge: keeping branch %d, failed to delete S3 object: %v", branchID, err)
+					failed = true
+					break
+				}
+			}
+			if failed {
+				continue
+			}
+			// Infrastructure, members, areas and branch media cascade with the branch
+			if err := config.DB.Unscoped().Delete(&models.Branch{}, branchID).Error; err != nil {
+				return purged, fmt.Errorf("failed to purge branch %d: %w", branchID, err)
+			}
+			purged++
+			progressed = true
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return purged, nil
+}
+
+// deleteEventMediaObjects removes the S3 objects (file and thumbnail) behind media rows
+func deleteEventMediaObjects(ctx context.Context, media []models.EventMedia) error {
+	for _, item := range media {
+		if item.S3Key != "" {
+			if err := DeleteFile(ctx, item.S3Key); err != nil {
+				return err
+			}
+		}
+		if item.ThumbnailS3Key != nil && *item.ThumbnailS3Key != "" {
+			if err := DeleteFile(ctx, *item.ThumbnailS3Key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -81,13 +81,13 @@ func UpdateVolunteer(id uint, updates map[string]interface{}) error {
 	return nil
 }
 
-// DeleteVolunteer removes a volunteer record
-func DeleteVolunteer(id uint) error {
-	result := config.DB.Delete(&models.Volunteer{}, id)
-	if result.Error != nil {
-		return result.Error
+// DeleteVolunteer moves a volunteer record to the trash
+func DeleteVolunteer(id uint, deletedBy string) error {
+	affected, err := softDeleteWhere(config.DB, &models.Volunteer{}, deletedBy, trashTimestamp(), "id = ?", id)
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
+	if affected == 0 {
 		return ErrVolunteerNotFound
 	}
 	return nil
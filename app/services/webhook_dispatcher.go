@@ -0,0 +1,359 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultWebhookPollInterval is how often the dispatcher looks for due webhook deliveries
+	DefaultWebhookPollInterval = 5 * time.Second
+	// WebhookMaxAttempts is the number of attempts after which a delivery is marked failed
+	WebhookMaxAttempts = 8
+	// DefaultWebhookDisableAfterFailures is the number of consecutive failed attempts after which a
+	// subscription is disabled
+	DefaultWebhookDisableAfterFailures = 20
+
+	webhookBatchSize         = 50
+	webhookLease             = 2 * time.Minute
+	webhookTimeout           = 10 * time.Second
+	webhookMaxBackoff        = 6 * time.Hour
+	webhookRetention         = 30 * 24 * time.Hour
+	webhookPurgeInterval     = time.Hour
+	webhookResponseMaxLength = 1024
+	webhookErrorMaxLength    = 2000
+	webhookUserAgent         = "DJJS-Event-Reporting-Webhooks/1.0"
+)
+
+// Headers of a webhook delivery. The signature is the hex HMAC-SHA256, keyed with the
+// subscription secret, of "<X-Webhook-Timestamp>.<raw body>", prefixed with "sha256=".
+const (
+	WebhookHeaderID        = "X-Webhook-Id"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+var (
+	webhookDisableAfterFailures = DefaultWebhookDisableAfterFailures
+
+	// webhookClient does not follow redirects: a redirect counts as a failed delivery
+	webhookClient = &http.Client{
+		Timeout: webhookTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+)
+
+// SetWebhookDisableAfterFailures sets after how many consecutive failed attempts a subscription
+// is disabled
+func SetWebhookDisableAfterFailures(n int) {
+	if n > 0 {
+		webhookDisableAfterFailures = n
+	}
+}
+
+func init() {
+	SubscribeDomainEvents("webhooks", enqueueWebhookDeliveries)
+}
+
+// enqueueWebhookDeliveries queues a delivery of a domain event for every active subscription to
+// its type. Re-running it for the same event queues nothing new.
+func enqueueWebhookDeliveries(ctx context.Context, msg DomainEventMessage) error {
+	var subs []models.WebhookSubscription
+	if err := config.DB.WithContext(ctx).Where("active = ?", true).Find(&subs).Error; err != nil {
+		return err
+	}
+
+	var data models.JSONB
+	if err := msg.Decode(&data); err != nil {
+		return err
+	}
+	body := models.JSONB{
+		"id":             msg.ID,
+		"type":           msg.Type,
+		"occurred_on":    msg.OccurredOn.UTC().Format(time.RFC3339),
+		"aggregate_type": msg.AggregateType,
+		"aggregate_id":   msg.AggregateID,
+		"data":           data,
+	}
+
+	now := time.Now()
+	eventID := msg.ID
+	for _, sub := range subs {
+		if !webhookSubscribedTo(sub, msg.Type) {
+			continue
+		}
+		if err := config.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			OutboxEventID:  &eventID,
+			EventType:      msg.Type,
+			Body:           body,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptOn:  now,
+			CreatedOn:      now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartWebhookDispatcher starts a background goroutine that sends due webhook deliveries every
+// pollInterval and purges the delivery log after 30 days
+func StartWebhookDispatcher(pollInterval time.Duration) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultWebhookPollInterval
+	}
+	log.Printf("Starting webhook dispatcher: polls every %v, disables subscriptions after %d consecutive failures",
+		pollInterval, webhookDisableAfterFailures)
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		lastPurge := time.Time{}
+		for range ticker.C {
+			for {
+				claimed, err := DispatchWebhookDeliveries()
+				if err != nil {
+					log.Printf("ERROR: Webhook dispatch failed: %v", err)
+					break
+				}
+				if claimed < webhookBatchSize {
+					break
+				}
+			}
+
+			if time.Since(lastPurge) >= webhookPurgeInterval {
+				lastPurge = time.Now()
+				if purged, err := PurgeWebhookDeliveries(time.Now().Add(-webhookRetention)); err != nil {
+					log.Printf("ERROR: Webhook delivery purge failed: %v", err)
+				} else if purged > 0 {
+					log.Printf("✓ Webhook delivery purge removed %d delivery record(s)", purged)
+				}
+			}
+		}
+	}()
+}
+
+// DispatchWebhookDeliveries claims one batch of due deliveries of active subscriptions and sends
+// them. It returns the number of deliveries claimed. Like the outbox, claimed deliveries are leased.
+func DispatchWebhookDeliveries() (int, error) {
+	deliveries, err := claimWebhookDeliveries(webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	subs := make(map[uint]*models.WebhookSubscription)
+	for i := range deliveries {
+		d := &deliveries[i]
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			if sub, err = GetWebhookSubscription(d.SubscriptionID); err != nil {
+				log.Printf("ERROR: Failed to load webhook subscription %d: %v", d.SubscriptionID, err)
+				continue
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		// A subscription disabled earlier in this batch keeps its remaining deliveries pending
+		if !sub.Active {
+			continue
+		}
+		if err := attemptWebhookDelivery(d, sub, true); err != nil {
+			log.Printf("ERROR: Failed to record webhook delivery %d: %v", d.ID, err)
+		}
+	}
+	return len(deliveries), nil
+}
+
+// claimWebhookDeliveries locks due pending deliveries and pushes their next attempt past the lease
+func claimWebhookDeliveries(limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_on <= ?", models.WebhookDeliveryPending, now).
+			Where("subscription_id IN (?)", tx.Model(&models.WebhookSubscription{}).Select("id").Where("active = ?", true)).
+			Order("next_attempt_on, id").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_on", now.Add(webhookLease)).Error
+	})
+	return deliveries, err
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value of a body sent at timestamp
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// attemptWebhookDelivery POSTs a delivery to the subscription URL and records the outcome on the
+// delivery. With retry, a failure schedules the next attempt with backoff and counts towards
+// disabling the subscription; without it the delivery fails right away.
+func attemptWebhookDelivery(delivery *models.WebhookDelivery, sub *models.WebhookSubscription, retry bool) error {
+	body, err := json.Marshal(delivery.Body)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	status, responseBody, sendErr := sendWebhook(sub, delivery, body, start)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.LastAttemptOn = &now
+	delivery.DurationMs = now.Sub(start).Milliseconds()
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = responseBody
+	delivery.Error = ""
+	if sendErr == nil && (status < 200 || status > 299) {
+		sendErr = fmt.Errorf("receiver responded with HTTP %d", status)
+	}
+
+	if sendErr == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredOn = &now
+	} else {
+		delivery.Error = sendErr.Error()
+		if len(delivery.Error) > webhookErrorMaxLength {
+			delivery.Error = delivery.Error[:webhookErrorMaxLength]
+		}
+		if !retry || delivery.Attempts >= WebhookMaxAttempts {
+			delivery.Status = models.WebhookDeliveryFailed
+		} else {
+			delivery.NextAttemptOn = now.Add(webhookBackoff(delivery.Attempts))
+		}
+	}
+
+	if err := config.DB.Model(delivery).Updates(map[string]interface{}{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_on": delivery.NextAttemptOn,
+		"response_status": delivery.ResponseStatus,
+		"response_body":   delivery.ResponseBody,
+		"error":           delivery.Error,
+		"duration_ms":     delivery.DurationMs,
+		"last_attempt_on": delivery.LastAttemptOn,
+		"delivered_on":    delivery.DeliveredOn,
+	}).Error; err != nil {
+		return err
+	}
+
+	if !retry {
+		return nil
+	}
+	if sendErr == nil {
+		sub.ConsecutiveFailures = 0
+		sub.LastDeliveryOn = &now
+		return config.DB.Model(sub).Updates(map[string]interface{}{
+			"consecutive_failures": 0,
+			"last_delivery_on":     &now,
+		}).Error
+	}
+	return recordWebhookFailure(sub, delivery.Error)
+}
+
+// sendWebhook POSTs the signed body and returns the response status and its (truncated) body
+func sendWebhook(sub *models.WebhookSubscription, delivery *models.WebhookDelivery, body []byte, sentOn time.Time) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := sentOn.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookHeaderID, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(sub.Secret, timestamp, body))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxLength))
+	return resp.StatusCode, string(responseBody), nil
+}
+
+// recordWebhookFailure counts a failed attempt against the subscription and disables it when
+// the count reaches the threshold
+func recordWebhookFailure(sub *models.WebhookSubscription, lastError string) error {
+	if err := config.DB.Model(sub).
+		UpdateColumn("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	reason := fmt.Sprintf("disabled after %d consecutive failed deliveries; last error: %s", webhookDisableAfterFailures, lastError)
+	result := config.DB.Model(&models.WebhookSubscription{}).
+		Where("id = ? AND active = ? AND consecutive_failures >= ?", sub.ID, true, webhookDisableAfterFailures).
+		Updates(map[string]interface{}{
+			"active":          false,
+			"disabled_on":     &now,
+			"disabled_reason": reason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		sub.Active = false
+		log.Printf("WARNING: Webhook subscription %d (%s) %s", sub.ID, sub.Name, reason)
+	}
+	return nil
+}
+
+// webhookBackoff returns the wait before the next attempt: 30s, 1m, 2m, ... up to six hours
+func webhookBackoff(attempts int) time.Duration {
+	backoff := 30 * time.Second
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	return backoff
+}
+
+// PurgeWebhookDeliveries deletes finished deliveries created before the given time
+func PurgeWebhookDeliveries(before time.Time) (int64, error) {
+	result := config.DB.Where("status <> ? AND created_on < ?", models.WebhookDeliveryPending, before).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
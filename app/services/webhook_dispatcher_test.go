@@ -0,0 +1,77 @@
+package services
+
+import (
+	"crypto/hmac"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		{
+			// Computed independently: printf '%s' '1767225600.{...}' | openssl dgst -sha256 -hmac whsec_test
+			name:      "known vector",
+			secret:    "whsec_test",
+			timestamp: 1767225600,
+			body:      `{"type":"event.created","data":{"event_id":42}}`,
+			want:      "sha256=950a50adc5ff57ef40b17a7db36fcc5caf91c4bd359f2e95908fa26ca14d8547",
+		},
+		{
+			name:      "empty secret and body",
+			secret:    "",
+			timestamp: 0,
+			body:      "",
+			want:      "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("SignWebhookPayload = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignWebhookPayloadCoversEveryInput(t *testing.T) {
+	base := SignWebhookPayload("secret", 1767225600, []byte(`{"a":1}`))
+	variants := map[string]string{
+		"other secret":    SignWebhookPayload("secret2", 1767225600, []byte(`{"a":1}`)),
+		"other timestamp": SignWebhookPayload("secret", 1767225601, []byte(`{"a":1}`)),
+		"other body":      SignWebhookPayload("secret", 1767225600, []byte(`{"a":2}`)),
+		// The separator keeps digits from moving between the timestamp and the body
+		"shifted digits": SignWebhookPayload("secret", 176722560, []byte(`0{"a":1}`)),
+	}
+	for name, signature := range variants {
+		if hmac.Equal([]byte(signature), []byte(base)) {
+			t.Errorf("%s: signature did not change", name)
+		}
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{10, 15360 * time.Second},
+		{11, webhookMaxBackoff},
+		{1000, webhookMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
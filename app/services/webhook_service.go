@@ -0,0 +1,345 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/followCode/djjs-event-reporting-backend/app/models"
+	"github.com/followCode/djjs-event-reporting-backend/config"
+	"gorm.io/gorm"
+)
+
+// WebhookEventPing is the event type of the test delivery sent on request
+const WebhookEventPing = "webhook.ping"
+
+// webhookMinSecretLength is the minimum length of a secret chosen by the admin
+const webhookMinSecretLength = 16
+
+var (
+	// ErrWebhookNotFound is returned when the webhook subscription does not exist
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrWebhookDeliveryNotFound is returned when the delivery does not exist for the subscription
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrInvalidWebhook is returned when the subscription fields are invalid
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// WebhookInput holds the fields of a webhook subscription to create or update. On update, empty
+// fields are left unchanged.
+type WebhookInput struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// Secret signs the deliveries; one is generated on create when empty
+	Secret string `json:"secret,omitempty"`
+	// Active enables or disables the subscription; enabling resets the failure counter
+	Active *bool `json:"active,omitempty"`
+}
+
+// WebhookDeliveryPage is a page of a subscription's delivery log, newest first
+type WebhookDeliveryPage struct {
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+	Total      int64                    `json:"total"`
+	Page       int                      `json:"page"`
+	Limit      int                      `json:"limit"`
+}
+
+// generateWebhookSecret returns a random 32-byte secret, hex encoded
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// validateWebhookURL accepts absolute http(s) URLs (plain http is allowed for local receivers)
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+// normalizeWebhookEventTypes checks the event types against the domain event types and drops
+// duplicates; "*" subscribes to all of them
+func normalizeWebhookEventTypes(eventTypes []string) (models.StringList, error) {
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+	known := map[string]bool{models.WebhookEventTypeAll: true}
+	for _, t := range DomainEventTypes() {
+		known[t] = true
+	}
+
+	seen := make(map[string]bool, len(eventTypes))
+	types := make(models.StringList, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		t = strings.TrimSpace(t)
+		if !known[t] {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	return types, nil
+}
+
+// webhookSubscribedTo reports whether a subscription receives an event type
+func webhookSubscribedTo(sub models.WebhookSubscription, eventType string) bool {
+	for _, t := range sub.EventTypes {
+		if t == models.WebhookEventTypeAll || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateWebhookSubscription creates a subscription and returns it with its secret, which is not
+// readable afterwards
+func CreateWebhookSubscription(input WebhookInput, createdBy string) (*models.WebhookSubscription, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidWebhook)
+	}
+	if err := validateWebhookURL(input.URL); err != nil {
+		return nil, "", err
+	}
+	eventTypes, err := normalizeWebhookEventTypes(input.EventTypes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, "", err
+		}
+	} else if len(secret) < webhookMinSecretLength {
+		return nil, "", fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, webhookMinSecretLength)
+	}
+
+	sub := models.WebhookSubscription{
+		Name:       name,
+		URL:        input.URL,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     input.Active == nil || *input.Active,
+		CreatedOn:  time.Now(),
+		CreatedBy:  createdBy,
+	}
+	if err := config.DB.Create(&sub).Error; err != nil {
+		return nil, "", err
+	}
+	return &sub, secret, nil
+}
+
+// GetWebhookSubscriptions lists all subscriptions
+func GetWebhookSubscriptions() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := config.DB.Order("id").Find(&subs).Error; err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// GetWebhookSubscription returns a subscription by ID
+func GetWebhookSubscription(id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := config.DB.First(&sub, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UpdateWebhookSubscription changes a subscription. Re-enabling a subscription clears its
+// failure counter and the reason it was disabled.
+func UpdateWebhookSubscription(id uint, input WebhookInput, updatedBy string) (*models.WebhookSubscription, error) {
+	sub, err := GetWebhookSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"updated_on": &now,
+		"updated_by": updatedBy,
+	}
+	if name := strings.TrimSpace(input.Name); name != "" {
+		updates["name"] = name
+	}
+	if input.URL != "" {
+		if err := validateWebhookURL(input.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = input.URL
+	}
+	if input.EventTypes != nil {
+		eventTypes, err := normalizeWebhookEventTypes(input.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		updates["event_types"] = eventTypes
+	}
+	if input.Secret != "" {
+		if len(input.Secret) < webhookMinSecretLength {
+			return nil, fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, webhookMinSecretLength)
+		}
+		updates["secret"] = input.Secret
+	}
+	if input.Active != nil {
+		updates["active"] = *input.Active
+		if *input.Active && !sub.Active {
+			updates["consecutive_failures"] = 0
+			updates["disabled_on"] = nil
+			updates["disabled_reason"] = ""
+		}
+	}
+
+	if err := config.DB.Model(sub).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return GetWebhookSubscription(id)
+}
+
+// DeleteWebhookSubscription deletes a subscription along with its delivery log
+func DeleteWebhookSubscription(id uint) error {
+	result := config.DB.Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// RotateWebhookSecret replaces the secret of a subscription with a new random one and returns it
+func RotateWebhookSecret(id uint, updatedBy string) (string, error) {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	result := config.DB.Model(&models.WebhookSubscription{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"secret":     secret,
+		"updated_on": &now,
+		"updated_by": updatedBy,
+	})
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", ErrWebhookNotFound
+	}
+	return secret, nil
+}
+
+// GetWebhookDeliveries returns a page of a subscription's delivery log, optionally filtered by status
+func GetWebhookDeliveries(subscriptionID uint, status string, page, limit int) (*WebhookDeliveryPage, error) {
+	if _, err := GetWebhookSubscription(subscriptionID); err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	query := config.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	result := &WebhookDeliveryPage{Page: page, Limit: limit}
+	if err := query.Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+	if err := query.Order("created_on DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&result.Deliveries).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReplayWebhookDelivery queues a new delivery of the body of an earlier one. The replay is sent
+// by the dispatcher like any other delivery, so the subscription must be active.
+func ReplayWebhookDelivery(subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	if _, err := GetWebhookSubscription(subscriptionID); err != nil {
+		return nil, err
+	}
+	var original models.WebhookDelivery
+	if err := config.DB.Where("id = ? AND subscription_id = ?", deliveryID, subscriptionID).First(&original).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	replay := models.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		OutboxEventID:  original.OutboxEventID,
+		ReplayOf:       &original.ID,
+		EventType:      original.EventType,
+		Body:           original.Body,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptOn:  now,
+		CreatedOn:      now,
+	}
+	if err := config.DB.Create(&replay).Error; err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
+
+// SendWebhookPing sends a webhook.ping delivery right away, also to a disabled subscription, and
+// returns its logged outcome. Pings are not retried and do not count towards disabling.
+func SendWebhookPing(subscriptionID uint, sentBy string) (*models.WebhookDelivery, error) {
+	sub, err := GetWebhookSubscription(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventType:      WebhookEventPing,
+		Body: models.JSONB{
+			"type":        WebhookEventPing,
+			"occurred_on": now.UTC().Format(time.RFC3339),
+			"data": map[string]interface{}{
+				"subscription_id": sub.ID,
+				"sent_by":         sentBy,
+			},
+		},
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptOn: now,
+		CreatedOn:     now,
+	}
+	if err := config.DB.Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+
+	if err := attemptWebhookDelivery(&delivery, sub, false); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -121,6 +121,10 @@ func ValidateBranchUpdateFields(updateData map[string]interface{}) error {
 		}
 	}
 
+	if err := ValidateCoordinateUpdate(updateData); err != nil {
+		return err
+	}
+
 	if establishedOn, ok := updateData["established_on"]; ok {
 		if establishedOn != nil {
 			if dateStr, ok := establishedOn.(string); ok {
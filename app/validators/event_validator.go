@@ -92,6 +92,10 @@ func ValidateEventUpdateFields(updateData map[string]interface{}) error {
 		}
 	}
 
+	if err := ValidateCoordinateUpdate(updateData); err != nil {
+		return err
+	}
+
 	// Validate beneficiary counts
 	if benMen, ok := updateData["beneficiary_men"]; ok {
 		val, _ := benMen.(float64)
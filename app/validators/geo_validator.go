@@ -0,0 +1,60 @@
+package validators
+
+import (
+	"errors"
+	"math"
+)
+
+// ValidateCoordinates validates an optional latitude/longitude pair (decimal degrees)
+func ValidateCoordinates(latitude, longitude *float64) error {
+	if latitude == nil && longitude == nil {
+		return nil
+	}
+	if latitude == nil || longitude == nil {
+		return errors.New("latitude and longitude must be provided together")
+	}
+	if math.IsNaN(*latitude) || *latitude < -90 || *latitude > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if math.IsNaN(*longitude) || *longitude < -180 || *longitude > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// ValidateCoordinateUpdate validates latitude/longitude in an update map. Both keys must be
+// sent together, either as numbers or as null to clear the location.
+func ValidateCoordinateUpdate(updateData map[string]interface{}) error {
+	latValue, hasLat := updateData["latitude"]
+	lngValue, hasLng := updateData["longitude"]
+	if !hasLat && !hasLng {
+		return nil
+	}
+	if hasLat != hasLng {
+		return errors.New("latitude and longitude must be updated together")
+	}
+
+	latitude, err := coordinateValue(latValue, "latitude")
+	if err != nil {
+		return err
+	}
+	longitude, err := coordinateValue(lngValue, "longitude")
+	if err != nil {
+		return err
+	}
+	return ValidateCoordinates(latitude, longitude)
+}
+
+// coordinateValue reads a coordinate from a decoded JSON value
+func coordinateValue(value interface{}, field string) (*float64, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return &v, nil
+	case *float64:
+		return v, nil
+	default:
+		return nil, errors.New(field + " must be a number")
+	}
+}
@@ -0,0 +1,440 @@
+package validators
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// JSONSchemaDialect is the JSON Schema draft the published request schemas follow
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema used to describe and validate request payloads
+type Schema struct {
+	Dialect              string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"-"`
+	Nullable             bool               `json:"-"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+
+	compiled *regexp.Regexp
+}
+
+// compiledPatterns caches the patterns of schemas built without a registered pattern
+var compiledPatterns sync.Map
+
+// patternRegexp returns the compiled Pattern
+func (s *Schema) patternRegexp() *regexp.Regexp {
+	if s.compiled != nil {
+		return s.compiled
+	}
+	if re, ok := compiledPatterns.Load(s.Pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(s.Pattern)
+	compiledPatterns.Store(s.Pattern, re)
+	return re
+}
+
+// MarshalJSON writes the type as "x" or ["x", "null"] for nullable schemas
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	out := struct {
+		Type interface{} `json:"type,omitempty"`
+		*plain
+	}{plain: (*plain)(s)}
+	if s.Type != "" {
+		out.Type = s.Type
+		if s.Nullable {
+			out.Type = []string{s.Type, "null"}
+		}
+	} else if s.Nullable && len(s.OneOf) > 0 {
+		out.OneOf = append(append([]*Schema{}, s.OneOf...), &Schema{Type: "null"})
+	}
+	return json.Marshal(out)
+}
+
+// SchemaProvider is implemented by types whose JSON shape cannot be derived from their Go type,
+// e.g. values the frontend may send either as a number or as a string
+type SchemaProvider interface {
+	JSONSchema() *Schema
+}
+
+// FieldError is one invalid value of a payload, addressed by its JSON path (e.g. $.volunteers[2].days)
+type FieldError struct {
+	Path   string `json:"path" example:"$.involvedParticipants.beneficiariesMen"`
+	Reason string `json:"reason" example:"must be an integer, got string"`
+}
+
+// SchemaError lists every invalid field of a payload
+type SchemaError struct {
+	Errors []FieldError
+}
+
+func (e *SchemaError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		parts[i] = fe.Path + ": " + fe.Reason
+	}
+	return "invalid payload: " + strings.Join(parts, "; ")
+}
+
+// namedPattern is a regular expression schema tags refer to by name
+type namedPattern struct {
+	re          *regexp.Regexp
+	description string
+}
+
+var schemaPatterns = map[string]namedPattern{}
+
+// RegisterSchemaPattern makes a regular expression available to `schema:"pattern=<name>"` tags.
+// Patterns are registered by name because struct tags cannot hold regex escapes comfortably.
+func RegisterSchemaPattern(name, expr, description string) {
+	schemaPatterns[name] = namedPattern{re: regexp.MustCompile(expr), description: description}
+}
+
+var schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+
+// SchemaFor derives the schema of a request type from its Go type, `json` tags and `schema` tags.
+// The `schema` tag is a comma-separated list of: required, enum=a|b, pattern=<registered name>,
+// min=N, max=N, minLength=N, maxLength=N and description=text (the last entry, may not contain commas).
+// Struct types become closed objects (unknown properties are rejected).
+func SchemaFor(v interface{}) *Schema {
+	return schemaForType(reflect.TypeOf(v))
+}
+
+func schemaForType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		s := schemaForType(t.Elem())
+		s.Nullable = true
+		return s
+	}
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(SchemaProvider).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		closed := false
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: &closed}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			// Unexported fields are skipped, except embedded structs whose exported fields encoding/json promotes
+			if field.PkgPath != "" && !(field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "") {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				// Embedded structs contribute their fields, as encoding/json does
+				embedded := schemaForType(field.Type)
+				for key, prop := range embedded.Properties {
+					s.Properties[key] = prop
+				}
+				s.Required = append(s.Required, embedded.Required...)
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			prop := schemaForType(field.Type)
+			if applySchemaTag(prop, field.Tag.Get("schema")) {
+				s.Required = append(s.Required, name)
+			}
+			s.Properties[name] = prop
+		}
+		return s
+	}
+	// interface{} and anything else accepts any value
+	return &Schema{}
+}
+
+// applySchemaTag applies the constraints of a `schema` tag and reports whether the field is required
+func applySchemaTag(s *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+	required := false
+	if i := strings.Index(tag, "description="); i >= 0 {
+		s.Description = tag[i+len("description="):]
+		tag = strings.TrimSuffix(tag[:i], ",")
+	}
+	for _, part := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "required":
+			required = true
+		case "enum":
+			for _, option := range strings.Split(value, "|") {
+				if s.Type == "integer" {
+					n, _ := strconv.Atoi(option)
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, option)
+				}
+			}
+		case "pattern":
+			p, ok := schemaPatterns[value]
+			if !ok {
+				panic("validators: unregistered schema pattern " + value)
+			}
+			s.Pattern = p.re.String()
+			s.compiled = p.re
+			if s.Description == "" {
+				s.Description = p.description
+			}
+		case "min", "max":
+			n, _ := strconv.ParseFloat(value, 64)
+			if key == "min" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		case "minLength", "maxLength":
+			n, _ := strconv.Atoi(value)
+			if key == "minLength" {
+				s.MinLength = &n
+			} else {
+				s.MaxLength = &n
+			}
+		}
+	}
+	return required
+}
+
+// DecodeJSONValue decodes a JSON document keeping numbers as json.Number, so integers
+// can be told apart from fractions when validating
+func DecodeJSONValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	// More misses a stray closing bracket, so look for the end of the input instead
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return value, nil
+}
+
+// ValidateValue checks a value decoded with DecodeJSONValue against a schema and returns every
+// violation (not only the first one), ordered by path
+func ValidateValue(value interface{}, s *Schema) []FieldError {
+	var errs []FieldError
+	validateValue(value, s, "$", &errs)
+	return errs
+}
+
+func validateValue(value interface{}, s *Schema, path string, errs *[]FieldError) {
+	add := func(reason string) {
+		*errs = append(*errs, FieldError{Path: path, Reason: reason})
+	}
+
+	if value == nil {
+		if s.Nullable {
+			return
+		}
+		if len(s.OneOf) > 0 {
+			add("must not be null")
+		} else if s.Type != "" {
+			add("must be " + typeArticle(s.Type) + ", got null")
+		}
+		return
+	}
+
+	if len(s.OneOf) > 0 {
+		kinds := make([]string, 0, len(s.OneOf))
+		for _, option := range s.OneOf {
+			var optionErrs []FieldError
+			validateValue(value, option, path, &optionErrs)
+			if len(optionErrs) == 0 {
+				return
+			}
+			kinds = append(kinds, describeSchema(option))
+		}
+		add("must be " + strings.Join(kinds, " or ") + ", got " + jsonKind(value))
+		return
+	}
+
+	if s.Type != "" && !matchesType(value, s.Type) {
+		add("must be " + typeArticle(s.Type) + ", got " + jsonKind(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(value, s.Enum) {
+		options := make([]string, len(s.Enum))
+		for i, option := range s.Enum {
+			options[i] = fmt.Sprint(option)
+		}
+		add("must be one of: " + strings.Join(options, ", "))
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			add(fmt.Sprintf("must be at least %d characters", *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			add(fmt.Sprintf("must not exceed %d characters", *s.MaxLength))
+		}
+		if s.Pattern != "" && !s.patternRegexp().MatchString(v) {
+			reason := "has an invalid format"
+			if s.Description != "" {
+				reason += " (" + s.Description + ")"
+			}
+			add(reason)
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			add("must be at least " + strconv.FormatFloat(*s.Minimum, 'f', -1, 64))
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			add("must be at most " + strconv.FormatFloat(*s.Maximum, 'f', -1, 64))
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				validateValue(item, s.Items, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, FieldError{Path: path + "." + name, Reason: "is required"})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, FieldError{Path: path + "." + key, Reason: "is not a known field"})
+				}
+				continue
+			}
+			validateValue(v[key], prop, path+"."+key, errs)
+		}
+	}
+}
+
+func matchesType(value interface{}, kind string) bool {
+	switch kind {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+func inEnum(value interface{}, options []interface{}) bool {
+	for _, option := range options {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonKind names the JSON type of a decoded value for error messages
+func jsonKind(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if matchesType(v, "integer") {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func describeSchema(s *Schema) string {
+	if s.Type == "string" && s.Pattern != "" {
+		if s.Description != "" {
+			return "a string (" + s.Description + ")"
+		}
+		return "a string in the expected format"
+	}
+	return typeArticle(s.Type)
+}
+
+func typeArticle(kind string) string {
+	switch kind {
+	case "integer", "object", "array":
+		return "an " + kind
+	case "":
+		return "a value"
+	}
+	return "a " + kind
+}
@@ -0,0 +1,243 @@
+package validators
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func init() {
+	RegisterSchemaPattern("testDate", `^\d{4}-\d{2}-\d{2}$`, "YYYY-MM-DD")
+}
+
+type testVolunteer struct {
+	Name string `json:"name" schema:"required,minLength=2,maxLength=10"`
+	Days int    `json:"days" schema:"min=1,max=30"`
+}
+
+type testAudit struct {
+	Note string `json:"note"`
+}
+
+type testPayload struct {
+	testAudit
+	Status     string          `json:"status" schema:"required,enum=complete|incomplete"`
+	Scale      int             `json:"scale" schema:"enum=1|2"`
+	StartDate  string          `json:"startDate" schema:"pattern=testDate,description=Start date, in the event's time zone"`
+	Count      uint            `json:"count"`
+	Ratio      float64         `json:"ratio"`
+	Branch     *string         `json:"branch"`
+	Volunteers []testVolunteer `json:"volunteers"`
+	Extra      map[string]int  `json:"extra"`
+	Skipped    string          `json:"-"`
+	internal   string
+}
+
+func mustDecode(t *testing.T, raw string) interface{} {
+	t.Helper()
+	value, err := DecodeJSONValue([]byte(raw))
+	if err != nil {
+		t.Fatalf("DecodeJSONValue(%s) error: %v", raw, err)
+	}
+	return value
+}
+
+func TestDecodeJSONValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "numbers stay json.Number", raw: `{"a": 1, "b": 1.5}`, want: map[string]interface{}{"a": json.Number("1"), "b": json.Number("1.5")}},
+		{name: "trailing whitespace", raw: "[true]\n", want: []interface{}{true}},
+		{name: "second document", raw: `{"a": 1} {"b": 2}`, wantErr: true},
+		{name: "trailing garbage", raw: `{"a": 1}]`, wantErr: true},
+		{name: "malformed", raw: `{"a": }`, wantErr: true},
+		{name: "empty", raw: ``, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJSONValue([]byte(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("DecodeJSONValue(%s) = %v, want an error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeJSONValue(%s) error: %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeJSONValue(%s) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	s := SchemaFor(testPayload{})
+
+	if s.Type != "object" || s.AdditionalProperties == nil || *s.AdditionalProperties {
+		t.Fatalf("struct schema is not a closed object: %+v", s)
+	}
+	if want := []string{"status"}; !reflect.DeepEqual(s.Required, want) {
+		t.Errorf("required = %v, want %v", s.Required, want)
+	}
+	for _, name := range []string{"Skipped", "-", "internal"} {
+		if _, ok := s.Properties[name]; ok {
+			t.Errorf("property %q should be left out", name)
+		}
+	}
+	if _, ok := s.Properties["note"]; !ok {
+		t.Error("fields of embedded structs should be promoted")
+	}
+	if got := s.Properties["scale"].Enum; !reflect.DeepEqual(got, []interface{}{1, 2}) {
+		t.Errorf("integer enum = %#v", got)
+	}
+	if got := s.Properties["startDate"].Description; got != "Start date, in the event's time zone" {
+		t.Errorf("description = %q", got)
+	}
+	if count := s.Properties["count"]; count.Type != "integer" || count.Minimum == nil || *count.Minimum != 0 {
+		t.Errorf("unsigned integers should have minimum 0: %+v", count)
+	}
+	if branch := s.Properties["branch"]; branch.Type != "string" || !branch.Nullable {
+		t.Errorf("pointers should be nullable: %+v", branch)
+	}
+	if items := s.Properties["volunteers"].Items; items == nil || items.Properties["name"] == nil {
+		t.Errorf("slice items should follow the element type: %+v", items)
+	}
+}
+
+func TestValidateValue(t *testing.T) {
+	s := SchemaFor(testPayload{})
+	tests := []struct {
+		name string
+		raw  string
+		want []FieldError
+	}{
+		{
+			name: "valid",
+			raw:  `{"status": "complete", "scale": 2, "startDate": "2026-03-01", "count": 3, "ratio": 0.5, "branch": null, "volunteers": [{"name": "Asha", "days": 2}], "extra": {"a": 1}, "note": "x"}`,
+		},
+		{
+			name: "missing required and unknown fields",
+			raw:  `{"zeta": 1, "alpha": true}`,
+			want: []FieldError{
+				{Path: "$.status", Reason: "is required"},
+				{Path: "$.alpha", Reason: "is not a known field"},
+				{Path: "$.zeta", Reason: "is not a known field"},
+			},
+		},
+		{
+			name: "type errors",
+			raw:  `{"status": 1, "scale": 1.5, "ratio": "x", "volunteers": {}}`,
+			want: []FieldError{
+				{Path: "$.ratio", Reason: "must be a number, got string"},
+				{Path: "$.scale", Reason: "must be an integer, got number"},
+				{Path: "$.status", Reason: "must be a string, got integer"},
+				{Path: "$.volunteers", Reason: "must be an array, got object"},
+			},
+		},
+		{
+			name: "null for a non-nullable field",
+			raw:  `{"status": null}`,
+			want: []FieldError{{Path: "$.status", Reason: "must be a string, got null"}},
+		},
+		{
+			name: "enum and pattern",
+			raw:  `{"status": "done", "scale": 3, "startDate": "01/03/2026"}`,
+			want: []FieldError{
+				{Path: "$.scale", Reason: "must be one of: 1, 2"},
+				{Path: "$.startDate", Reason: "has an invalid format (Start date, in the event's time zone)"},
+				{Path: "$.status", Reason: "must be one of: complete, incomplete"},
+			},
+		},
+		{
+			name: "nested array items and bounds",
+			raw:  `{"status": "complete", "count": -1, "volunteers": [{"name": "Asha", "days": 2}, {"name": "A", "days": 31}, {"days": 0, "age": 3}]}`,
+			want: []FieldError{
+				{Path: "$.count", Reason: "must be at least 0"},
+				{Path: "$.volunteers[1].days", Reason: "must be at most 30"},
+				{Path: "$.volunteers[1].name", Reason: "must be at least 2 characters"},
+				{Path: "$.volunteers[2].name", Reason: "is required"},
+				{Path: "$.volunteers[2].age", Reason: "is not a known field"},
+				{Path: "$.volunteers[2].days", Reason: "must be at least 1"},
+			},
+		},
+		{
+			name: "length counts characters, not bytes",
+			raw:  `{"status": "complete", "volunteers": [{"name": "ज्योतिर्मयीदे"}]}`,
+			want: []FieldError{{Path: "$.volunteers[0].name", Reason: "must not exceed 10 characters"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateValue(mustDecode(t, tt.raw), s)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateValue = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateValueOneOf(t *testing.T) {
+	s := &Schema{OneOf: []*Schema{{Type: "integer"}, {Type: "string", Pattern: `^\d+$`, Description: "digits"}}}
+	nullable := &Schema{OneOf: s.OneOf, Nullable: true}
+
+	tests := []struct {
+		name   string
+		schema *Schema
+		raw    string
+		want   []FieldError
+	}{
+		{name: "first option", schema: s, raw: `12`},
+		{name: "second option", schema: s, raw: `"12"`},
+		{name: "no option", schema: s, raw: `"twelve"`, want: []FieldError{{Path: "$", Reason: "must be an integer or a string (digits), got string"}}},
+		{name: "null", schema: s, raw: `null`, want: []FieldError{{Path: "$", Reason: "must not be null"}}},
+		{name: "nullable null", schema: nullable, raw: `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateValue(mustDecode(t, tt.raw), tt.schema)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateValue = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaMarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *Schema
+		want   string
+	}{
+		{name: "plain type", schema: &Schema{Type: "string"}, want: `{"type":"string"}`},
+		{name: "nullable type", schema: &Schema{Type: "integer", Nullable: true}, want: `{"type":["integer","null"]}`},
+		{
+			name:   "nullable oneOf",
+			schema: &Schema{Nullable: true, OneOf: []*Schema{{Type: "integer"}, {Type: "string"}}},
+			want:   `{"oneOf":[{"type":"integer"},{"type":"string"},{"type":"null"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("json = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaErrorMessage(t *testing.T) {
+	err := &SchemaError{Errors: []FieldError{{Path: "$.a", Reason: "is required"}, {Path: "$.b[0]", Reason: "must be a string, got null"}}}
+	want := "invalid payload: $.a: is required; $.b[0]: must be a string, got null"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,29 @@
+-- Migration: Add review workflow to events
+-- Description: Adds workflow_state to event_details and an event_status_history table
+-- recording every transition with actor, time and reviewer comment
+
+ALTER TABLE event_details
+ADD COLUMN IF NOT EXISTS workflow_state VARCHAR(30) DEFAULT 'draft'
+    CHECK (workflow_state IN ('draft', 'submitted', 'under_review', 'approved', 'returned_with_comments', 'resubmitted'));
+
+-- Events already marked complete are treated as submitted for review
+UPDATE event_details
+SET workflow_state = CASE WHEN status = 'complete' THEN 'submitted' ELSE 'draft' END
+WHERE workflow_state IS NULL OR workflow_state = 'draft';
+
+CREATE INDEX IF NOT EXISTS idx_event_details_workflow_state ON event_details(workflow_state);
+
+CREATE TABLE IF NOT EXISTS event_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    from_state VARCHAR(30),
+    to_state VARCHAR(30) NOT NULL,
+    comment TEXT,
+    actor_id BIGINT,
+    actor_email VARCHAR(255),
+    actor_role VARCHAR(100),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_status_history_event_id ON event_status_history(event_id);
+CREATE INDEX IF NOT EXISTS idx_event_status_history_created_on ON event_status_history(created_on);
@@ -0,0 +1,19 @@
+-- Migration: Create event_revisions table
+-- Description: Stores a complete snapshot (event + related rows) for every create, update
+-- and restore of an event so that earlier versions can be compared and reinstated
+
+CREATE TABLE IF NOT EXISTS event_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    revision_number INT NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    restored_from BIGINT REFERENCES event_revisions(id) ON DELETE SET NULL,
+    snapshot JSONB NOT NULL,
+    actor_id BIGINT,
+    actor_email VARCHAR(255),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_event_revision_number UNIQUE (event_id, revision_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_revisions_event_id ON event_revisions(event_id);
+CREATE INDEX IF NOT EXISTS idx_event_revisions_created_on ON event_revisions(created_on);
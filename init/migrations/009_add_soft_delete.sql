@@ -0,0 +1,37 @@
+-- Migration: Soft delete for events, related records and branches
+-- Description: Adds deleted_at/deleted_by so deleted rows stay in the trash (restorable)
+-- until the purge scheduler removes them after the configured retention period
+
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_event_details_deleted_at ON event_details(deleted_at);
+
+ALTER TABLE special_guests ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE special_guests ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_special_guests_deleted_at ON special_guests(deleted_at);
+
+ALTER TABLE volunteers ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE volunteers ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_volunteers_deleted_at ON volunteers(deleted_at);
+
+ALTER TABLE donations ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE donations ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_donations_deleted_at ON donations(deleted_at);
+
+ALTER TABLE event_media ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE event_media ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_event_media_deleted_at ON event_media(deleted_at);
+
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_branches_deleted_at ON branches(deleted_at);
+
+-- Unique values only need to be unique among live rows, otherwise a record in the trash
+-- would block re-creating it
+ALTER TABLE special_guests DROP CONSTRAINT IF EXISTS special_guests_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_special_guests_email_live ON special_guests(email) WHERE deleted_at IS NULL;
+
+ALTER TABLE branches DROP CONSTRAINT IF EXISTS branches_email_key;
+ALTER TABLE branches DROP CONSTRAINT IF EXISTS branches_contact_number_key;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_branches_email_live ON branches(email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_branches_contact_number_live ON branches(contact_number) WHERE deleted_at IS NULL;
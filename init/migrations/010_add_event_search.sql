@@ -0,0 +1,69 @@
+-- Migration: Full-text event search
+-- Description: Adds a weighted search_vector to event_details (kept current by triggers, including
+-- when a referenced branch, type or category is renamed) and trigram indexes for fuzzy place names.
+-- The 'simple' configuration is used because most searched terms are proper nouns (orators,
+-- cities, districts) that must not be stemmed.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION event_details_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('simple', coalesce(NEW.theme, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(NEW.spiritual_orator, '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM event_types WHERE id = NEW.event_type_id), '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM event_categories WHERE id = NEW.event_category_id), '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM event_sub_categories WHERE id = NEW.event_sub_category_id), '')), 'B') ||
+        setweight(to_tsvector('simple', coalesce((SELECT name FROM branches WHERE id = NEW.branch_id), '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(NEW.city, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(NEW.district, '')), 'C') ||
+        setweight(to_tsvector('simple', coalesce(NEW.address, '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_event_details_search_vector ON event_details;
+CREATE TRIGGER trg_event_details_search_vector
+    BEFORE INSERT OR UPDATE ON event_details
+    FOR EACH ROW EXECUTE FUNCTION event_details_search_vector_update();
+
+-- Renaming a branch, type or category refreshes the vectors of the events that reference it
+CREATE OR REPLACE FUNCTION event_details_search_vector_refresh() RETURNS trigger AS $$
+BEGIN
+    IF NEW.name IS DISTINCT FROM OLD.name THEN
+        EXECUTE format('UPDATE event_details SET search_vector = NULL WHERE %I = $1', TG_ARGV[0]) USING NEW.id;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_branches_search_vector ON branches;
+CREATE TRIGGER trg_branches_search_vector
+    AFTER UPDATE OF name ON branches
+    FOR EACH ROW EXECUTE FUNCTION event_details_search_vector_refresh('branch_id');
+
+DROP TRIGGER IF EXISTS trg_event_types_search_vector ON event_types;
+CREATE TRIGGER trg_event_types_search_vector
+    AFTER UPDATE OF name ON event_types
+    FOR EACH ROW EXECUTE FUNCTION event_details_search_vector_refresh('event_type_id');
+
+DROP TRIGGER IF EXISTS trg_event_categories_search_vector ON event_categories;
+CREATE TRIGGER trg_event_categories_search_vector
+    AFTER UPDATE OF name ON event_categories
+    FOR EACH ROW EXECUTE FUNCTION event_details_search_vector_refresh('event_category_id');
+
+DROP TRIGGER IF EXISTS trg_event_sub_categories_search_vector ON event_sub_categories;
+CREATE TRIGGER trg_event_sub_categories_search_vector
+    AFTER UPDATE OF name ON event_sub_categories
+    FOR EACH ROW EXECUTE FUNCTION event_details_search_vector_refresh('event_sub_category_id');
+
+-- Backfill (the BEFORE UPDATE trigger computes the vector)
+UPDATE event_details SET search_vector = NULL;
+
+CREATE INDEX IF NOT EXISTS idx_event_details_search_vector ON event_details USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_event_details_city_trgm ON event_details USING GIN (city gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_event_details_district_trgm ON event_details USING GIN (district gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_event_details_address_trgm ON event_details USING GIN (address gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_branches_name_trgm ON branches USING GIN (name gin_trgm_ops);
@@ -0,0 +1,58 @@
+-- Migration: Recurring event series
+-- Description: A series holds a recurrence rule and a template; occurrences are ordinary
+-- event_details rows linked through series_id and pre-filled from the template
+
+CREATE TABLE IF NOT EXISTS event_series (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    rrule VARCHAR(255) NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE,
+    duration_days INT NOT NULL DEFAULT 1 CHECK (duration_days >= 1),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    generated_until DATE,
+    branch_id BIGINT REFERENCES branches(id),
+    event_type_id BIGINT REFERENCES event_types(id),
+    event_category_id BIGINT REFERENCES event_categories(id),
+    event_sub_category_id BIGINT REFERENCES event_sub_categories(id),
+    scale VARCHAR(255),
+    theme TEXT,
+    daily_start_time TIME,
+    daily_end_time TIME,
+    spiritual_orator VARCHAR(255),
+    language VARCHAR(255),
+    country VARCHAR(255),
+    state VARCHAR(255),
+    city VARCHAR(255),
+    district VARCHAR(255),
+    post_office VARCHAR(255),
+    pincode VARCHAR(20),
+    address TEXT,
+    address_type VARCHAR(255),
+    police_station VARCHAR(255),
+    area_covered VARCHAR(255),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_on TIMESTAMPTZ,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_series_branch_id ON event_series(branch_id);
+
+CREATE TABLE IF NOT EXISTS event_series_exceptions (
+    id BIGSERIAL PRIMARY KEY,
+    series_id BIGINT NOT NULL REFERENCES event_series(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    reason TEXT,
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(255),
+    CONSTRAINT unique_event_series_exception UNIQUE (series_id, date)
+);
+
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS series_id BIGINT REFERENCES event_series(id) ON DELETE SET NULL;
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS occurrence_date DATE;
+
+-- One live occurrence per series and date; generation relies on this to stay idempotent
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_details_series_occurrence
+    ON event_details(series_id, occurrence_date)
+    WHERE series_id IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,17 @@
+-- Migration: Calendar feed tokens
+-- Description: Revocable per-user tokens that authenticate subscribable .ics calendar feeds.
+-- Only the SHA-256 hash of a token is stored.
+
+CREATE TABLE IF NOT EXISTS calendar_feed_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    token_hash CHAR(64) NOT NULL,
+    token_hint VARCHAR(8),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_used_on TIMESTAMPTZ,
+    revoked_on TIMESTAMPTZ,
+    CONSTRAINT unique_calendar_feed_token_hash UNIQUE (token_hash)
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_feed_tokens_user_id ON calendar_feed_tokens(user_id);
@@ -0,0 +1,23 @@
+-- Migration: Suspected duplicate events
+-- Description: Pairs of events found by the periodic duplicate scan (same branch, overlapping
+-- dates, similar type/category and place). Admins review and dismiss them; dismissed pairs are
+-- not reported again. Scoring relies on pg_trgm (enabled in 010_add_event_search.sql).
+
+CREATE TABLE IF NOT EXISTS event_duplicate_suspects (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    duplicate_event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT,
+    detected_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    last_seen_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    dismissed_on TIMESTAMPTZ,
+    dismissed_by VARCHAR(255),
+    CONSTRAINT unique_event_duplicate_pair UNIQUE (event_id, duplicate_event_id),
+    CONSTRAINT event_duplicate_pair_order CHECK (event_id < duplicate_event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_duplicate_suspects_open
+    ON event_duplicate_suspects(score DESC) WHERE dismissed_on IS NULL;
+CREATE INDEX IF NOT EXISTS idx_event_details_branch_dates
+    ON event_details(branch_id, start_date, end_date) WHERE deleted_at IS NULL;
@@ -0,0 +1,19 @@
+-- Migration: Create event_merges table
+-- Description: History of duplicate events merged into a surviving event. The merged events
+-- are moved to the trash; their child rows are re-parented to the survivor.
+
+CREATE TABLE IF NOT EXISTS event_merges (
+    id BIGSERIAL PRIMARY KEY,
+    survivor_event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    merged_event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    survivor_revision_id BIGINT REFERENCES event_revisions(id) ON DELETE SET NULL,
+    fields JSONB,
+    moved JSONB,
+    removed JSONB,
+    actor_id BIGINT,
+    actor_email VARCHAR(255),
+    merged_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_merges_survivor ON event_merges(survivor_event_id);
+CREATE INDEX IF NOT EXISTS idx_event_merges_merged ON event_merges(merged_event_id);
@@ -0,0 +1,23 @@
+-- Migration: Create event_templates table
+-- Description: Named templates that pre-populate the general details and media/promotion steps
+-- of a new event draft. Templates are scoped to a branch and/or an event category; templates
+-- without either are shared by everyone. Names are unique within a scope.
+
+CREATE TABLE IF NOT EXISTS event_templates (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    branch_id BIGINT REFERENCES branches(id) ON DELETE CASCADE,
+    event_category_id BIGINT REFERENCES event_categories(id) ON DELETE CASCADE,
+    general_details JSONB,
+    media_promotion JSONB,
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_on TIMESTAMPTZ,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_templates_scope_name
+    ON event_templates(LOWER(name), COALESCE(branch_id, 0), COALESCE(event_category_id, 0));
+CREATE INDEX IF NOT EXISTS idx_event_templates_branch ON event_templates(branch_id);
+CREATE INDEX IF NOT EXISTS idx_event_templates_category ON event_templates(event_category_id);
@@ -0,0 +1,21 @@
+-- Migration: Create event_attendance table
+-- Description: Day-wise (and optionally session-wise) attendance of multi-day events. The
+-- beneficiary counts of an event with attendance records are derived from them.
+
+CREATE TABLE IF NOT EXISTS event_attendance (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    attendance_date DATE NOT NULL,
+    session VARCHAR(50) NOT NULL DEFAULT '',
+    men INT NOT NULL DEFAULT 0 CHECK (men >= 0),
+    women INT NOT NULL DEFAULT 0 CHECK (women >= 0),
+    children INT NOT NULL DEFAULT 0 CHECK (children >= 0),
+    new_attendees INT NOT NULL DEFAULT 0 CHECK (new_attendees >= 0),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_on TIMESTAMPTZ,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    CONSTRAINT unique_event_attendance_day_session UNIQUE (event_id, attendance_date, session)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_attendance_event_date ON event_attendance(event_id, attendance_date);
@@ -0,0 +1,27 @@
+-- Migration: Add geo-coordinates to events and branches
+-- Description: Optional latitude/longitude (WGS84, decimal degrees) used by the map endpoints.
+-- Both coordinates are set together or left empty.
+
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+ALTER TABLE event_details DROP CONSTRAINT IF EXISTS chk_event_details_coordinates;
+ALTER TABLE event_details ADD CONSTRAINT chk_event_details_coordinates CHECK (
+    (latitude IS NULL AND longitude IS NULL) OR
+    (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_details_coordinates ON event_details(latitude, longitude)
+    WHERE latitude IS NOT NULL AND deleted_at IS NULL;
+
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+
+ALTER TABLE branches DROP CONSTRAINT IF EXISTS chk_branches_coordinates;
+ALTER TABLE branches ADD CONSTRAINT chk_branches_coordinates CHECK (
+    (latitude IS NULL AND longitude IS NULL) OR
+    (latitude BETWEEN -90 AND 90 AND longitude BETWEEN -180 AND 180)
+);
+
+CREATE INDEX IF NOT EXISTS idx_branches_coordinates ON branches(latitude, longitude)
+    WHERE latitude IS NOT NULL AND deleted_at IS NULL;
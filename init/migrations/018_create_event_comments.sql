@@ -0,0 +1,40 @@
+-- Migration: Create event comment tables
+-- Description: Reviewer discussion threads on events, optionally anchored to a section of the
+-- report, with @mentions of users. Replies point to the top-level comment of their thread.
+
+CREATE TABLE IF NOT EXISTS event_comments (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    parent_id BIGINT REFERENCES event_comments(id) ON DELETE CASCADE,
+    section VARCHAR(30) NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    author_id BIGINT,
+    author_email VARCHAR(255),
+    author_name VARCHAR(255),
+    resolved BOOLEAN NOT NULL DEFAULT FALSE,
+    resolved_by VARCHAR(255),
+    resolved_on TIMESTAMPTZ,
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    edited_on TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    deleted_by VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_comments_event_id ON event_comments(event_id);
+CREATE INDEX IF NOT EXISTS idx_event_comments_parent_id ON event_comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_event_comments_deleted_at ON event_comments(deleted_at);
+-- Open thread counts of the event list
+CREATE INDEX IF NOT EXISTS idx_event_comments_open_threads ON event_comments(event_id)
+    WHERE parent_id IS NULL AND resolved = FALSE AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS event_comment_mentions (
+    id BIGSERIAL PRIMARY KEY,
+    comment_id BIGINT NOT NULL REFERENCES event_comments(id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_email VARCHAR(255),
+    user_name VARCHAR(255),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_event_comment_mention UNIQUE (comment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_comment_mentions_user_id ON event_comment_mentions(user_id);
@@ -0,0 +1,31 @@
+-- Migration: Overdue report reminders
+-- Description: Events whose end date has passed while their report is still incomplete get
+-- reminders at configurable thresholds and are escalated to the admins of their branch's region.
+
+CREATE TABLE IF NOT EXISTS event_overdue_reminders (
+    id BIGSERIAL PRIMARY KEY,
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    level VARCHAR(20) NOT NULL,
+    threshold_days INT NOT NULL,
+    days_overdue INT,
+    recipients TEXT,
+    sent_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_event_overdue_reminder_stage UNIQUE (event_id, level, threshold_days)
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_overdue_reminders_event_id ON event_overdue_reminders(event_id);
+
+CREATE TABLE IF NOT EXISTS region_admins (
+    id BIGSERIAL PRIMARY KEY,
+    region_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(255),
+    CONSTRAINT unique_region_admin UNIQUE (region_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_region_admins_region_id ON region_admins(region_id);
+
+-- Overdue report lookups
+CREATE INDEX IF NOT EXISTS idx_event_details_overdue ON event_details(end_date)
+    WHERE status = 'incomplete' AND deleted_at IS NULL;
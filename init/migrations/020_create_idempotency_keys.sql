@@ -0,0 +1,23 @@
+-- Migration: Idempotency keys
+-- Description: POST requests sent with an Idempotency-Key header store their first response here
+-- (per user, key, method and route) so retries replay it instead of creating duplicates.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'in_progress',
+    response_status INT,
+    response_content_type VARCHAR(255),
+    response_body BYTEA,
+    created_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_on TIMESTAMPTZ,
+    expires_on TIMESTAMPTZ NOT NULL,
+    CONSTRAINT unique_idempotency_key UNIQUE (user_id, idempotency_key, method, route),
+    CONSTRAINT chk_idempotency_status CHECK (status IN ('in_progress', 'completed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_on ON idempotency_keys(expires_on);
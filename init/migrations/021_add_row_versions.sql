@@ -0,0 +1,37 @@
+-- Migration: Row versions for optimistic concurrency
+-- Description: Adds a version to events, branches and branch members. It is exposed as the ETag of
+-- the record and checked against If-Match on updates. A trigger owns the column: inserts start at 1
+-- and every update that changes the row (bookkeeping columns aside) increments it, whichever code
+-- path writes it; values sent by clients are ignored.
+
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.version := 1;
+    ELSIF (to_jsonb(NEW) - 'version' - 'updated_on' - 'updated_by' - 'search_vector')
+          IS DISTINCT FROM (to_jsonb(OLD) - 'version' - 'updated_on' - 'updated_by' - 'search_vector') THEN
+        NEW.version := OLD.version + 1;
+    ELSE
+        NEW.version := OLD.version;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+DROP TRIGGER IF EXISTS trg_event_details_version ON event_details;
+CREATE TRIGGER trg_event_details_version
+    BEFORE INSERT OR UPDATE ON event_details
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+DROP TRIGGER IF EXISTS trg_branches_version ON branches;
+CREATE TRIGGER trg_branches_version
+    BEFORE INSERT OR UPDATE ON branches
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+ALTER TABLE branch_member ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+DROP TRIGGER IF EXISTS trg_branch_member_version ON branch_member;
+CREATE TRIGGER trg_branch_member_version
+    BEFORE INSERT OR UPDATE ON branch_member
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
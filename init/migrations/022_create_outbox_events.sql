@@ -0,0 +1,34 @@
+-- Migration: Transactional outbox of domain events
+-- Description: Domain events are inserted in the same transaction as the change they announce and
+-- delivered to in-process subscribers by the outbox dispatcher (at least once). Deliveries record
+-- which subscribers already handled an event so retries only go to the ones that failed.
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    occurred_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    dispatched_on TIMESTAMPTZ,
+    CONSTRAINT chk_outbox_events_status CHECK (status IN ('pending', 'dispatched', 'failed'))
+);
+
+-- Dispatcher polling
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_on, id)
+    WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dispatched_on ON outbox_events(dispatched_on)
+    WHERE dispatched_on IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    outbox_event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    subscriber VARCHAR(100) NOT NULL,
+    delivered_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_outbox_delivery UNIQUE (outbox_event_id, subscriber)
+);
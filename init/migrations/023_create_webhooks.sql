@@ -0,0 +1,50 @@
+-- Migration: Outgoing webhooks
+-- Description: Admin-managed subscriptions that receive domain events from the outbox as
+-- HMAC-SHA256 signed JSON, with a log of every delivery. Deliveries are retried with backoff and a
+-- subscription is disabled after too many consecutive failed attempts.
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    consecutive_failures INT NOT NULL DEFAULT 0,
+    disabled_on TIMESTAMPTZ,
+    disabled_reason TEXT,
+    last_delivery_on TIMESTAMPTZ,
+    created_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_on TIMESTAMPTZ,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    outbox_event_id BIGINT,
+    replay_of BIGINT REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    event_type VARCHAR(100) NOT NULL,
+    body JSONB,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    response_status INT,
+    response_body TEXT,
+    error TEXT,
+    duration_ms BIGINT,
+    created_on TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_attempt_on TIMESTAMPTZ,
+    delivered_on TIMESTAMPTZ,
+    CONSTRAINT chk_webhook_deliveries_status CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+
+-- An outbox event is turned into at most one (original) delivery per subscription
+CREATE UNIQUE INDEX IF NOT EXISTS unique_webhook_delivery_event ON webhook_deliveries(subscription_id, outbox_event_id)
+    WHERE outbox_event_id IS NOT NULL AND replay_of IS NULL;
+
+-- Delivery log per subscription and dispatcher polling
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_on DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_on, id)
+    WHERE status = 'pending';
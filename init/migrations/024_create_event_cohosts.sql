@@ -0,0 +1,17 @@
+-- Migration: Create event_cohost_branches table
+-- Description: Branches participating in an event organised jointly with its lead branch
+-- (event_details.branch_id). Co-hosted events are listed for every hosting branch; a co-host's
+-- optional share is the percentage of the event's beneficiaries credited to it in branch
+-- analytics, the lead branch keeping the rest, so national totals count every event once.
+
+CREATE TABLE IF NOT EXISTS event_cohost_branches (
+    event_id BIGINT NOT NULL REFERENCES event_details(id) ON DELETE CASCADE,
+    branch_id BIGINT NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    beneficiary_share_percent NUMERIC(5,2) CHECK (beneficiary_share_percent >= 0 AND beneficiary_share_percent <= 100),
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    created_by VARCHAR(255),
+    PRIMARY KEY (event_id, branch_id)
+);
+
+-- Listings and analytics look up the events a branch co-hosts
+CREATE INDEX IF NOT EXISTS idx_event_cohost_branches_branch ON event_cohost_branches(branch_id, event_id);
@@ -0,0 +1,34 @@
+-- Migration: Create event_custom_fields table
+-- Description: Admin-defined extra fields of the events of a category or sub category (for
+-- example the number of doctors at a health camp). A field belongs to exactly one category or
+-- sub category; an event gets the fields of its category and of its sub category. The values are
+-- stored by field key in event_details.custom_fields.
+
+CREATE TABLE IF NOT EXISTS event_custom_fields (
+    id BIGSERIAL PRIMARY KEY,
+    event_category_id BIGINT REFERENCES event_categories(id) ON DELETE CASCADE,
+    event_sub_category_id BIGINT REFERENCES event_sub_categories(id) ON DELETE CASCADE,
+    key VARCHAR(100) NOT NULL,
+    label VARCHAR(255) NOT NULL,
+    field_type VARCHAR(20) NOT NULL CHECK (field_type IN ('text', 'textarea', 'number', 'integer', 'boolean', 'date', 'select', 'multiselect')),
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    options JSONB NOT NULL DEFAULT '[]',
+    validation JSONB NOT NULL DEFAULT '{}',
+    help_text TEXT,
+    sort_order INT NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_on TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_on TIMESTAMPTZ,
+    created_by VARCHAR(255),
+    updated_by VARCHAR(255),
+    CHECK (num_nonnulls(event_category_id, event_sub_category_id) = 1)
+);
+
+-- Keys are unique within a category and within a sub category
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_custom_fields_category_key
+    ON event_custom_fields(event_category_id, key) WHERE event_category_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_custom_fields_sub_category_key
+    ON event_custom_fields(event_sub_category_id, key) WHERE event_sub_category_id IS NOT NULL;
+
+-- Values by field key; NULL (events saved before this migration, restored old revisions) means none
+ALTER TABLE event_details ADD COLUMN IF NOT EXISTS custom_fields JSONB DEFAULT '{}';
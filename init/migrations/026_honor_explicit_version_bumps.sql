@@ -0,0 +1,21 @@
+-- Migration: Honor explicit row version bumps
+-- Description: The related rows of an event (guests, volunteers, media, donations, promotion
+-- materials) and of a branch (infrastructure, child branches, members) are part of its
+-- representation but live in other tables, so changing only them leaves the row itself untouched.
+-- The code then sets version = version + 1 explicitly; the trigger now keeps such a bump instead of
+-- resetting it. Whatever value is written, an update still moves the version by exactly one.
+
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        NEW.version := 1;
+    ELSIF NEW.version > OLD.version
+          OR (to_jsonb(NEW) - 'version' - 'updated_on' - 'updated_by' - 'search_vector')
+             IS DISTINCT FROM (to_jsonb(OLD) - 'version' - 'updated_on' - 'updated_by' - 'search_vector') THEN
+        NEW.version := OLD.version + 1;
+    ELSE
+        NEW.version := OLD.version;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
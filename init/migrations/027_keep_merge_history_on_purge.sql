@@ -0,0 +1,6 @@
+-- Migration: Keep merge history when a merged event is purged
+-- Description: Merged duplicates sit in the trash, so purging the trash used to cascade into
+-- event_merges and erase the record of the merge. merged_event_id no longer references
+-- event_details; it keeps the ID of the absorbed event after that event is gone.
+
+ALTER TABLE event_merges DROP CONSTRAINT IF EXISTS event_merges_merged_event_id_fkey;